@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"github.com/docker/cli/cli-plugins/metadata"
+	"github.com/docker/cli/cli/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// builderAliasKey is the key in the "aliases" of the CLI's config file
+	// that sets the plugin to use as builder.
+	builderAliasKey = "builder"
+
+	// defaultBuilderPlugin is the plugin that is used as builder if no
+	// builder alias is set.
+	defaultBuilderPlugin = "buildx"
+)
+
+// BuilderPluginName returns the name of the plugin that "docker build" is
+// forwarded to, which is "buildx", unless another plugin is set through the
+// "builder" alias in the CLI's config file.
+func BuilderPluginName(dockerCLI config.Provider) string {
+	if name := dockerCLI.ConfigFile().Aliases[builderAliasKey]; name != "" {
+		return name
+	}
+	return defaultBuilderPlugin
+}
+
+// GetBuilderMetadata returns the metadata of the builder plugin, as returned
+// by [BuilderPluginName], for example to report its version in diagnostics.
+// The error returned satisfies the [errdefs.IsNotFound] predicate if the
+// plugin is not installed. If the plugin is installed, but is not a valid
+// plugin, the error of the plugin is returned.
+//
+// The rootcmd argument is referenced to determine the set of builtin commands
+// in order to detect conflicts.
+//
+// [errdefs.IsNotFound]: https://pkg.go.dev/github.com/containerd/errdefs#IsNotFound
+func GetBuilderMetadata(dockerCLI config.Provider, rootcmd *cobra.Command) (metadata.Metadata, error) {
+	plugin, err := GetPlugin(BuilderPluginName(dockerCLI), dockerCLI, rootcmd)
+	if err != nil {
+		return metadata.Metadata{}, err
+	}
+	if plugin.Err != nil {
+		return metadata.Metadata{}, plugin.Err
+	}
+	return plugin.Metadata, nil
+}
@@ -6,6 +6,7 @@
 	"testing"
 
 	"github.com/containerd/errdefs"
+	"github.com/docker/cli/cli-plugins/metadata"
 	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/cli/internal/test"
@@ -132,6 +133,63 @@ func TestGetPlugin(t *testing.T) {
 	assert.Assert(t, errdefs.IsNotFound(err))
 }
 
+func TestGetBuilderMetadata(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("docker-buildx", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"Docker Inc.","Version":"v0.20.0"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-mybuilder", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"Example","Version":"v1.0.0"}'`, fs.WithMode(0o777)),
+		fs.WithFile("docker-invalid", `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	testCases := []struct {
+		doc         string
+		alias       string
+		expected    metadata.Metadata
+		expectedErr string
+	}{
+		{
+			doc:      "default",
+			expected: metadata.Metadata{SchemaVersion: "0.1.0", Vendor: "Docker Inc.", Version: "v0.20.0"},
+		},
+		{
+			doc:      "alias",
+			alias:    "mybuilder",
+			expected: metadata.Metadata{SchemaVersion: "0.1.0", Vendor: "Example", Version: "v1.0.0"},
+		},
+		{
+			doc:         "invalid",
+			alias:       "invalid",
+			expectedErr: "plugin metadata does not define a vendor",
+		},
+		{
+			doc:         "not found",
+			alias:       "nosuchbuilder",
+			expectedErr: "Error: No such CLI plugin: nosuchbuilder",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(nil)
+			cfg := &configfile.ConfigFile{CLIPluginsExtraDirs: []string{dir.Path()}}
+			if tc.alias != "" {
+				cfg.Aliases = map[string]string{"builder": tc.alias}
+			}
+			cli.SetConfigFile(cfg)
+
+			meta, err := GetBuilderMetadata(cli, &cobra.Command{})
+			if tc.expectedErr != "" {
+				assert.Error(t, err, tc.expectedErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, meta, tc.expected)
+		})
+	}
+}
+
 func TestListPluginsIsSorted(t *testing.T) {
 	dir := fs.NewDir(t, t.Name(),
 		fs.WithFile("docker-bbb", `
@@ -0,0 +1,130 @@
+package command
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/spf13/pflag"
+)
+
+// ContextBuilderField is the field in the metadata of a context to set the
+// name of the builder to use by default for that context.
+const ContextBuilderField = "com.docker.default-builder"
+
+// ProjectBuilderFile is the path of the file, relative to the root of a
+// project, that holds the name of the builder to use for the project.
+const ProjectBuilderFile = ".docker/builder"
+
+// BuilderSource describes where the name of the builder to use was set.
+type BuilderSource string
+
+const (
+	// BuilderSourceFlag is used for a builder set through the --builder flag.
+	BuilderSourceFlag BuilderSource = "flag"
+
+	// BuilderSourceEnv is used for a builder set through the BUILDX_BUILDER
+	// environment variable.
+	BuilderSourceEnv BuilderSource = "env"
+
+	// BuilderSourceProject is used for a builder set through a
+	// [ProjectBuilderFile] in the working directory, or one of its parent
+	// directories.
+	BuilderSourceProject BuilderSource = "project"
+
+	// BuilderSourceContext is used for a builder set through the
+	// [ContextBuilderField] field in the metadata of the current context.
+	BuilderSourceContext BuilderSource = "context"
+
+	// BuilderSourceDefault is used if no builder is set, in which case the
+	// builder has the name of the current context.
+	BuilderSourceDefault BuilderSource = "default"
+)
+
+// ResolvedBuilderName returns the name of the builder that is used for a
+// build with the given args and environment variables, and where it was set.
+//
+// The --builder flag takes precedence over the BUILDX_BUILDER environment
+// variable, as it does in buildx. If neither is set, the builder set in a
+// [ProjectBuilderFile] is used, then the builder set in the metadata of the
+// current context, or otherwise the builder with the name of the current
+// context.
+func ResolvedBuilderName(dockerCli Cli, args, envs []string) (string, BuilderSource) {
+	name, source := builderNameFromArgs(args, envs)
+	if name != "" {
+		return name, source
+	}
+	// An empty --builder flag also unsets the builder set for the project.
+	if wd, err := os.Getwd(); err == nil && source != BuilderSourceFlag {
+		if name := projectBuilderName(wd); name != "" {
+			return name, BuilderSourceProject
+		}
+	}
+	name = dockerCli.CurrentContext()
+	meta, err := dockerCli.ContextStore().GetMetadata(name)
+	if err != nil {
+		return name, BuilderSourceDefault
+	}
+	var builder any
+	switch m := meta.Metadata.(type) {
+	case DockerContext:
+		builder = m.AdditionalFields[ContextBuilderField]
+	case map[string]any:
+		builder = m[ContextBuilderField]
+	}
+	if b, ok := builder.(string); ok && b != "" {
+		return b, BuilderSourceContext
+	}
+	return name, BuilderSourceDefault
+}
+
+// builderNameFromArgs returns the builder name that is defined in args or
+// env vars, and where it was set. It returns an empty name if no builder
+// name is defined. An empty --builder flag unsets the builder, like an empty
+// BUILDX_BUILDER environment variable does, and takes precedence over the
+// environment variable; in that case, [BuilderSourceFlag] is returned with
+// an empty name.
+func builderNameFromArgs(args, envs []string) (string, BuilderSource) {
+	var builder string
+	flagset := pflag.NewFlagSet("buildx", pflag.ContinueOnError)
+	flagset.Usage = func() {}
+	flagset.SetOutput(io.Discard)
+	flagset.StringVar(&builder, "builder", "", "")
+	_ = flagset.Parse(args)
+	if flagset.Changed("builder") {
+		if builder == "" {
+			return "", BuilderSourceFlag
+		}
+		return builder, BuilderSourceFlag
+	}
+	for _, e := range envs {
+		if v, ok := strings.CutPrefix(e, "BUILDX_BUILDER="); ok && v != "" {
+			return v, BuilderSourceEnv
+		}
+	}
+	return "", ""
+}
+
+// projectBuilderName returns the builder name that is set in the nearest
+// [ProjectBuilderFile], walking up from dir. It returns an empty name if no
+// file is found, or if the nearest file is empty. The CLI's configuration
+// directory (~/.docker) is skipped, as it is not a project directory.
+func projectBuilderName(dir string) string {
+	configDir := filepath.Clean(config.Dir())
+	for {
+		fileName := filepath.Join(dir, filepath.FromSlash(ProjectBuilderFile))
+		if filepath.Dir(fileName) != configDir {
+			if b, err := os.ReadFile(fileName); err == nil {
+				name, _, _ := strings.Cut(string(b), "\n")
+				return strings.TrimSpace(name)
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,203 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/context/store"
+	"github.com/docker/cli/cli/flags"
+	"github.com/moby/moby/client"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestResolvedBuilderName(t *testing.T) {
+	cases := []struct {
+		name           string
+		context        string
+		metadata       map[string]any
+		project        string
+		args           []string
+		envs           []string
+		expectedName   string
+		expectedSource BuilderSource
+	}{
+		{
+			name:           "no args",
+			args:           []string{"docker", "build", "."},
+			envs:           []string{"FOO=bar"},
+			expectedName:   DefaultContextName,
+			expectedSource: BuilderSourceDefault,
+		},
+		{
+			name:           "env var",
+			args:           []string{"docker", "build", "."},
+			envs:           []string{"BUILDX_BUILDER=foo"},
+			expectedName:   "foo",
+			expectedSource: BuilderSourceEnv,
+		},
+		{
+			name:           "empty env var",
+			args:           []string{"docker", "build", "."},
+			envs:           []string{"BUILDX_BUILDER="},
+			expectedName:   DefaultContextName,
+			expectedSource: BuilderSourceDefault,
+		},
+		{
+			name:           "flag",
+			args:           []string{"docker", "build", "--builder", "foo", "."},
+			envs:           []string{"FOO=bar"},
+			expectedName:   "foo",
+			expectedSource: BuilderSourceFlag,
+		},
+		{
+			name:           "both",
+			args:           []string{"docker", "build", "--builder", "foo", "."},
+			envs:           []string{"BUILDX_BUILDER=bar"},
+			expectedName:   "foo",
+			expectedSource: BuilderSourceFlag,
+		},
+		{
+			name:           "empty flag",
+			args:           []string{"docker", "build", "--builder=", "."},
+			envs:           []string{"FOO=bar"},
+			expectedName:   DefaultContextName,
+			expectedSource: BuilderSourceDefault,
+		},
+		{
+			name:           "empty flag with separate value",
+			args:           []string{"docker", "build", "--builder", "", "."},
+			expectedName:   DefaultContextName,
+			expectedSource: BuilderSourceDefault,
+		},
+		{
+			name:           "empty flag unsets env var",
+			args:           []string{"docker", "build", "--builder=", "."},
+			envs:           []string{"BUILDX_BUILDER=foo"},
+			expectedName:   DefaultContextName,
+			expectedSource: BuilderSourceDefault,
+		},
+		{
+			name:           "custom context",
+			context:        "mycontext",
+			args:           []string{"docker", "build", "."},
+			expectedName:   "mycontext",
+			expectedSource: BuilderSourceDefault,
+		},
+		{
+			name:           "custom context with default builder",
+			context:        "mycontext",
+			metadata:       map[string]any{ContextBuilderField: "mybuilder"},
+			args:           []string{"docker", "build", "."},
+			expectedName:   "mybuilder",
+			expectedSource: BuilderSourceContext,
+		},
+		{
+			name:           "env var overrides context",
+			context:        "mycontext",
+			metadata:       map[string]any{ContextBuilderField: "mybuilder"},
+			args:           []string{"docker", "build", "."},
+			envs:           []string{"BUILDX_BUILDER=foo"},
+			expectedName:   "foo",
+			expectedSource: BuilderSourceEnv,
+		},
+		{
+			name:           "project file",
+			project:        "projectbuilder\n",
+			args:           []string{"docker", "build", "."},
+			expectedName:   "projectbuilder",
+			expectedSource: BuilderSourceProject,
+		},
+		{
+			name:           "empty project file",
+			project:        "\n",
+			args:           []string{"docker", "build", "."},
+			expectedName:   DefaultContextName,
+			expectedSource: BuilderSourceDefault,
+		},
+		{
+			name:           "project file overrides context",
+			context:        "mycontext",
+			metadata:       map[string]any{ContextBuilderField: "mybuilder"},
+			project:        "projectbuilder",
+			args:           []string{"docker", "build", "."},
+			expectedName:   "projectbuilder",
+			expectedSource: BuilderSourceProject,
+		},
+		{
+			name:           "env var overrides project file",
+			project:        "projectbuilder",
+			args:           []string{"docker", "build", "."},
+			envs:           []string{"BUILDX_BUILDER=foo"},
+			expectedName:   "foo",
+			expectedSource: BuilderSourceEnv,
+		},
+		{
+			name:           "flag overrides project file",
+			project:        "projectbuilder",
+			args:           []string{"docker", "build", "--builder", "foo", "."},
+			envs:           []string{"BUILDX_BUILDER=bar"},
+			expectedName:   "foo",
+			expectedSource: BuilderSourceFlag,
+		},
+		{
+			name:           "empty flag unsets project file",
+			project:        "projectbuilder",
+			args:           []string{"docker", "build", "--builder=", "."},
+			expectedName:   DefaultContextName,
+			expectedSource: BuilderSourceDefault,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config.SetDir(t.TempDir())
+			projectDir := t.TempDir()
+			if tc.project != "" {
+				assert.NilError(t, os.MkdirAll(filepath.Join(projectDir, ".docker"), 0o755))
+				assert.NilError(t, os.WriteFile(filepath.Join(projectDir, ".docker", "builder"), []byte(tc.project), 0o644))
+			}
+			// the file is looked up in parent directories of the working directory
+			wd := filepath.Join(projectDir, "sub", "dir")
+			assert.NilError(t, os.MkdirAll(wd, 0o755))
+			t.Chdir(wd)
+			cli, err := NewDockerCli(WithAPIClient(&fakeClient{
+				pingFunc: func() (client.PingResult, error) { return client.PingResult{}, nil },
+			}))
+			assert.NilError(t, err)
+			opts := flags.NewClientOptions()
+			if tc.context != "" {
+				assert.NilError(t, cli.Initialize(opts))
+				assert.NilError(t, cli.ContextStore().CreateOrUpdate(store.Metadata{
+					Name:     tc.context,
+					Metadata: DockerContext{AdditionalFields: tc.metadata},
+					Endpoints: map[string]any{
+						"docker": map[string]any{
+							"host": "unix://" + filepath.Join(t.TempDir(), "docker.sock"),
+						},
+					},
+				}))
+				opts.Context = tc.context
+			}
+			assert.NilError(t, cli.Initialize(opts))
+
+			name, source := ResolvedBuilderName(cli, tc.args, tc.envs)
+			assert.Check(t, is.Equal(name, tc.expectedName))
+			assert.Check(t, is.Equal(source, tc.expectedSource))
+		})
+	}
+}
+
+func TestProjectBuilderNameSkipsConfigDir(t *testing.T) {
+	home := t.TempDir()
+	configDir := filepath.Join(home, ".docker")
+	assert.NilError(t, os.MkdirAll(configDir, 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(configDir, "builder"), []byte("mybuilder"), 0o644))
+	config.SetDir(configDir)
+
+	assert.Check(t, is.Equal(projectBuilderName(filepath.Join(home, "project")), ""))
+
+	config.SetDir(t.TempDir())
+	assert.Check(t, is.Equal(projectBuilderName(filepath.Join(home, "project")), "mybuilder"))
+}
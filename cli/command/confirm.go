@@ -0,0 +1,46 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/docker/cli/internal/prompt"
+)
+
+// EnvAssumeYes is the name of the environment variable that can be set to
+// automatically answer yes to confirmation prompts. It is set for CLI plugins
+// when using the "--assume-yes" global option, so that they also honor it.
+const EnvAssumeYes = "DOCKER_CLI_ASSUME_YES"
+
+// AssumeYes returns whether confirmation prompts should be answered with yes
+// automatically, either through the "--assume-yes" global option of
+// dockerCLI, or through the DOCKER_CLI_ASSUME_YES environment variable.
+func AssumeYes(dockerCLI Streams) bool {
+	if c, ok := dockerCLI.(*DockerCli); ok && c.options != nil && c.options.AssumeYes {
+		return true
+	}
+	v, _ := strconv.ParseBool(os.Getenv(EnvAssumeYes))
+	return v
+}
+
+// Confirm requests confirmation from the user, using the given message. It
+// returns true without prompting if [AssumeYes] is enabled.
+//
+// If stdin is not a terminal, no prompt is shown and false is returned, as
+// there is no user to confirm. A message is printed to stderr to explain how
+// to confirm non-interactively.
+//
+// It returns false with an [prompt.ErrTerminated] if the user terminates
+// the CLI with SIGINT or SIGTERM while the prompt is active.
+func Confirm(ctx context.Context, dockerCLI Streams, message string) (bool, error) {
+	if AssumeYes(dockerCLI) {
+		return true, nil
+	}
+	if !dockerCLI.In().IsTerminal() {
+		_, _ = fmt.Fprintf(dockerCLI.Err(), "%s\nNot prompting for confirmation as stdin is not a terminal; use --assume-yes (-y) or set %s=1 to confirm.\n", message, EnvAssumeYes)
+		return false, nil
+	}
+	return prompt.Confirm(ctx, dockerCLI.In(), dockerCLI.Out(), message)
+}
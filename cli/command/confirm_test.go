@@ -0,0 +1,85 @@
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/flags"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		doc         string
+		assumeYes   string
+		option      bool
+		input       string
+		terminal    bool
+		expected    bool
+		expectedOut string
+		expectedErr string
+	}{
+		{
+			doc:         "terminal, confirmed",
+			input:       "y\n",
+			terminal:    true,
+			expected:    true,
+			expectedOut: "Remove it? [y/N] ",
+		},
+		{
+			doc:         "terminal, declined",
+			input:       "n\n",
+			terminal:    true,
+			expectedOut: "Remove it? [y/N] ",
+		},
+		{
+			doc:         "not a terminal",
+			input:       "y\n",
+			expectedErr: "Remove it?\nNot prompting for confirmation as stdin is not a terminal; use --assume-yes (-y) or set DOCKER_CLI_ASSUME_YES=1 to confirm.\n",
+		},
+		{
+			doc:       "assume yes",
+			assumeYes: "1",
+			expected:  true,
+		},
+		{
+			doc:      "assume yes option",
+			option:   true,
+			expected: true,
+		},
+		{
+			doc:         "assume yes disabled",
+			assumeYes:   "false",
+			expectedErr: "Remove it?\nNot prompting for confirmation as stdin is not a terminal; use --assume-yes (-y) or set DOCKER_CLI_ASSUME_YES=1 to confirm.\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			t.Setenv(command.EnvAssumeYes, tc.assumeYes)
+			outBuf, errBuf := new(bytes.Buffer), new(bytes.Buffer)
+			cli, err := command.NewDockerCli(
+				command.WithInputStream(io.NopCloser(strings.NewReader(tc.input))),
+				command.WithOutputStream(outBuf),
+				command.WithErrorStream(errBuf),
+			)
+			assert.NilError(t, err)
+			opts := flags.NewClientOptions()
+			opts.AssumeYes = tc.option
+			assert.NilError(t, cli.Initialize(opts))
+			cli.In().SetIsTerminal(tc.terminal)
+
+			confirmed, err := command.Confirm(context.Background(), cli, "Remove it?")
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(confirmed, tc.expected))
+			assert.Check(t, is.Equal(outBuf.String(), tc.expectedOut))
+			assert.Check(t, is.Equal(errBuf.String(), tc.expectedErr))
+			assert.Check(t, is.Equal(os.Getenv(command.EnvAssumeYes), tc.assumeYes), "the environment must not be changed")
+		})
+	}
+}
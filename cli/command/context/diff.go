@@ -0,0 +1,128 @@
+package context
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/formatter/tabwriter"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/docker/cli/cli/context/store"
+	"github.com/spf13/cobra"
+)
+
+// newDiffCommand creates a new cobra.Command for `docker context diff`
+func newDiffCommand(dockerCLI command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff CONTEXT CONTEXT",
+		Short: "Show differences between two contexts",
+		Args:  cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiff(dockerCLI, args[0], args[1])
+		},
+		ValidArgsFunction:     completeContextNames(dockerCLI, 2, false),
+		DisableFlagsInUseLine: true,
+	}
+	return cmd
+}
+
+// contextField is a single field of a context that is compared.
+type contextField struct {
+	name  string
+	value string
+}
+
+func runDiff(dockerCLI command.Cli, nameA, nameB string) error {
+	fieldsA, err := contextFields(dockerCLI.ContextStore(), nameA)
+	if err != nil {
+		return err
+	}
+	fieldsB, err := contextFields(dockerCLI.ContextStore(), nameB)
+	if err != nil {
+		return err
+	}
+
+	diffs := diffContextFields(fieldsA, fieldsB)
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(dockerCLI.Out(), 20, 1, 3, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "FIELD\t%s\t%s\n", nameA, nameB)
+	for _, d := range diffs {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", d[0], d[1], d[2])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return cli.StatusError{StatusCode: 1}
+}
+
+// contextFields returns the fields of the given context that are compared
+// by "docker context diff", sorted by name. It only reads TLS presence
+// through the store's file listing, and never loads the TLS material itself.
+func contextFields(s store.Reader, name string) ([]contextField, error) {
+	meta, err := s.GetMetadata(name)
+	if err != nil {
+		return nil, err
+	}
+	dockerContext, err := command.GetDockerContext(meta)
+	if err != nil {
+		return nil, err
+	}
+	ep, err := docker.EndpointFromContext(meta)
+	if err != nil {
+		return nil, err
+	}
+	tlsFiles, err := s.ListTLSFiles(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []contextField{
+		{name: "Description", value: dockerContext.Description},
+		{name: "Endpoints.docker.Host", value: ep.Host},
+		{name: "Endpoints.docker.SkipTLSVerify", value: strconv.FormatBool(ep.SkipTLSVerify)},
+		{name: "Endpoints.docker.TLS.CA", value: strconv.FormatBool(slices.Contains(tlsFiles[docker.DockerEndpoint], "ca.pem"))},
+		{name: "Endpoints.docker.TLS.Cert", value: strconv.FormatBool(slices.Contains(tlsFiles[docker.DockerEndpoint], "cert.pem"))},
+		{name: "Endpoints.docker.TLS.Key", value: strconv.FormatBool(slices.Contains(tlsFiles[docker.DockerEndpoint], "key.pem"))},
+	}
+	for k, v := range dockerContext.AdditionalFields {
+		fields = append(fields, contextField{name: "Metadata." + k, value: fmt.Sprint(v)})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	return fields, nil
+}
+
+// diffContextFields returns the name and values of fields that differ
+// between a and b. Fields that are only present in one of the contexts
+// are included with an empty value for the other.
+func diffContextFields(a, b []contextField) [][3]string {
+	valuesB := make(map[string]string, len(b))
+	for _, f := range b {
+		valuesB[f.name] = f.value
+	}
+
+	var diffs [][3]string
+	seen := make(map[string]struct{}, len(a))
+	for _, f := range a {
+		seen[f.name] = struct{}{}
+		if vb, ok := valuesB[f.name]; !ok || vb != f.value {
+			diffs = append(diffs, [3]string{f.name, f.value, valuesB[f.name]})
+		}
+	}
+	for _, f := range b {
+		if _, ok := seen[f.name]; !ok {
+			diffs = append(diffs, [3]string{f.name, "", f.value})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i][0] < diffs[j][0]
+	})
+	return diffs
+}
@@ -0,0 +1,54 @@
+package context
+
+import (
+	"strings"
+	"testing"
+
+	dockercli "github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/context/store"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/golden"
+)
+
+func TestDiffIdentical(t *testing.T) {
+	cli := makeFakeCli(t)
+	for _, name := range []string{"context-a", "context-b"} {
+		assert.NilError(t, runCreate(cli, name, createOptions{
+			description: "same description",
+			endpoint:    map[string]string{keyHost: "tcp://example.com:2376"},
+		}))
+	}
+	cli.OutBuffer().Reset()
+	assert.NilError(t, runDiff(cli, "context-a", "context-b"))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), ""))
+}
+
+func TestDiff(t *testing.T) {
+	cli := makeFakeCli(t)
+	assert.NilError(t, runCreate(cli, "context-a", createOptions{
+		description: "context a",
+		endpoint:    map[string]string{keyHost: "tcp://a.example.com:2376"},
+		metaData:    map[string]any{"Type": "moby"},
+	}))
+	assert.NilError(t, runCreate(cli, "context-b", createOptions{
+		description: "context b",
+		endpoint:    map[string]string{keyHost: "tcp://b.example.com:2376", keySkipTLSVerify: "true"},
+	}))
+	assert.NilError(t, cli.ContextStore().ResetEndpointTLSMaterial("context-b", "docker", &store.EndpointTLSData{
+		Files: map[string][]byte{"ca.pem": []byte("not-a-real-ca")},
+	}))
+	cli.OutBuffer().Reset()
+
+	err := runDiff(cli, "context-a", "context-b")
+	assert.Check(t, is.DeepEqual(err, dockercli.StatusError{StatusCode: 1}))
+	assert.Check(t, !strings.Contains(cli.OutBuffer().String(), "not-a-real-ca"), "TLS material must not be printed")
+	golden.Assert(t, cli.OutBuffer().String(), "diff.golden")
+}
+
+func TestDiffNotFound(t *testing.T) {
+	cli := makeFakeCli(t)
+	createTestContext(t, cli, "context-a", nil)
+	err := runDiff(cli, "context-a", "missing")
+	assert.Check(t, is.ErrorContains(err, `context "missing": context not found`))
+}
@@ -12,12 +12,21 @@
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/formatter"
 	"github.com/docker/cli/cli/context/docker"
-	flagsHelper "github.com/docker/cli/cli/flags"
 	"github.com/fvbommel/sortorder"
 	"github.com/moby/moby/client"
 	"github.com/spf13/cobra"
 )
 
+// listFormatHelp describes the --format flag behavior for "docker context ls",
+// which, in addition to the standard formats, can print a JSON array.
+const listFormatHelp = `Format output using a custom template:
+'table':            Print output in table format with column headers (default)
+'table TEMPLATE':   Print output in table format using the given Go template
+'json':             Print in JSON format, one object per line
+'jsonarray':        Print in JSON format, as a single array
+'TEMPLATE':         Print output using the given Go template.
+Refer to https://docs.docker.com/go/formatting/ for more information about formatting output with templates`
+
 type listOptions struct {
 	format string
 	quiet  bool
@@ -38,7 +47,7 @@ func newListCommand(dockerCLI command.Cli) *cobra.Command {
 	}
 
 	flags := cmd.Flags()
-	flags.StringVar(&opts.format, "format", "", flagsHelper.FormatHelp)
+	flags.StringVar(&opts.format, "format", "", listFormatHelp)
 	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Only show context names")
 	return cmd
 }
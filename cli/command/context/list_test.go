@@ -1,11 +1,13 @@
 package context
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/formatter"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/golden"
 )
 
@@ -57,6 +59,31 @@ func TestListJSON(t *testing.T) {
 		golden.Assert(t, cli.OutBuffer().String(), "list-json.golden")
 	})
 
+	t.Run("format=jsonarray", func(t *testing.T) {
+		cli.OutBuffer().Reset()
+		assert.NilError(t, runList(cli, &listOptions{format: formatter.ClientContextJSONArrayFormatKey}))
+		golden.Assert(t, cli.OutBuffer().String(), "list-json-array.golden")
+	})
+
+	t.Run("json and jsonarray are equivalent", func(t *testing.T) {
+		cli.OutBuffer().Reset()
+		assert.NilError(t, runList(cli, &listOptions{format: formatter.JSONFormatKey}))
+		var objects []map[string]any
+		dec := json.NewDecoder(cli.OutBuffer())
+		for dec.More() {
+			var obj map[string]any
+			assert.NilError(t, dec.Decode(&obj))
+			objects = append(objects, obj)
+		}
+
+		cli.OutBuffer().Reset()
+		assert.NilError(t, runList(cli, &listOptions{format: formatter.ClientContextJSONArrayFormatKey}))
+		var array []map[string]any
+		assert.NilError(t, json.Unmarshal(cli.OutBuffer().Bytes(), &array))
+		assert.Check(t, is.Len(array, 5))
+		assert.Check(t, is.DeepEqual(array, objects))
+	})
+
 	t.Run("format={{ json .Name }}", func(t *testing.T) {
 		cli.OutBuffer().Reset()
 		assert.NilError(t, runList(cli, &listOptions{format: `{{ json .Name }}`}))
@@ -1,6 +1,7 @@
 package context
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -23,7 +24,7 @@ func newRemoveCommand(dockerCLI command.Cli) *cobra.Command {
 		Short:   "Remove one or more contexts",
 		Args:    cli.RequiresMinArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runRemove(dockerCLI, opts, args)
+			return runRemove(cmd.Context(), dockerCLI, opts, args)
 		},
 		ValidArgsFunction:     completeContextNames(dockerCLI, -1, false),
 		DisableFlagsInUseLine: true,
@@ -33,13 +34,13 @@ func newRemoveCommand(dockerCLI command.Cli) *cobra.Command {
 }
 
 // runRemove removes one or more contexts.
-func runRemove(dockerCLI command.Cli, opts removeOptions, names []string) error {
+func runRemove(ctx context.Context, dockerCLI command.Cli, opts removeOptions, names []string) error {
 	var errs []error
 	currentCtx := dockerCLI.CurrentContext()
 	for _, name := range names {
 		if name == "default" {
 			errs = append(errs, errors.New(`context "default" cannot be removed`))
-		} else if err := doRemove(dockerCLI, name, name == currentCtx, opts.force); err != nil {
+		} else if err := doRemove(ctx, dockerCLI, name, name == currentCtx, opts.force); err != nil {
 			errs = append(errs, err)
 		} else {
 			_, _ = fmt.Fprintln(dockerCLI.Out(), name)
@@ -48,10 +49,16 @@ func runRemove(dockerCLI command.Cli, opts removeOptions, names []string) error
 	return errors.Join(errs...)
 }
 
-func doRemove(dockerCli command.Cli, name string, isCurrent, force bool) error {
+func doRemove(ctx context.Context, dockerCli command.Cli, name string, isCurrent, force bool) error {
 	if isCurrent {
 		if !force {
-			return fmt.Errorf("context %q is in use, set -f flag to force remove", name)
+			confirmed, err := command.Confirm(ctx, dockerCli, fmt.Sprintf("Context %q is in use. Are you sure you want to remove it?", name))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return fmt.Errorf("context %q is in use, set -f flag to force remove", name)
+			}
 		}
 		// fallback to DOCKER_HOST
 		cfg := dockerCli.ConfigFile()
@@ -1,10 +1,12 @@
 package context
 
 import (
+	"context"
 	"path/filepath"
 	"testing"
 
 	"github.com/containerd/errdefs"
+	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cli/config/configfile"
 	"gotest.tools/v3/assert"
@@ -14,7 +16,7 @@
 func TestRemove(t *testing.T) {
 	cli := makeFakeCli(t)
 	createTestContexts(t, cli, "current", "other")
-	assert.NilError(t, runRemove(cli, removeOptions{}, []string{"other"}))
+	assert.NilError(t, runRemove(context.Background(), cli, removeOptions{}, []string{"other"}))
 	_, err := cli.ContextStore().GetMetadata("current")
 	assert.NilError(t, err)
 	_, err = cli.ContextStore().GetMetadata("other")
@@ -24,10 +26,10 @@ func TestRemove(t *testing.T) {
 func TestRemoveNotAContext(t *testing.T) {
 	cli := makeFakeCli(t)
 	createTestContexts(t, cli, "current", "other")
-	err := runRemove(cli, removeOptions{}, []string{"not-a-context"})
+	err := runRemove(context.Background(), cli, removeOptions{}, []string{"not-a-context"})
 	assert.ErrorContains(t, err, `context "not-a-context" does not exist`)
 
-	err = runRemove(cli, removeOptions{force: true}, []string{"not-a-context"})
+	err = runRemove(context.Background(), cli, removeOptions{force: true}, []string{"not-a-context"})
 	assert.NilError(t, err)
 }
 
@@ -35,8 +37,9 @@ func TestRemoveCurrent(t *testing.T) {
 	cli := makeFakeCli(t)
 	createTestContexts(t, cli, "current", "other")
 	cli.SetCurrentContext("current")
-	err := runRemove(cli, removeOptions{}, []string{"current"})
+	err := runRemove(context.Background(), cli, removeOptions{}, []string{"current"})
 	assert.ErrorContains(t, err, `context "current" is in use, set -f flag to force remove`)
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(), "stdin is not a terminal"))
 }
 
 func TestRemoveCurrentForce(t *testing.T) {
@@ -49,7 +52,7 @@ func TestRemoveCurrentForce(t *testing.T) {
 	cli := makeFakeCli(t, withCliConfig(testCfg))
 	createTestContexts(t, cli, "current", "other")
 	cli.SetCurrentContext("current")
-	assert.NilError(t, runRemove(cli, removeOptions{force: true}, []string{"current"}))
+	assert.NilError(t, runRemove(context.Background(), cli, removeOptions{force: true}, []string{"current"}))
 	reloadedConfig, err := config.Load(configDir)
 	assert.NilError(t, err)
 	assert.Equal(t, "", reloadedConfig.CurrentContext)
@@ -59,6 +62,23 @@ func TestRemoveDefault(t *testing.T) {
 	cli := makeFakeCli(t)
 	createTestContext(t, cli, "other", nil)
 	cli.SetCurrentContext("current")
-	err := runRemove(cli, removeOptions{}, []string{"default"})
+	err := runRemove(context.Background(), cli, removeOptions{}, []string{"default"})
 	assert.ErrorContains(t, err, `context "default" cannot be removed`)
 }
+
+func TestRemoveCurrentAssumeYes(t *testing.T) {
+	configDir := t.TempDir()
+	configFilePath := filepath.Join(configDir, "config.json")
+	testCfg := configfile.New(configFilePath)
+	testCfg.CurrentContext = "current"
+	assert.NilError(t, testCfg.Save())
+
+	t.Setenv(command.EnvAssumeYes, "1")
+	cli := makeFakeCli(t, withCliConfig(testCfg))
+	createTestContexts(t, cli, "current", "other")
+	cli.SetCurrentContext("current")
+	assert.NilError(t, runRemove(context.Background(), cli, removeOptions{}, []string{"current"}))
+	reloadedConfig, err := config.Load(configDir)
+	assert.NilError(t, err)
+	assert.Equal(t, "", reloadedConfig.CurrentContext)
+}
@@ -1,5 +1,7 @@
 package formatter
 
+import "encoding/json"
+
 const (
 	// ClientContextTableFormat is the default client context format.
 	ClientContextTableFormat = "table {{.Name}}{{if .Current}} *{{end}}\t{{.Description}}\t{{.DockerEndpoint}}\t{{.Error}}"
@@ -7,6 +9,10 @@
 	dockerEndpointHeader = "DOCKER ENDPOINT"
 	quietContextFormat   = "{{.Name}}"
 
+	// ClientContextJSONArrayFormatKey is the format to print all contexts
+	// as a single JSON array, instead of one JSON object per line.
+	ClientContextJSONArrayFormatKey = "jsonarray"
+
 	maxErrLength = 45
 )
 
@@ -32,6 +38,9 @@ type ClientContext struct {
 
 // ClientContextWrite writes formatted contexts using the Context
 func ClientContextWrite(ctx Context, contexts []*ClientContext) error {
+	if ctx.Format == ClientContextJSONArrayFormatKey {
+		return clientContextWriteJSONArray(ctx, contexts)
+	}
 	render := func(format func(subContext SubContext) error) error {
 		for _, context := range contexts {
 			if err := format(&clientContextContext{c: context}); err != nil {
@@ -43,6 +52,23 @@ func ClientContextWrite(ctx Context, contexts []*ClientContext) error {
 	return ctx.Write(newClientContextContext(), render)
 }
 
+// clientContextWriteJSONArray writes all contexts as a single JSON array,
+// using the same fields as the "json" format.
+func clientContextWriteJSONArray(ctx Context, contexts []*ClientContext) error {
+	items := make([]*clientContextContext, 0, len(contexts))
+	for _, context := range contexts {
+		items = append(items, &clientContextContext{c: context})
+	}
+	out, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	if ctx.Output != nil {
+		_, err = ctx.Output.Write(append(out, '\n'))
+	}
+	return err
+}
+
 type clientContextContext struct {
 	HeaderContext
 	c *ClientContext
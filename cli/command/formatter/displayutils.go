@@ -6,8 +6,10 @@
 import (
 	"fmt"
 	"strings"
+	"time"
 	"unicode/utf8"
 
+	"github.com/docker/go-units"
 	"github.com/moby/moby/client/pkg/stringid"
 	"golang.org/x/text/width"
 )
@@ -36,6 +38,22 @@ func TruncateID(id string) string {
 	return stringid.TruncateID(id)
 }
 
+// HumanTimeAgo returns how long ago t was in a human-readable form, for
+// example "2 hours ago". Times in the future, for example due to a clock skew
+// between the client and the daemon, are returned as "2 hours from now", and
+// times less than a second in the future as "Less than a second ago". An
+// empty string is returned for the zero time.
+func HumanTimeAgo(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := time.Since(t)
+	if d <= -time.Second {
+		return units.HumanDuration(-d) + " from now"
+	}
+	return units.HumanDuration(max(d, 0)) + " ago"
+}
+
 // Ellipsis truncates a string to fit within maxDisplayWidth, and appends ellipsis (…).
 // For maxDisplayWidth of 1 and lower, no ellipsis is appended.
 // For maxDisplayWidth of 1, first char of string will return even if its width > 1.
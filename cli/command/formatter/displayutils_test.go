@@ -2,6 +2,7 @@
 
 import (
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
@@ -72,3 +73,23 @@ func TestEllipsis(t *testing.T) {
 		assert.Check(t, is.Equal(testcase.expected, Ellipsis(testcase.source, testcase.width)))
 	}
 }
+
+func TestHumanTimeAgo(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		doc      string
+		t        time.Time
+		expected string
+	}{
+		{doc: "zero time", t: time.Time{}, expected: ""},
+		{doc: "past", t: now.Add(-2 * time.Hour), expected: "2 hours ago"},
+		{doc: "now", t: now, expected: "Less than a second ago"},
+		{doc: "slightly in the future", t: now.Add(500 * time.Millisecond), expected: "Less than a second ago"},
+		{doc: "future", t: now.Add(2*time.Hour + time.Minute), expected: "2 hours from now"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			assert.Check(t, is.Equal(HumanTimeAgo(tc.t), tc.expected))
+		})
+	}
+}
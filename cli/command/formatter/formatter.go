@@ -7,8 +7,10 @@
 	"bytes"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"text/template"
+	"unicode/utf8"
 
 	"github.com/docker/cli/cli/command/formatter/tabwriter"
 	"github.com/docker/cli/templates"
@@ -76,6 +78,21 @@ type Context struct {
 	Format Format
 	// Trunc when set to true will truncate the output of certain fields such as Container ID.
 	Trunc bool
+	// Funcs are functions that can be used in the format, in addition to the
+	// basic functions of the [templates] package.
+	Funcs template.FuncMap
+	// NoHeader, if set, omits the header row of table formats. The columns
+	// keep the widths they have with the header, so that the rows are the
+	// same as the rows printed with the header.
+	NoHeader bool
+	// StreamRows, if set, bounds the number of formatted rows that are held
+	// in memory. Rows of formats other than tables are written to Output as
+	// they're formatted. Rows of table formats are written in parts of
+	// StreamRows rows, and are formatted twice: first to compute the widths
+	// of the columns, and then to write the parts aligned to these widths.
+	// The SubFormat passed to Write must therefore format the same rows each
+	// time it's called.
+	StreamRows int
 
 	// internal element
 	header any
@@ -83,7 +100,7 @@ type Context struct {
 }
 
 func (c *Context) parseFormat() (*template.Template, error) {
-	tmpl, err := templates.Parse(c.Format.templateString())
+	tmpl, err := templates.New("").Funcs(c.Funcs).Parse(c.Format.templateString())
 	if err != nil {
 		return nil, fmt.Errorf("template parsing error: %w", err)
 	}
@@ -99,6 +116,9 @@ func (c *Context) postFormat(tmpl *template.Template, subContext SubContext) {
 		_, _ = c.buffer.WriteTo(out)
 		return
 	}
+	if c.NoHeader {
+		out = &skipLineWriter{w: out}
+	}
 
 	// Write column-headers and rows to the tab-writer buffer, then flush the output.
 	tw := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
@@ -108,6 +128,28 @@ func (c *Context) postFormat(tmpl *template.Template, subContext SubContext) {
 	_ = tw.Flush()
 }
 
+// skipLineWriter omits the first line that's written to it.
+type skipLineWriter struct {
+	w       io.Writer
+	skipped bool
+}
+
+func (s *skipLineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if !s.skipped {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			return n, nil
+		}
+		s.skipped = true
+		p = p[i+1:]
+	}
+	if _, err := s.w.Write(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
 func (c *Context) contextFormat(tmpl *template.Template, subContext SubContext) error {
 	if err := tmpl.Execute(c.buffer, subContext); err != nil {
 		return fmt.Errorf("template parsing error: %w", err)
@@ -129,6 +171,9 @@ func (c *Context) Write(sub SubContext, f SubFormat) error {
 	if err != nil {
 		return err
 	}
+	if c.StreamRows > 0 {
+		return c.writeStreamed(tmpl, sub, f)
+	}
 
 	subFormat := func(subContext SubContext) error {
 		return c.contextFormat(tmpl, subContext)
@@ -140,3 +185,100 @@ func (c *Context) Write(sub SubContext, f SubFormat) error {
 	c.postFormat(tmpl, sub)
 	return nil
 }
+
+// writeStreamed writes the rows formatted by f as described in [Context.StreamRows].
+func (c *Context) writeStreamed(tmpl *template.Template, sub SubContext, f SubFormat) error {
+	out := c.Output
+	if out == nil {
+		out = io.Discard
+	}
+	if !c.Format.IsTable() {
+		return f(func(subContext SubContext) error {
+			if err := c.contextFormat(tmpl, subContext); err != nil {
+				return err
+			}
+			_, err := c.buffer.WriteTo(out)
+			return err
+		})
+	}
+
+	// Use a copy of the template for the header, as the header functions
+	// must not be used for the rows.
+	headerTmpl, err := tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	var header bytes.Buffer
+	_ = headerTmpl.Funcs(templates.HeaderFunctions).Execute(&header, sub.FullHeader())
+	header.WriteByte('\n')
+
+	// The first pass only keeps the widths of the columns.
+	widths := columnWidths(nil, header.Bytes())
+	err = f(func(subContext SubContext) error {
+		if err := c.contextFormat(tmpl, subContext); err != nil {
+			return err
+		}
+		widths = columnWidths(widths, c.buffer.Bytes())
+		c.buffer.Reset()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Each part starts with a line with cells of the widths of the columns,
+	// which is omitted from the output, so that all parts are aligned the
+	// same as the table would be if it was written at once.
+	var widthsLine bytes.Buffer
+	for _, w := range widths {
+		widthsLine.WriteString(strings.Repeat("x", w))
+		widthsLine.WriteByte('\t')
+	}
+	widthsLine.WriteByte('\n')
+
+	var rows int
+	headerWritten := c.NoHeader
+	writePart := func() {
+		part := bytes.NewBuffer(slices.Clone(widthsLine.Bytes()))
+		if !headerWritten {
+			_, _ = header.WriteTo(part)
+			headerWritten = true
+		}
+		_, _ = c.buffer.WriteTo(part)
+		tw := tabwriter.NewWriter(&skipLineWriter{w: out}, 10, 1, 3, ' ', 0)
+		_, _ = part.WriteTo(tw)
+		_ = tw.Flush()
+		rows = 0
+	}
+	err = f(func(subContext SubContext) error {
+		if err := c.contextFormat(tmpl, subContext); err != nil {
+			return err
+		}
+		if rows++; rows == c.StreamRows {
+			writePart()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if rows > 0 || !headerWritten {
+		writePart()
+	}
+	return nil
+}
+
+// columnWidths returns the widths of the tab-terminated cells of the given
+// lines, and at least the given widths.
+func columnWidths(widths []int, lines []byte) []int {
+	for line := range bytes.SplitSeq(lines, []byte{'\n'}) {
+		cells := bytes.Split(line, []byte{'\t'})
+		for i, cell := range cells[:len(cells)-1] {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCount(cell))
+		}
+	}
+	return widths
+}
@@ -5,6 +5,8 @@
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -91,6 +93,7 @@ func TestContext(t *testing.T) {
 	testCases := []struct {
 		name     string
 		format   string
+		noHeader bool
 		expected string
 	}{
 		{
@@ -104,6 +107,20 @@ func TestContext(t *testing.T) {
 			format: `table {{.Name}}`,
 			expected: `NAME
 test
+`,
+		},
+		{
+			name:     "table format without header",
+			format:   `table {{.Name}}`,
+			noHeader: true,
+			expected: `test
+`,
+		},
+		{
+			name:     "json format without header",
+			format:   JSONFormatKey,
+			noHeader: true,
+			expected: `{"Name":"test"}
 `,
 		},
 	}
@@ -111,8 +128,9 @@ func TestContext(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			buf := bytes.NewBuffer(nil)
 			ctx := Context{
-				Format: Format(tc.format),
-				Output: buf,
+				Format:   Format(tc.format),
+				Output:   buf,
+				NoHeader: tc.noHeader,
 			}
 			subContext := fakeSubContext{Name: "test"}
 			subFormat := func(f func(sub SubContext) error) error {
@@ -124,3 +142,113 @@ func TestContext(t *testing.T) {
 		})
 	}
 }
+
+func TestContextNoHeader(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   string
+		rows     []string
+		expected string
+	}{
+		{
+			name:   "table format",
+			format: "table {{.Name}}\t{{.Name}}",
+			rows:   []string{"a", "b", "a-long-name"},
+			expected: `a             a
+b             b
+a-long-name   a-long-name
+`,
+		},
+		{
+			name:     "table format without rows",
+			format:   "table {{.Name}}\t{{.Name}}",
+			expected: "",
+		},
+		{
+			name:   "custom format",
+			format: "{{.Name}}",
+			rows:   []string{"a", "b"},
+			expected: `a
+b
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			ctx := Context{
+				Format:   Format(tc.format),
+				Output:   buf,
+				NoHeader: true,
+			}
+			subFormat := func(f func(sub SubContext) error) error {
+				for _, name := range tc.rows {
+					if err := f(&fakeSubContext{Name: name}); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			err := ctx.Write(&fakeSubContext{}, subFormat)
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(buf.String(), tc.expected))
+		})
+	}
+}
+
+func TestContextStreamRows(t *testing.T) {
+	rows := []string{"a", "a-longer-name", "b", "a-very-long-name", "c"}
+	testCases := []struct {
+		name     string
+		format   string
+		noHeader bool
+		rows     []string
+	}{
+		{name: "table format", format: "table {{.Name}}\t{{.Name}}\t{{.Name}}", rows: rows},
+		{name: "table format without header", format: "table {{.Name}}\t{{.Name}}", noHeader: true, rows: rows},
+		{name: "table format without rows", format: "table {{.Name}}\t{{.Name}}"},
+		{name: "custom format", format: "{{.Name}} {{.Name}}", rows: rows},
+		{name: "json format", format: JSONFormatKey, rows: rows},
+	}
+	for _, tc := range testCases {
+		for _, streamRows := range []int{1, 2, 3, 10} {
+			t.Run(fmt.Sprintf("%s/%d", tc.name, streamRows), func(t *testing.T) {
+				write := func(streamRows int, written *[]string) string {
+					buf := bytes.NewBuffer(nil)
+					ctx := Context{
+						Format:     Format(tc.format),
+						Output:     buf,
+						NoHeader:   tc.noHeader,
+						StreamRows: streamRows,
+					}
+					err := ctx.Write(&fakeSubContext{}, func(f func(sub SubContext) error) error {
+						for _, name := range tc.rows {
+							if written != nil {
+								*written = append(*written, buf.String())
+							}
+							if err := f(&fakeSubContext{Name: name}); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+					assert.NilError(t, err)
+					return buf.String()
+				}
+
+				// Streamed rows must be aligned the same as the rows of a
+				// table that's written at once.
+				var written []string
+				out := write(streamRows, &written)
+				assert.Check(t, is.Equal(out, write(0, nil)))
+
+				// Rows are written before all rows are formatted.
+				if len(tc.rows) > streamRows {
+					last := written[len(written)-1]
+					assert.Check(t, last != "", "expected rows to be written before the last row was formatted")
+					assert.Check(t, strings.HasPrefix(out, last))
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// FIXME(thaJeztah): remove once we are a module; the go:build directive prevents go from downgrading language version to go1.16:
+//go:build go1.25
+
+package formatter
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode"
+
+	"github.com/docker/cli/templates"
+)
+
+// ValidateFields validates that the format can be rendered using the given
+// SubContext, without rendering it. It returns an error listing the valid
+// field names if the format references a field that the SubContext does not
+// have, for example because of a typo such as "{{.Nmae}}", instead of
+// failing when the format is executed. Only fields of the SubContext itself
+// are validated, and not fields of values that are returned by them, or of
+// values in "range" and "with" actions. Methods with arguments, such as
+// "{{.Label "tier"}}", are valid if they're called with arguments.
+//
+// The funcs are the functions that can be used in the format, in addition to
+// the basic functions of the [templates] package, as set in [Context.Funcs].
+func ValidateFields(format Format, subContext SubContext, funcs template.FuncMap) error {
+	tmpl, err := templates.New("").Funcs(funcs).Parse(format.templateString())
+	if err != nil {
+		return fmt.Errorf("template parsing error: %w", err)
+	}
+	if tmpl.Tree == nil {
+		return nil
+	}
+	fields := FieldNames(subContext)
+	var unknown string
+	walkFields(tmpl.Tree.Root, true, func(name string, withArgs bool) {
+		valid := slices.Contains(fields, name)
+		if withArgs {
+			valid = hasMethodWithArgs(subContext, name)
+		}
+		if unknown == "" && !valid {
+			unknown = name
+		}
+	})
+	if unknown != "" {
+		return fmt.Errorf("invalid format: unknown field %q; valid fields are: %s", unknown, strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// FieldNames returns the sorted names of the fields of the SubContext that
+// can be used in a format, which are the names of its exported methods
+// without arguments that return a single value, as included by [MarshalJSON].
+func FieldNames(subContext SubContext) []string {
+	typ := reflect.TypeOf(subContext)
+	var names []string
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		if _, ok := unmarshallableNames[method.Name]; ok || !unicode.IsUpper(rune(method.Name[0])) {
+			continue
+		}
+		// The receiver is the first argument of the method.
+		if method.Type.NumIn() == 1 && method.Type.NumOut() == 1 {
+			names = append(names, method.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// hasMethodWithArgs returns whether the SubContext has an exported method
+// with the given name that takes arguments and returns a single value.
+func hasMethodWithArgs(subContext SubContext, name string) bool {
+	method, ok := reflect.TypeOf(subContext).MethodByName(name)
+	// The receiver is the first argument of the method.
+	return ok && method.Type.NumIn() > 1 && method.Type.NumOut() == 1
+}
+
+// walkFields calls fn with the name of each field of the top-level value
+// that is referenced in node, and whether it's called with arguments, as in
+// "{{.Label "tier"}}". If dot is set, "." refers to the top-level
+// value in node, which is not the case in the body of "range" and "with"
+// actions. Fields referenced through "$" always refer to the top-level value.
+func walkFields(node parse.Node, dot bool, fn func(name string, withArgs bool)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkFields(child, dot, fn)
+		}
+	case *parse.ActionNode:
+		walkFields(n.Pipe, dot, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkFields(cmd, dot, fn)
+		}
+	case *parse.CommandNode:
+		if f, ok := n.Args[0].(*parse.FieldNode); ok && len(n.Args) > 1 {
+			if dot && len(f.Ident) == 1 {
+				fn(f.Ident[0], true)
+			}
+			for _, arg := range n.Args[1:] {
+				walkFields(arg, dot, fn)
+			}
+			return
+		}
+		for _, arg := range n.Args {
+			walkFields(arg, dot, fn)
+		}
+	case *parse.ChainNode:
+		walkFields(n.Node, dot, fn)
+	case *parse.FieldNode:
+		if dot {
+			fn(n.Ident[0], false)
+		}
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" && len(n.Ident) > 1 {
+			fn(n.Ident[1], false)
+		}
+	case *parse.IfNode:
+		walkFields(n.Pipe, dot, fn)
+		walkFields(n.List, dot, fn)
+		walkFields(n.ElseList, dot, fn)
+	case *parse.RangeNode:
+		walkFields(n.Pipe, dot, fn)
+		walkFields(n.List, false, fn)
+		walkFields(n.ElseList, dot, fn)
+	case *parse.WithNode:
+		walkFields(n.Pipe, dot, fn)
+		walkFields(n.List, false, fn)
+		walkFields(n.ElseList, dot, fn)
+	}
+}
@@ -0,0 +1,86 @@
+// FIXME(thaJeztah): remove once we are a module; the go:build directive prevents go from downgrading language version to go1.16:
+//go:build go1.25
+
+package formatter
+
+import (
+	"testing"
+	"text/template"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type validateContext struct {
+	HeaderContext
+}
+
+func (*validateContext) ID() string               { return "id" }
+func (*validateContext) Name() string             { return "name" }
+func (*validateContext) Labels() []string         { return nil }
+func (*validateContext) Label(name string) string { return name }
+func (*validateContext) MarshalJSON() ([]byte, error) {
+	return []byte("{}"), nil
+}
+
+func TestFieldNames(t *testing.T) {
+	assert.Check(t, is.DeepEqual(FieldNames(&validateContext{}), []string{"ID", "Labels", "Name"}))
+}
+
+func TestValidateFields(t *testing.T) {
+	funcs := template.FuncMap{"env": func(string) string { return "" }}
+	testCases := []struct {
+		format      Format
+		expectedErr string
+	}{
+		{format: "{{.ID}}"},
+		{format: "table {{.ID}}\t{{.Name}}"},
+		{format: "json"},
+		{format: "{{json .}}"},
+		{format: `{{.ID}} {{env "HOME"}} {{upper .Name}}`},
+		{format: "{{range .Labels}}{{.Nmae}}{{end}}"},
+		{format: "{{with .Name}}{{.Foo}}{{end}}"},
+		{format: "{{range .Labels}}{{$.ID}}{{end}}"},
+		{format: "{{if .ID}}{{.Name}}{{else}}{{.ID}}{{end}}"},
+		{format: `table {{.ID}}\t{{.Label "tier"}}`},
+		{format: `{{upper (.Label "tier")}}`},
+		{
+			format:      "{{.Nmae}}",
+			expectedErr: `invalid format: unknown field "Nmae"; valid fields are: ID, Labels, Name`,
+		},
+		{
+			format:      "table {{.ID}}\t{{.Foo.Bar}}",
+			expectedErr: `invalid format: unknown field "Foo"; valid fields are: ID, Labels, Name`,
+		},
+		{
+			format:      "{{range .Labels}}{{$.Nmae}}{{end}}",
+			expectedErr: `invalid format: unknown field "Nmae"; valid fields are: ID, Labels, Name`,
+		},
+		{
+			format:      "{{if .ID}}{{.Label}}{{end}}",
+			expectedErr: `invalid format: unknown field "Label"; valid fields are: ID, Labels, Name`,
+		},
+		{
+			format:      `{{.Name "tier"}}`,
+			expectedErr: `invalid format: unknown field "Name"; valid fields are: ID, Labels, Name`,
+		},
+		{
+			format:      `{{.Nmae "tier"}}`,
+			expectedErr: `invalid format: unknown field "Nmae"; valid fields are: ID, Labels, Name`,
+		},
+		{
+			format:      "{{.ID",
+			expectedErr: "template parsing error: ",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			err := ValidateFields(tc.format, &validateContext{}, funcs)
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr))
+		})
+	}
+}
@@ -9,6 +9,7 @@
 type fakeClient struct {
 	client.Client
 	nodeInspectFunc    func(string) (client.NodeInspectResult, error)
+	nodeListFunc       func(client.NodeListOptions) (client.NodeListResult, error)
 	serviceInspectFunc func(string) (client.ServiceInspectResult, error)
 }
 
@@ -25,3 +26,10 @@ func (cli *fakeClient) ServiceInspect(_ context.Context, serviceID string, _ cli
 	}
 	return client.ServiceInspectResult{}, nil
 }
+
+func (cli *fakeClient) NodeList(_ context.Context, options client.NodeListOptions) (client.NodeListResult, error) {
+	if cli.nodeListFunc != nil {
+		return cli.nodeListFunc(options)
+	}
+	return client.NodeListResult{}, nil
+}
@@ -6,7 +6,9 @@
 import (
 	"context"
 	"errors"
+	"fmt"
 
+	"github.com/containerd/errdefs"
 	"github.com/moby/moby/api/types/swarm"
 	"github.com/moby/moby/client"
 )
@@ -16,6 +18,7 @@ type IDResolver struct {
 	client    client.APIClient
 	noResolve bool
 	cache     map[string]string
+	nodes     map[string]swarm.Node
 }
 
 // New creates a new IDResolver.
@@ -24,9 +27,31 @@ func New(apiClient client.APIClient, noResolve bool) *IDResolver {
 		client:    apiClient,
 		noResolve: noResolve,
 		cache:     make(map[string]string),
+		nodes:     make(map[string]swarm.Node),
 	}
 }
 
+// NewWithPrefetch creates a new IDResolver that lists all nodes up front,
+// so that resolving node IDs does not require inspecting each node. Nodes
+// that are not in the list are inspected when resolved, as with [New].
+//
+// Listing nodes is best-effort; if it fails, nodes are resolved individually.
+func NewWithPrefetch(ctx context.Context, apiClient client.APIClient, noResolve bool) *IDResolver {
+	r := New(apiClient, noResolve)
+	if noResolve {
+		return r
+	}
+	res, err := apiClient.NodeList(ctx, client.NodeListOptions{})
+	if err != nil {
+		return r
+	}
+	for _, n := range res.Items {
+		r.nodes[n.ID] = n
+		r.cache[n.ID] = nodeName(n, n.ID)
+	}
+	return r
+}
+
 func (r *IDResolver) get(ctx context.Context, t any, id string) (string, error) {
 	switch t.(type) {
 	case swarm.Node:
@@ -35,13 +60,8 @@ func (r *IDResolver) get(ctx context.Context, t any, id string) (string, error)
 			// TODO(thaJeztah): should error-handling be more specific, or is it ok to ignore any error?
 			return id, nil //nolint:nilerr // ignore nil-error being returned, as this is a best-effort.
 		}
-		if res.Node.Spec.Annotations.Name != "" {
-			return res.Node.Spec.Annotations.Name, nil
-		}
-		if res.Node.Description.Hostname != "" {
-			return res.Node.Description.Hostname, nil
-		}
-		return id, nil
+		r.nodes[id] = res.Node
+		return nodeName(res.Node, id), nil
 	case swarm.Service:
 		res, err := r.client.ServiceInspect(ctx, id, client.ServiceInspectOptions{})
 		if err != nil {
@@ -54,6 +74,18 @@ func (r *IDResolver) get(ctx context.Context, t any, id string) (string, error)
 	}
 }
 
+// nodeName returns the name of the node, its hostname if the node has no
+// name, or the given ID if neither is set.
+func nodeName(n swarm.Node, id string) string {
+	if n.Spec.Annotations.Name != "" {
+		return n.Spec.Annotations.Name
+	}
+	if n.Description.Hostname != "" {
+		return n.Description.Hostname
+	}
+	return id
+}
+
 // Resolve will attempt to resolve an ID to a Name by querying the manager.
 // Results are stored into a cache.
 // If the `-n` flag is used in the command-line, resolution is disabled.
@@ -71,3 +103,51 @@ func (r *IDResolver) Resolve(ctx context.Context, t any, id string) (string, err
 	r.cache[id] = name
 	return name, nil
 }
+
+// Node returns the node that was inspected when resolving the given node ID.
+// It returns false if the node was not resolved, either because resolution
+// is disabled, or because the node could not be inspected.
+func (r *IDResolver) Node(id string) (swarm.Node, bool) {
+	n, ok := r.nodes[id]
+	return n, ok
+}
+
+// NodeID resolves a node name to a node ID, the reverse of resolving a node
+// ID to a name. The name is matched against the name and the hostname of all
+// nodes. If no node matches, nameOrID is looked up as a node ID, or ID prefix.
+// An error is returned if the name is ambiguous, or if no node is found.
+//
+// Unlike [IDResolver.Resolve], resolution is not disabled by the `-n` flag,
+// as the name is used to select nodes, not for display.
+func (r *IDResolver) NodeID(ctx context.Context, nameOrID string) (string, error) {
+	res, err := r.client.NodeList(ctx, client.NodeListOptions{})
+	if err != nil {
+		return "", err
+	}
+	var matches []string
+	for _, n := range res.Items {
+		r.nodes[n.ID] = n
+		r.cache[n.ID] = nodeName(n, n.ID)
+		if n.Spec.Annotations.Name == nameOrID || n.Description.Hostname == nameOrID {
+			matches = append(matches, n.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+	case 1:
+		return matches[0], nil
+	default:
+		return "", errdefs.ErrInvalidArgument.WithMessage(fmt.Sprintf("node name %s is ambiguous (%d matches found)", nameOrID, len(matches)))
+	}
+
+	n, err := r.client.NodeInspect(ctx, nameOrID, client.NodeInspectOptions{})
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return "", errdefs.ErrNotFound.WithMessage("no such node: " + nameOrID)
+		}
+		return "", err
+	}
+	r.nodes[n.Node.ID] = n.Node
+	r.cache[n.Node.ID] = nodeName(n.Node, n.Node.ID)
+	return n.Node.ID, nil
+}
@@ -5,6 +5,7 @@
 	"errors"
 	"testing"
 
+	"github.com/containerd/errdefs"
 	"github.com/docker/cli/internal/test/builders"
 	"github.com/moby/moby/api/types/swarm"
 	"github.com/moby/moby/client"
@@ -151,3 +152,179 @@ func TestResolveService(t *testing.T) {
 		assert.Check(t, is.Equal(tc.expectedID, id))
 	}
 }
+
+func TestResolveNodeInfo(t *testing.T) {
+	apiClient := &fakeClient{
+		nodeInspectFunc: func(nodeID string) (client.NodeInspectResult, error) {
+			if nodeID != "nodeID" {
+				return client.NodeInspectResult{}, errors.New("error inspecting node")
+			}
+			return client.NodeInspectResult{
+				Node: *builders.Node(builders.NodeName("node-foo")),
+			}, nil
+		},
+	}
+
+	ctx := context.Background()
+	idResolver := New(apiClient, false)
+	_, ok := idResolver.Node("nodeID")
+	assert.Check(t, !ok, "expected node to not be resolved before calling Resolve")
+
+	_, err := idResolver.Resolve(ctx, swarm.Node{}, "nodeID")
+	assert.NilError(t, err)
+	n, ok := idResolver.Node("nodeID")
+	assert.Check(t, ok)
+	assert.Check(t, is.Equal(n.Spec.Annotations.Name, "node-foo"))
+
+	_, err = idResolver.Resolve(ctx, swarm.Node{}, "otherID")
+	assert.NilError(t, err)
+	_, ok = idResolver.Node("otherID")
+	assert.Check(t, !ok, "expected node to not be resolved if inspecting failed")
+
+	idResolver = New(apiClient, true)
+	_, err = idResolver.Resolve(ctx, swarm.Node{}, "nodeID")
+	assert.NilError(t, err)
+	_, ok = idResolver.Node("nodeID")
+	assert.Check(t, !ok, "expected node to not be resolved with resolution disabled")
+}
+
+func TestResolveWithPrefetch(t *testing.T) {
+	var listCounter, inspectCounter int
+	apiClient := &fakeClient{
+		nodeListFunc: func(client.NodeListOptions) (client.NodeListResult, error) {
+			listCounter++
+			return client.NodeListResult{
+				Items: []swarm.Node{
+					*builders.Node(builders.NodeID("node-id-1"), builders.NodeName("node-foo")),
+					*builders.Node(builders.NodeID("node-id-2"), builders.NodeName(""), builders.Hostname("node-bar")),
+				},
+			}, nil
+		},
+		nodeInspectFunc: func(nodeID string) (client.NodeInspectResult, error) {
+			inspectCounter++
+			return client.NodeInspectResult{
+				Node: *builders.Node(builders.NodeID(nodeID), builders.NodeName("node-baz")),
+			}, nil
+		},
+	}
+
+	ctx := context.Background()
+	idResolver := NewWithPrefetch(ctx, apiClient, false)
+	for _, tc := range []struct{ id, expected string }{
+		{id: "node-id-1", expected: "node-foo"},
+		{id: "node-id-2", expected: "node-bar"},
+		{id: "node-id-3", expected: "node-baz"},
+		{id: "node-id-1", expected: "node-foo"},
+	} {
+		name, err := idResolver.Resolve(ctx, swarm.Node{}, tc.id)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(name, tc.expected))
+	}
+	assert.Check(t, is.Equal(listCounter, 1))
+	assert.Check(t, is.Equal(inspectCounter, 1), "only nodes that were not listed should be inspected")
+
+	n, ok := idResolver.Node("node-id-2")
+	assert.Check(t, ok)
+	assert.Check(t, is.Equal(n.Description.Hostname, "node-bar"))
+}
+
+func TestResolveWithPrefetchListError(t *testing.T) {
+	var inspectCounter int
+	apiClient := &fakeClient{
+		nodeListFunc: func(client.NodeListOptions) (client.NodeListResult, error) {
+			return client.NodeListResult{}, errors.New("error listing nodes")
+		},
+		nodeInspectFunc: func(string) (client.NodeInspectResult, error) {
+			inspectCounter++
+			return client.NodeInspectResult{
+				Node: *builders.Node(builders.NodeName("node-foo")),
+			}, nil
+		},
+	}
+
+	ctx := context.Background()
+	name, err := NewWithPrefetch(ctx, apiClient, false).Resolve(ctx, swarm.Node{}, "nodeID")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(name, "node-foo"))
+	assert.Check(t, is.Equal(inspectCounter, 1))
+}
+
+func TestResolveWithPrefetchNoResolve(t *testing.T) {
+	apiClient := &fakeClient{
+		nodeListFunc: func(client.NodeListOptions) (client.NodeListResult, error) {
+			t.Error("nodes should not be listed if resolution is disabled")
+			return client.NodeListResult{}, nil
+		},
+	}
+
+	ctx := context.Background()
+	name, err := NewWithPrefetch(ctx, apiClient, true).Resolve(ctx, swarm.Node{}, "nodeID")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(name, "nodeID"))
+}
+
+func TestNodeID(t *testing.T) {
+	nodes := []swarm.Node{
+		*builders.Node(builders.NodeID("node-id-1"), builders.NodeName("node-foo"), builders.Hostname("host-1")),
+		*builders.Node(builders.NodeID("node-id-2"), builders.NodeName(""), builders.Hostname("node-bar")),
+		*builders.Node(builders.NodeID("node-id-3"), builders.NodeName(""), builders.Hostname("node-dup")),
+		*builders.Node(builders.NodeID("node-id-4"), builders.NodeName("node-dup"), builders.Hostname("host-4")),
+	}
+	apiClient := &fakeClient{
+		nodeListFunc: func(client.NodeListOptions) (client.NodeListResult, error) {
+			return client.NodeListResult{Items: nodes}, nil
+		},
+		nodeInspectFunc: func(ref string) (client.NodeInspectResult, error) {
+			if ref == "node-id-5" {
+				return client.NodeInspectResult{
+					Node: *builders.Node(builders.NodeID("node-id-5"), builders.NodeName("node-baz")),
+				}, nil
+			}
+			return client.NodeInspectResult{}, errdefs.ErrNotFound.WithMessage("node " + ref + " not found")
+		},
+	}
+
+	testCases := []struct {
+		doc           string
+		nameOrID      string
+		expectedID    string
+		expectedError string
+	}{
+		{
+			doc:        "node name",
+			nameOrID:   "node-foo",
+			expectedID: "node-id-1",
+		},
+		{
+			doc:        "hostname",
+			nameOrID:   "node-bar",
+			expectedID: "node-id-2",
+		},
+		{
+			doc:        "node ID",
+			nameOrID:   "node-id-5",
+			expectedID: "node-id-5",
+		},
+		{
+			doc:           "ambiguous",
+			nameOrID:      "node-dup",
+			expectedError: "node name node-dup is ambiguous (2 matches found)",
+		},
+		{
+			doc:           "unknown",
+			nameOrID:      "node-unknown",
+			expectedError: "no such node: node-unknown",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			id, err := New(apiClient, false).NodeID(context.Background(), tc.nameOrID)
+			if tc.expectedError != "" {
+				assert.Check(t, is.Error(err, tc.expectedError))
+				return
+			}
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(id, tc.expectedID))
+		})
+	}
+}
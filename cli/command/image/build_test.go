@@ -16,6 +16,7 @@
 	"github.com/google/go-cmp/cmp"
 	"github.com/moby/go-archive/compression"
 	"github.com/moby/moby/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 	"gotest.tools/v3/skip"
@@ -172,6 +173,45 @@ func TestRunBuildWithSymlinkedContext(t *testing.T) {
 	assert.DeepEqual(t, fakeBuild.filenames(t), []string{"Dockerfile"})
 }
 
+func TestBuildDefaultPlatform(t *testing.T) {
+	t.Setenv("DOCKER_BUILDKIT", "0")
+	t.Setenv("DOCKER_DEFAULT_PLATFORM", "linux/arm64")
+
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile("Dockerfile", "FROM alpine:frozen"),
+	)
+	defer dir.Remove()
+
+	testCases := []struct {
+		doc      string
+		args     []string
+		expected []ocispec.Platform
+	}{
+		{
+			doc:      "from environment",
+			args:     []string{dir.Path()},
+			expected: []ocispec.Platform{{OS: "linux", Architecture: "arm64"}},
+		},
+		{
+			doc:      "flag overrides environment",
+			args:     []string{"--platform", "linux/amd64", dir.Path()},
+			expected: []ocispec.Platform{{OS: "linux", Architecture: "amd64"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			fakeBuild := newFakeBuild()
+			cli := test.NewFakeCli(&fakeClient{imageBuildFunc: fakeBuild.build})
+			cmd := newBuildCommand(cli)
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.NilError(t, cmd.Execute())
+			assert.DeepEqual(t, fakeBuild.options.Platforms, tc.expected)
+		})
+	}
+}
+
 type fakeBuild struct {
 	context *tar.Reader
 	options client.ImageBuildOptions
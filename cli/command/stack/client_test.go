@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"io"
 	"strings"
 
 	"github.com/docker/cli/cli/compose/convert"
@@ -23,18 +24,22 @@ type fakeClient struct {
 	removedSecrets  []string
 	removedConfigs  []string
 
-	serviceListFunc   func(options client.ServiceListOptions) (client.ServiceListResult, error)
-	networkListFunc   func(options client.NetworkListOptions) (client.NetworkListResult, error)
-	secretListFunc    func(options client.SecretListOptions) (client.SecretListResult, error)
-	configListFunc    func(options client.ConfigListOptions) (client.ConfigListResult, error)
-	nodeListFunc      func(options client.NodeListOptions) (client.NodeListResult, error)
-	taskListFunc      func(options client.TaskListOptions) (client.TaskListResult, error)
-	nodeInspectFunc   func(ref string) (client.NodeInspectResult, error)
-	serviceUpdateFunc func(serviceID string, options client.ServiceUpdateOptions) (client.ServiceUpdateResult, error)
-	serviceRemoveFunc func(serviceID string) (client.ServiceRemoveResult, error)
-	networkRemoveFunc func(networkID string) error
-	secretRemoveFunc  func(secretID string) (client.SecretRemoveResult, error)
-	configRemoveFunc  func(configID string) (client.ConfigRemoveResult, error)
+	serviceListFunc    func(options client.ServiceListOptions) (client.ServiceListResult, error)
+	networkListFunc    func(options client.NetworkListOptions) (client.NetworkListResult, error)
+	secretListFunc     func(options client.SecretListOptions) (client.SecretListResult, error)
+	configListFunc     func(options client.ConfigListOptions) (client.ConfigListResult, error)
+	nodeListFunc       func(options client.NodeListOptions) (client.NodeListResult, error)
+	taskListFunc       func(options client.TaskListOptions) (client.TaskListResult, error)
+	nodeInspectFunc    func(ref string) (client.NodeInspectResult, error)
+	imageInspectFunc   func(image string) (client.ImageInspectResult, error)
+	serviceCreateFunc  func(options client.ServiceCreateOptions) (client.ServiceCreateResult, error)
+	serviceUpdateFunc  func(serviceID string, options client.ServiceUpdateOptions) (client.ServiceUpdateResult, error)
+	serviceRemoveFunc  func(serviceID string) (client.ServiceRemoveResult, error)
+	networkRemoveFunc  func(networkID string) error
+	secretRemoveFunc   func(secretID string) (client.SecretRemoveResult, error)
+	configRemoveFunc   func(configID string) (client.ConfigRemoveResult, error)
+	serviceInspectFunc func(serviceID string) (client.ServiceInspectResult, error)
+	eventsFunc         func(options client.EventsListOptions) client.EventsResult
 }
 
 func (*fakeClient) ServerVersion(context.Context, client.ServerVersionOptions) (client.ServerVersionResult, error) {
@@ -128,6 +133,21 @@ func (cli *fakeClient) NodeInspect(_ context.Context, ref string, _ client.NodeI
 	return client.NodeInspectResult{}, nil
 }
 
+func (cli *fakeClient) ImageInspect(_ context.Context, image string, _ ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+	if cli.imageInspectFunc != nil {
+		return cli.imageInspectFunc(image)
+	}
+	return client.ImageInspectResult{}, nil
+}
+
+func (cli *fakeClient) ServiceCreate(_ context.Context, options client.ServiceCreateOptions) (client.ServiceCreateResult, error) {
+	if cli.serviceCreateFunc != nil {
+		return cli.serviceCreateFunc(options)
+	}
+
+	return client.ServiceCreateResult{}, nil
+}
+
 func (cli *fakeClient) ServiceUpdate(_ context.Context, serviceID string, options client.ServiceUpdateOptions) (client.ServiceUpdateResult, error) {
 	if cli.serviceUpdateFunc != nil {
 		return cli.serviceUpdateFunc(serviceID, options)
@@ -172,7 +192,10 @@ func (cli *fakeClient) ConfigRemove(_ context.Context, configID string, _ client
 	return client.ConfigRemoveResult{}, nil
 }
 
-func (*fakeClient) ServiceInspect(_ context.Context, serviceID string, _ client.ServiceInspectOptions) (client.ServiceInspectResult, error) {
+func (cli *fakeClient) ServiceInspect(_ context.Context, serviceID string, _ client.ServiceInspectOptions) (client.ServiceInspectResult, error) {
+	if cli.serviceInspectFunc != nil {
+		return cli.serviceInspectFunc(serviceID)
+	}
 	return client.ServiceInspectResult{
 		Service: swarm.Service{
 			ID: serviceID,
@@ -185,6 +208,15 @@ func (*fakeClient) ServiceInspect(_ context.Context, serviceID string, _ client.
 	}, nil
 }
 
+func (cli *fakeClient) Events(_ context.Context, options client.EventsListOptions) client.EventsResult {
+	if cli.eventsFunc != nil {
+		return cli.eventsFunc(options)
+	}
+	errs := make(chan error, 1)
+	errs <- io.EOF
+	return client.EventsResult{Err: errs}
+}
+
 func serviceFromName(name string) swarm.Service {
 	return swarm.Service{
 		ID: "ID-" + name,
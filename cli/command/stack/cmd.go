@@ -50,9 +50,10 @@ func newStackCommand(dockerCLI command.Cli) *cobra.Command {
 	return cmd
 }
 
-// completeNames offers completion for swarm stacks
+// completeNames offers completion for swarm stacks. Stacks that were already
+// passed as argument are not offered again.
 func completeNames(dockerCLI completion.APIClientProvider) cobra.CompletionFunc {
-	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completion.Unique(func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		list, err := getStacks(cmd.Context(), dockerCLI.Client())
 		if err != nil {
 			return nil, cobra.ShellCompDirectiveError
@@ -62,5 +63,5 @@ func completeNames(dockerCLI completion.APIClientProvider) cobra.CompletionFunc
 			names = append(names, stack.Name)
 		}
 		return names, cobra.ShellCompDirectiveNoFileComp
-	}
+	})
 }
@@ -3,6 +3,8 @@
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -11,20 +13,32 @@
 	"github.com/moby/moby/client"
 )
 
-// validateStackName checks if the provided string is a valid stack name (namespace).
-// It currently only does a rudimentary check if the string is empty, or consists
-// of only whitespace and quoting characters.
-func validateStackName(namespace string) error {
-	v := strings.TrimFunc(namespace, quotesOrWhitespace)
+// validNamespace matches the stack names that are allowed. The stack name is
+// used as a prefix for the names of services, networks, secrets and configs,
+// and as the value of the namespace label. It follows the rules of swarm for
+// the names of services, which must be valid DNS names.
+var validNamespace = regexp.MustCompile(`^[a-zA-Z0-9](?:[-_]*[a-zA-Z0-9]+)*$`)
+
+// ValidateNamespace checks if the provided string is a valid stack name
+// (namespace). A name is invalid if it's empty or consists of only whitespace
+// and quoting characters, or if it contains characters other than letters,
+// digits, "_" and "-", or does not start and end with a letter or digit.
+// The length of the names of services in the stack is checked when deploying;
+// see [validateServiceNames].
+func ValidateNamespace(name string) error {
+	v := strings.TrimFunc(name, quotesOrWhitespace)
 	if v == "" {
-		return fmt.Errorf("invalid stack name: %q", namespace)
+		return fmt.Errorf("invalid stack name: %q", name)
+	}
+	if !validNamespace.MatchString(name) {
+		return fmt.Errorf(`invalid stack name: %q: must start and end with a letter or digit, and only contain letters, digits, "_" and "-"`, name)
 	}
 	return nil
 }
 
 func validateStackNames(namespaces []string) error {
 	for _, ns := range namespaces {
-		if err := validateStackName(ns); err != nil {
+		if err := ValidateNamespace(ns); err != nil {
 			return err
 		}
 	}
@@ -39,12 +53,46 @@ func getStackFilter(namespace string) client.Filters {
 	return make(client.Filters).Add("label", convert.LabelNamespace+"="+namespace)
 }
 
+// negatedLabelFilter is the filter term to exclude objects with a label, as
+// in "--filter label!=tier=frontend". The API doesn't support negated filters,
+// so this filter is applied on the client; see [getNegatedLabels].
+const negatedLabelFilter = "label!"
+
+// getStackFilterFromOpt returns the filters to send to the API for the
+// objects of the stack, including the user filters. Negated label filters are
+// not included, as they're not supported by the API.
 func getStackFilterFromOpt(namespace string, opt opts.FilterOpt) client.Filters {
-	filter := opt.Value()
+	filter := opt.Value().Clone()
+	delete(filter, negatedLabelFilter)
 	filter.Add("label", convert.LabelNamespace+"="+namespace)
 	return filter
 }
 
+// getNegatedLabels returns the values of the negated label filters in opt,
+// each either a label name ("tier"), or a label name and value
+// ("tier=frontend"), sorted for a stable output.
+func getNegatedLabels(opt opts.FilterOpt) []string {
+	values := make([]string, 0, len(opt.Value()[negatedLabelFilter]))
+	for value := range opt.Value()[negatedLabelFilter] {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// hasAnyLabel returns whether labels matches any of the given label filters.
+// A filter without a value ("tier") matches if the label is set, regardless
+// of its value.
+func hasAnyLabel(labels map[string]string, filters []string) bool {
+	for _, f := range filters {
+		key, value, hasValue := strings.Cut(f, "=")
+		if v, ok := labels[key]; ok && (!hasValue || v == value) {
+			return true
+		}
+	}
+	return false
+}
+
 func getAllStacksFilter() client.Filters {
 	return make(client.Filters).Add("label", convert.LabelNamespace)
 }
@@ -0,0 +1,39 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestValidateNamespace(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectedErr string
+	}{
+		{name: "foo"},
+		{name: "foo_bar-baz-1"},
+		{name: "foo--bar__baz"},
+		{name: "1foo"},
+		{name: strings.Repeat("a", 100)},
+		{name: "", expectedErr: `invalid stack name: ""`},
+		{name: "'   '", expectedErr: `invalid stack name: "'   '"`},
+		{name: "foo bar", expectedErr: "must start and end with a letter or digit"},
+		{name: "foo/bar", expectedErr: "must start and end with a letter or digit"},
+		{name: "foo.bar", expectedErr: "must start and end with a letter or digit"},
+		{name: "_foo", expectedErr: "must start and end with a letter or digit"},
+		{name: "-foo", expectedErr: "must start and end with a letter or digit"},
+		{name: "foo-", expectedErr: "must start and end with a letter or digit"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateNamespace(tc.name)
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/compose/convert"
 	composetypes "github.com/docker/cli/cli/compose/types"
+	"github.com/docker/cli/internal/retry"
 	"github.com/moby/moby/api/types/swarm"
 	"github.com/moby/moby/client"
 	"github.com/spf13/cobra"
@@ -24,6 +25,8 @@ type deployOptions struct {
 	prune            bool
 	detach           bool
 	quiet            bool
+	dryRun           bool
+	retry            retry.Options
 }
 
 func newDeployCommand(dockerCLI command.Cli) *cobra.Command {
@@ -36,7 +39,7 @@ func newDeployCommand(dockerCLI command.Cli) *cobra.Command {
 		Args:    cli.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.namespace = args[0]
-			if err := validateStackName(opts.namespace); err != nil {
+			if err := ValidateNamespace(opts.namespace); err != nil {
 				return err
 			}
 			config, err := loadComposeFile(dockerCLI, opts)
@@ -65,6 +68,8 @@ func newDeployCommand(dockerCLI command.Cli) *cobra.Command {
 	flags.SetAnnotation("resolve-image", "version", []string{"1.30"})
 	flags.BoolVarP(&opts.detach, "detach", "d", true, "Exit immediately instead of waiting for the stack services to converge")
 	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress progress output")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "Print the changes that would be made, without deploying the stack")
+	retry.AddFlags(flags, &opts.retry)
 	return cmd
 }
 
@@ -85,6 +90,13 @@ func runDeploy(ctx context.Context, dockerCLI command.Cli, flags *pflag.FlagSet,
 	default:
 		return fmt.Errorf("invalid option %s for flag --resolve-image", opts.resolveImage)
 	}
+	if err := opts.retry.LoadEnv(flags); err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		return deployComposeDryRun(ctx, dockerCLI, opts, cfg)
+	}
 
 	if opts.detach && !flags.Changed("detach") {
 		_, _ = fmt.Fprintln(dockerCLI.Err(), "Since --detach=false was not specified, tasks will be created in the background.\n"+
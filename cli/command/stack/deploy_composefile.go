@@ -10,6 +10,7 @@
 	"github.com/docker/cli/cli/command/service"
 	"github.com/docker/cli/cli/compose/convert"
 	composetypes "github.com/docker/cli/cli/compose/types"
+	"github.com/docker/cli/internal/retry"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/api/types/swarm"
@@ -17,11 +18,14 @@
 )
 
 func deployCompose(ctx context.Context, dockerCli command.Cli, opts *deployOptions, config *composetypes.Config) error {
-	if err := checkDaemonIsSwarmManager(ctx, dockerCli); err != nil {
+	namespace := convert.NewNamespace(opts.namespace)
+	if err := validateServiceNames(namespace, config.Services); err != nil {
 		return err
 	}
 
-	namespace := convert.NewNamespace(opts.namespace)
+	if err := checkDaemonIsSwarmManager(ctx, dockerCli); err != nil {
+		return err
+	}
 
 	if opts.prune {
 		services := map[string]struct{}{}
@@ -61,7 +65,7 @@ func deployCompose(ctx context.Context, dockerCli command.Cli, opts *deployOptio
 		return err
 	}
 
-	serviceIDs, err := deployServices(ctx, dockerCli, services, namespace, opts.sendRegistryAuth, opts.resolveImage)
+	serviceIDs, err := deployServices(ctx, dockerCli, services, namespace, opts.sendRegistryAuth, opts.resolveImage, opts.retry)
 	if err != nil {
 		return err
 	}
@@ -73,6 +77,21 @@ func deployCompose(ctx context.Context, dockerCli command.Cli, opts *deployOptio
 	return waitOnServices(ctx, dockerCli, serviceIDs, opts.quiet)
 }
 
+// maxServiceNameLength is the maximum length of the name of a service, which
+// swarm limits to 63 characters, as it's used as a DNS name.
+const maxServiceNameLength = 63
+
+// validateServiceNames checks that the names of the services, prefixed with
+// the stack name, are not longer than allowed by swarm.
+func validateServiceNames(namespace convert.Namespace, services []composetypes.ServiceConfig) error {
+	for _, svc := range services {
+		if name := namespace.Scope(svc.Name); len(name) > maxServiceNameLength {
+			return fmt.Errorf("invalid service name: %q: the name of the service in the stack (%q) must be at most %d characters", svc.Name, name, maxServiceNameLength)
+		}
+	}
+	return nil
+}
+
 func getServicesDeclaredNetworks(serviceConfigs []composetypes.ServiceConfig) map[string]struct{} {
 	serviceNetworks := map[string]struct{}{}
 	for _, serviceConfig := range serviceConfigs {
@@ -199,7 +218,7 @@ func createNetworks(ctx context.Context, dockerCLI command.Cli, namespace conver
 	return nil
 }
 
-func deployServices(ctx context.Context, dockerCLI command.Cli, services map[string]swarm.ServiceSpec, namespace convert.Namespace, sendAuth bool, resolveImage string) ([]string, error) {
+func deployServices(ctx context.Context, dockerCLI command.Cli, services map[string]swarm.ServiceSpec, namespace convert.Namespace, sendAuth bool, resolveImage string, retryOpts retry.Options) ([]string, error) {
 	apiClient := dockerCLI.Client()
 	out := dockerCLI.Out()
 
@@ -213,6 +232,12 @@ func deployServices(ctx context.Context, dockerCLI command.Cli, services map[str
 		existingServiceMap[svc.Spec.Name] = svc
 	}
 
+	// Creating and updating services is not idempotent, so only requests that
+	// were not handled by the daemon are retried. Other errors, such as
+	// interrupted connections, may occur after the service was created, or
+	// after the version of the service was updated.
+	retryOpts.Retryable = retry.IsNotDelivered
+
 	var serviceIDs []string
 
 	for internalName, serviceSpec := range services {
@@ -238,29 +263,13 @@ func deployServices(ctx context.Context, dockerCLI command.Cli, services map[str
 				EncodedRegistryAuth: encodedAuth,
 			}
 
-			switch resolveImage {
-			case resolveImageAlways:
-				// image should be updated by the server using QueryRegistry
-				updateOpts.QueryRegistry = true
-			case resolveImageChanged:
-				if image != svc.Spec.Labels[convert.LabelImage] {
-					// Query the registry to resolve digest for the updated image
-					updateOpts.QueryRegistry = true
-				} else {
-					// image has not changed; update the serviceSpec with the
-					// existing information that was set by QueryRegistry on the
-					// previous deploy. Otherwise this will trigger an incorrect
-					// service update.
-					serviceSpec.TaskTemplate.ContainerSpec.Image = svc.Spec.TaskTemplate.ContainerSpec.Image
-				}
-			default:
-				if image == svc.Spec.Labels[convert.LabelImage] {
-					// image has not changed; update the serviceSpec with the
-					// existing information that was set by QueryRegistry on the
-					// previous deploy. Otherwise this will trigger an incorrect
-					// service update.
-					serviceSpec.TaskTemplate.ContainerSpec.Image = svc.Spec.TaskTemplate.ContainerSpec.Image
-				}
+			updateOpts.QueryRegistry = queryRegistry(resolveImage, image, &svc)
+			if !updateOpts.QueryRegistry && image == svc.Spec.Labels[convert.LabelImage] {
+				// image has not changed; update the serviceSpec with the
+				// existing information that was set by QueryRegistry on the
+				// previous deploy. Otherwise this will trigger an incorrect
+				// service update.
+				serviceSpec.TaskTemplate.ContainerSpec.Image = svc.Spec.TaskTemplate.ContainerSpec.Image
 			}
 
 			// Stack deploy does not have a `--force` option. Preserve existing
@@ -269,7 +278,11 @@ func deployServices(ctx context.Context, dockerCLI command.Cli, services map[str
 			serviceSpec.TaskTemplate.ForceUpdate = svc.Spec.TaskTemplate.ForceUpdate
 
 			updateOpts.Spec = serviceSpec
-			response, err := apiClient.ServiceUpdate(ctx, svc.ID, updateOpts)
+			var response client.ServiceUpdateResult
+			err := retry.Do(ctx, retryOpts, func() (err error) {
+				response, err = apiClient.ServiceUpdate(ctx, svc.ID, updateOpts)
+				return err
+			})
 			if err != nil {
 				return nil, fmt.Errorf("failed to update service %s: %w", name, err)
 			}
@@ -282,13 +295,15 @@ func deployServices(ctx context.Context, dockerCLI command.Cli, services map[str
 		} else {
 			_, _ = fmt.Fprintln(out, "Creating service", name)
 
-			// query registry if flag disabling it was not set
-			queryRegistry := resolveImage == resolveImageAlways || resolveImage == resolveImageChanged
-
-			response, err := apiClient.ServiceCreate(ctx, client.ServiceCreateOptions{
+			createOpts := client.ServiceCreateOptions{
 				Spec:                serviceSpec,
 				EncodedRegistryAuth: encodedAuth,
-				QueryRegistry:       queryRegistry,
+				QueryRegistry:       queryRegistry(resolveImage, image, nil),
+			}
+			var response client.ServiceCreateResult
+			err := retry.Do(ctx, retryOpts, func() (err error) {
+				response, err = apiClient.ServiceCreate(ctx, createOpts)
+				return err
 			})
 			if err != nil {
 				return nil, fmt.Errorf("failed to create service %s: %w", name, err)
@@ -301,6 +316,22 @@ func deployServices(ctx context.Context, dockerCLI command.Cli, services map[str
 	return serviceIDs, nil
 }
 
+// queryRegistry returns whether the daemon should query the registry to
+// resolve the image digest and supported platforms for a service, based on
+// the --resolve-image mode. The existing service is nil for services that
+// are created by this deploy; such services are always resolved unless
+// resolving is disabled.
+func queryRegistry(resolveImage string, image string, existing *swarm.Service) bool {
+	switch resolveImage {
+	case resolveImageAlways:
+		return true
+	case resolveImageChanged:
+		return existing == nil || image != existing.Spec.Labels[convert.LabelImage]
+	default:
+		return false
+	}
+}
+
 func waitOnServices(ctx context.Context, dockerCli command.Cli, serviceIDs []string, quiet bool) error {
 	var errs []error
 	for _, serviceID := range serviceIDs {
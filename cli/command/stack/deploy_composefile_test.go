@@ -3,8 +3,11 @@
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
+	"github.com/docker/cli/cli/compose/convert"
+	composetypes "github.com/docker/cli/cli/compose/types"
 	"github.com/docker/cli/internal/test/network"
 	networktypes "github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/client"
@@ -70,3 +73,33 @@ func TestValidateExternalNetworks(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateServiceNames(t *testing.T) {
+	testcases := []struct {
+		namespace   string
+		service     string
+		expectedErr string
+	}{
+		{namespace: "foo", service: "web"},
+		{namespace: "foo", service: strings.Repeat("a", 59)},
+		{namespace: strings.Repeat("a", 61), service: "b"},
+		{
+			namespace:   "foo",
+			service:     strings.Repeat("a", 60),
+			expectedErr: "must be at most 63 characters",
+		},
+		{
+			namespace:   strings.Repeat("a", 62),
+			service:     "b",
+			expectedErr: "must be at most 63 characters",
+		},
+	}
+	for _, tc := range testcases {
+		err := validateServiceNames(convert.NewNamespace(tc.namespace), []composetypes.ServiceConfig{{Name: tc.service}})
+		if tc.expectedErr == "" {
+			assert.NilError(t, err)
+		} else {
+			assert.ErrorContains(t, err, tc.expectedErr)
+		}
+	}
+}
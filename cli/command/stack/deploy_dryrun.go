@@ -0,0 +1,165 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/errdefs"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/compose/convert"
+	composetypes "github.com/docker/cli/cli/compose/types"
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+)
+
+// deployComposeDryRun prints the changes that deploying the stack would
+// make, without creating, updating, or removing any objects.
+func deployComposeDryRun(ctx context.Context, dockerCLI command.Cli, opts *deployOptions, config *composetypes.Config) error {
+	if err := checkDaemonIsSwarmManager(ctx, dockerCLI); err != nil {
+		return err
+	}
+
+	apiClient := dockerCLI.Client()
+	out := dockerCLI.Out()
+	namespace := convert.NewNamespace(opts.namespace)
+
+	serviceNetworks := getServicesDeclaredNetworks(config.Services)
+	networks, externalNetworks := convert.Networks(namespace, config.Networks, serviceNetworks)
+	if err := validateExternalNetworks(ctx, apiClient, externalNetworks); err != nil {
+		return err
+	}
+	existingNetworks, err := getStackNetworks(ctx, apiClient, namespace.Name())
+	if err != nil {
+		return err
+	}
+	existingNetworkMap := make(map[string]struct{}, len(existingNetworks.Items))
+	for _, nw := range existingNetworks.Items {
+		existingNetworkMap[nw.Name] = struct{}{}
+	}
+	var toCreate []string
+	for name := range networks {
+		if _, exists := existingNetworkMap[name]; !exists {
+			toCreate = append(toCreate, name)
+		}
+	}
+	sort.Strings(toCreate)
+	for _, name := range toCreate {
+		_, _ = fmt.Fprintln(out, "Would create network", name)
+	}
+
+	secrets, err := convert.Secrets(namespace, config.Secrets)
+	if err != nil {
+		return err
+	}
+	for _, secretSpec := range secrets {
+		_, err := apiClient.SecretInspect(ctx, secretSpec.Name, client.SecretInspectOptions{})
+		switch {
+		case err == nil:
+			_, _ = fmt.Fprintln(out, "Would update secret", secretSpec.Name)
+		case errdefs.IsNotFound(err):
+			_, _ = fmt.Fprintln(out, "Would create secret", secretSpec.Name)
+		default:
+			return err
+		}
+	}
+
+	configs, err := convert.Configs(namespace, config.Configs)
+	if err != nil {
+		return err
+	}
+	for _, configSpec := range configs {
+		_, err := apiClient.ConfigInspect(ctx, configSpec.Name, client.ConfigInspectOptions{})
+		switch {
+		case err == nil:
+			_, _ = fmt.Fprintln(out, "Would update config", configSpec.Name)
+		case errdefs.IsNotFound(err):
+			_, _ = fmt.Fprintln(out, "Would create config", configSpec.Name)
+		default:
+			return err
+		}
+	}
+
+	services, err := convert.Services(ctx, namespace, config, apiClient)
+	if err != nil {
+		return err
+	}
+	return planServices(ctx, dockerCLI, services, namespace, opts.prune)
+}
+
+// planServices prints the services that would be created, updated, or
+// (when pruning) removed. For services that would be updated, it prints
+// the update and rollback strategy that would be applied.
+func planServices(ctx context.Context, dockerCLI command.Cli, services map[string]swarm.ServiceSpec, namespace convert.Namespace, prune bool) error {
+	out := dockerCLI.Out()
+
+	existingServices, err := getStackServices(ctx, dockerCLI.Client(), namespace.Name())
+	if err != nil {
+		return err
+	}
+	existingServiceMap := make(map[string]swarm.Service, len(existingServices.Items))
+	for _, svc := range existingServices.Items {
+		existingServiceMap[svc.Spec.Name] = svc
+	}
+
+	internalNames := make([]string, 0, len(services))
+	for internalName := range services {
+		internalNames = append(internalNames, internalName)
+	}
+	sort.Strings(internalNames)
+	for _, internalName := range internalNames {
+		name := namespace.Scope(internalName)
+		svc, exists := existingServiceMap[name]
+		if !exists {
+			_, _ = fmt.Fprintln(out, "Would create service", name)
+			continue
+		}
+		serviceSpec := services[internalName]
+		_, _ = fmt.Fprintf(out, "Would update service %s (id: %s)\n", name, svc.ID)
+		printUpdateStrategy(out, "Update config:  ", serviceSpec.UpdateConfig)
+		printUpdateStrategy(out, "Rollback config:", serviceSpec.RollbackConfig)
+	}
+
+	if prune {
+		var toRemove []string
+		for _, svc := range existingServices.Items {
+			if _, ok := services[namespace.Descope(svc.Spec.Name)]; !ok {
+				toRemove = append(toRemove, svc.Spec.Name)
+			}
+		}
+		sort.Strings(toRemove)
+		for _, name := range toRemove {
+			_, _ = fmt.Fprintln(out, "Would remove service", name)
+		}
+	}
+	return nil
+}
+
+// printUpdateStrategy prints a one-line summary of an update or rollback
+// config. A nil config means the daemon's defaults are used.
+func printUpdateStrategy(out io.Writer, prefix string, cfg *swarm.UpdateConfig) {
+	if cfg == nil {
+		_, _ = fmt.Fprintf(out, "  %s default\n", prefix)
+		return
+	}
+	parts := []string{"parallelism=" + strconv.FormatUint(cfg.Parallelism, 10)}
+	if cfg.Delay > 0 {
+		parts = append(parts, "delay="+cfg.Delay.String())
+	}
+	if cfg.FailureAction != "" {
+		parts = append(parts, "failure-action="+string(cfg.FailureAction))
+	}
+	if cfg.Monitor > 0 {
+		parts = append(parts, "monitor="+cfg.Monitor.String())
+	}
+	if cfg.MaxFailureRatio > 0 {
+		parts = append(parts, "max-failure-ratio="+strconv.FormatFloat(float64(cfg.MaxFailureRatio), 'g', -1, 32))
+	}
+	if cfg.Order != "" {
+		parts = append(parts, "order="+string(cfg.Order))
+	}
+	_, _ = fmt.Fprintf(out, "  %s %s\n", prefix, strings.Join(parts, ", "))
+}
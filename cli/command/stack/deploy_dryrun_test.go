@@ -0,0 +1,64 @@
+package stack
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli/compose/convert"
+	"github.com/docker/cli/internal/test"
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func TestPlanServices(t *testing.T) {
+	namespace := convert.NewNamespace("mystack")
+	fakeCli := test.NewFakeCli(&fakeClient{
+		serviceListFunc: func(options client.ServiceListOptions) (client.ServiceListResult, error) {
+			return client.ServiceListResult{
+				Items: []swarm.Service{
+					{ID: "id-web", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "mystack_web"}}},
+					{ID: "id-worker", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "mystack_worker"}}},
+					{ID: "id-old", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "mystack_old"}}},
+				},
+			}, nil
+		},
+		serviceCreateFunc: func(options client.ServiceCreateOptions) (client.ServiceCreateResult, error) {
+			t.Error("unexpected service create")
+			return client.ServiceCreateResult{}, nil
+		},
+		serviceUpdateFunc: func(serviceID string, options client.ServiceUpdateOptions) (client.ServiceUpdateResult, error) {
+			t.Error("unexpected service update")
+			return client.ServiceUpdateResult{}, nil
+		},
+		serviceRemoveFunc: func(serviceID string) (client.ServiceRemoveResult, error) {
+			t.Error("unexpected service remove")
+			return client.ServiceRemoveResult{}, nil
+		},
+	})
+
+	services := map[string]swarm.ServiceSpec{
+		"web": {
+			UpdateConfig: &swarm.UpdateConfig{
+				Parallelism:     2,
+				Delay:           10 * time.Second,
+				FailureAction:   swarm.UpdateFailureActionRollback,
+				Monitor:         time.Minute,
+				MaxFailureRatio: 0.25,
+				Order:           swarm.UpdateOrderStartFirst,
+			},
+			RollbackConfig: &swarm.UpdateConfig{
+				Parallelism:   0,
+				FailureAction: swarm.UpdateFailureActionPause,
+				Order:         swarm.UpdateOrderStopFirst,
+			},
+		},
+		"worker": {},
+		"db":     {},
+	}
+
+	assert.NilError(t, planServices(context.Background(), fakeCli, services, namespace, true))
+	golden.Assert(t, fakeCli.OutBuffer().String(), "stack-deploy-dry-run-services.golden")
+}
@@ -2,11 +2,19 @@
 
 import (
 	"context"
+	"errors"
 	"io"
+	"net"
+	"os"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/containerd/errdefs"
 	"github.com/docker/cli/cli/compose/convert"
+	"github.com/docker/cli/internal/retry"
 	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/builders"
 	"github.com/moby/moby/api/types/swarm"
 	"github.com/moby/moby/client"
 	"gotest.tools/v3/assert"
@@ -106,7 +114,7 @@ func TestServiceUpdateResolveImageChanged(t *testing.T) {
 					},
 				},
 			}
-			_, err := deployServices(ctx, fakeCli, spec, namespace, false, resolveImageChanged)
+			_, err := deployServices(ctx, fakeCli, spec, namespace, false, resolveImageChanged, retry.Options{})
 			assert.NilError(t, err)
 			assert.Check(t, is.Equal(receivedOptions.QueryRegistry, tc.expectedQueryRegistry))
 			assert.Check(t, is.Equal(receivedOptions.Spec.TaskTemplate.ContainerSpec.Image, tc.expectedImage))
@@ -116,3 +124,191 @@ func TestServiceUpdateResolveImageChanged(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceResolveImageModes(t *testing.T) {
+	namespace := convert.NewNamespace("mystack")
+
+	testcases := []struct {
+		resolveImage string
+		image        string
+		existing     bool
+		expected     bool
+	}{
+		{resolveImage: resolveImageAlways, image: "foobar:1.2.3", expected: true},
+		{resolveImage: resolveImageAlways, image: "foobar:1.2.3", existing: true, expected: true},
+		{resolveImage: resolveImageAlways, image: "foobar:1.2.4", existing: true, expected: true},
+		{resolveImage: resolveImageChanged, image: "foobar:1.2.3", expected: true},
+		{resolveImage: resolveImageChanged, image: "foobar:1.2.3", existing: true, expected: false},
+		{resolveImage: resolveImageChanged, image: "foobar:1.2.4", existing: true, expected: true},
+		{resolveImage: resolveImageNever, image: "foobar:1.2.3", expected: false},
+		{resolveImage: resolveImageNever, image: "foobar:1.2.3", existing: true, expected: false},
+		{resolveImage: resolveImageNever, image: "foobar:1.2.4", existing: true, expected: false},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range testcases {
+		name := tc.resolveImage + "/" + tc.image
+		if tc.existing {
+			name += "/update"
+		} else {
+			name += "/create"
+		}
+		t.Run(name, func(t *testing.T) {
+			var (
+				existing      []swarm.Service
+				queryRegistry bool
+			)
+			if tc.existing {
+				existing = append(existing, swarm.Service{
+					Spec: swarm.ServiceSpec{
+						Annotations: swarm.Annotations{
+							Name:   namespace.Name() + "_myservice",
+							Labels: map[string]string{"com.docker.stack.image": "foobar:1.2.3"},
+						},
+						TaskTemplate: swarm.TaskSpec{
+							ContainerSpec: &swarm.ContainerSpec{
+								Image: "foobar:1.2.3@sha256:deadbeef",
+							},
+						},
+					},
+				})
+			}
+			fakeCli := test.NewFakeCli(&fakeClient{
+				serviceListFunc: func(options client.ServiceListOptions) (client.ServiceListResult, error) {
+					return client.ServiceListResult{Items: existing}, nil
+				},
+				serviceCreateFunc: func(options client.ServiceCreateOptions) (client.ServiceCreateResult, error) {
+					assert.Check(t, !tc.existing, "unexpected service create")
+					queryRegistry = options.QueryRegistry
+					return client.ServiceCreateResult{}, nil
+				},
+				serviceUpdateFunc: func(serviceID string, options client.ServiceUpdateOptions) (client.ServiceUpdateResult, error) {
+					assert.Check(t, tc.existing, "unexpected service update")
+					queryRegistry = options.QueryRegistry
+					return client.ServiceUpdateResult{}, nil
+				},
+			})
+			spec := map[string]swarm.ServiceSpec{
+				"myservice": {
+					TaskTemplate: swarm.TaskSpec{
+						ContainerSpec: &swarm.ContainerSpec{
+							Image: tc.image,
+						},
+					},
+				},
+			}
+			_, err := deployServices(ctx, fakeCli, spec, namespace, false, tc.resolveImage, retry.Options{})
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(queryRegistry, tc.expected))
+		})
+	}
+}
+
+func TestDeployInvalidResolveImage(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cmd := newDeployCommand(cli)
+	err := runDeploy(context.Background(), cli, cmd.Flags(), &deployOptions{resolveImage: "sometimes"}, nil)
+	assert.Error(t, err, "invalid option sometimes for flag --resolve-image")
+}
+
+func TestDeployServicesRetry(t *testing.T) {
+	namespace := convert.NewNamespace("mystack")
+	spec := map[string]swarm.ServiceSpec{
+		"myservice": {
+			TaskTemplate: swarm.TaskSpec{
+				ContainerSpec: &swarm.ContainerSpec{
+					Image: "foobar:1.2.3",
+				},
+			},
+		},
+	}
+	retryOpts := retry.Options{Retries: 2, InitialDelay: time.Millisecond}
+
+	t.Run("transient error", func(t *testing.T) {
+		var attempts int
+		fakeCli := test.NewFakeCli(&fakeClient{
+			serviceCreateFunc: func(client.ServiceCreateOptions) (client.ServiceCreateResult, error) {
+				attempts++
+				if attempts < 3 {
+					return client.ServiceCreateResult{}, errdefs.ErrUnavailable.WithMessage("swarm is busy")
+				}
+				return client.ServiceCreateResult{ID: "service-id"}, nil
+			},
+		})
+		ids, err := deployServices(context.Background(), fakeCli, spec, namespace, false, resolveImageNever, retryOpts)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(attempts, 3))
+		assert.Check(t, is.DeepEqual(ids, []string{"service-id"}))
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		var attempts int
+		fakeCli := test.NewFakeCli(&fakeClient{
+			serviceCreateFunc: func(client.ServiceCreateOptions) (client.ServiceCreateResult, error) {
+				attempts++
+				return client.ServiceCreateResult{}, errdefs.ErrUnavailable.WithMessage("swarm is busy")
+			},
+		})
+		_, err := deployServices(context.Background(), fakeCli, spec, namespace, false, resolveImageNever, retryOpts)
+		assert.Check(t, is.ErrorContains(err, "failed to create service mystack_myservice: swarm is busy"))
+		assert.Check(t, is.Equal(attempts, 3))
+	})
+
+	t.Run("interrupted connection", func(t *testing.T) {
+		// The service may have been created, so the request is not retried.
+		var attempts int
+		fakeCli := test.NewFakeCli(&fakeClient{
+			serviceCreateFunc: func(client.ServiceCreateOptions) (client.ServiceCreateResult, error) {
+				attempts++
+				return client.ServiceCreateResult{}, &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+			},
+		})
+		_, err := deployServices(context.Background(), fakeCli, spec, namespace, false, resolveImageNever, retryOpts)
+		assert.Check(t, is.ErrorContains(err, "failed to create service mystack_myservice"))
+		assert.Check(t, is.Equal(attempts, 1))
+	})
+
+	t.Run("update refused", func(t *testing.T) {
+		var attempts int
+		fakeCli := test.NewFakeCli(&fakeClient{
+			serviceListFunc: func(client.ServiceListOptions) (client.ServiceListResult, error) {
+				return client.ServiceListResult{
+					Items: []swarm.Service{*builders.Service(builders.ServiceID("service-id"), builders.ServiceName("mystack_myservice"))},
+				}, nil
+			},
+			serviceUpdateFunc: func(string, client.ServiceUpdateOptions) (client.ServiceUpdateResult, error) {
+				attempts++
+				if attempts < 2 {
+					return client.ServiceUpdateResult{}, &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+				}
+				return client.ServiceUpdateResult{}, nil
+			},
+		})
+		ids, err := deployServices(context.Background(), fakeCli, spec, namespace, false, resolveImageNever, retryOpts)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(attempts, 2))
+		assert.Check(t, is.DeepEqual(ids, []string{"service-id"}))
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		var attempts int
+		fakeCli := test.NewFakeCli(&fakeClient{
+			serviceCreateFunc: func(client.ServiceCreateOptions) (client.ServiceCreateResult, error) {
+				attempts++
+				return client.ServiceCreateResult{}, errors.New("invalid spec")
+			},
+		})
+		_, err := deployServices(context.Background(), fakeCli, spec, namespace, false, resolveImageNever, retryOpts)
+		assert.Check(t, is.ErrorContains(err, "invalid spec"))
+		assert.Check(t, is.Equal(attempts, 1))
+	})
+}
+
+func TestDeployInvalidRetries(t *testing.T) {
+	t.Setenv(retry.EnvRetries, "many")
+	cli := test.NewFakeCli(&fakeClient{})
+	cmd := newDeployCommand(cli)
+	err := runDeploy(context.Background(), cli, cmd.Flags(), &deployOptions{resolveImage: resolveImageAlways}, nil)
+	assert.Error(t, err, `invalid value for DOCKER_CLI_RETRIES: "many" is not a valid number`)
+}
@@ -0,0 +1,175 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/cli/cli/command/formatter"
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
+)
+
+// placementConstraint is a parsed placement constraint of a service, for
+// example "node.labels.zone==eu".
+type placementConstraint struct {
+	raw   string
+	key   string
+	value string
+	equal bool
+}
+
+// parsePlacementConstraint parses a placement constraint expression. It
+// returns false if the expression could not be parsed.
+func parsePlacementConstraint(expr string) (placementConstraint, bool) {
+	for _, op := range []string{"==", "!="} {
+		if k, v, ok := strings.Cut(expr, op); ok {
+			return placementConstraint{
+				raw:   expr,
+				key:   strings.ToLower(strings.TrimSpace(k)),
+				value: strings.TrimSpace(v),
+				equal: op == "==",
+			}, true
+		}
+	}
+	return placementConstraint{}, false
+}
+
+// matches returns whether the given node satisfies the constraint.
+func (c placementConstraint) matches(node swarm.Node) bool {
+	var (
+		actual string
+		found  = true
+	)
+	switch {
+	case c.key == "node.id":
+		actual = node.ID
+	case c.key == "node.hostname":
+		actual = node.Description.Hostname
+	case c.key == "node.role":
+		actual = string(node.Spec.Role)
+	case c.key == "node.platform.os":
+		actual = node.Description.Platform.OS
+	case c.key == "node.platform.arch":
+		actual = node.Description.Platform.Architecture
+	case strings.HasPrefix(c.key, "node.labels."):
+		actual, found = lookupLabel(node.Spec.Labels, strings.TrimPrefix(c.key, "node.labels."))
+	case strings.HasPrefix(c.key, "engine.labels."):
+		actual, found = lookupLabel(node.Description.Engine.Labels, strings.TrimPrefix(c.key, "engine.labels."))
+	default:
+		// unknown constraint; assume it's satisfied, as this is best-effort.
+		return true
+	}
+	if !found {
+		// a missing label never equals the expected value
+		return !c.equal
+	}
+	if c.equal {
+		return strings.EqualFold(actual, c.value)
+	}
+	return !strings.EqualFold(actual, c.value)
+}
+
+// lookupLabel looks up a label by key. Constraint keys are case-insensitive,
+// so labels are matched case-insensitively.
+func lookupLabel(labels map[string]string, key string) (string, bool) {
+	for k, v := range labels {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// explainPlacement returns a best-effort explanation why tasks with the given
+// placement constraints cannot be scheduled on any of the given nodes. It
+// returns an empty string if the constraints can be satisfied.
+func explainPlacement(constraints []string, nodes []swarm.Node) string {
+	var available []swarm.Node
+	for _, n := range nodes {
+		if n.Status.State == swarm.NodeStateReady && n.Spec.Availability == swarm.NodeAvailabilityActive {
+			available = append(available, n)
+		}
+	}
+	if len(available) == 0 {
+		return "no available nodes (all nodes are down, paused, or drained)"
+	}
+
+	var parsed []placementConstraint
+	for _, expr := range constraints {
+		if c, ok := parsePlacementConstraint(expr); ok {
+			parsed = append(parsed, c)
+		}
+	}
+
+	var unsatisfied []string
+	for _, c := range parsed {
+		var matched bool
+		for _, n := range available {
+			if c.matches(n) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unsatisfied = append(unsatisfied, c.raw)
+		}
+	}
+	if len(unsatisfied) > 0 {
+		return "no node matches " + strings.Join(unsatisfied, ", ")
+	}
+
+	for _, n := range available {
+		matchesAll := true
+		for _, c := range parsed {
+			if !c.matches(n) {
+				matchesAll = false
+				break
+			}
+		}
+		if matchesAll {
+			return ""
+		}
+	}
+	return "no node matches all of " + strings.Join(constraints, ", ")
+}
+
+// printExplanations prints an explanation for each pending task that is
+// likely not scheduled because its placement constraints cannot be satisfied
+// by any available node. It's best-effort; nothing is printed if the nodes
+// cannot be listed, and services that cannot be inspected are skipped.
+func printExplanations(ctx context.Context, out io.Writer, apiClient client.APIClient, tasks client.TaskListResult, trunc bool) {
+	var nodes []swarm.Node
+	explanations := make(map[string]string)
+	for _, t := range tasks.Items {
+		if t.Status.State != swarm.TaskStatePending {
+			continue
+		}
+		explanation, ok := explanations[t.ServiceID]
+		if !ok {
+			if nodes == nil {
+				res, err := apiClient.NodeList(ctx, client.NodeListOptions{})
+				if err != nil {
+					logrus.Debugf("failed to list nodes to explain pending tasks: %v", err)
+					return
+				}
+				nodes = res.Items
+			}
+			res, err := apiClient.ServiceInspect(ctx, t.ServiceID, client.ServiceInspectOptions{})
+			if err == nil && res.Service.Spec.TaskTemplate.Placement != nil {
+				explanation = explainPlacement(res.Service.Spec.TaskTemplate.Placement.Constraints, nodes)
+			}
+			explanations[t.ServiceID] = explanation
+		}
+		if explanation == "" {
+			continue
+		}
+		taskID := t.ID
+		if trunc {
+			taskID = formatter.TruncateID(taskID)
+		}
+		_, _ = fmt.Fprintf(out, "%s: %s\n", taskID, explanation)
+	}
+}
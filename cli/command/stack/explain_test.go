@@ -0,0 +1,80 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/docker/cli/internal/test/builders"
+	"github.com/moby/moby/api/types/swarm"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestExplainPlacement(t *testing.T) {
+	nodeEU := *builders.Node(builders.NodeName("node-eu"), builders.Hostname("host-eu"), builders.NodeLabels(map[string]string{"zone": "eu"}))
+	nodeUS := *builders.Node(builders.NodeName("node-us"), builders.Hostname("host-us"), builders.NodeLabels(map[string]string{"zone": "us", "ssd": "true"}))
+	drained := *builders.Node(builders.NodeLabels(map[string]string{"zone": "ap"}))
+	drained.Spec.Availability = swarm.NodeAvailabilityDrain
+
+	testCases := []struct {
+		doc         string
+		constraints []string
+		nodes       []swarm.Node
+		expected    string
+	}{
+		{
+			doc:         "no constraints",
+			constraints: nil,
+			nodes:       []swarm.Node{nodeEU},
+		},
+		{
+			doc:         "satisfied",
+			constraints: []string{"node.labels.zone==eu", "node.role==worker", "node.platform.os==linux"},
+			nodes:       []swarm.Node{nodeEU, nodeUS},
+		},
+		{
+			doc:         "not equal",
+			constraints: []string{"node.hostname!=host-eu"},
+			nodes:       []swarm.Node{nodeEU, nodeUS},
+		},
+		{
+			doc:         "no available nodes",
+			constraints: []string{"node.labels.zone==ap"},
+			nodes:       []swarm.Node{drained},
+			expected:    "no available nodes (all nodes are down, paused, or drained)",
+		},
+		{
+			doc:         "drained node is not considered",
+			constraints: []string{"node.labels.zone==ap"},
+			nodes:       []swarm.Node{nodeEU, drained},
+			expected:    "no node matches node.labels.zone==ap",
+		},
+		{
+			doc:         "unsatisfied constraints",
+			constraints: []string{"node.labels.zone==ap", "node.role==manager", "engine.labels.engine==label"},
+			nodes:       []swarm.Node{nodeEU, nodeUS},
+			expected:    "no node matches node.labels.zone==ap, node.role==manager",
+		},
+		{
+			doc:         "missing label",
+			constraints: []string{"node.labels.ssd==true"},
+			nodes:       []swarm.Node{nodeEU},
+			expected:    "no node matches node.labels.ssd==true",
+		},
+		{
+			doc:         "no single node matches all",
+			constraints: []string{"node.labels.zone==eu", "node.labels.ssd==true"},
+			nodes:       []swarm.Node{nodeEU, nodeUS},
+			expected:    "no node matches all of node.labels.zone==eu, node.labels.ssd==true",
+		},
+		{
+			doc:         "unknown constraints are ignored",
+			constraints: []string{"node.foo==bar", "invalid"},
+			nodes:       []swarm.Node{nodeEU},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			assert.Check(t, is.Equal(explainPlacement(tc.constraints, tc.nodes), tc.expected))
+		})
+	}
+}
@@ -2,38 +2,103 @@
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/distribution/reference"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/formatter"
 	"github.com/docker/cli/cli/command/idresolver"
 	"github.com/docker/cli/cli/command/task"
-	flagsHelper "github.com/docker/cli/cli/flags"
+	"github.com/docker/cli/internal/retry"
 	cliopts "github.com/docker/cli/opts"
+	"github.com/moby/moby/api/types/swarm"
 	"github.com/moby/moby/client"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+// psFormatHelp describes the --format flag behavior for "docker stack ps",
+// which, in addition to the standard formats, can print a JSON report.
+const psFormatHelp = `Format output using a custom template:
+'table':            Print output in table format with column headers (default)
+'table TEMPLATE':   Print output in table format using the given Go template
+'json':             Print in JSON format, one object per line
+'jsonreport':       Print in JSON format, as a single report including the health of the stack
+'csv':              Print in CSV format, with a header row
+'yaml':             Print in YAML format, as a single list of tasks
+'TEMPLATE':         Print output using the given Go template.
+Refer to https://docs.docker.com/go/formatting/ for more information about formatting output with templates`
+
 // psOptions holds docker stack ps options
 type psOptions struct {
-	filter    cliopts.FilterOpt
-	noTrunc   bool
-	namespace string
-	noResolve bool
-	quiet     bool
-	format    string
+	filter     cliopts.FilterOpt
+	noTrunc    bool
+	namespaces []string
+	// allNamespaces is set to list the tasks of all stacks, instead of the
+	// stacks in namespaces.
+	allNamespaces bool
+	noResolve     bool
+	quiet         bool
+	format        string
+	digests       bool
+	resources     bool
+	nodeStatus    bool
+	shortStates   bool
+	noHeader      bool
+	node          string
+	limit         int
+
+	// labelColumns are the labels of the services of tasks to show as
+	// additional columns.
+	labelColumns []string
+
+	absoluteTime bool
+
+	resolveImages bool
+
+	collapseErrors bool
+	onlyErrors     bool
+	explain        bool
+	exitCode       bool
+	groupBy        string
+	retry          retry.Options
+	since          string
+	until          string
+	watch          bool
+	interval       time.Duration
 }
 
 func newPsCommand(dockerCLI command.Cli) *cobra.Command {
 	opts := psOptions{filter: cliopts.NewFilterOpt()}
 
 	cmd := &cobra.Command{
-		Use:   "ps [OPTIONS] STACK",
-		Short: "List the tasks in the stack",
-		Args:  cli.ExactArgs(1),
+		Use:   "ps [OPTIONS] STACK [STACK...]",
+		Short: "List the tasks in one or more stacks",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.allNamespaces {
+				if len(args) > 0 {
+					return errors.New("conflicting options: --all-namespaces and stack names cannot be used together")
+				}
+				return nil
+			}
+			return cli.RequiresMinArgs(1)(cmd, args)
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			opts.namespaces = args
+			return validatePSOptions(cmd.Flags(), opts)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			opts.namespace = args[0]
-			if err := validateStackName(opts.namespace); err != nil {
+			if err := opts.retry.LoadEnv(cmd.Flags()); err != nil {
 				return err
 			}
 			return runPS(cmd.Context(), dockerCLI, opts)
@@ -42,31 +107,698 @@ func newPsCommand(dockerCLI command.Cli) *cobra.Command {
 		DisableFlagsInUseLine: true,
 	}
 	flags := cmd.Flags()
+	flags.BoolVar(&opts.allNamespaces, "all-namespaces", false, "List the tasks of all stacks")
 	flags.BoolVar(&opts.noTrunc, "no-trunc", false, "Do not truncate output")
 	flags.BoolVar(&opts.noResolve, "no-resolve", false, "Do not map IDs to Names")
+	flags.BoolVar(&opts.noHeader, "no-header", false, "Do not print the header row of tables")
 	flags.VarP(&opts.filter, "filter", "f", "Filter output based on conditions provided")
 	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Only display task IDs")
-	flags.StringVar(&opts.format, "format", "", flagsHelper.FormatHelp)
+	flags.StringVar(&opts.format, "format", "", psFormatHelp)
+	flags.StringVar(&opts.node, "node", "", "Only show tasks on this node (name, hostname, or ID)")
+	flags.IntVar(&opts.limit, "limit", 0, "Only show the tasks that most recently entered their current state, up to this number (0 for no limit)")
+	flags.BoolVar(&opts.digests, "digests", false, "Show image digests")
+	flags.BoolVar(&opts.resources, "resources", false, "Show the CPU and memory reservations of tasks")
+	flags.BoolVar(&opts.nodeStatus, "node-status", false, "Show the status and availability of the nodes of tasks")
+	flags.StringSliceVar(&opts.labelColumns, "label-column", nil, "Show the value of this label of the service of each task as a column (can be specified multiple times)")
+	flags.BoolVar(&opts.absoluteTime, "absolute-time", false, "Show when tasks entered their current state as an absolute time")
+	flags.BoolVar(&opts.shortStates, "short-states", false, `Show the current state of tasks as an abbreviation (for example "Run"), without the time`)
+	flags.BoolVar(&opts.resolveImages, "resolve-images", false, "Show the tags of images pinned by digest, resolved from the local image store")
+	flags.BoolVar(&opts.collapseErrors, "collapse-errors", false, "Group tasks with identical error messages")
+	flags.BoolVar(&opts.onlyErrors, "only-errors", false, "Only show tasks that failed, were rejected or orphaned, or have an error")
+	flags.BoolVar(&opts.explain, "explain", false, "Explain why pending tasks cannot be scheduled")
+	flags.BoolVar(&opts.exitCode, "exit-code", false, "Exit with a non-zero status if the stack is degraded (2) or failed (3)")
+	flags.StringVar(&opts.groupBy, "group-by", "", `Group tasks, showing the number of running and total tasks ("service")`)
+	flags.StringVar(&opts.since, "since", "", `Only show tasks that entered their current state since a timestamp (e.g. "2024-01-02T13:23:37Z") or relative duration (e.g. "1h")`)
+	flags.StringVar(&opts.until, "until", "", `Only show tasks that entered their current state before a timestamp (e.g. "2024-01-02T13:23:37Z") or relative duration (e.g. "1h")`)
+	flags.BoolVar(&opts.watch, "watch", false, "Refresh the output until interrupted")
+	flags.DurationVar(&opts.interval, "interval", 2*time.Second, "Time between refreshes (with --watch)")
+	retry.AddFlags(flags, &opts.retry)
 	return cmd
 }
 
-// runPS is the swarm implementation of docker stack ps
-func runPS(ctx context.Context, dockerCLI command.Cli, opts psOptions) error {
-	apiClient := dockerCLI.Client()
-	res, err := apiClient.TaskList(ctx, client.TaskListOptions{
-		Filters: getStackFilterFromOpt(opts.namespace, opts.filter),
+// psConflicts are the options of docker stack ps that cannot be used together,
+// in the order in which they're checked. Options are flag names, optionally
+// with the value that conflicts, such as "format=csv"; without a value, any
+// value other than the default conflicts. The hint, if set, is added to the
+// error.
+var psConflicts = []struct {
+	option, other string
+	hint          string
+}{
+	{option: "collapse-errors", other: "quiet"},
+	{option: "explain", other: "quiet"},
+	{option: "group-by", other: "collapse-errors"},
+	{option: "group-by", other: "explain"},
+	{option: "group-by", other: "format=" + jsonReportFormatKey},
+	{option: "group-by", other: "format=" + task.CSVFormatKey},
+	{option: "group-by", other: "format=" + task.YAMLFormatKey},
+	{option: "format=" + jsonReportFormatKey, other: "quiet"},
+	{option: "format=" + jsonReportFormatKey, other: "collapse-errors"},
+	{option: "format=" + jsonReportFormatKey, other: "explain"},
+	{option: "format=" + jsonReportFormatKey, other: "only-errors"},
+	{option: "digests", other: "quiet"},
+	{option: "digests", other: "format", hint: "use the .ImageDigest placeholder instead"},
+	{option: "digests", other: "group-by"},
+	{option: "digests", other: "collapse-errors"},
+	{option: "resources", other: "quiet"},
+	{option: "resources", other: "format", hint: "use the .CPUReservation and .MemoryReservation placeholders instead"},
+	{option: "resources", other: "group-by"},
+	{option: "resources", other: "collapse-errors"},
+	{option: "node-status", other: "quiet"},
+	{option: "node-status", other: "format", hint: "use the .NodeStatus and .NodeAvailability placeholders instead"},
+	{option: "node-status", other: "group-by"},
+	{option: "node-status", other: "collapse-errors"},
+	{option: "node-status", other: "no-resolve"},
+	{option: "short-states", other: "quiet"},
+	{option: "short-states", other: "format", hint: "use the .ShortState placeholder instead"},
+	{option: "short-states", other: "group-by"},
+	{option: "short-states", other: "collapse-errors"},
+	{option: "short-states", other: "absolute-time"},
+	{option: "no-header", other: "collapse-errors"},
+	{option: "label-column", other: "quiet"},
+	{option: "label-column", other: "format", hint: `use the .Label placeholder instead, for example {{.Label "tier"}}`},
+	{option: "label-column", other: "group-by"},
+	{option: "label-column", other: "collapse-errors"},
+	{option: "resolve-images", other: "quiet"},
+	{option: "resolve-images", other: "group-by"},
+	{option: "resolve-images", other: "collapse-errors"},
+	{option: "resolve-images", other: "format=" + jsonReportFormatKey},
+	{option: "resolve-images", other: "format=" + task.CSVFormatKey},
+	{option: "limit", other: "group-by"},
+	{option: "limit", other: "collapse-errors"},
+	{option: "limit", other: "format=" + jsonReportFormatKey},
+	{option: "format=" + task.CSVFormatKey, other: "collapse-errors"},
+	{option: "format=" + task.YAMLFormatKey, other: "collapse-errors"},
+	{option: "watch", other: "exit-code"},
+}
+
+// isOptionSet returns whether the option, as used in psConflicts, is set.
+func isOptionSet(flags *pflag.FlagSet, option string) bool {
+	name, value, hasValue := strings.Cut(option, "=")
+	f := flags.Lookup(name)
+	if hasValue {
+		return f.Value.String() == value
+	}
+	return f.Value.String() != f.DefValue
+}
+
+// validatePSOptions validates the options of docker stack ps.
+func validatePSOptions(flags *pflag.FlagSet, opts psOptions) error {
+	if err := validateStackNames(opts.namespaces); err != nil {
+		return err
+	}
+	if opts.groupBy != "" && opts.groupBy != groupByService {
+		return fmt.Errorf("invalid value for --group-by: %q: only %q is supported", opts.groupBy, groupByService)
+	}
+	if opts.limit < 0 {
+		return fmt.Errorf("invalid value for --limit: %d: must be a positive number", opts.limit)
+	}
+	for _, c := range psConflicts {
+		if isOptionSet(flags, c.option) && isOptionSet(flags, c.other) {
+			err := fmt.Sprintf("conflicting options: --%s and --%s cannot be used together", c.option, c.other)
+			if c.hint != "" {
+				err += "; " + c.hint
+			}
+			return errors.New(err)
+		}
+	}
+	if opts.format == jsonReportFormatKey && opts.multipleStacks() {
+		return errors.New("--format=jsonreport can only be used with a single stack")
+	}
+	if opts.format != "" && !formatter.Format(opts.format).IsTable() {
+		if opts.explain {
+			// Explanations are printed below the table, which would make
+			// the output of other formats invalid.
+			return errors.New("--explain can only be used with table formats")
+		}
+		if opts.noHeader {
+			return errors.New("--no-header can only be used with table formats")
+		}
+	}
+	if opts.node != "" && len(opts.filter.Value()["node"]) > 0 {
+		return errors.New("conflicting options: --node and --filter node cannot be used together")
+	}
+	if flags.Changed("interval") && !opts.watch {
+		return errors.New("--interval can only be used with --watch")
+	}
+	if opts.watch && opts.interval <= 0 {
+		return fmt.Errorf("invalid value for --interval: %s: must be a positive duration", opts.interval)
+	}
+	if opts.groupBy == "" && !opts.collapseErrors && opts.format != jsonReportFormatKey {
+		// With these options, the format is used for groups or a report
+		// instead of tasks, so it has other fields.
+		return task.ValidateFormat(opts.format, opts.quiet)
+	}
+	return nil
+}
+
+// multipleStacks returns whether the tasks of multiple stacks are listed, in
+// which case the namespace of each task is printed.
+func (opts psOptions) multipleStacks() bool {
+	return opts.allNamespaces || len(opts.namespaces) > 1
+}
+
+// getStackNames returns the names of the deployed stacks, discovered from the
+// labels of their services, sorted by name.
+func getStackNames(ctx context.Context, apiClient client.ServiceAPIClient, retryOpts retry.Options) ([]string, error) {
+	var stacks []stackSummary
+	err := retry.Do(ctx, retryOpts, func() (err error) {
+		stacks, err = getStacks(ctx, apiClient)
+		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(stacks))
+	for _, s := range stacks {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// runPS is the swarm implementation of docker stack ps. When listing the
+// tasks of multiple stacks, stacks without tasks are reported on stderr, and
+// an error is only returned if none of the stacks has tasks. With
+// --all-namespaces, stacks without tasks are not reported.
+func runPS(ctx context.Context, dockerCLI command.Cli, opts psOptions) error {
+	if err := validateDesiredStateFilter(opts.filter.Value()); err != nil {
+		return err
+	}
+	if err := validateImageFilter(opts.filter.Value()); err != nil {
+		return err
+	}
+	if opts.node != "" {
+		// Resolve the node once, as the "node" filter only accepts node IDs.
+		nodeID, err := idresolver.New(dockerCLI.Client(), false).NodeID(ctx, opts.node)
+		if err != nil {
+			return err
+		}
+		if err := opts.filter.Set("node=" + nodeID); err != nil {
+			return err
+		}
+	}
+	if opts.watch {
+		return watchPS(ctx, dockerCLI, opts)
+	}
+	return listPS(ctx, dockerCLI, opts)
+}
+
+// watchPS lists the tasks every opts.interval until ctx is cancelled. When
+// printing to a terminal, the screen is cleared before each refresh, so that
+// the output is redrawn in place; otherwise, the output of each refresh is
+// appended, separated by an empty line.
+func watchPS(ctx context.Context, dockerCLI command.Cli, opts psOptions) error {
+	isTerminal := dockerCLI.Out().IsTerminal()
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	for first := true; ; first = false {
+		if isTerminal {
+			// Move the cursor to the top-left, and clear the screen
+			_, _ = io.WriteString(dockerCLI.Out(), "\033[H\033[J")
+		} else if !first {
+			_, _ = fmt.Fprintln(dockerCLI.Out())
+		}
+		if err := listPS(ctx, dockerCLI, opts); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// exitCodeNothingFound is the exit code used if none of the stacks has tasks,
+// for example because the stack does not exist, to distinguish it from other
+// errors. It differs from the exit codes used with the --exit-code option.
+const exitCodeNothingFound = 4
+
+// listPS lists the tasks of the stacks once.
+func listPS(ctx context.Context, dockerCLI command.Cli, opts psOptions) error {
+	since, until, err := parseTimeRange(opts.since, opts.until, time.Now())
 	if err != nil {
 		return err
 	}
+	apiClient := dockerCLI.Client()
+
+	namespaces := opts.namespaces
+	if opts.allNamespaces {
+		// Discover the stacks on each refresh, so that stacks that are
+		// deployed while watching are included.
+		namespaces, err = getStackNames(ctx, apiClient, opts.retry)
+		if err != nil {
+			return err
+		}
+		if len(namespaces) == 0 {
+			return cli.StatusError{
+				StatusCode: exitCodeNothingFound,
+				Status:     "no stacks are deployed",
+			}
+		}
+	}
+
+	var (
+		res      client.TaskListResult
+		empty    []string
+		found    []string
+		images   = getImageFilters(opts.filter)
+		excluded = getNegatedLabels(opts.filter)
+
+		// serviceLabels holds the labels of the services of the stacks, if
+		// they were listed to exclude tasks by label.
+		serviceLabels map[string]map[string]string
+	)
+	if len(excluded) > 0 {
+		serviceLabels = make(map[string]map[string]string)
+	}
+	for _, namespace := range namespaces {
+		filters := getStackFilterFromOpt(namespace, opts.filter)
+		delete(filters, imageFilter)
+
+		var tasks client.TaskListResult
+		err := retry.Do(ctx, opts.retry, func() (err error) {
+			tasks, err = apiClient.TaskList(ctx, client.TaskListOptions{
+				Filters: filters,
+			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if len(images) > 0 {
+			tasks.Items = filterTasksByImage(tasks.Items, images)
+		}
+		if len(excluded) > 0 {
+			labels, err := listServiceLabels(ctx, apiClient, opts.retry, []string{namespace})
+			if err != nil {
+				return err
+			}
+			maps.Copy(serviceLabels, labels)
+			tasks.Items = excludeTasksByServiceLabel(tasks.Items, labels, excluded)
+		}
+		if len(tasks.Items) == 0 {
+			empty = append(empty, namespace)
+			continue
+		}
+		res.Items = append(res.Items, tasks.Items...)
+		found = append(found, namespace)
+	}
 
 	if len(res.Items) == 0 {
-		return fmt.Errorf("nothing found in stack: %s", opts.namespace)
+		status := "nothing found in stack: " + strings.Join(empty, ", ")
+		if opts.allNamespaces {
+			status = "nothing found in any stack"
+		}
+		return cli.StatusError{
+			StatusCode: exitCodeNothingFound,
+			Status:     status,
+		}
+	}
+	if !opts.allNamespaces {
+		// Stacks without matching tasks are expected when listing all
+		// stacks, so they're only reported for stacks that were named.
+		for _, namespace := range empty {
+			_, _ = fmt.Fprintln(dockerCLI.Err(), "nothing found in stack:", namespace)
+		}
+	}
+	if !since.IsZero() || !until.IsZero() {
+		res.Items = filterTasksByTime(res.Items, since, until)
+	}
+
+	// Filter and limit the tasks that are printed, but not the tasks that
+	// are used for the health of the stack.
+	shown := res
+	if opts.onlyErrors {
+		shown.Items = filterFailedTasks(res.Items)
+		if len(shown.Items) == 0 {
+			_, _ = fmt.Fprintln(dockerCLI.Err(), "no failed tasks in stack", strings.Join(found, ", "))
+		}
+	}
+	total := len(shown.Items)
+	if opts.limit > 0 {
+		shown.Items = limitTasks(shown.Items, opts.limit)
+	}
+	if len(shown.Items) > 0 || !opts.onlyErrors {
+		if err := printPS(ctx, dockerCLI, opts, shown, found, serviceLabels); err != nil {
+			return err
+		}
+	}
+	if len(shown.Items) < total {
+		_, _ = fmt.Fprintf(dockerCLI.Err(), "showing %d of %d tasks\n", len(shown.Items), total)
+	}
+	if opts.exitCode {
+		if code := computeVerdict(res.Items).exitCode(); code != 0 {
+			return cli.StatusError{StatusCode: code}
+		}
 	}
+	return nil
+}
 
-	if opts.format == "" {
+// printPS prints the given tasks of the given stacks. The serviceLabels map
+// holds the labels of the services of the stacks, if they were already listed;
+// otherwise they're listed if the format uses the Label field.
+func printPS(ctx context.Context, dockerCLI command.Cli, opts psOptions, res client.TaskListResult, namespaces []string, serviceLabels map[string]map[string]string) error {
+	apiClient := dockerCLI.Client()
+	if opts.format == jsonReportFormatKey {
+		return printReport(ctx, dockerCLI, opts.namespaces[0], res, idresolver.NewWithPrefetch(ctx, apiClient, opts.noResolve), task.PrintOptions{
+			Trunc:        !opts.noTrunc,
+			AbsoluteTime: opts.absoluteTime,
+		})
+	}
+
+	if opts.groupBy == groupByService {
+		return printServiceGroups(ctx, formatter.Context{
+			Output:   dockerCLI.Out(),
+			Format:   newServiceGroupFormat(opts.format, opts.quiet),
+			Trunc:    !opts.noTrunc,
+			NoHeader: opts.noHeader,
+		}, res, idresolver.New(apiClient, opts.noResolve))
+	}
+
+	if opts.collapseErrors && len(task.GroupErrors(res)) > 0 {
+		return task.PrintErrorGroups(dockerCLI, res, !opts.noTrunc, opts.format)
+	}
+
+	switch {
+	case opts.digests && opts.multipleStacks():
+		opts.format = task.NamespaceDigestsTableFormat
+	case opts.digests:
+		opts.format = task.DigestsTableFormat
+	case opts.resolveImages && opts.format == "" && opts.multipleStacks():
+		opts.format = task.NamespaceImageTagsTableFormat
+	case opts.resolveImages && opts.format == "":
+		opts.format = task.ImageTagsTableFormat
+	case opts.resources && opts.multipleStacks():
+		opts.format = task.NamespaceResourcesTableFormat
+	case opts.resources:
+		opts.format = task.ResourcesTableFormat
+	case (opts.nodeStatus || opts.shortStates || len(opts.labelColumns) > 0) && opts.multipleStacks():
+		opts.format = task.NamespaceTableFormat
+	case opts.nodeStatus || opts.shortStates || len(opts.labelColumns) > 0:
+		opts.format = task.TableFormat
+	case opts.format == "":
 		opts.format = task.DefaultFormat(dockerCLI.ConfigFile(), opts.quiet)
+		if opts.format == formatter.TableFormatKey && !opts.quiet && opts.multipleStacks() {
+			opts.format = task.NamespaceTableFormat
+		}
+	}
+
+	if opts.shortStates {
+		opts.format = strings.Replace(opts.format, "{{.CurrentState}}", "{{.ShortState}}", 1)
+	}
+
+	var imageTags map[string]string
+	if opts.resolveImages {
+		if opts.digests {
+			// show the tags after the digests
+			opts.format += "\t{{.ImageTag}}"
+		}
+		imageTags = task.ResolveImageTags(ctx, apiClient, res)
+	}
+	if opts.resources && (opts.digests || opts.resolveImages) {
+		// show the reservations after the digests and tags
+		opts.format += "\t{{.CPUReservation}}\t{{.MemoryReservation}}"
+	}
+	if opts.nodeStatus {
+		// show the status of nodes after the digests, tags, and
+		// reservations, but before the labels
+		opts.format += "\t{{.NodeStatus}}\t{{.NodeAvailability}}"
+	}
+	if len(opts.labelColumns) > 0 {
+		// show the labels after all other columns
+		opts.format += task.LabelColumnsFormat(opts.labelColumns)
+	}
+	if formatter.Format(opts.format).Contains(".Label") && serviceLabels == nil {
+		var err error
+		serviceLabels, err = listServiceLabels(ctx, apiClient, opts.retry, namespaces)
+		if err != nil {
+			return err
+		}
+	}
+	if err := task.PrintWithOptions(ctx, dockerCLI, res, idresolver.NewWithPrefetch(ctx, apiClient, opts.noResolve), task.PrintOptions{
+		Trunc:         !opts.noTrunc,
+		Quiet:         opts.quiet,
+		NoHeader:      opts.noHeader,
+		AbsoluteTime:  opts.absoluteTime,
+		Format:        opts.format,
+		ImageTags:     imageTags,
+		ServiceLabels: serviceLabels,
+	}); err != nil {
+		return err
 	}
+	if opts.explain {
+		out := dockerCLI.Out()
+		if !formatter.Format(opts.format).IsTable() {
+			// The default format in the config file is not a table.
+			out = dockerCLI.Err()
+		}
+		printExplanations(ctx, out, apiClient, res, !opts.noTrunc)
+	}
+	return nil
+}
+
+// taskStates are the valid values for the "desired-state" filter.
+var taskStates = []swarm.TaskState{
+	swarm.TaskStateNew,
+	swarm.TaskStateAllocated,
+	swarm.TaskStatePending,
+	swarm.TaskStateAssigned,
+	swarm.TaskStateAccepted,
+	swarm.TaskStatePreparing,
+	swarm.TaskStateReady,
+	swarm.TaskStateStarting,
+	swarm.TaskStateRunning,
+	swarm.TaskStateComplete,
+	swarm.TaskStateShutdown,
+	swarm.TaskStateFailed,
+	swarm.TaskStateRejected,
+	swarm.TaskStateRemove,
+	swarm.TaskStateOrphaned,
+}
+
+// validateDesiredStateFilter validates the values of the "desired-state"
+// filter before sending them to the daemon, which produces a less helpful
+// error for invalid values. Values are case-insensitive, as on the daemon.
+func validateDesiredStateFilter(filters client.Filters) error {
+	valid := make(map[swarm.TaskState]bool, len(taskStates))
+	validValues := make([]string, 0, len(taskStates))
+	for _, s := range taskStates {
+		valid[s] = true
+		validValues = append(validValues, string(s))
+	}
+
+	values := make([]string, 0, len(filters["desired-state"]))
+	for value := range filters["desired-state"] {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		if !valid[swarm.TaskState(strings.ToLower(value))] {
+			return errdefs.ErrInvalidArgument.WithMessage(fmt.Sprintf("invalid filter value 'desired-state=%s': valid values are %s", value, strings.Join(validValues, ", ")))
+		}
+	}
+	return nil
+}
+
+// imageFilter is the filter term to match tasks by the image of their spec,
+// as in "--filter image=redis:7.4". The API doesn't support filtering tasks by
+// image, so this filter is applied on the client; see [filterTasksByImage].
+const imageFilter = "image"
 
-	return task.Print(ctx, dockerCLI, res, idresolver.New(apiClient, opts.noResolve), !opts.noTrunc, opts.quiet, opts.format)
+// validateImageFilter validates the values of the "image" filter. Values
+// ending with "*" are prefixes, and are not validated.
+func validateImageFilter(filters client.Filters) error {
+	values := make([]string, 0, len(filters[imageFilter]))
+	for value := range filters[imageFilter] {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		if strings.HasSuffix(value, "*") {
+			continue
+		}
+		if _, err := reference.ParseNormalizedNamed(value); err != nil {
+			return errdefs.ErrInvalidArgument.WithMessage(fmt.Sprintf("invalid filter value 'image=%s': %v", value, err))
+		}
+	}
+	return nil
+}
+
+// parseTimeRange parses the values of the --since and --until options,
+// relative to now. An empty value produces a zero time.
+func parseTimeRange(sinceValue, untilValue string, now time.Time) (since, until time.Time, _ error) {
+	var err error
+	if sinceValue != "" {
+		since, err = parseTaskTime(sinceValue, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid value for --since: %w", err)
+		}
+	}
+	if untilValue != "" {
+		until, err = parseTaskTime(untilValue, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid value for --until: %w", err)
+		}
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return time.Time{}, time.Time{}, errors.New("invalid time range: --until must not be before --since")
+	}
+	return since, until, nil
+}
+
+// parseTaskTime parses a time, which is either a duration relative to now
+// (e.g. "1h" for one hour ago), or an RFC 3339 timestamp or date.
+func parseTaskTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf(`%q is not a duration (e.g. "1h"), or an RFC 3339 timestamp (e.g. "2024-01-02T13:23:37Z") or date (e.g. "2024-01-02")`, value)
+}
+
+// listServiceLabels returns the labels of the services of the given stacks,
+// indexed by service ID. The services of each stack are listed with a single
+// request.
+func listServiceLabels(ctx context.Context, apiClient client.ServiceAPIClient, retryOpts retry.Options, namespaces []string) (map[string]map[string]string, error) {
+	labels := make(map[string]map[string]string)
+	for _, namespace := range namespaces {
+		var services client.ServiceListResult
+		err := retry.Do(ctx, retryOpts, func() (err error) {
+			services, err = apiClient.ServiceList(ctx, client.ServiceListOptions{
+				Filters: getStackFilter(namespace),
+			})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, svc := range services.Items {
+			labels[svc.ID] = svc.Spec.Labels
+		}
+	}
+	return labels, nil
+}
+
+// excludeTasksByServiceLabel removes the tasks of services that have any of
+// the given labels. The labels of the services are looked up in serviceLabels,
+// as returned by [listServiceLabels].
+func excludeTasksByServiceLabel(tasks []swarm.Task, serviceLabels map[string]map[string]string, labels []string) []swarm.Task {
+	return slices.DeleteFunc(tasks, func(t swarm.Task) bool {
+		return hasAnyLabel(serviceLabels[t.ServiceID], labels)
+	})
+}
+
+// getImageFilters returns the values of the image filters in opt, sorted for
+// a stable output.
+func getImageFilters(opt cliopts.FilterOpt) []string {
+	values := make([]string, 0, len(opt.Value()[imageFilter]))
+	for value := range opt.Value()[imageFilter] {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// filterTasksByImage returns the tasks of which the image of the spec matches
+// any of the given image filters; see [matchesImage].
+func filterTasksByImage(tasks []swarm.Task, images []string) []swarm.Task {
+	return slices.DeleteFunc(tasks, func(t swarm.Task) bool {
+		if t.Spec.ContainerSpec == nil {
+			return true
+		}
+		return !slices.ContainsFunc(images, func(image string) bool {
+			return matchesImage(t.Spec.ContainerSpec.Image, image)
+		})
+	})
+}
+
+// matchesImage returns whether the image of a task matches the value of an
+// "image" filter. A value ending with "*" matches images of which the familiar
+// reference, for example "redis:7.4@sha256:...", starts with the value without
+// the "*". Otherwise, the repository name must be equal, and a tag or digest
+// in the value must be equal to the tag or digest of the image.
+func matchesImage(image, value string) bool {
+	ref, err := reference.ParseNormalizedNamed(image)
+	if prefix, ok := strings.CutSuffix(value, "*"); ok {
+		if err == nil {
+			image = reference.FamiliarString(ref)
+		}
+		return strings.HasPrefix(image, prefix)
+	}
+	if err != nil {
+		return image == value
+	}
+	filterRef, err := reference.ParseNormalizedNamed(value)
+	if err != nil || filterRef.Name() != ref.Name() {
+		return false
+	}
+	if tagged, ok := filterRef.(reference.Tagged); ok {
+		if t, ok := ref.(reference.Tagged); !ok || t.Tag() != tagged.Tag() {
+			return false
+		}
+	}
+	if digested, ok := filterRef.(reference.Digested); ok {
+		if d, ok := ref.(reference.Digested); !ok || d.Digest() != digested.Digest() {
+			return false
+		}
+	}
+	return true
+}
+
+// limitTasks returns the limit tasks that most recently entered their current
+// state. Tasks are sorted by the timestamp of their status, and by ID for
+// tasks with the same timestamp, so that the same tasks are returned for the
+// same input. The order of the given tasks is not changed.
+func limitTasks(tasks []swarm.Task, limit int) []swarm.Task {
+	sorted := slices.Clone(tasks)
+	slices.SortStableFunc(sorted, func(a, b swarm.Task) int {
+		if c := b.Status.Timestamp.Compare(a.Status.Timestamp); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return sorted[:min(limit, len(sorted))]
+}
+
+// filterTasksByTime returns the tasks that entered their current state
+// within the given time range. A zero since or until leaves the range open
+// on that side. Tasks without a status timestamp are excluded.
+func filterTasksByTime(tasks []swarm.Task, since, until time.Time) []swarm.Task {
+	filtered := make([]swarm.Task, 0, len(tasks))
+	for _, t := range tasks {
+		ts := t.Status.Timestamp
+		if ts.IsZero() || (!since.IsZero() && ts.Before(since)) || (!until.IsZero() && ts.After(until)) {
+			continue
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
+}
+
+// filterFailedTasks returns the tasks that failed, were rejected, or were
+// orphaned, and the tasks with an error in their status, such as tasks that
+// cannot be scheduled.
+func filterFailedTasks(tasks []swarm.Task) []swarm.Task {
+	filtered := make([]swarm.Task, 0, len(tasks))
+	for _, t := range tasks {
+		switch t.Status.State {
+		case swarm.TaskStateFailed, swarm.TaskStateRejected, swarm.TaskStateOrphaned:
+		default:
+			if t.Status.Err == "" {
+				continue
+			}
+		}
+		filtered = append(filtered, t)
+	}
+	return filtered
 }
@@ -0,0 +1,149 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/docker/cli/cli/command/formatter"
+	"github.com/docker/cli/cli/command/idresolver"
+	"github.com/fvbommel/sortorder"
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+)
+
+const (
+	// groupByService is the value of the --group-by option to group tasks
+	// by the service they belong to.
+	groupByService = "service"
+
+	defaultServiceGroupTableFormat = "table {{.ID}}\t{{.Name}}\t{{.Tasks}}"
+
+	serviceIDHeader = "ID"
+	runningHeader   = "RUNNING"
+	totalHeader     = "TOTAL"
+	tasksHeader     = "TASKS"
+)
+
+// serviceGroup is a group of tasks that belong to the same service.
+type serviceGroup struct {
+	ServiceID string
+	Name      string
+	Running   int
+	Total     int
+}
+
+// groupTasksByService groups the given tasks by the service they belong to,
+// counting the tasks that are running, and the total number of current tasks.
+// Previous tasks that were shut down, or removed are not counted. The names
+// of services are resolved using resolver, and groups are sorted by name.
+func groupTasksByService(ctx context.Context, tasks []swarm.Task, resolver *idresolver.IDResolver) ([]serviceGroup, error) {
+	var groups []serviceGroup
+	idx := make(map[string]int)
+	for _, t := range tasks {
+		i, ok := idx[t.ServiceID]
+		if !ok {
+			name, err := resolver.Resolve(ctx, swarm.Service{}, t.ServiceID)
+			if err != nil {
+				return nil, err
+			}
+			i = len(groups)
+			idx[t.ServiceID] = i
+			groups = append(groups, serviceGroup{ServiceID: t.ServiceID, Name: name})
+		}
+		if !isCurrent(t) {
+			continue
+		}
+		groups[i].Total++
+		if t.Status.State == swarm.TaskStateRunning {
+			groups[i].Running++
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return sortorder.NaturalLess(groups[i].Name, groups[j].Name)
+	})
+	return groups, nil
+}
+
+// newServiceGroupFormat returns a Format for rendering using a
+// serviceGroupContext.
+func newServiceGroupFormat(source string, quiet bool) formatter.Format {
+	switch source {
+	case "", formatter.TableFormatKey:
+		if quiet {
+			return formatter.DefaultQuietFormat
+		}
+		return defaultServiceGroupTableFormat
+	case formatter.RawFormatKey:
+		if quiet {
+			return `id: {{.ID}}`
+		}
+		return `id: {{.ID}}\nname: {{.Name}}\nrunning: {{.Running}}\ntotal: {{.Total}}\n`
+	}
+	return formatter.Format(source)
+}
+
+// printServiceGroups prints the tasks grouped by service, with a single row
+// for each service showing the number of running tasks and the total number
+// of tasks, instead of a row for each task.
+func printServiceGroups(ctx context.Context, fmtCtx formatter.Context, tasks client.TaskListResult, resolver *idresolver.IDResolver) error {
+	groups, err := groupTasksByService(ctx, tasks.Items, resolver)
+	if err != nil {
+		return err
+	}
+	groupCtx := &serviceGroupContext{
+		HeaderContext: formatter.HeaderContext{
+			Header: formatter.SubHeaderContext{
+				"ID":      serviceIDHeader,
+				"Name":    formatter.NameHeader,
+				"Running": runningHeader,
+				"Total":   totalHeader,
+				"Tasks":   tasksHeader,
+			},
+		},
+	}
+	return fmtCtx.Write(groupCtx, func(format func(subContext formatter.SubContext) error) error {
+		for _, g := range groups {
+			if err := format(&serviceGroupContext{trunc: fmtCtx.Trunc, group: g}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type serviceGroupContext struct {
+	formatter.HeaderContext
+	trunc bool
+	group serviceGroup
+}
+
+func (c *serviceGroupContext) MarshalJSON() ([]byte, error) {
+	return formatter.MarshalJSON(c)
+}
+
+func (c *serviceGroupContext) ID() string {
+	if c.trunc {
+		return formatter.TruncateID(c.group.ServiceID)
+	}
+	return c.group.ServiceID
+}
+
+func (c *serviceGroupContext) Name() string {
+	return c.group.Name
+}
+
+func (c *serviceGroupContext) Running() string {
+	return strconv.Itoa(c.group.Running)
+}
+
+func (c *serviceGroupContext) Total() string {
+	return strconv.Itoa(c.group.Total)
+}
+
+// Tasks returns the number of running tasks and the total number of tasks
+// of the service, for example, "2/3".
+func (c *serviceGroupContext) Tasks() string {
+	return fmt.Sprintf("%d/%d", c.group.Running, c.group.Total)
+}
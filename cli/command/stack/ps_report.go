@@ -0,0 +1,122 @@
+package stack
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/idresolver"
+	"github.com/docker/cli/cli/command/task"
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+)
+
+// jsonReportFormatKey is the format to print the tasks of a stack as a
+// single JSON object, including the health verdict of the stack.
+const jsonReportFormatKey = "jsonreport"
+
+// reportSchemaVersion is the version of the JSON report printed with the
+// "jsonreport" format. It must be incremented when making incompatible
+// changes to the report.
+const reportSchemaVersion = 1
+
+// verdict is the health of a stack, as computed from its tasks.
+type verdict string
+
+const (
+	// verdictOK means that all current tasks reached their desired state.
+	verdictOK verdict = "ok"
+	// verdictDegraded means that some, but not all, current tasks reached
+	// their desired state.
+	verdictDegraded verdict = "degraded"
+	// verdictFailed means that none of the current tasks reached their
+	// desired state, or that the stack has no current tasks.
+	verdictFailed verdict = "failed"
+)
+
+// Exit codes used with the --exit-code option.
+const (
+	exitCodeDegraded = 2
+	exitCodeFailed   = 3
+)
+
+// exitCode returns the exit code for the verdict with the --exit-code option.
+func (v verdict) exitCode() int {
+	switch v {
+	case verdictDegraded:
+		return exitCodeDegraded
+	case verdictFailed:
+		return exitCodeFailed
+	default:
+		return 0
+	}
+}
+
+// isCurrent returns whether t is a current task, and not a previous task
+// that was shut down or removed, for example, because it was replaced by
+// an update or restart.
+func isCurrent(t swarm.Task) bool {
+	return t.DesiredState != swarm.TaskStateShutdown && t.DesiredState != swarm.TaskStateRemove
+}
+
+// computeVerdict returns the health verdict of a stack with the given tasks.
+// Only current tasks are taken into account; a task is healthy if its state
+// matches its desired state, for example, "running" for services, or
+// "complete" for jobs.
+func computeVerdict(tasks []swarm.Task) verdict {
+	var current, healthy int
+	for _, t := range tasks {
+		if !isCurrent(t) {
+			continue
+		}
+		current++
+		if t.Status.State == t.DesiredState {
+			healthy++
+		}
+	}
+	switch {
+	case healthy == 0:
+		return verdictFailed
+	case healthy < current:
+		return verdictDegraded
+	default:
+		return verdictOK
+	}
+}
+
+// countStates returns the number of tasks in each state.
+func countStates(tasks []swarm.Task) map[swarm.TaskState]int {
+	counts := make(map[swarm.TaskState]int)
+	for _, t := range tasks {
+		counts[t.Status.State]++
+	}
+	return counts
+}
+
+// psReport is the JSON report printed with the "jsonreport" format.
+type psReport struct {
+	SchemaVersion int                     `json:"schemaVersion"`
+	Stack         string                  `json:"stack"`
+	Verdict       verdict                 `json:"verdict"`
+	Counts        map[swarm.TaskState]int `json:"counts"`
+	Tasks         []json.RawMessage       `json:"tasks"`
+}
+
+// printReport prints the JSON report for the tasks of the stack. The tasks
+// are printed with the same fields as with the "json" format and the given
+// options.
+func printReport(ctx context.Context, dockerCLI command.Cli, namespace string, tasks client.TaskListResult, resolver *idresolver.IDResolver, opts task.PrintOptions) error {
+	rows, err := task.JSONRows(ctx, dockerCLI, tasks, resolver, opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(dockerCLI.Out())
+	enc.SetIndent("", "  ")
+	return enc.Encode(psReport{
+		SchemaVersion: reportSchemaVersion,
+		Stack:         namespace,
+		Verdict:       computeVerdict(tasks.Items),
+		Counts:        countStates(tasks.Items),
+		Tasks:         rows,
+	})
+}
@@ -1,16 +1,29 @@
 package stack
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/containerd/errdefs"
+	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/internal/retry"
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/cli/internal/test/builders"
+	cliopts "github.com/docker/cli/opts"
+	"github.com/moby/moby/api/types/image"
 	"github.com/moby/moby/api/types/swarm"
 	"github.com/moby/moby/client"
+	"github.com/spf13/cobra"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/golden"
@@ -24,11 +37,66 @@ func TestStackPsErrors(t *testing.T) {
 	}{
 		{
 			args:          []string{},
-			expectedError: "requires 1 argument",
+			expectedError: "requires at least 1 argument",
 		},
 		{
 			args:          []string{"foo", "bar"},
-			expectedError: "requires 1 argument",
+			expectedError: "nothing found in stack: foo, bar",
+		},
+		{
+			args:          []string{"--format", "jsonreport", "foo", "bar"},
+			expectedError: "--format=jsonreport can only be used with a single stack",
+		},
+		{
+			args:          []string{"--all-namespaces", "foo"},
+			expectedError: "conflicting options: --all-namespaces and stack names cannot be used together",
+		},
+		{
+			args:          []string{"--all-namespaces"},
+			expectedError: "no stacks are deployed",
+		},
+		{
+			args:          []string{"--all-namespaces", "--format", "jsonreport"},
+			expectedError: "--format=jsonreport can only be used with a single stack",
+		},
+		{
+			args:          []string{"--interval", "1s", "foo"},
+			expectedError: "--interval can only be used with --watch",
+		},
+		{
+			args:          []string{"--watch", "--interval", "0s", "foo"},
+			expectedError: "invalid value for --interval: 0s: must be a positive duration",
+		},
+		{
+			args:          []string{"--node", "node-foo", "--filter", "node=id-node-foo", "foo"},
+			expectedError: "conflicting options: --node and --filter node cannot be used together",
+		},
+		{
+			args:          []string{"--limit", "-1", "foo"},
+			expectedError: "invalid value for --limit: -1: must be a positive number",
+		},
+		{
+			args:          []string{"--limit", "2", "--group-by", "service", "foo"},
+			expectedError: "conflicting options: --limit and --group-by cannot be used together",
+		},
+		{
+			args:          []string{"--limit", "2", "--format", "jsonreport", "foo"},
+			expectedError: "conflicting options: --limit and --format=jsonreport cannot be used together",
+		},
+		{
+			args:          []string{"--only-errors", "--format", "jsonreport", "foo"},
+			expectedError: "conflicting options: --format=jsonreport and --only-errors cannot be used together",
+		},
+		{
+			args:          []string{"--watch", "--exit-code", "foo"},
+			expectedError: "conflicting options: --watch and --exit-code cannot be used together",
+		},
+		{
+			args: []string{"--format", "{{.Nmae}}", "foo"},
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{}, errors.New("unexpected API call")
+			},
+			expectedError: `invalid format: unknown field "Nmae"; valid fields are: CPUReservation, CurrentState, DesiredState, Error, ID, Image, ImageDigest, ImageTag, MemoryReservation, Name, Namespace, Node, NodeAvailability, NodeStatus, PortList, Ports, ShortState, Slot`,
 		},
 		{
 			args: []string{"foo"},
@@ -37,6 +105,20 @@ func TestStackPsErrors(t *testing.T) {
 			},
 			expectedError: "error getting tasks",
 		},
+		{
+			args: []string{"--filter", "desired-state=bogus", "foo"},
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{}, errors.New("unexpected API call")
+			},
+			expectedError: "invalid filter value 'desired-state=bogus': valid values are new, allocated, pending, assigned, accepted, preparing, ready, starting, running, complete, shutdown, failed, rejected, remove, orphaned",
+		},
+		{
+			args: []string{"--filter", "image=Redis", "foo"},
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{}, errors.New("unexpected API call")
+			},
+			expectedError: "invalid filter value 'image=Redis': invalid reference format: repository name (library/Redis) must be lowercase",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -52,15 +134,46 @@ func TestStackPsErrors(t *testing.T) {
 	}
 }
 
+func TestStackPsConflicts(t *testing.T) {
+	// values of the flags of conflicts that aren't booleans
+	values := map[string]string{
+		"format":       "table {{.ID}}",
+		"group-by":     "service",
+		"label-column": "tier",
+		"limit":        "1",
+	}
+	flagArgs := func(option string) []string {
+		name, value, hasValue := strings.Cut(option, "=")
+		if !hasValue {
+			value = cmp.Or(values[name], "true")
+		}
+		return []string{"--" + name + "=" + value}
+	}
+	for _, c := range psConflicts {
+		t.Run(c.option+" and "+c.other, func(t *testing.T) {
+			cmd := newPsCommand(test.NewFakeCli(&fakeClient{}))
+			cmd.SetArgs(slices.Concat(flagArgs(c.option), flagArgs(c.other), []string{"foo"}))
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.ErrorContains(t, cmd.Execute(), "conflicting options: --"+c.option+" and --"+c.other+" cannot be used together")
+		})
+	}
+}
+
 func TestStackPs(t *testing.T) {
 	testCases := []struct {
 		doc             string
 		taskListFunc    func(client.TaskListOptions) (client.TaskListResult, error)
 		nodeInspectFunc func(ref string) (client.NodeInspectResult, error)
+		nodeListFunc    func(client.NodeListOptions) (client.NodeListResult, error)
+		serviceList     func(client.ServiceListOptions) (client.ServiceListResult, error)
+		serviceInspect  func(serviceID string) (client.ServiceInspectResult, error)
+		imageInspect    func(image string) (client.ImageInspectResult, error)
 		config          configfile.ConfigFile
 		args            []string
 		flags           map[string]string
 		expectedErr     string
+		expectedStderr  string
 		golden          string
 	}{
 		{
@@ -91,6 +204,67 @@ func TestStackPs(t *testing.T) {
 			},
 			golden: "stack-ps-with-quiet-option.golden",
 		},
+		{
+			doc: "WithTaskError",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.TaskDesiredState(swarm.TaskStateShutdown),
+							builders.WithStatus(
+								builders.TaskState(swarm.TaskStateFailed),
+								builders.Timestamp(time.Now().Add(-2*time.Hour)),
+								builders.StatusErr("task: non-zero exit (1): container exited with a non-zero exit code"),
+							),
+						),
+					},
+				}, nil
+			},
+			args:   []string{"foo"},
+			golden: "stack-ps-with-task-error.golden",
+		},
+		{
+			doc: "WithTaskErrorNoTrunc",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.TaskDesiredState(swarm.TaskStateShutdown),
+							builders.WithStatus(
+								builders.TaskState(swarm.TaskStateFailed),
+								builders.Timestamp(time.Now().Add(-2*time.Hour)),
+								builders.StatusErr("task: non-zero exit (1): container exited with a non-zero exit code"),
+							),
+						),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"no-trunc": "true",
+			},
+			golden: "stack-ps-with-task-error-no-trunc.golden",
+		},
+		{
+			doc: "WithTaskErrorFormat",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo"), builders.WithStatus(builders.StatusErr("No such image: myimage:mytag"))),
+						*builders.Task(builders.TaskID("id-bar")),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format": "{{.ID}}: {{.Error}}",
+			},
+			golden: "stack-ps-with-task-error-format.golden",
+		},
 		{
 			doc: "WithNoTruncOption",
 			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
@@ -128,6 +302,41 @@ func TestStackPs(t *testing.T) {
 			},
 			golden: "stack-ps-with-no-resolve-option.golden",
 		},
+		{
+			doc: "WithNoResolveTruncatesNodeID",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(
+						builders.TaskID("xn4cypcov06f2w8gsbaf2lst3"),
+						builders.TaskNodeID("q3bjb8hvplqjqzw2dbhgn8f7a"),
+					)},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"no-resolve": "true",
+				"format":     "{{ .ID }} {{ .Node }}",
+			},
+			golden: "stack-ps-with-no-resolve-truncated-node-id.golden",
+		},
+		{
+			doc: "WithNoResolveAndNoTruncOption",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(
+						builders.TaskID("xn4cypcov06f2w8gsbaf2lst3"),
+						builders.TaskNodeID("q3bjb8hvplqjqzw2dbhgn8f7a"),
+					)},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"no-resolve": "true",
+				"no-trunc":   "true",
+				"format":     "{{ .ID }} {{ .Node }}",
+			},
+			golden: "stack-ps-with-no-resolve-and-no-trunc-option.golden",
+		},
 		{
 			doc: "WithFormat",
 			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
@@ -141,6 +350,22 @@ func TestStackPs(t *testing.T) {
 			},
 			golden: "stack-ps-with-format.golden",
 		},
+		{
+			doc: "WithTableFormat",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(
+						builders.TaskServiceID("service-id-foo"),
+						builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+					)},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format": "table {{.Name}}\t{{.CurrentState}}",
+			},
+			golden: "stack-ps-with-table-format.golden",
+		},
 		{
 			doc: "WithConfigFormat",
 			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
@@ -155,52 +380,1865 @@ func TestStackPs(t *testing.T) {
 			golden: "stack-ps-with-config-format.golden",
 		},
 		{
-			doc: "WithoutFormat",
+			doc: "WithConfigTableFormat",
 			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
 				return client.TaskListResult{
 					Items: []swarm.Task{*builders.Task(
-						builders.TaskID("id-foo"),
 						builders.TaskServiceID("service-id-foo"),
-						builders.TaskNodeID("id-node"),
-						builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
-						builders.TaskDesiredState(swarm.TaskStateReady),
-						builders.WithStatus(builders.TaskState(swarm.TaskStateFailed), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
 					)},
 				}, nil
 			},
-			nodeInspectFunc: func(ref string) (client.NodeInspectResult, error) {
-				return client.NodeInspectResult{
-					Node: *builders.Node(builders.NodeName("node-name-bar")),
+			config: configfile.ConfigFile{
+				TasksFormat: "table {{.Name}}\t{{.CurrentState}}",
+			},
+			args:   []string{"foo"},
+			golden: "stack-ps-with-table-format.golden",
+		},
+		{
+			doc: "WithJSONTemplateFormat",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo"), builders.TaskServiceID("service-id-foo"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour)))),
+						*builders.Task(builders.TaskID("id-bar"), builders.TaskServiceID("service-id-bar"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour)))),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format": "{{json .}}",
+			},
+			golden: "stack-ps-with-json-format.golden",
+		},
+		{
+			doc: "WithJSONFormat",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo"), builders.TaskServiceID("service-id-foo"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour)))),
+						*builders.Task(builders.TaskID("id-bar"), builders.TaskServiceID("service-id-bar"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour)))),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format": "json",
+			},
+			golden: "stack-ps-with-json-format.golden",
+		},
+		{
+			doc: "WithFormatFunctions",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(
+						builders.TaskID("xn4cypcov06f2w8gsbaf2lst3"),
+						builders.TaskServiceID("service-id-foo"),
+					)},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format": "{{ .Name | upper }} {{ truncate .ID 8 }}",
+			},
+			golden: "stack-ps-with-format-functions.golden",
+		},
+		{
+			doc: "WithConfigFormatFunctions",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(
+						builders.TaskID("xn4cypcov06f2w8gsbaf2lst3"),
+						builders.TaskServiceID("service-id-foo"),
+					)},
 				}, nil
 			},
+			config: configfile.ConfigFile{
+				TasksFormat: "{{ .Name | upper }} {{ truncate .ID 8 }}",
+			},
 			args:   []string{"foo"},
-			golden: "stack-ps-without-format.golden",
+			golden: "stack-ps-with-format-functions.golden",
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.doc, func(t *testing.T) {
-			cli := test.NewFakeCli(&fakeClient{
-				taskListFunc:    tc.taskListFunc,
-				nodeInspectFunc: tc.nodeInspectFunc,
-			})
-			cli.SetConfigFile(&tc.config)
-
-			cmd := newPsCommand(cli)
-			cmd.SetArgs(tc.args)
-			for key, value := range tc.flags {
-				assert.Check(t, cmd.Flags().Set(key, value))
-			}
-			cmd.SetOut(io.Discard)
-			cmd.SetErr(io.Discard)
-
-			if tc.expectedErr != "" {
-				assert.Error(t, cmd.Execute(), tc.expectedErr)
-				assert.Check(t, is.Equal("", cli.OutBuffer().String()))
-				return
-			}
-			assert.NilError(t, cmd.Execute())
-			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
-		})
-	}
+		{
+			doc: "WithResolveImages",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo"), builders.TaskServiceID("service-id-foo"), builders.WithTaskSpec(builders.TaskImage("nginx@sha256:4cfb2d5b2bcd6bcf0a3b8aafbbd2a3aa3e9d2a6ae1e8a8b0b16f8c1e1c3f4b5a"))),
+						*builders.Task(builders.TaskID("id-bar"), builders.TaskServiceID("service-id-bar"), builders.WithTaskSpec(builders.TaskImage("postgres@sha256:aa3e9d2a6ae1e8a8b0b16f8c1e1c3f4b5a4cfb2d5b2bcd6bcf0a3b8aafbbd2a3"))),
+					},
+				}, nil
+			},
+			imageInspect: func(img string) (client.ImageInspectResult, error) {
+				if strings.HasPrefix(img, "nginx@") {
+					return client.ImageInspectResult{InspectResponse: image.InspectResponse{RepoTags: []string{"nginx:1.25"}}}, nil
+				}
+				return client.ImageInspectResult{}, errdefs.ErrNotFound.WithMessage("no such image: " + img)
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"resolve-images": "true",
+				"format":         "{{ .ID }} {{ .ImageTag }}",
+			},
+			golden: "stack-ps-with-resolve-images.golden",
+		},
+		{
+			doc:  "WithResolveImagesAndQuiet",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"resolve-images": "true",
+				"quiet":          "true",
+			},
+			expectedErr: "conflicting options: --resolve-images and --quiet cannot be used together",
+		},
+		{
+			doc:  "WithResolveImagesAndCSV",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"resolve-images": "true",
+				"format":         "csv",
+			},
+			expectedErr: "conflicting options: --resolve-images and --format=csv cannot be used together",
+		},
+		{
+			doc: "WithCollapseErrors",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo"), builders.WithStatus(builders.StatusErr("task: non-zero exit (1)"))),
+						*builders.Task(builders.TaskID("id-bar"), builders.WithStatus(builders.StatusErr("task: non-zero exit (1)"))),
+						*builders.Task(builders.TaskID("id-baz")),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"collapse-errors": "true",
+			},
+			golden: "stack-ps-with-collapse-errors.golden",
+		},
+		{
+			doc: "WithCollapseErrorsNoErrors",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(builders.TaskID("id-foo"))},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"collapse-errors": "true",
+				"format":          "{{ .ID }}",
+			},
+			golden: "stack-ps-with-collapse-errors-no-errors.golden",
+		},
+		{
+			doc:  "WithCollapseErrorsAndQuiet",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"collapse-errors": "true",
+				"quiet":           "true",
+			},
+			expectedErr: "conflicting options: --collapse-errors and --quiet cannot be used together",
+		},
+		{
+			doc: "WithExplain",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo"), builders.TaskServiceID("service-id-foo"), builders.WithStatus(builders.TaskState(swarm.TaskStatePending))),
+						*builders.Task(builders.TaskID("id-bar"), builders.TaskServiceID("service-id-bar"), builders.WithStatus(builders.TaskState(swarm.TaskStatePending))),
+						*builders.Task(builders.TaskID("id-baz"), builders.TaskServiceID("service-id-foo")),
+					},
+				}, nil
+			},
+			nodeListFunc: func(client.NodeListOptions) (client.NodeListResult, error) {
+				return client.NodeListResult{
+					Items: []swarm.Node{*builders.Node(builders.NodeLabels(map[string]string{"zone": "us"}))},
+				}, nil
+			},
+			serviceInspect: func(serviceID string) (client.ServiceInspectResult, error) {
+				var constraints []string
+				if serviceID == "service-id-foo" {
+					constraints = []string{"node.labels.zone==eu", "node.role==worker"}
+				}
+				return client.ServiceInspectResult{
+					Service: swarm.Service{
+						ID: serviceID,
+						Spec: swarm.ServiceSpec{
+							TaskTemplate: swarm.TaskSpec{
+								Placement: &swarm.Placement{Constraints: constraints},
+							},
+						},
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"explain": "true",
+				"format":  "table {{ .ID }}",
+			},
+			golden: "stack-ps-with-explain.golden",
+		},
+		{
+			doc: "WithExplainNodeListError",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo"), builders.TaskServiceID("service-id-foo"), builders.WithStatus(builders.TaskState(swarm.TaskStatePending))),
+					},
+				}, nil
+			},
+			nodeListFunc: func(client.NodeListOptions) (client.NodeListResult, error) {
+				return client.NodeListResult{}, errors.New("error listing nodes")
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"explain": "true",
+				"format":  "table {{ .ID }}",
+			},
+			golden: "stack-ps-with-explain-node-list-error.golden",
+		},
+		{
+			doc:  "WithExplainAndJSONFormat",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"explain": "true",
+				"format":  "json",
+			},
+			expectedErr: "--explain can only be used with table formats",
+		},
+		{
+			doc:  "WithExplainAndQuiet",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"explain": "true",
+				"quiet":   "true",
+			},
+			expectedErr: "conflicting options: --explain and --quiet cannot be used together",
+		},
+		{
+			doc: "WithGroupByService",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo-1"), builders.TaskServiceID("service-id-foo"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning))),
+						*builders.Task(builders.TaskID("id-foo-2"), builders.TaskServiceID("service-id-foo"), builders.WithStatus(builders.TaskState(swarm.TaskStatePending))),
+						*builders.Task(builders.TaskID("id-foo-3"), builders.TaskServiceID("service-id-foo"), builders.TaskDesiredState(swarm.TaskStateShutdown), builders.WithStatus(builders.TaskState(swarm.TaskStateShutdown))),
+						*builders.Task(builders.TaskID("id-bar-1"), builders.TaskServiceID("service-id-bar"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning))),
+						*builders.Task(builders.TaskID("id-bar-2"), builders.TaskServiceID("service-id-bar"), builders.TaskDesiredState(swarm.TaskStateShutdown), builders.WithStatus(builders.TaskState(swarm.TaskStateFailed))),
+					},
+				}, nil
+			},
+			serviceInspect: func(serviceID string) (client.ServiceInspectResult, error) {
+				return client.ServiceInspectResult{
+					Service: *builders.Service(builders.ServiceID(serviceID), builders.ServiceName("foo_"+serviceID[len("service-id-"):])),
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"group-by": "service",
+			},
+			golden: "stack-ps-with-group-by-service.golden",
+		},
+		{
+			doc: "WithGroupByServiceNoResolveNoTrunc",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo-1"), builders.TaskServiceID("xn4cypcov06f2w8gsbaf2lst3"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning))),
+						*builders.Task(builders.TaskID("id-foo-2"), builders.TaskServiceID("xn4cypcov06f2w8gsbaf2lst3")),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"group-by":   "service",
+				"no-resolve": "true",
+				"no-trunc":   "true",
+				"format":     "{{ .ID }} {{ .Name }} {{ .Running }} {{ .Total }}",
+			},
+			golden: "stack-ps-with-group-by-service-no-resolve.golden",
+		},
+		{
+			doc: "WithGroupByServiceQuiet",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo-1"), builders.TaskServiceID("service-id-foo")),
+						*builders.Task(builders.TaskID("id-foo-2"), builders.TaskServiceID("service-id-foo")),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"group-by": "service",
+				"quiet":    "true",
+			},
+			golden: "stack-ps-with-group-by-service-quiet.golden",
+		},
+		{
+			doc:  "WithGroupByInvalid",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"group-by": "node",
+			},
+			expectedErr: `invalid value for --group-by: "node": only "service" is supported`,
+		},
+		{
+			doc:  "WithGroupByAndCollapseErrors",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"group-by":        "service",
+				"collapse-errors": "true",
+			},
+			expectedErr: "conflicting options: --group-by and --collapse-errors cannot be used together",
+		},
+		{
+			doc: "WithMultipleStacks",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				var tasks []swarm.Task
+				for _, namespace := range []string{"foo", "bar"} {
+					if _, ok := options.Filters["label"]["com.docker.stack.namespace="+namespace]; ok {
+						tasks = append(tasks, *builders.Task(
+							builders.TaskID("id-"+namespace),
+							builders.TaskServiceID(namespace+"_web"),
+							builders.WithTaskSpec(builders.TaskLabels(map[string]string{"com.docker.stack.namespace": namespace})),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						))
+					}
+				}
+				return client.TaskListResult{Items: tasks}, nil
+			},
+			args: []string{"foo", "bar", "baz"},
+			flags: map[string]string{
+				"no-resolve": "true",
+			},
+			expectedStderr: "nothing found in stack: baz\n",
+			golden:         "stack-ps-with-multiple-stacks.golden",
+		},
+		{
+			doc: "WithAllNamespaces",
+			serviceList: func(client.ServiceListOptions) (client.ServiceListResult, error) {
+				var services []swarm.Service
+				for _, namespace := range []string{"foo", "baz", "bar"} {
+					services = append(services, *builders.Service(
+						builders.ServiceID(namespace+"_web"),
+						builders.ServiceLabels(map[string]string{"com.docker.stack.namespace": namespace}),
+					))
+				}
+				return client.ServiceListResult{Items: services}, nil
+			},
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				var tasks []swarm.Task
+				for _, namespace := range []string{"foo", "bar"} {
+					if _, ok := options.Filters["label"]["com.docker.stack.namespace="+namespace]; ok {
+						tasks = append(tasks, *builders.Task(
+							builders.TaskID("id-"+namespace),
+							builders.TaskServiceID(namespace+"_web"),
+							builders.WithTaskSpec(builders.TaskLabels(map[string]string{"com.docker.stack.namespace": namespace})),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						))
+					}
+				}
+				return client.TaskListResult{Items: tasks}, nil
+			},
+			args: []string{},
+			flags: map[string]string{
+				"all-namespaces": "true",
+				"no-resolve":     "true",
+			},
+			golden: "stack-ps-with-all-namespaces.golden",
+		},
+		{
+			doc: "WithSince",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-old"), builders.WithStatus(builders.Timestamp(time.Now().Add(-3*time.Hour)))),
+						*builders.Task(builders.TaskID("id-recent"), builders.WithStatus(builders.Timestamp(time.Now().Add(-30*time.Minute)))),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"since":  "1h",
+				"format": "{{ .ID }}",
+			},
+			golden: "stack-ps-with-since.golden",
+		},
+		{
+			doc:  "WithInvalidUntil",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"until": "tomorrow",
+			},
+			expectedErr: `invalid value for --until: "tomorrow" is not a duration (e.g. "1h"), or an RFC 3339 timestamp (e.g. "2024-01-02T13:23:37Z") or date (e.g. "2024-01-02")`,
+		},
+		{
+			doc: "WithJSONReport",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+						*builders.Task(
+							builders.TaskID("id-bar"),
+							builders.TaskServiceID("service-id-bar"),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStatePending), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format": "jsonreport",
+			},
+			golden: "stack-ps-with-json-report.golden",
+		},
+		{
+			doc:  "WithJSONReportAndQuiet",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format": "jsonreport",
+				"quiet":  "true",
+			},
+			expectedErr: "conflicting options: --format=jsonreport and --quiet cannot be used together",
+		},
+		{
+			doc:  "WithJSONReportAndExplain",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format":  "jsonreport",
+				"explain": "true",
+			},
+			expectedErr: "conflicting options: --format=jsonreport and --explain cannot be used together",
+		},
+		{
+			doc: "WithDigests",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.TaskNodeID("id-node"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag@sha256:4cfb2d5b6b8a8a3a2a1f2ce0d4b3bb6d4e6bbf6e4ad0b8e9b51f7e7b0c6b8fd5")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+						*builders.Task(
+							builders.TaskID("id-bar"),
+							builders.TaskServiceID("service-id-bar"),
+							builders.TaskNodeID("id-node"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"digests": "true",
+			},
+			golden: "stack-ps-with-digests.golden",
+		},
+		{
+			doc: "WithDigestsAndNoTruncOption",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(
+						builders.TaskID("xn4cypcov06f2w8gsbaf2lst3"),
+						builders.TaskServiceID("service-id-foo"),
+						builders.TaskNodeID("id-node"),
+						builders.WithTaskSpec(builders.TaskImage("myimage:mytag@sha256:4cfb2d5b6b8a8a3a2a1f2ce0d4b3bb6d4e6bbf6e4ad0b8e9b51f7e7b0c6b8fd5")),
+						builders.TaskDesiredState(swarm.TaskStateRunning),
+						builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+					)},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"digests":  "true",
+				"no-trunc": "true",
+			},
+			golden: "stack-ps-with-digests-no-trunc.golden",
+		},
+		{
+			doc: "WithResources",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.TaskNodeID("id-node"),
+							builders.WithTaskSpec(
+								builders.TaskImage("myimage:mytag"),
+								builders.TaskResources(&swarm.ResourceRequirements{
+									Reservations: &swarm.Resources{NanoCPUs: 5e8, MemoryBytes: 512 * 1024 * 1024},
+								}),
+							),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+						*builders.Task(
+							builders.TaskID("id-bar"),
+							builders.TaskServiceID("service-id-bar"),
+							builders.TaskNodeID("id-node"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"resources": "true",
+			},
+			golden: "stack-ps-with-resources.golden",
+		},
+		{
+			doc:  "WithResourcesAndFormat",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"resources": "true",
+				"format":    "{{.Name}}",
+			},
+			expectedErr: "conflicting options: --resources and --format cannot be used together; use the .CPUReservation and .MemoryReservation placeholders instead",
+		},
+		{
+			doc:  "WithResourcesAndQuiet",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"resources": "true",
+				"quiet":     "true",
+			},
+			expectedErr: "conflicting options: --resources and --quiet cannot be used together",
+		},
+		{
+			doc: "WithNodeStatus",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.TaskNodeID("id-node-ready"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+						*builders.Task(
+							builders.TaskID("id-bar"),
+							builders.TaskServiceID("service-id-bar"),
+							builders.TaskNodeID("id-node-down"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+					},
+				}, nil
+			},
+			nodeListFunc: func(client.NodeListOptions) (client.NodeListResult, error) {
+				return client.NodeListResult{
+					Items: []swarm.Node{
+						*builders.Node(builders.NodeID("id-node-ready"), builders.NodeName("node-ready")),
+						*builders.Node(builders.NodeID("id-node-down"), builders.NodeName("node-down"), func(n *swarm.Node) {
+							n.Status.State = swarm.NodeStateDown
+							n.Spec.Availability = swarm.NodeAvailabilityDrain
+						}),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"node-status": "true",
+			},
+			golden: "stack-ps-with-node-status.golden",
+		},
+		{
+			doc: "WithShortStates",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+						*builders.Task(
+							builders.TaskID("id-bar"),
+							builders.TaskServiceID("service-id-bar"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateShutdown),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateFailed), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"short-states": "true",
+			},
+			golden: "stack-ps-with-short-states.golden",
+		},
+		{
+			doc:  "WithShortStatesAndFormat",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"short-states": "true",
+				"format":       "{{.Name}}",
+			},
+			expectedErr: "conflicting options: --short-states and --format cannot be used together; use the .ShortState placeholder instead",
+		},
+		{
+			doc:  "WithShortStatesAndAbsoluteTime",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"short-states":  "true",
+				"absolute-time": "true",
+			},
+			expectedErr: "conflicting options: --short-states and --absolute-time cannot be used together",
+		},
+		{
+			doc: "WithNoHeader",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+						*builders.Task(
+							builders.TaskID("id-bar"),
+							builders.TaskServiceID("service-id-bar"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateShutdown),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateFailed), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"no-header": "true",
+			},
+			golden: "stack-ps-with-no-header.golden",
+		},
+		{
+			doc: "WithNoHeaderAndTableFormat",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(builders.TaskID("id-foo"), builders.TaskServiceID("service-id-foo")),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"no-header": "true",
+				"format":    "table {{.ID}}\t{{.Name}}",
+			},
+			golden: "stack-ps-with-no-header-and-table-format.golden",
+		},
+		{
+			doc:  "WithNoHeaderAndJSONFormat",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"no-header": "true",
+				"format":    "json",
+			},
+			expectedErr: "--no-header can only be used with table formats",
+		},
+		{
+			doc:  "WithNoHeaderAndCollapseErrors",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"no-header":       "true",
+				"collapse-errors": "true",
+			},
+			expectedErr: "conflicting options: --no-header and --collapse-errors cannot be used together",
+		},
+		{
+			doc:  "WithNodeStatusAndFormat",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"node-status": "true",
+				"format":      "{{.Name}}",
+			},
+			expectedErr: "conflicting options: --node-status and --format cannot be used together; use the .NodeStatus and .NodeAvailability placeholders instead",
+		},
+		{
+			doc:  "WithNodeStatusAndNoResolve",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"node-status": "true",
+				"no-resolve":  "true",
+			},
+			expectedErr: "conflicting options: --node-status and --no-resolve cannot be used together",
+		},
+		{
+			doc:  "WithDigestsAndFormat",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"digests": "true",
+				"format":  "{{.Name}}",
+			},
+			expectedErr: "conflicting options: --digests and --format cannot be used together; use the .ImageDigest placeholder instead",
+		},
+		{
+			doc:  "WithDigestsAndQuiet",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"digests": "true",
+				"quiet":   "true",
+			},
+			expectedErr: "conflicting options: --digests and --quiet cannot be used together",
+		},
+		{
+			doc:  "WithDigestsAndGroupBy",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"digests":  "true",
+				"group-by": "service",
+			},
+			expectedErr: "conflicting options: --digests and --group-by cannot be used together",
+		},
+		{
+			doc: "WithCSVFormat",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(
+						builders.TaskID("id-foo"),
+						builders.TaskServiceID("service-id-foo"),
+						builders.TaskNodeID("id-node"),
+						builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+						builders.TaskDesiredState(swarm.TaskStateShutdown),
+						builders.WithStatus(
+							builders.TaskState(swarm.TaskStateFailed),
+							builders.Timestamp(time.Now().Add(-2*time.Hour)),
+							builders.StatusErr(`starting container failed: "a, b"`),
+						),
+					)},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format":   "csv",
+				"no-trunc": "true",
+			},
+			golden: "stack-ps-with-csv-format.golden",
+		},
+		{
+			doc: "WithYAMLFormat",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.TaskNodeID("id-node"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateShutdown),
+							builders.WithStatus(
+								builders.TaskState(swarm.TaskStateFailed),
+								builders.Timestamp(time.Now().Add(-2*time.Hour)),
+								builders.StatusErr(`starting container failed: "a, b"`),
+							),
+						),
+						*builders.Task(
+							builders.TaskID("id-bar"),
+							builders.TaskServiceID("service-id-bar"),
+							builders.TaskNodeID("id-node"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format": "yaml",
+			},
+			golden: "stack-ps-with-yaml-format.golden",
+		},
+		{
+			doc: "WithLabelColumn",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{
+						*builders.Task(
+							builders.TaskID("id-foo"),
+							builders.TaskServiceID("service-id-foo"),
+							builders.TaskNodeID("id-node"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+						*builders.Task(
+							builders.TaskID("id-bar"),
+							builders.TaskServiceID("service-id-bar"),
+							builders.TaskNodeID("id-node"),
+							builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+							builders.TaskDesiredState(swarm.TaskStateRunning),
+							builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+						),
+					},
+				}, nil
+			},
+			serviceInspect: func(serviceID string) (client.ServiceInspectResult, error) {
+				spec := swarm.ServiceSpec{Annotations: swarm.Annotations{Name: strings.TrimPrefix(serviceID, "service-id-")}}
+				return client.ServiceInspectResult{Service: swarm.Service{ID: serviceID, Spec: spec}}, nil
+			},
+			serviceList: func(client.ServiceListOptions) (client.ServiceListResult, error) {
+				return client.ServiceListResult{
+					Items: []swarm.Service{
+						*builders.Service(builders.ServiceID("service-id-foo"), builders.ServiceLabels(map[string]string{"tier": "frontend", "com.example.owner": "web-team"})),
+						*builders.Service(builders.ServiceID("service-id-bar")),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"label-column": "tier,com.example.owner",
+			},
+			golden: "stack-ps-with-label-column.golden",
+		},
+		{
+			doc: "WithLabelPlaceholder",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(
+						builders.TaskID("id-foo"),
+						builders.TaskServiceID("service-id-foo"),
+					)},
+				}, nil
+			},
+			serviceInspect: func(serviceID string) (client.ServiceInspectResult, error) {
+				return client.ServiceInspectResult{Service: swarm.Service{ID: serviceID, Spec: swarm.ServiceSpec{
+					Annotations: swarm.Annotations{Name: "foo"},
+				}}}, nil
+			},
+			serviceList: func(client.ServiceListOptions) (client.ServiceListResult, error) {
+				return client.ServiceListResult{
+					Items: []swarm.Service{
+						*builders.Service(builders.ServiceID("service-id-foo"), builders.ServiceLabels(map[string]string{"tier": "frontend"})),
+					},
+				}, nil
+			},
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format": `{{.Name}} {{.Label "tier"}}`,
+			},
+			golden: "stack-ps-with-label-placeholder.golden",
+		},
+		{
+			doc:  "WithLabelColumnAndFormat",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"label-column": "tier",
+				"format":       "{{.Name}}",
+			},
+			expectedErr: `conflicting options: --label-column and --format cannot be used together; use the .Label placeholder instead, for example {{.Label "tier"}}`,
+		},
+		{
+			doc:  "WithLabelColumnAndQuiet",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"label-column": "tier",
+				"quiet":        "true",
+			},
+			expectedErr: "conflicting options: --label-column and --quiet cannot be used together",
+		},
+		{
+			doc:  "WithLabelColumnAndGroupBy",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"label-column": "tier",
+				"group-by":     "service",
+			},
+			expectedErr: "conflicting options: --label-column and --group-by cannot be used together",
+		},
+		{
+			doc:  "WithYAMLFormatAndCollapseErrors",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format":          "yaml",
+				"collapse-errors": "true",
+			},
+			expectedErr: "conflicting options: --format=yaml and --collapse-errors cannot be used together",
+		},
+		{
+			doc:  "WithYAMLFormatAndGroupBy",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format":   "yaml",
+				"group-by": "service",
+			},
+			expectedErr: "conflicting options: --group-by and --format=yaml cannot be used together",
+		},
+		{
+			doc:  "WithCSVFormatAndCollapseErrors",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format":          "csv",
+				"collapse-errors": "true",
+			},
+			expectedErr: "conflicting options: --format=csv and --collapse-errors cannot be used together",
+		},
+		{
+			doc:  "WithCSVFormatAndGroupBy",
+			args: []string{"foo"},
+			flags: map[string]string{
+				"format":   "csv",
+				"group-by": "service",
+			},
+			expectedErr: "conflicting options: --group-by and --format=csv cannot be used together",
+		},
+		{
+			doc: "WithoutFormat",
+			taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{
+					Items: []swarm.Task{*builders.Task(
+						builders.TaskID("id-foo"),
+						builders.TaskServiceID("service-id-foo"),
+						builders.TaskNodeID("id-node"),
+						builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+						builders.TaskDesiredState(swarm.TaskStateReady),
+						builders.WithStatus(builders.TaskState(swarm.TaskStateFailed), builders.Timestamp(time.Now().Add(-2*time.Hour))),
+					)},
+				}, nil
+			},
+			nodeInspectFunc: func(ref string) (client.NodeInspectResult, error) {
+				return client.NodeInspectResult{
+					Node: *builders.Node(builders.NodeName("node-name-bar")),
+				}, nil
+			},
+			args:   []string{"foo"},
+			golden: "stack-ps-without-format.golden",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{
+				taskListFunc:       tc.taskListFunc,
+				nodeInspectFunc:    tc.nodeInspectFunc,
+				nodeListFunc:       tc.nodeListFunc,
+				serviceListFunc:    tc.serviceList,
+				serviceInspectFunc: tc.serviceInspect,
+				imageInspectFunc:   tc.imageInspect,
+			})
+			cli.SetConfigFile(&tc.config)
+
+			cmd := newPsCommand(cli)
+			cmd.SetArgs(tc.args)
+			for key, value := range tc.flags {
+				assert.Check(t, cmd.Flags().Set(key, value))
+			}
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+
+			if tc.expectedErr != "" {
+				assert.Error(t, cmd.Execute(), tc.expectedErr)
+				assert.Check(t, is.Equal("", cli.OutBuffer().String()))
+				return
+			}
+			assert.NilError(t, cmd.Execute())
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+			assert.Check(t, is.Equal(cli.ErrBuffer().String(), tc.expectedStderr))
+		})
+	}
+}
+
+func TestStackPsRetry(t *testing.T) {
+	retryOpts := retry.Options{Retries: 2, InitialDelay: time.Millisecond}
+	tasks := client.TaskListResult{
+		Items: []swarm.Task{*builders.Task(builders.TaskID("id-foo"))},
+	}
+
+	t.Run("transient error", func(t *testing.T) {
+		var attempts int
+		cli := test.NewFakeCli(&fakeClient{
+			taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+				attempts++
+				if attempts < 3 {
+					return client.TaskListResult{}, errdefs.ErrUnavailable.WithMessage("swarm is busy")
+				}
+				return tasks, nil
+			},
+		})
+		err := runPS(context.Background(), cli, psOptions{
+			filter:     cliopts.NewFilterOpt(),
+			namespaces: []string{"foo"},
+			quiet:      true,
+			retry:      retryOpts,
+		})
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(attempts, 3))
+		assert.Check(t, is.Equal(cli.OutBuffer().String(), "id-foo\n"))
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		var attempts int
+		cli := test.NewFakeCli(&fakeClient{
+			taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+				attempts++
+				return client.TaskListResult{}, errdefs.ErrUnavailable.WithMessage("swarm is busy")
+			},
+		})
+		err := runPS(context.Background(), cli, psOptions{
+			filter:     cliopts.NewFilterOpt(),
+			namespaces: []string{"foo"},
+			retry:      retryOpts,
+		})
+		assert.Check(t, is.ErrorContains(err, "swarm is busy"))
+		assert.Check(t, is.Equal(attempts, 3))
+	})
+
+	t.Run("permanent error", func(t *testing.T) {
+		var attempts int
+		cli := test.NewFakeCli(&fakeClient{
+			taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+				attempts++
+				return client.TaskListResult{}, errdefs.ErrInvalidArgument.WithMessage("invalid filter")
+			},
+		})
+		err := runPS(context.Background(), cli, psOptions{
+			filter:     cliopts.NewFilterOpt(),
+			namespaces: []string{"foo"},
+			retry:      retryOpts,
+		})
+		assert.Check(t, is.ErrorContains(err, "invalid filter"))
+		assert.Check(t, is.Equal(attempts, 1))
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var attempts int
+		cli := test.NewFakeCli(&fakeClient{
+			taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+				attempts++
+				cancel()
+				return client.TaskListResult{}, errdefs.ErrUnavailable.WithMessage("swarm is busy")
+			},
+		})
+		err := runPS(ctx, cli, psOptions{
+			filter:     cliopts.NewFilterOpt(),
+			namespaces: []string{"foo"},
+			retry:      retry.Options{Retries: 2, InitialDelay: time.Hour},
+		})
+		assert.Check(t, is.ErrorContains(err, "swarm is busy"))
+		assert.Check(t, is.Equal(attempts, 1))
+	})
+}
+
+func TestStackPsPrefetchNodes(t *testing.T) {
+	var listCounter int
+	cli := test.NewFakeCli(&fakeClient{
+		taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+			return client.TaskListResult{
+				Items: []swarm.Task{
+					*builders.Task(builders.TaskID("id-foo"), builders.TaskNodeID("id-node-foo")),
+					*builders.Task(builders.TaskID("id-bar"), builders.TaskNodeID("id-node-foo")),
+				},
+			}, nil
+		},
+		nodeListFunc: func(client.NodeListOptions) (client.NodeListResult, error) {
+			listCounter++
+			return client.NodeListResult{
+				Items: []swarm.Node{*builders.Node(builders.NodeID("id-node-foo"), builders.NodeName("node-foo"))},
+			}, nil
+		},
+		nodeInspectFunc: func(string) (client.NodeInspectResult, error) {
+			t.Error("nodes should not be inspected if they were listed")
+			return client.NodeInspectResult{}, nil
+		},
+	})
+	err := runPS(context.Background(), cli, psOptions{
+		filter:     cliopts.NewFilterOpt(),
+		namespaces: []string{"foo"},
+		format:     "{{ .Node }}",
+	})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(listCounter, 1))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "node-foo\nnode-foo\n"))
+}
+
+func TestStackPsNode(t *testing.T) {
+	var taskFilters client.Filters
+	apiClient := &fakeClient{
+		taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+			taskFilters = options.Filters
+			return client.TaskListResult{
+				Items: []swarm.Task{*builders.Task(builders.TaskID("id-foo"), builders.TaskNodeID("id-node-bar"))},
+			}, nil
+		},
+		nodeListFunc: func(client.NodeListOptions) (client.NodeListResult, error) {
+			return client.NodeListResult{
+				Items: []swarm.Node{
+					*builders.Node(builders.NodeID("id-node-foo"), builders.NodeName(""), builders.Hostname("host-foo")),
+					*builders.Node(builders.NodeID("id-node-dup-1"), builders.NodeName("node-dup")),
+					*builders.Node(builders.NodeID("id-node-dup-2"), builders.NodeName("node-dup")),
+				},
+			}, nil
+		},
+		nodeInspectFunc: func(ref string) (client.NodeInspectResult, error) {
+			if ref == "node-name-bar" {
+				return client.NodeInspectResult{
+					Node: *builders.Node(builders.NodeID("id-node-bar"), builders.NodeName("node-name-bar")),
+				}, nil
+			}
+			return client.NodeInspectResult{}, errdefs.ErrNotFound.WithMessage("node " + ref + " not found")
+		},
+	}
+
+	testCases := []struct {
+		node          string
+		expectedNode  string
+		expectedError string
+	}{
+		{node: "node-name-bar", expectedNode: "id-node-bar"},
+		{node: "host-foo", expectedNode: "id-node-foo"},
+		{node: "node-dup", expectedError: "node name node-dup is ambiguous (2 matches found)"},
+		{node: "node-unknown", expectedError: "no such node: node-unknown"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.node, func(t *testing.T) {
+			taskFilters = nil
+			cmd := newPsCommand(test.NewFakeCli(apiClient))
+			cmd.SetArgs([]string{"--node", tc.node, "--quiet", "foo"})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.Execute()
+			if tc.expectedError != "" {
+				assert.Check(t, is.Error(err, tc.expectedError))
+				assert.Check(t, is.Nil(taskFilters), "tasks should not be listed if the node is not resolved")
+				return
+			}
+			assert.NilError(t, err)
+			assert.Check(t, is.DeepEqual(taskFilters["node"], map[string]bool{tc.expectedNode: true}))
+		})
+	}
+}
+
+func TestStackPsWatch(t *testing.T) {
+	tasks := client.TaskListResult{
+		Items: []swarm.Task{*builders.Task(builders.TaskID("id-foo"))},
+	}
+	for _, isTerminal := range []bool{false, true} {
+		t.Run(fmt.Sprintf("terminal=%t", isTerminal), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			var calls int
+			cli := test.NewFakeCli(&fakeClient{
+				taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+					calls++
+					if calls == 3 {
+						cancel()
+					}
+					return tasks, nil
+				},
+			})
+			cli.Out().SetIsTerminal(isTerminal)
+			err := runPS(ctx, cli, psOptions{
+				filter:     cliopts.NewFilterOpt(),
+				namespaces: []string{"foo"},
+				quiet:      true,
+				watch:      true,
+				interval:   time.Millisecond,
+			})
+			assert.Check(t, is.ErrorIs(err, context.Canceled))
+			assert.Check(t, is.Equal(calls, 3))
+
+			expected := "id-foo\n\nid-foo\n\nid-foo\n"
+			if isTerminal {
+				expected = "\033[H\033[Jid-foo\n\033[H\033[Jid-foo\n\033[H\033[Jid-foo\n"
+			}
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), expected))
+		})
+	}
+}
+
+func TestStackPsWatchError(t *testing.T) {
+	var calls int
+	cli := test.NewFakeCli(&fakeClient{
+		taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+			calls++
+			if calls == 2 {
+				return client.TaskListResult{}, errors.New("error getting tasks")
+			}
+			return client.TaskListResult{
+				Items: []swarm.Task{*builders.Task(builders.TaskID("id-foo"))},
+			}, nil
+		},
+	})
+	err := runPS(context.Background(), cli, psOptions{
+		filter:     cliopts.NewFilterOpt(),
+		namespaces: []string{"foo"},
+		quiet:      true,
+		watch:      true,
+		interval:   time.Millisecond,
+	})
+	assert.Check(t, is.Error(err, "error getting tasks"))
+	assert.Check(t, is.Equal(calls, 2))
+}
+
+func TestStackPsInvalidRetries(t *testing.T) {
+	t.Setenv(retry.EnvRetries, "-1")
+	cmd := newPsCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"foo"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "invalid number of retries: -1: must be a positive number")
+}
+
+func TestParseTimeRange(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		doc           string
+		since, until  string
+		expectedSince time.Time
+		expectedUntil time.Time
+		expectedErr   string
+	}{
+		{
+			doc: "empty",
+		},
+		{
+			doc:           "duration",
+			since:         "1h",
+			until:         "30m",
+			expectedSince: now.Add(-time.Hour),
+			expectedUntil: now.Add(-30 * time.Minute),
+		},
+		{
+			doc:           "RFC 3339",
+			since:         "2024-01-01T13:23:37Z",
+			expectedSince: time.Date(2024, 1, 1, 13, 23, 37, 0, time.UTC),
+		},
+		{
+			doc:           "date",
+			until:         "2024-01-01",
+			expectedUntil: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			doc:         "invalid since",
+			since:       "yesterday",
+			expectedErr: `invalid value for --since: "yesterday" is not a duration`,
+		},
+		{
+			doc:         "invalid until",
+			until:       "2024-13-01",
+			expectedErr: `invalid value for --until: "2024-13-01" is not a duration`,
+		},
+		{
+			doc:         "until before since",
+			since:       "1h",
+			until:       "2h",
+			expectedErr: "invalid time range: --until must not be before --since",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			since, until, err := parseTimeRange(tc.since, tc.until, now)
+			if tc.expectedErr != "" {
+				assert.Check(t, is.ErrorContains(err, tc.expectedErr))
+				return
+			}
+			assert.NilError(t, err)
+			assert.Check(t, since.Equal(tc.expectedSince), "since: %s", since)
+			assert.Check(t, until.Equal(tc.expectedUntil), "until: %s", until)
+		})
+	}
+}
+
+func TestFilterTasksByTime(t *testing.T) {
+	now := time.Now()
+	tasks := []swarm.Task{
+		*builders.Task(builders.TaskID("id-old"), builders.WithStatus(builders.Timestamp(now.Add(-3*time.Hour)))),
+		*builders.Task(builders.TaskID("id-recent"), builders.WithStatus(builders.Timestamp(now.Add(-30*time.Minute)))),
+		*builders.Task(builders.TaskID("id-no-timestamp"), builders.WithStatus(builders.Timestamp(time.Time{}))),
+	}
+	ids := func(tasks []swarm.Task) []string {
+		var out []string
+		for _, t := range tasks {
+			out = append(out, t.ID)
+		}
+		return out
+	}
+
+	assert.Check(t, is.DeepEqual(ids(filterTasksByTime(tasks, now.Add(-time.Hour), time.Time{})), []string{"id-recent"}))
+	assert.Check(t, is.DeepEqual(ids(filterTasksByTime(tasks, time.Time{}, now.Add(-time.Hour))), []string{"id-old"}))
+	assert.Check(t, is.DeepEqual(ids(filterTasksByTime(tasks, now.Add(-4*time.Hour), now)), []string{"id-old", "id-recent"}))
+}
+
+func TestFilterFailedTasks(t *testing.T) {
+	tasks := []swarm.Task{
+		*builders.Task(builders.TaskID("id-running"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning))),
+		*builders.Task(builders.TaskID("id-failed"), builders.WithStatus(builders.TaskState(swarm.TaskStateFailed))),
+		*builders.Task(builders.TaskID("id-rejected"), builders.WithStatus(builders.TaskState(swarm.TaskStateRejected))),
+		*builders.Task(builders.TaskID("id-complete"), builders.WithStatus(builders.TaskState(swarm.TaskStateComplete))),
+		*builders.Task(builders.TaskID("id-orphaned"), builders.WithStatus(builders.TaskState(swarm.TaskStateOrphaned))),
+		*builders.Task(builders.TaskID("id-pending"), builders.WithStatus(builders.TaskState(swarm.TaskStatePending), builders.StatusErr("no suitable node"))),
+	}
+	var ids []string
+	for _, t := range filterFailedTasks(tasks) {
+		ids = append(ids, t.ID)
+	}
+	assert.Check(t, is.DeepEqual(ids, []string{"id-failed", "id-rejected", "id-orphaned", "id-pending"}))
+}
+
+func TestLimitTasks(t *testing.T) {
+	now := time.Now()
+	tasks := []swarm.Task{
+		*builders.Task(builders.TaskID("id-old"), builders.WithStatus(builders.Timestamp(now.Add(-3*time.Hour)))),
+		*builders.Task(builders.TaskID("id-recent-b"), builders.WithStatus(builders.Timestamp(now.Add(-30*time.Minute)))),
+		*builders.Task(builders.TaskID("id-no-timestamp"), builders.WithStatus(builders.Timestamp(time.Time{}))),
+		*builders.Task(builders.TaskID("id-recent-a"), builders.WithStatus(builders.Timestamp(now.Add(-30*time.Minute)))),
+		*builders.Task(builders.TaskID("id-newest"), builders.WithStatus(builders.Timestamp(now))),
+	}
+	ids := func(tasks []swarm.Task) []string {
+		var out []string
+		for _, t := range tasks {
+			out = append(out, t.ID)
+		}
+		return out
+	}
+
+	assert.Check(t, is.DeepEqual(ids(limitTasks(tasks, 3)), []string{"id-newest", "id-recent-a", "id-recent-b"}))
+	assert.Check(t, is.DeepEqual(ids(limitTasks(tasks, 10)), []string{"id-newest", "id-recent-a", "id-recent-b", "id-old", "id-no-timestamp"}))
+	// the given tasks are not reordered
+	assert.Check(t, is.Equal(tasks[0].ID, "id-old"))
+}
+
+func TestStackPsLimit(t *testing.T) {
+	now := time.Now()
+	taskListFunc := func(client.TaskListOptions) (client.TaskListResult, error) {
+		var tasks client.TaskListResult
+		for i := range 5 {
+			tasks.Items = append(tasks.Items, *builders.Task(
+				builders.TaskID(fmt.Sprintf("id-%d", i)),
+				builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(now.Add(time.Duration(i)*time.Minute))),
+			))
+		}
+		return tasks, nil
+	}
+
+	testCases := []struct {
+		doc         string
+		args        []string
+		expectedOut string
+		expectedErr string
+	}{
+		{
+			doc:         "quiet",
+			args:        []string{"--limit", "2", "--quiet", "foo"},
+			expectedOut: "id-4\nid-3\n",
+			expectedErr: "showing 2 of 5 tasks\n",
+		},
+		{
+			doc:         "format",
+			args:        []string{"--limit", "3", "--format", "{{ .ID }}", "foo"},
+			expectedOut: "id-4\nid-3\nid-2\n",
+			expectedErr: "showing 3 of 5 tasks\n",
+		},
+		{
+			doc:         "limit not reached",
+			args:        []string{"--limit", "5", "--quiet", "foo"},
+			expectedOut: "id-4\nid-3\nid-2\nid-1\nid-0\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{taskListFunc: taskListFunc})
+			cmd := newPsCommand(cli)
+			cmd.SetArgs(tc.args)
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expectedOut))
+			assert.Check(t, is.Equal(cli.ErrBuffer().String(), tc.expectedErr))
+		})
+	}
+}
+
+func TestValidateDesiredStateFilter(t *testing.T) {
+	testCases := []struct {
+		doc         string
+		values      []string
+		expectedErr string
+	}{
+		{
+			doc: "no filter",
+		},
+		{
+			doc:    "valid values",
+			values: []string{"running", "shutdown", "accepted"},
+		},
+		{
+			doc:    "case-insensitive",
+			values: []string{"Running"},
+		},
+		{
+			doc:         "invalid value",
+			values:      []string{"running", "bogus"},
+			expectedErr: "invalid filter value 'desired-state=bogus'",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			filters := make(client.Filters)
+			if len(tc.values) > 0 {
+				filters.Add("desired-state", tc.values...)
+			}
+			err := validateDesiredStateFilter(filters)
+			if tc.expectedErr == "" {
+				assert.Check(t, err)
+				return
+			}
+			assert.Check(t, is.ErrorContains(err, tc.expectedErr))
+			assert.Check(t, is.ErrorType(err, errdefs.IsInvalidArgument))
+		})
+	}
+}
+
+func TestComputeVerdict(t *testing.T) {
+	task := func(desired, state swarm.TaskState) swarm.Task {
+		return *builders.Task(builders.TaskDesiredState(desired), builders.WithStatus(builders.TaskState(state)))
+	}
+	testCases := []struct {
+		doc      string
+		tasks    []swarm.Task
+		expected verdict
+	}{
+		{
+			doc:      "no tasks",
+			expected: verdictFailed,
+		},
+		{
+			doc: "all running",
+			tasks: []swarm.Task{
+				task(swarm.TaskStateRunning, swarm.TaskStateRunning),
+				task(swarm.TaskStateRunning, swarm.TaskStateRunning),
+			},
+			expected: verdictOK,
+		},
+		{
+			doc: "completed job",
+			tasks: []swarm.Task{
+				task(swarm.TaskStateComplete, swarm.TaskStateComplete),
+			},
+			expected: verdictOK,
+		},
+		{
+			doc: "previous tasks are ignored",
+			tasks: []swarm.Task{
+				task(swarm.TaskStateRunning, swarm.TaskStateRunning),
+				task(swarm.TaskStateShutdown, swarm.TaskStateFailed),
+				task(swarm.TaskStateRemove, swarm.TaskStateRejected),
+			},
+			expected: verdictOK,
+		},
+		{
+			doc: "some running",
+			tasks: []swarm.Task{
+				task(swarm.TaskStateRunning, swarm.TaskStateRunning),
+				task(swarm.TaskStateRunning, swarm.TaskStatePending),
+			},
+			expected: verdictDegraded,
+		},
+		{
+			doc: "none running",
+			tasks: []swarm.Task{
+				task(swarm.TaskStateRunning, swarm.TaskStatePending),
+				task(swarm.TaskStateRunning, swarm.TaskStateStarting),
+			},
+			expected: verdictFailed,
+		},
+		{
+			doc: "only previous tasks",
+			tasks: []swarm.Task{
+				task(swarm.TaskStateShutdown, swarm.TaskStateShutdown),
+			},
+			expected: verdictFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			assert.Check(t, is.Equal(computeVerdict(tc.tasks), tc.expected))
+		})
+	}
+}
+
+func TestStackPsExitCode(t *testing.T) {
+	task := func(state swarm.TaskState) swarm.Task {
+		return *builders.Task(builders.TaskDesiredState(swarm.TaskStateRunning), builders.WithStatus(builders.TaskState(state)))
+	}
+	testCases := []struct {
+		doc             string
+		tasks           []swarm.Task
+		expectedVerdict verdict
+		expectedCode    int
+	}{
+		{
+			doc:             "ok",
+			tasks:           []swarm.Task{task(swarm.TaskStateRunning)},
+			expectedVerdict: verdictOK,
+		},
+		{
+			doc:             "degraded",
+			tasks:           []swarm.Task{task(swarm.TaskStateRunning), task(swarm.TaskStatePending)},
+			expectedVerdict: verdictDegraded,
+			expectedCode:    exitCodeDegraded,
+		},
+		{
+			doc:             "failed",
+			tasks:           []swarm.Task{task(swarm.TaskStateFailed)},
+			expectedVerdict: verdictFailed,
+			expectedCode:    exitCodeFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			fakeCli := test.NewFakeCli(&fakeClient{
+				taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+					return client.TaskListResult{Items: tc.tasks}, nil
+				},
+			})
+			cmd := newPsCommand(fakeCli)
+			cmd.SetArgs([]string{"foo", "--format=jsonreport", "--exit-code"})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+
+			err := cmd.Execute()
+			if tc.expectedCode == 0 {
+				assert.NilError(t, err)
+			} else {
+				var statusErr cli.StatusError
+				assert.Assert(t, errors.As(err, &statusErr))
+				assert.Check(t, is.Equal(statusErr.StatusCode, tc.expectedCode))
+			}
+
+			// The verdict in the report must match the exit code.
+			var report psReport
+			assert.NilError(t, json.NewDecoder(bytes.NewReader(fakeCli.OutBuffer().Bytes())).Decode(&report))
+			assert.Check(t, is.Equal(report.SchemaVersion, reportSchemaVersion))
+			assert.Check(t, is.Equal(report.Verdict, tc.expectedVerdict))
+			assert.Check(t, is.Equal(report.Verdict.exitCode(), tc.expectedCode))
+			assert.Check(t, is.Len(report.Tasks, len(tc.tasks)))
+		})
+	}
+}
+
+func TestStackPsNothingFound(t *testing.T) {
+	cmd := newPsCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"foo", "bar"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	err := cmd.Execute()
+	var statusErr cli.StatusError
+	assert.Assert(t, errors.As(err, &statusErr))
+	assert.Check(t, is.Equal(statusErr.StatusCode, exitCodeNothingFound))
+	assert.Check(t, is.Error(err, "nothing found in stack: foo, bar"))
+
+	// other errors use the default exit code
+	cmd = newPsCommand(test.NewFakeCli(&fakeClient{
+		taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+			return client.TaskListResult{}, errors.New("error getting tasks")
+		},
+	}))
+	cmd.SetArgs([]string{"foo"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	err = cmd.Execute()
+	assert.Check(t, !errors.As(err, &statusErr))
+}
+
+func TestStackPsAbsoluteTime(t *testing.T) {
+	timestamp := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	newCLI := func() *test.FakeCli {
+		return test.NewFakeCli(&fakeClient{
+			taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+				return client.TaskListResult{Items: []swarm.Task{
+					*builders.Task(builders.TaskID("id-foo"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning), builders.Timestamp(timestamp))),
+				}}, nil
+			},
+		})
+	}
+
+	cli := newCLI()
+	cmd := newPsCommand(cli)
+	cmd.SetArgs([]string{"--format", "{{ .CurrentState }}", "foo"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "Running 2 hours ago\n"))
+
+	cli = newCLI()
+	cmd = newPsCommand(cli)
+	cmd.SetArgs([]string{"--absolute-time", "--format", "{{ .CurrentState }}", "foo"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "Running since "+timestamp.Local().Format(time.RFC3339)+"\n"))
+}
+
+func TestStackPsImageFilter(t *testing.T) {
+	var taskFilters client.Filters
+	cli := test.NewFakeCli(&fakeClient{
+		taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+			taskFilters = options.Filters
+			return client.TaskListResult{
+				Items: []swarm.Task{
+					*builders.Task(builders.TaskID("id-redis-7.4"), builders.WithTaskSpec(builders.TaskImage("redis:7.4@sha256:6a692a76c2081888b589e26e6ec835743119fe453d67ecf03df7de5b73d69842"))),
+					*builders.Task(builders.TaskID("id-redis-7.2"), builders.WithTaskSpec(builders.TaskImage("redis:7.2"))),
+					*builders.Task(builders.TaskID("id-result"), builders.WithTaskSpec(builders.TaskImage("dockersamples/examplevotingapp_result:before"))),
+					*builders.Task(builders.TaskID("id-vote"), builders.WithTaskSpec(builders.TaskImage("dockersamples/examplevotingapp_vote:before"))),
+				},
+			}, nil
+		},
+	})
+
+	testCases := []struct {
+		doc      string
+		filters  []string
+		expected string
+	}{
+		{
+			doc:      "name",
+			filters:  []string{"image=redis"},
+			expected: "id-redis-7.4\nid-redis-7.2\n",
+		},
+		{
+			doc:      "normalized name",
+			filters:  []string{"image=docker.io/library/redis"},
+			expected: "id-redis-7.4\nid-redis-7.2\n",
+		},
+		{
+			doc:      "tag",
+			filters:  []string{"image=redis:7.4"},
+			expected: "id-redis-7.4\n",
+		},
+		{
+			doc:      "digest",
+			filters:  []string{"image=redis@sha256:6a692a76c2081888b589e26e6ec835743119fe453d67ecf03df7de5b73d69842"},
+			expected: "id-redis-7.4\n",
+		},
+		{
+			doc:      "prefix",
+			filters:  []string{"image=dockersamples/*"},
+			expected: "id-result\nid-vote\n",
+		},
+		{
+			doc:      "prefix with tag",
+			filters:  []string{"image=redis:7*"},
+			expected: "id-redis-7.4\nid-redis-7.2\n",
+		},
+		{
+			doc:      "multiple filters",
+			filters:  []string{"image=redis:7.2", "image=dockersamples/examplevotingapp_vote"},
+			expected: "id-redis-7.2\nid-vote\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli.ResetOutputBuffers()
+			filter := cliopts.NewFilterOpt()
+			for _, f := range tc.filters {
+				assert.NilError(t, filter.Set(f))
+			}
+			err := runPS(context.Background(), cli, psOptions{
+				filter:     filter,
+				namespaces: []string{"foo"},
+				quiet:      true,
+			})
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected))
+
+			// the image filter is applied on the client, and not sent to the API
+			_, sent := taskFilters[imageFilter]
+			assert.Check(t, !sent)
+		})
+	}
+
+	t.Run("no match", func(t *testing.T) {
+		cli.ResetOutputBuffers()
+		filter := cliopts.NewFilterOpt()
+		assert.NilError(t, filter.Set("image=redis:6"))
+		err := runPS(context.Background(), cli, psOptions{
+			filter:     filter,
+			namespaces: []string{"foo"},
+			quiet:      true,
+		})
+		assert.Check(t, is.Error(err, "nothing found in stack: foo"))
+	})
+}
+
+func TestStackPsNegatedLabelFilter(t *testing.T) {
+	var (
+		taskFilters  client.Filters
+		serviceLists int
+	)
+	cli := test.NewFakeCli(&fakeClient{
+		taskListFunc: func(options client.TaskListOptions) (client.TaskListResult, error) {
+			taskFilters = options.Filters
+			return client.TaskListResult{
+				Items: []swarm.Task{
+					*builders.Task(builders.TaskID("id-frontend"), builders.TaskServiceID("service-frontend")),
+					*builders.Task(builders.TaskID("id-backend"), builders.TaskServiceID("service-backend")),
+					*builders.Task(builders.TaskID("id-unlabeled"), builders.TaskServiceID("service-unlabeled")),
+				},
+			}, nil
+		},
+		serviceListFunc: func(options client.ServiceListOptions) (client.ServiceListResult, error) {
+			serviceLists++
+			return client.ServiceListResult{
+				Items: []swarm.Service{
+					*builders.Service(builders.ServiceID("service-frontend"), builders.ServiceLabels(map[string]string{"tier": "frontend"})),
+					*builders.Service(builders.ServiceID("service-backend"), builders.ServiceLabels(map[string]string{"tier": "backend"})),
+					*builders.Service(builders.ServiceID("service-unlabeled")),
+				},
+			}, nil
+		},
+	})
+
+	filter := cliopts.NewFilterOpt()
+	assert.NilError(t, filter.Set("label!=tier=frontend"))
+	assert.NilError(t, filter.Set("desired-state=running"))
+	err := runPS(context.Background(), cli, psOptions{
+		filter:     filter,
+		namespaces: []string{"foo"},
+		quiet:      true,
+	})
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "id-backend\nid-unlabeled\n"))
+
+	// the negated filter is applied on the client, and not sent to the API
+	_, sent := taskFilters[negatedLabelFilter]
+	assert.Check(t, !sent)
+	assert.Check(t, taskFilters["desired-state"]["running"])
+
+	t.Run("label name only", func(t *testing.T) {
+		cli.ResetOutputBuffers()
+		filter := cliopts.NewFilterOpt()
+		assert.NilError(t, filter.Set("label!=tier"))
+		assert.NilError(t, filter.Set("label!=does-not-exist"))
+		err := runPS(context.Background(), cli, psOptions{
+			filter:     filter,
+			namespaces: []string{"foo"},
+			quiet:      true,
+		})
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(cli.OutBuffer().String(), "id-unlabeled\n"))
+	})
+
+	t.Run("label placeholder", func(t *testing.T) {
+		// The services that were listed to exclude tasks are reused for the
+		// labels in the output.
+		cli.ResetOutputBuffers()
+		serviceLists = 0
+		filter := cliopts.NewFilterOpt()
+		assert.NilError(t, filter.Set("label!=tier=frontend"))
+		err := runPS(context.Background(), cli, psOptions{
+			filter:     filter,
+			namespaces: []string{"foo"},
+			format:     `{{.ID}} {{.Label "tier"}}`,
+		})
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(cli.OutBuffer().String(), "id-backend backend\nid-unlabeled \n"))
+		assert.Check(t, is.Equal(serviceLists, 1))
+	})
+}
+
+func TestStackPsOnlyErrors(t *testing.T) {
+	tasks := []swarm.Task{
+		*builders.Task(builders.TaskID("id-running"), builders.WithStatus(builders.TaskState(swarm.TaskStateRunning))),
+		*builders.Task(builders.TaskID("id-failed"), builders.WithStatus(builders.TaskState(swarm.TaskStateFailed), builders.StatusErr("task: non-zero exit (1)"))),
+		*builders.Task(builders.TaskID("id-rejected"), builders.WithStatus(builders.TaskState(swarm.TaskStateRejected), builders.StatusErr("No such image: foo:latest"))),
+	}
+	testCases := []struct {
+		doc         string
+		args        []string
+		tasks       []swarm.Task
+		expectedOut string
+		expectedErr string
+	}{
+		{
+			doc:         "quiet",
+			args:        []string{"--only-errors", "--quiet", "foo"},
+			tasks:       tasks,
+			expectedOut: "id-failed\nid-rejected\n",
+		},
+		{
+			doc:         "format",
+			args:        []string{"--only-errors", "--format", "{{ .ID }} {{ .Error }}", "foo"},
+			tasks:       tasks,
+			expectedOut: "id-failed \"task: non-zero exit (1)\"\nid-rejected \"No such image: foo:latest\"\n",
+		},
+		{
+			doc:         "limit",
+			args:        []string{"--only-errors", "--limit", "1", "--quiet", "foo"},
+			tasks:       tasks,
+			expectedOut: "id-failed\n",
+			expectedErr: "showing 1 of 2 tasks\n",
+		},
+		{
+			doc:         "no failed tasks",
+			args:        []string{"--only-errors", "--quiet", "foo"},
+			tasks:       tasks[:1],
+			expectedErr: "no failed tasks in stack foo\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{
+				taskListFunc: func(client.TaskListOptions) (client.TaskListResult, error) {
+					return client.TaskListResult{Items: tc.tasks}, nil
+				},
+			})
+			cmd := newPsCommand(cli)
+			cmd.SetArgs(tc.args)
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expectedOut))
+			assert.Check(t, is.Equal(cli.ErrBuffer().String(), tc.expectedErr))
+		})
+	}
+}
+
+func TestStackPsCompletion(t *testing.T) {
+	services := client.ServiceListResult{Items: []swarm.Service{
+		*builders.Service(builders.ServiceID("id-1"), builders.ServiceLabels(map[string]string{"com.docker.stack.namespace": "foo"})),
+		*builders.Service(builders.ServiceID("id-2"), builders.ServiceLabels(map[string]string{"com.docker.stack.namespace": "bar"})),
+		*builders.Service(builders.ServiceID("id-3"), builders.ServiceLabels(map[string]string{"com.docker.stack.namespace": "foo"})),
+	}}
+	cmd := newPsCommand(test.NewFakeCli(&fakeClient{
+		serviceListFunc: func(client.ServiceListOptions) (client.ServiceListResult, error) {
+			return services, nil
+		},
+	}))
+
+	names, directive := cmd.ValidArgsFunction(cmd, nil, "")
+	assert.Check(t, is.DeepEqual(names, []string{"foo", "bar"}))
+	assert.Check(t, is.Equal(directive, cobra.ShellCompDirectiveNoFileComp))
+
+	// stacks that were already passed are not offered again
+	names, _ = cmd.ValidArgsFunction(cmd, []string{"foo"}, "")
+	assert.Check(t, is.DeepEqual(names, []string{"bar"}))
+
+	cmd = newPsCommand(test.NewFakeCli(&fakeClient{
+		serviceListFunc: func(client.ServiceListOptions) (client.ServiceListResult, error) {
+			return client.ServiceListResult{}, errors.New("cannot connect to the Docker daemon")
+		},
+	}))
+	names, directive = cmd.ValidArgsFunction(cmd, nil, "")
+	assert.Check(t, is.Len(names, 0))
+	assert.Check(t, is.Equal(directive, cobra.ShellCompDirectiveError))
 }
@@ -18,6 +18,7 @@
 type removeOptions struct {
 	namespaces []string
 	detach     bool
+	follow     bool
 }
 
 func newRemoveCommand(dockerCLI command.Cli) *cobra.Command {
@@ -41,6 +42,7 @@ func newRemoveCommand(dockerCLI command.Cli) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.BoolVarP(&opts.detach, "detach", "d", true, "Do not wait for stack removal")
+	flags.BoolVar(&opts.follow, "follow", false, "Print removal events reported by the daemon")
 	return cmd
 }
 
@@ -75,11 +77,23 @@ func runRemove(ctx context.Context, dockerCli command.Cli, opts removeOptions) e
 			continue
 		}
 
+		cli := dockerCli
+		var removal *removalEvents
+		if opts.follow {
+			// Removal events are printed while the objects are removed.
+			cli = withSyncOutput(dockerCli)
+			removal = followRemoval(ctx, cli, apiClient, services.Items, networks.Items, secrets.Items, configs.Items)
+		}
+
 		// TODO(thaJeztah): change this "hasError" boolean to return a (multi-)error for each of these functions instead.
-		hasError := removeServices(ctx, dockerCli, services.Items)
-		hasError = removeSecrets(ctx, dockerCli, secrets.Items) || hasError
-		hasError = removeConfigs(ctx, dockerCli, configs.Items) || hasError
-		hasError = removeNetworks(ctx, dockerCli, networks.Items) || hasError
+		hasError := removeServices(ctx, cli, services.Items)
+		hasError = removeSecrets(ctx, cli, secrets.Items) || hasError
+		hasError = removeConfigs(ctx, cli, configs.Items) || hasError
+		hasError = removeNetworks(ctx, cli, networks.Items) || hasError
+
+		if removal != nil {
+			removal.wait(cli, followRemoveTimeout)
+		}
 
 		if hasError {
 			errs = append(errs, errors.New("failed to remove some resources from stack: "+namespace))
@@ -0,0 +1,154 @@
+package stack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/streams"
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+)
+
+// followRemoveTimeout is the maximum time to wait for removal events after
+// all objects of a stack have been requested to be removed.
+var followRemoveTimeout = 10 * time.Second
+
+// removalEvents follows the event stream for the removal of the objects
+// of a stack, and prints the removal events as they are received, while the
+// objects are removed.
+type removalEvents struct {
+	res    client.EventsResult
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// pending holds the objects for which no removal event was received
+	// yet, indexed by ID. It's only accessed by the goroutine printing the
+	// events until done is closed.
+	pending map[string]string
+}
+
+// followRemoval subscribes to the event stream for removal events of the
+// given objects, and prints them to the output of dockerCLI as they are
+// received. The subscription starts before any object is removed so that no
+// events are missed. The output of dockerCLI must be safe for concurrent use
+// while the objects are removed; see [withSyncOutput].
+func followRemoval(ctx context.Context, dockerCLI command.Streams, apiClient client.APIClient, services []swarm.Service, networks []network.Summary, secrets []swarm.Secret, configs []swarm.Config) *removalEvents {
+	pending := make(map[string]string)
+	for _, s := range services {
+		pending[s.ID] = "service " + s.Spec.Name
+	}
+	for _, s := range secrets {
+		pending[s.ID] = "secret " + s.Spec.Name
+	}
+	for _, c := range configs {
+		pending[c.ID] = "config " + c.Spec.Name
+	}
+	for _, n := range networks {
+		pending[n.ID] = "network " + n.Name
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	res := apiClient.Events(ctx, client.EventsListOptions{
+		Since: strconv.FormatInt(time.Now().Unix(), 10),
+		Filters: make(client.Filters).
+			Add("type", string(events.ServiceEventType), string(events.NetworkEventType), string(events.SecretEventType), string(events.ConfigEventType)).
+			Add("event", string(events.ActionRemove), string(events.ActionDestroy)),
+	})
+	r := &removalEvents{res: res, cancel: cancel, done: make(chan struct{}), pending: pending}
+	go r.print(ctx, dockerCLI)
+	return r
+}
+
+// print prints removal events as they are received, until an event was
+// received for each object, the event stream is closed, or ctx is cancelled.
+// Errors on the event stream are printed as a warning, as following events
+// is best-effort and must not fail the removal.
+func (r *removalEvents) print(ctx context.Context, dockerCLI command.Streams) {
+	defer close(r.done)
+	for len(r.pending) > 0 {
+		select {
+		case msg, ok := <-r.res.Messages:
+			if !ok {
+				return
+			}
+			if name, found := r.pending[msg.Actor.ID]; found {
+				delete(r.pending, msg.Actor.ID)
+				_, _ = fmt.Fprintln(dockerCLI.Out(), "Removed", name)
+			}
+		case err := <-r.res.Err:
+			if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, context.Canceled) {
+				_, _ = fmt.Fprintln(dockerCLI.Err(), "Failed to follow stack removal events:", err)
+			}
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// wait waits until the removal events of all objects were printed, or the
+// event stream ends, for at most the given timeout. It's called after all
+// objects were requested to be removed, and stops following the events when
+// it returns.
+func (r *removalEvents) wait(dockerCLI command.Streams, timeout time.Duration) {
+	defer r.cancel()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-r.done:
+	case <-timer.C:
+		r.cancel()
+		<-r.done
+		if len(r.pending) > 0 {
+			_, _ = fmt.Fprintf(dockerCLI.Err(), "Timed out waiting for removal events of %d object(s)\n", len(r.pending))
+		}
+	}
+}
+
+// withSyncOutput returns a CLI of which the output and error streams can be
+// written to concurrently, so that removal events are printed while the
+// objects of a stack are removed.
+func withSyncOutput(dockerCLI command.Cli) command.Cli {
+	var mu sync.Mutex
+	return &syncOutputCli{
+		Cli: dockerCLI,
+		out: streams.NewOut(&syncWriter{mu: &mu, w: dockerCLI.Out()}),
+		err: streams.NewOut(&syncWriter{mu: &mu, w: dockerCLI.Err()}),
+	}
+}
+
+type syncOutputCli struct {
+	command.Cli
+	out, err *streams.Out
+}
+
+func (c *syncOutputCli) Out() *streams.Out {
+	return c.out
+}
+
+func (c *syncOutputCli) Err() *streams.Out {
+	return c.err
+}
+
+// syncWriter serializes writes to w with the writes of other writers that
+// share the same mutex.
+type syncWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
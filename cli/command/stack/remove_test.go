@@ -3,10 +3,13 @@
 import (
 	"errors"
 	"io"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/cli/internal/test"
+	"github.com/moby/moby/api/types/events"
 	"github.com/moby/moby/client"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
@@ -141,3 +144,110 @@ func TestRemoveContinueAfterError(t *testing.T) {
 	assert.Check(t, is.DeepEqual(allSecretIDs, apiClient.removedSecrets))
 	assert.Check(t, is.DeepEqual(allConfigIDs, apiClient.removedConfigs))
 }
+
+func TestRemoveStackFollow(t *testing.T) {
+	apiClient := &fakeClient{
+		services: []string{objectName("foo", "service1")},
+		networks: []string{objectName("foo", "network1")},
+		secrets:  []string{objectName("foo", "secret1")},
+		configs:  []string{objectName("foo", "config1")},
+	}
+
+	messages := make(chan events.Message)
+	sendEvent := func(ev events.Message) {
+		t.Helper()
+		select {
+		case messages <- ev:
+		case <-time.After(5 * time.Second):
+			t.Error("removal events are not received while objects are removed")
+		}
+	}
+	apiClient.serviceRemoveFunc = func(serviceID string) (client.ServiceRemoveResult, error) {
+		sendEvent(events.Message{Type: events.ServiceEventType, Action: events.ActionRemove, Actor: events.Actor{ID: serviceID}})
+		sendEvent(events.Message{Type: events.ServiceEventType, Action: events.ActionRemove, Actor: events.Actor{ID: "ID-unrelated"}})
+		return client.ServiceRemoveResult{}, nil
+	}
+	apiClient.secretRemoveFunc = func(secretID string) (client.SecretRemoveResult, error) {
+		sendEvent(events.Message{Type: events.SecretEventType, Action: events.ActionRemove, Actor: events.Actor{ID: secretID}})
+		return client.SecretRemoveResult{}, nil
+	}
+	apiClient.configRemoveFunc = func(configID string) (client.ConfigRemoveResult, error) {
+		sendEvent(events.Message{Type: events.ConfigEventType, Action: events.ActionRemove, Actor: events.Actor{ID: configID}})
+		return client.ConfigRemoveResult{}, nil
+	}
+	apiClient.networkRemoveFunc = func(networkID string) error {
+		sendEvent(events.Message{Type: events.NetworkEventType, Action: events.ActionRemove, Actor: events.Actor{ID: networkID}})
+		return nil
+	}
+	apiClient.eventsFunc = func(options client.EventsListOptions) client.EventsResult {
+		assert.Check(t, is.DeepEqual(options.Filters["type"], map[string]bool{"service": true, "network": true, "secret": true, "config": true}))
+		assert.Check(t, is.DeepEqual(options.Filters["event"], map[string]bool{"remove": true, "destroy": true}))
+		assert.Check(t, options.Since != "")
+		// the error channel is not closed; following must stop once
+		// an event was received for each object.
+		return client.EventsResult{Messages: messages, Err: make(chan error)}
+	}
+
+	fakeCli := test.NewFakeCli(apiClient)
+	cmd := newRemoveCommand(fakeCli)
+	cmd.SetArgs([]string{"--follow", "foo"})
+	assert.NilError(t, cmd.Execute())
+
+	// Removal events are printed as they are received, so they may be
+	// printed before or after the next object is requested to be removed.
+	lines := strings.Split(strings.TrimSuffix(fakeCli.OutBuffer().String(), "\n"), "\n")
+	sort.Strings(lines)
+	expected := []string{
+		"Removed config foo_config1",
+		"Removed network foo_network1",
+		"Removed secret foo_secret1",
+		"Removed service foo_service1",
+		"Removing config foo_config1",
+		"Removing network foo_network1",
+		"Removing secret foo_secret1",
+		"Removing service foo_service1",
+	}
+	assert.Check(t, is.DeepEqual(lines, expected))
+	assert.Check(t, is.Equal(fakeCli.ErrBuffer().String(), ""))
+}
+
+func TestRemoveStackFollowStreamClosed(t *testing.T) {
+	apiClient := &fakeClient{
+		services: []string{objectName("foo", "service1"), objectName("foo", "service2")},
+	}
+	apiClient.eventsFunc = func(client.EventsListOptions) client.EventsResult {
+		messages := make(chan events.Message)
+		errs := make(chan error, 1)
+		go func() {
+			messages <- events.Message{Type: events.ServiceEventType, Action: events.ActionRemove, Actor: events.Actor{ID: objectID(objectName("foo", "service1"))}}
+			errs <- io.EOF
+		}()
+		return client.EventsResult{Messages: messages, Err: errs}
+	}
+
+	fakeCli := test.NewFakeCli(apiClient)
+	cmd := newRemoveCommand(fakeCli)
+	cmd.SetArgs([]string{"--follow", "foo"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(fakeCli.OutBuffer().String(), "Removed service foo_service1\n"))
+	assert.Check(t, !strings.Contains(fakeCli.OutBuffer().String(), "Removed service foo_service2"))
+	assert.Check(t, is.Equal(fakeCli.ErrBuffer().String(), ""))
+}
+
+func TestRemoveStackFollowTimeout(t *testing.T) {
+	defer func(orig time.Duration) { followRemoveTimeout = orig }(followRemoveTimeout)
+	followRemoveTimeout = 10 * time.Millisecond
+
+	apiClient := &fakeClient{
+		services: []string{objectName("foo", "service1")},
+		eventsFunc: func(client.EventsListOptions) client.EventsResult {
+			return client.EventsResult{Messages: make(chan events.Message), Err: make(chan error)}
+		},
+	}
+
+	fakeCli := test.NewFakeCli(apiClient)
+	cmd := newRemoveCommand(fakeCli)
+	cmd.SetArgs([]string{"--follow", "foo"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(fakeCli.ErrBuffer().String(), "Timed out waiting for removal events of 1 object(s)\n"))
+}
@@ -3,6 +3,7 @@
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 
 	"github.com/docker/cli/cli"
@@ -12,6 +13,7 @@
 	flagsHelper "github.com/docker/cli/cli/flags"
 	cliopts "github.com/docker/cli/opts"
 	"github.com/fvbommel/sortorder"
+	"github.com/moby/moby/api/types/swarm"
 	"github.com/moby/moby/client"
 	"github.com/spf13/cobra"
 )
@@ -33,7 +35,7 @@ func newServicesCommand(dockerCLI command.Cli) *cobra.Command {
 		Args:  cli.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.namespace = args[0]
-			if err := validateStackName(opts.namespace); err != nil {
+			if err := ValidateNamespace(opts.namespace); err != nil {
 				return err
 			}
 			return runServices(cmd.Context(), dockerCLI, opts)
@@ -59,6 +61,11 @@ func runServices(ctx context.Context, dockerCLI command.Cli, opts serviceListOpt
 	if err != nil {
 		return err
 	}
+	if excluded := getNegatedLabels(opts.filter); len(excluded) > 0 {
+		res.Items = slices.DeleteFunc(res.Items, func(s swarm.Service) bool {
+			return hasAnyLabel(s.Spec.Labels, excluded)
+		})
+	}
 	return formatWrite(dockerCLI, res, opts)
 }
 
@@ -154,3 +154,24 @@ func TestStackServicesWithoutFormat(t *testing.T) {
 	assert.NilError(t, cmd.Execute())
 	golden.Assert(t, cli.OutBuffer().String(), "stack-services-without-format.golden")
 }
+
+func TestStackServicesNegatedLabelFilter(t *testing.T) {
+	var serviceFilters client.Filters
+	cli := test.NewFakeCli(&fakeClient{
+		serviceListFunc: func(options client.ServiceListOptions) (client.ServiceListResult, error) {
+			serviceFilters = options.Filters
+			return client.ServiceListResult{
+				Items: []swarm.Service{
+					*builders.Service(builders.ServiceID("id-frontend"), builders.ServiceLabels(map[string]string{"tier": "frontend"})),
+					*builders.Service(builders.ServiceID("id-backend"), builders.ServiceLabels(map[string]string{"tier": "backend"})),
+				},
+			}, nil
+		},
+	})
+	cmd := newServicesCommand(cli)
+	cmd.SetArgs([]string{"--quiet", "--filter", "label!=tier=frontend", "foo"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "id-backend\n"))
+	_, sent := serviceFilters[negatedLabelFilter]
+	assert.Check(t, !sent)
+}
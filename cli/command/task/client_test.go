@@ -10,6 +10,7 @@ type fakeClient struct {
 	client.APIClient
 	nodeInspectFunc    func(ref string) (client.NodeInspectResult, error)
 	serviceInspectFunc func(ref string, options client.ServiceInspectOptions) (client.ServiceInspectResult, error)
+	imageInspectFunc   func(image string) (client.ImageInspectResult, error)
 }
 
 func (cli *fakeClient) NodeInspect(_ context.Context, ref string, _ client.NodeInspectOptions) (client.NodeInspectResult, error) {
@@ -25,3 +26,10 @@ func (cli *fakeClient) ServiceInspect(_ context.Context, ref string, options cli
 	}
 	return client.ServiceInspectResult{}, nil
 }
+
+func (cli *fakeClient) ImageInspect(_ context.Context, image string, _ ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+	if cli.imageInspectFunc != nil {
+		return cli.imageInspectFunc(image)
+	}
+	return client.ImageInspectResult{}, nil
+}
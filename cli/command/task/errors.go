@@ -0,0 +1,121 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/formatter"
+	"github.com/moby/moby/client"
+)
+
+const (
+	defaultErrorGroupTableFormat = "table {{.Count}}\t{{.Error}}\t{{.Tasks}}"
+
+	countHeader = "COUNT"
+	tasksHeader = "TASKS"
+
+	// maxGroupTaskIDs is the number of representative task IDs to print for
+	// each group of errors when truncating output.
+	maxGroupTaskIDs = 3
+)
+
+// ErrorGroup is a group of tasks that failed with the same error message.
+type ErrorGroup struct {
+	Error   string
+	TaskIDs []string
+}
+
+// GroupErrors groups the given tasks by their error message. Tasks without
+// an error are omitted. Groups are sorted by the number of tasks (largest
+// first), then by error message.
+func GroupErrors(tasks client.TaskListResult) []ErrorGroup {
+	var groups []ErrorGroup
+	idx := make(map[string]int)
+	for _, t := range tasks.Items {
+		if t.Status.Err == "" {
+			continue
+		}
+		i, ok := idx[t.Status.Err]
+		if !ok {
+			i = len(groups)
+			idx[t.Status.Err] = i
+			groups = append(groups, ErrorGroup{Error: t.Status.Err})
+		}
+		groups[i].TaskIDs = append(groups[i].TaskIDs, t.ID)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].TaskIDs) != len(groups[j].TaskIDs) {
+			return len(groups[i].TaskIDs) > len(groups[j].TaskIDs)
+		}
+		return groups[i].Error < groups[j].Error
+	})
+	return groups
+}
+
+// PrintErrorGroups prints the tasks that have an error, grouped by their
+// error message, showing each message once with the number of tasks, and
+// a selection of the IDs of the tasks that failed with that error.
+func PrintErrorGroups(dockerCli command.Cli, tasks client.TaskListResult, trunc bool, format string) error {
+	if format == "" || format == formatter.TableFormatKey {
+		format = defaultErrorGroupTableFormat
+	}
+	groupsCtx := formatter.Context{
+		Output: dockerCli.Out(),
+		Format: formatter.Format(format),
+		Trunc:  trunc,
+	}
+	groups := GroupErrors(tasks)
+	errCtx := &errorGroupContext{
+		HeaderContext: formatter.HeaderContext{
+			Header: formatter.SubHeaderContext{
+				"Count": countHeader,
+				"Error": formatter.ErrorHeader,
+				"Tasks": tasksHeader,
+			},
+		},
+	}
+	return groupsCtx.Write(errCtx, func(format func(subContext formatter.SubContext) error) error {
+		for _, g := range groups {
+			if err := format(&errorGroupContext{trunc: trunc, group: g}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type errorGroupContext struct {
+	formatter.HeaderContext
+	trunc bool
+	group ErrorGroup
+}
+
+func (c *errorGroupContext) MarshalJSON() ([]byte, error) {
+	return formatter.MarshalJSON(c)
+}
+
+func (c *errorGroupContext) Count() string {
+	return strconv.Itoa(len(c.group.TaskIDs))
+}
+
+func (c *errorGroupContext) Error() string {
+	return fmt.Sprintf(`"%s"`, c.group.Error)
+}
+
+func (c *errorGroupContext) Tasks() string {
+	if !c.trunc {
+		return strings.Join(c.group.TaskIDs, ",")
+	}
+	ids := make([]string, 0, maxGroupTaskIDs+1)
+	for i, id := range c.group.TaskIDs {
+		if i == maxGroupTaskIDs {
+			ids = append(ids, "...")
+			break
+		}
+		ids = append(ids, formatter.TruncateID(id))
+	}
+	return strings.Join(ids, ",")
+}
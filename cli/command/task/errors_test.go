@@ -0,0 +1,73 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/docker/cli/cli/command/formatter"
+	"github.com/docker/cli/internal/test"
+	"github.com/docker/cli/internal/test/builders"
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/golden"
+)
+
+func errorTasks() client.TaskListResult {
+	return client.TaskListResult{
+		Items: []swarm.Task{
+			*builders.Task(builders.TaskID("id-ok")),
+			*builders.Task(builders.TaskID("id-oom"), builders.WithStatus(builders.StatusErr("OOM killed"))),
+			*builders.Task(builders.TaskID("id-exit-1"), builders.WithStatus(builders.StatusErr("task: non-zero exit (1)"))),
+			*builders.Task(builders.TaskID("id-exit-2"), builders.WithStatus(builders.StatusErr("task: non-zero exit (1)"))),
+			*builders.Task(builders.TaskID("id-exit-3"), builders.WithStatus(builders.StatusErr("task: non-zero exit (1)"))),
+			*builders.Task(builders.TaskID("id-exit-4"), builders.WithStatus(builders.StatusErr("task: non-zero exit (1)"))),
+		},
+	}
+}
+
+func TestGroupErrors(t *testing.T) {
+	groups := GroupErrors(errorTasks())
+	assert.Check(t, is.DeepEqual(groups, []ErrorGroup{
+		{Error: "task: non-zero exit (1)", TaskIDs: []string{"id-exit-1", "id-exit-2", "id-exit-3", "id-exit-4"}},
+		{Error: "OOM killed", TaskIDs: []string{"id-oom"}},
+	}))
+
+	assert.Check(t, is.Len(GroupErrors(client.TaskListResult{
+		Items: []swarm.Task{*builders.Task(builders.TaskID("id-ok"))},
+	}), 0))
+}
+
+func TestPrintErrorGroups(t *testing.T) {
+	testCases := []struct {
+		doc    string
+		trunc  bool
+		format string
+		golden string
+	}{
+		{
+			doc:    "table",
+			trunc:  true,
+			format: formatter.TableFormatKey,
+			golden: "task-print-error-groups.golden",
+		},
+		{
+			doc:    "no-trunc",
+			format: formatter.TableFormatKey,
+			golden: "task-print-error-groups-no-trunc.golden",
+		},
+		{
+			doc:    "custom format",
+			trunc:  true,
+			format: "{{.Count}}: {{.Error}}",
+			golden: "task-print-error-groups-format.golden",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			assert.NilError(t, PrintErrorGroups(cli, errorTasks(), tc.trunc, tc.format))
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+		})
+	}
+}
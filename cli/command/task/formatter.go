@@ -2,7 +2,10 @@
 
 import (
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/distribution/reference"
@@ -15,14 +18,71 @@
 const (
 	defaultTaskTableFormat = "table {{.ID}}\t{{.Name}}\t{{.Image}}\t{{.Node}}\t{{.DesiredState}}\t{{.CurrentState}}\t{{.Error}}\t{{.Ports}}"
 
-	nodeHeader         = "NODE"
-	taskIDHeader       = "ID"
-	desiredStateHeader = "DESIRED STATE"
-	currentStateHeader = "CURRENT STATE"
+	// TableFormat is the default table format, for commands that add
+	// columns to it.
+	TableFormat = defaultTaskTableFormat
+
+	// NamespaceTableFormat is the default table format, prefixed with the
+	// namespace of the stack each task is part of. It's used when printing
+	// the tasks of multiple stacks.
+	NamespaceTableFormat = "table {{.Namespace}}\t{{.ID}}\t{{.Name}}\t{{.Image}}\t{{.Node}}\t{{.DesiredState}}\t{{.CurrentState}}\t{{.Error}}\t{{.Ports}}"
+
+	// DigestsTableFormat is the default table format, with an additional
+	// column for the digest of the image.
+	DigestsTableFormat = defaultTaskTableFormat + "\t{{.ImageDigest}}"
+
+	// NamespaceDigestsTableFormat is [NamespaceTableFormat], with an
+	// additional column for the digest of the image.
+	NamespaceDigestsTableFormat = NamespaceTableFormat + "\t{{.ImageDigest}}"
+
+	// ImageTagsTableFormat is the default table format, with an additional
+	// column for the resolved tag of the image.
+	ImageTagsTableFormat = defaultTaskTableFormat + "\t{{.ImageTag}}"
+
+	// NamespaceImageTagsTableFormat is [NamespaceTableFormat], with an
+	// additional column for the resolved tag of the image.
+	NamespaceImageTagsTableFormat = NamespaceTableFormat + "\t{{.ImageTag}}"
+
+	// ResourcesTableFormat is the default table format, with additional
+	// columns for the CPU and memory reservations of the task.
+	ResourcesTableFormat = defaultTaskTableFormat + "\t{{.CPUReservation}}\t{{.MemoryReservation}}"
+
+	// NamespaceResourcesTableFormat is [NamespaceTableFormat], with
+	// additional columns for the CPU and memory reservations of the task.
+	NamespaceResourcesTableFormat = NamespaceTableFormat + "\t{{.CPUReservation}}\t{{.MemoryReservation}}"
+
+	namespaceHeader        = "NAMESPACE"
+	nodeHeader             = "NODE"
+	nodeStatusHeader       = "NODE STATUS"
+	nodeAvailabilityHeader = "NODE AVAILABILITY"
+	taskIDHeader           = "ID"
+	slotHeader             = "SLOT"
+	desiredStateHeader     = "DESIRED STATE"
+	currentStateHeader     = "CURRENT STATE"
+	shortStateHeader       = "STATE"
+	imageDigestHeader      = "DIGEST"
+	imageTagHeader         = "IMAGE TAG"
+	cpuReservationHeader   = "CPU RESERVATION"
+	memReservationHeader   = "MEM RESERVATION"
 
 	maxErrLength = 30
+
+	// stackNamespaceLabel is the label that holds the namespace of the stack
+	// a task is part of. It's the same as [convert.LabelNamespace], which is
+	// not used here to prevent an import cycle.
+	//
+	// [convert.LabelNamespace]: https://pkg.go.dev/github.com/docker/cli/cli/compose/convert#LabelNamespace
+	stackNamespaceLabel = "com.docker.stack.namespace"
 )
 
+// templateFuncs are the functions that can be used in the format of tasks, in
+// addition to the basic template functions. The "env" function returns the
+// value of an environment variable, or an empty string if it's not set. It
+// only looks up the variable, and does not evaluate shell expressions.
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+}
+
 // newTaskFormat returns a Format for rendering using a taskContext.
 func newTaskFormat(source string, quiet bool) formatter.Format {
 	switch source {
@@ -40,30 +100,57 @@ func newTaskFormat(source string, quiet bool) formatter.Format {
 	return formatter.Format(source)
 }
 
+// formatOptions holds the options for [formatWrite].
+type formatOptions struct {
+	// imageTags holds the resolved tag of each image, as returned by
+	// [ResolveImageTags].
+	imageTags map[string]string
+
+	// serviceLabels holds the labels of each service, indexed by service ID.
+	serviceLabels map[string]map[string]string
+
+	// truncLength is the number of characters of IDs and digests that are
+	// shown if the context truncates, or 0 to use the default length.
+	truncLength int
+
+	// absoluteTime is set to print the time of the current state as an
+	// absolute time, instead of relative to the current time.
+	absoluteTime bool
+}
+
 // formatWrite writes the context.
-func formatWrite(fmtCtx formatter.Context, tasks client.TaskListResult, names map[string]string, nodes map[string]string) error {
+//
+// The nodeInfo of the resolved tasks holds the resolved node for each task,
+// indexed by task ID. It's used for the NodeStatus and NodeAvailability
+// fields, which are empty for tasks for which the node was not resolved.
+func formatWrite(fmtCtx formatter.Context, tasks client.TaskListResult, resolved resolvedTasks, opts formatOptions) error {
 	taskCtx := &taskContext{
 		HeaderContext: formatter.HeaderContext{
 			Header: formatter.SubHeaderContext{
-				"ID":           taskIDHeader,
-				"Name":         formatter.NameHeader,
-				"Image":        formatter.ImageHeader,
-				"Node":         nodeHeader,
-				"DesiredState": desiredStateHeader,
-				"CurrentState": currentStateHeader,
-				"Error":        formatter.ErrorHeader,
-				"Ports":        formatter.PortsHeader,
+				"ID":                taskIDHeader,
+				"Name":              formatter.NameHeader,
+				"Namespace":         namespaceHeader,
+				"Slot":              slotHeader,
+				"Image":             formatter.ImageHeader,
+				"ImageDigest":       imageDigestHeader,
+				"ImageTag":          imageTagHeader,
+				"Node":              nodeHeader,
+				"NodeStatus":        nodeStatusHeader,
+				"NodeAvailability":  nodeAvailabilityHeader,
+				"DesiredState":      desiredStateHeader,
+				"CurrentState":      currentStateHeader,
+				"ShortState":        shortStateHeader,
+				"Error":             formatter.ErrorHeader,
+				"Ports":             formatter.PortsHeader,
+				"PortList":          formatter.PortsHeader,
+				"CPUReservation":    cpuReservationHeader,
+				"MemoryReservation": memReservationHeader,
 			},
 		},
 	}
 	return fmtCtx.Write(taskCtx, func(format func(subContext formatter.SubContext) error) error {
 		for _, task := range tasks.Items {
-			if err := format(&taskContext{
-				trunc: fmtCtx.Trunc,
-				task:  task,
-				name:  names[task.ID],
-				node:  nodes[task.ID],
-			}); err != nil {
+			if err := format(newTaskContext(task, resolved, fmtCtx.Trunc, opts)); err != nil {
 				return err
 			}
 		}
@@ -71,12 +158,46 @@ func formatWrite(fmtCtx formatter.Context, tasks client.TaskListResult, names ma
 	})
 }
 
+// newTaskContext returns the context to format the given task with.
+func newTaskContext(task swarm.Task, resolved resolvedTasks, trunc bool, opts formatOptions) *taskContext {
+	n, nodeResolved := resolved.nodeInfo[task.ID]
+	return &taskContext{
+		trunc:         trunc,
+		truncLength:   opts.truncLength,
+		absoluteTime:  opts.absoluteTime,
+		task:          task,
+		name:          resolved.names[task.ID],
+		node:          resolved.nodes[task.ID],
+		nodeInfo:      n,
+		nodeResolved:  nodeResolved,
+		imageTags:     opts.imageTags,
+		serviceLabels: opts.serviceLabels,
+	}
+}
+
 type taskContext struct {
 	formatter.HeaderContext
 	trunc bool
 	task  swarm.Task
 	name  string
 	node  string
+
+	// truncLength is the number of characters of IDs and digests that are
+	// shown if trunc is set, or 0 to use the default length.
+	truncLength int
+
+	// absoluteTime is set to print the time of the current state as an
+	// absolute time, instead of relative to the current time.
+	absoluteTime bool
+
+	nodeInfo     swarm.Node
+	nodeResolved bool
+
+	imageTags map[string]string
+
+	// serviceLabels holds the labels of the services of tasks, indexed by
+	// service ID.
+	serviceLabels map[string]map[string]string
 }
 
 func (c *taskContext) MarshalJSON() ([]byte, error) {
@@ -85,7 +206,7 @@ func (c *taskContext) MarshalJSON() ([]byte, error) {
 
 func (c *taskContext) ID() string {
 	if c.trunc {
-		return formatter.TruncateID(c.task.ID)
+		return truncateID(c.task.ID, c.truncLength)
 	}
 	return c.task.ID
 }
@@ -94,6 +215,27 @@ func (c *taskContext) Name() string {
 	return c.name
 }
 
+// Namespace returns the namespace of the stack the task is part of, as set
+// through the stack's namespace label, or an empty string if the task is not
+// part of a stack. Unlike splitting the task name on the "_" separator, this
+// is reliable for namespaces and service names that contain underscores.
+func (c *taskContext) Namespace() string {
+	if c.task.Spec.ContainerSpec == nil {
+		return ""
+	}
+	return c.task.Spec.ContainerSpec.Labels[stackNamespaceLabel]
+}
+
+// Slot returns the slot of the task, which is the replica index of tasks of
+// a replicated service, or an empty string for tasks that have no slot, such
+// as the tasks of a global service.
+func (c *taskContext) Slot() string {
+	if c.task.Slot == 0 {
+		return ""
+	}
+	return strconv.Itoa(c.task.Slot)
+}
+
 func (c *taskContext) Image() string {
 	image := c.task.Spec.ContainerSpec.Image
 	if c.trunc {
@@ -110,33 +252,193 @@ func (c *taskContext) Image() string {
 	return image
 }
 
+// ImageDigest returns the digest of the task's image, for example
+// "sha256:4cfb2d5b...", or an empty string if the image reference has no
+// digest. If trunc is set, the digest is truncated like IDs are, keeping the
+// algorithm prefix.
+func (c *taskContext) ImageDigest() string {
+	if c.task.Spec.ContainerSpec == nil {
+		return ""
+	}
+	ref, err := reference.ParseNormalizedNamed(c.task.Spec.ContainerSpec.Image)
+	if err != nil {
+		return ""
+	}
+	digested, ok := ref.(reference.Digested)
+	if !ok {
+		return ""
+	}
+	if c.trunc {
+		dgst := digested.Digest()
+		return dgst.Algorithm().String() + ":" + truncateID(dgst.Encoded(), c.truncLength)
+	}
+	return digested.Digest().String()
+}
+
+// ImageTag returns the resolved tag of the task's image, for example
+// "nginx:1.25", or an empty string if the tag was not resolved.
+func (c *taskContext) ImageTag() string {
+	if c.task.Spec.ContainerSpec == nil {
+		return ""
+	}
+	return c.imageTags[c.task.Spec.ContainerSpec.Image]
+}
+
+// Label returns the value of the label with the given name of the task's
+// service, or an empty string if the service has no such label, or if its
+// labels were not looked up.
+func (c *taskContext) Label(name string) string {
+	return c.serviceLabels[c.task.ServiceID][name]
+}
+
+// Node returns the name of the node the task is assigned to, or its ID if
+// the node was not resolved, in which case the ID is truncated if trunc is
+// set.
 func (c *taskContext) Node() string {
+	if c.trunc && c.node == c.task.NodeID {
+		return truncateID(c.node, c.truncLength)
+	}
 	return c.node
 }
 
+// NodeStatus returns the status of the node the task is assigned to, or an
+// empty string if the node was not resolved.
+func (c *taskContext) NodeStatus() string {
+	if !c.nodeResolved {
+		return ""
+	}
+	return string(c.nodeInfo.Status.State)
+}
+
+// NodeAvailability returns the availability of the node the task is assigned
+// to, or an empty string if the node was not resolved.
+func (c *taskContext) NodeAvailability() string {
+	if !c.nodeResolved {
+		return ""
+	}
+	return string(c.nodeInfo.Spec.Availability)
+}
+
 func (c *taskContext) DesiredState() string {
 	return formatter.PrettyPrint(c.task.DesiredState)
 }
 
+// CurrentState returns the current state of the task, and when the task
+// entered it, for example "Running 2 hours ago", or "Running since
+// 2024-05-01T10:00:00Z" if absoluteTime is set. Only the state is returned if
+// the time is not known.
 func (c *taskContext) CurrentState() string {
-	return fmt.Sprintf("%s %s ago",
-		formatter.PrettyPrint(c.task.Status.State),
-		strings.ToLower(units.HumanDuration(time.Since(c.task.Status.Timestamp))),
-	)
+	state := formatter.PrettyPrint(c.task.Status.State)
+	timestamp := c.task.Status.Timestamp
+	switch {
+	case timestamp.IsZero():
+		return state
+	case c.absoluteTime:
+		return state + " since " + timestamp.Local().Format(time.RFC3339)
+	default:
+		return state + " " + strings.ToLower(formatter.HumanTimeAgo(timestamp))
+	}
+}
+
+// shortStates are the abbreviations of task states, as printed by
+// [taskContext.ShortState].
+var shortStates = map[swarm.TaskState]string{
+	swarm.TaskStateNew:       "New",
+	swarm.TaskStateAllocated: "Alloc",
+	swarm.TaskStatePending:   "Pend",
+	swarm.TaskStateAssigned:  "Asgn",
+	swarm.TaskStateAccepted:  "Acpt",
+	swarm.TaskStatePreparing: "Prep",
+	swarm.TaskStateReady:     "Ready",
+	swarm.TaskStateStarting:  "Start",
+	swarm.TaskStateRunning:   "Run",
+	swarm.TaskStateComplete:  "Done",
+	swarm.TaskStateShutdown:  "Shut",
+	swarm.TaskStateFailed:    "Fail",
+	swarm.TaskStateRejected:  "Rej",
+	swarm.TaskStateRemove:    "Rm",
+	swarm.TaskStateOrphaned:  "Orph",
+}
+
+// ShortState returns an abbreviation of the current state of the task, for
+// example "Run" or "Fail", without the time the task entered it. Unknown
+// states are returned as with [taskContext.CurrentState].
+func (c *taskContext) ShortState() string {
+	if s, ok := shortStates[c.task.Status.State]; ok {
+		return s
+	}
+	return formatter.PrettyPrint(c.task.Status.State)
 }
 
 func (c *taskContext) Error() string {
 	// Trim and quote the error message.
-	taskErr := c.task.Status.Err
-	if c.trunc {
-		taskErr = formatter.Ellipsis(taskErr, maxErrLength)
-	}
+	taskErr := c.rawError()
 	if len(taskErr) > 0 {
 		taskErr = fmt.Sprintf(`"%s"`, taskErr)
 	}
 	return taskErr
 }
 
+// rawError returns the error message of the task, truncated if trunc is set.
+// Unlike [taskContext.Error], the message is not quoted.
+func (c *taskContext) rawError() string {
+	if c.trunc {
+		return formatter.Ellipsis(c.task.Status.Err, maxErrLength)
+	}
+	return c.task.Status.Err
+}
+
+// truncateID truncates id to length characters, or to the default length of
+// [formatter.TruncateID] if length is 0 or less.
+func truncateID(id string, length int) string {
+	if length <= 0 {
+		return formatter.TruncateID(id)
+	}
+	if len(id) > length {
+		return id[:length]
+	}
+	return id
+}
+
+// CPUReservation returns the number of CPUs reserved for the task, for
+// example "0.5", or an empty string if no CPUs are reserved.
+func (c *taskContext) CPUReservation() string {
+	reservations := c.reservations()
+	if reservations == nil || reservations.NanoCPUs == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(reservations.NanoCPUs)/1e9, 'f', -1, 64)
+}
+
+// MemoryReservation returns the memory reserved for the task, for example
+// "512MiB", or an empty string if no memory is reserved.
+func (c *taskContext) MemoryReservation() string {
+	reservations := c.reservations()
+	if reservations == nil || reservations.MemoryBytes == 0 {
+		return ""
+	}
+	return units.BytesSize(float64(reservations.MemoryBytes))
+}
+
+// reservations returns the resources reserved for the task, or nil if the
+// task has no resource spec.
+func (c *taskContext) reservations() *swarm.Resources {
+	if c.task.Spec.Resources == nil {
+		return nil
+	}
+	return c.task.Spec.Resources.Reservations
+}
+
+// PortList returns the published ports of the task, so that they can be
+// iterated over in a format, for example "{{range .PortList}}". It returns
+// an empty list for tasks without published ports.
+func (c *taskContext) PortList() []swarm.PortConfig {
+	if len(c.task.Status.PortStatus.Ports) == 0 {
+		return []swarm.PortConfig{}
+	}
+	return c.task.Status.PortStatus.Ports
+}
+
 func (c *taskContext) Ports() string {
 	if len(c.task.Status.PortStatus.Ports) == 0 {
 		return ""
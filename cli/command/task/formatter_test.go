@@ -5,6 +5,7 @@
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/cli/cli/command/formatter"
 	"github.com/moby/moby/api/types/swarm"
@@ -42,6 +43,13 @@ func TestTaskContextWrite(t *testing.T) {
 			`NAME
 foobar_baz
 foobar_bar
+`,
+		},
+		{
+			formatter.Context{Format: newTaskFormat("table {{.Name | upper}}\t{{truncate .Node 2}}", false)},
+			`NAME         NODE
+FOOBAR_BAZ   fo
+FOOBAR_BAR   fo
 `,
 		},
 		{
@@ -54,13 +62,25 @@ func TestTaskContextWrite(t *testing.T) {
 			formatter.Context{Format: newTaskFormat("{{.Name}} {{.Node}}", false)},
 			`foobar_baz foo1
 foobar_bar foo2
+`,
+		},
+		{
+			formatter.Context{Format: newTaskFormat("table {{.Name}}\t{{.Node}}\t{{.NodeStatus}}\t{{.NodeAvailability}}", false)},
+			string(golden.Get(t, "task-context-write-table-node-status.golden")),
+		},
+		{
+			formatter.Context{Format: newTaskFormat("table {{.Name}}\t{{.Slot}}", false)},
+			`NAME         SLOT
+foobar_baz   1
+foobar_bar   
 `,
 		},
 	}
 
+	// taskID2 has no slot, like the tasks of a global service
 	tasks := client.TaskListResult{
 		Items: []swarm.Task{
-			{ID: "taskID1"},
+			{ID: "taskID1", Slot: 1},
 			{ID: "taskID2"},
 		},
 	}
@@ -72,13 +92,20 @@ func TestTaskContextWrite(t *testing.T) {
 		"taskID1": "foo1",
 		"taskID2": "foo2",
 	}
+	// the node for taskID2 is not resolved
+	nodeInfo := map[string]swarm.Node{
+		"taskID1": {
+			Spec:   swarm.NodeSpec{Availability: swarm.NodeAvailabilityDrain},
+			Status: swarm.NodeStatus{State: swarm.NodeStateDown},
+		},
+	}
 
 	for _, tc := range cases {
 		t.Run(string(tc.context.Format), func(t *testing.T) {
 			var out bytes.Buffer
 			tc.context.Output = &out
 
-			if err := formatWrite(tc.context, tasks, names, nodes); err != nil {
+			if err := formatWrite(tc.context, tasks, resolvedTasks{names: names, nodes: nodes, nodeInfo: nodeInfo}, formatOptions{}); err != nil {
 				assert.Error(t, err, tc.expected)
 			} else {
 				assert.Equal(t, out.String(), tc.expected)
@@ -87,6 +114,266 @@ func TestTaskContextWrite(t *testing.T) {
 	}
 }
 
+func TestTaskContextWriteNamespace(t *testing.T) {
+	tasks := client.TaskListResult{
+		Items: []swarm.Task{
+			{
+				ID: "taskID1",
+				Spec: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{
+					Labels: map[string]string{"com.docker.stack.namespace": "my_stack"},
+				}},
+			},
+			{
+				ID:   "taskID2",
+				Spec: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{}},
+			},
+			{ID: "taskID3"},
+		},
+	}
+	names := map[string]string{
+		"taskID1": "my_stack_web_frontend.1",
+		"taskID2": "standalone.1",
+		"taskID3": "other.1",
+	}
+	out := bytes.NewBufferString("")
+	err := formatWrite(formatter.Context{Format: newTaskFormat("table {{.Name}}\t{{.Namespace}}", false), Output: out}, tasks, resolvedTasks{names: names}, formatOptions{})
+	assert.NilError(t, err)
+	golden.Assert(t, out.String(), "task-context-write-table-namespace.golden")
+}
+
+func TestTaskContextImageDigest(t *testing.T) {
+	const digest = "sha256:4cfb2d5b6b8a8a3a2a1f2ce0d4b3bb6d4e6bbf6e4ad0b8e9b51f7e7b0c6b8fd5"
+	tests := []struct {
+		doc         string
+		image       string
+		trunc       bool
+		truncLength int
+		expected    string
+	}{
+		{
+			doc:      "tag and digest",
+			image:    "myimage:mytag@" + digest,
+			expected: digest,
+		},
+		{
+			doc:      "tag and digest, truncated",
+			image:    "myimage:mytag@" + digest,
+			trunc:    true,
+			expected: "sha256:4cfb2d5b6b8a",
+		},
+		{
+			doc:         "tag and digest, truncated to length",
+			image:       "myimage:mytag@" + digest,
+			trunc:       true,
+			truncLength: 6,
+			expected:    "sha256:4cfb2d",
+		},
+		{
+			doc:         "tag and digest, not truncated with length",
+			image:       "myimage:mytag@" + digest,
+			truncLength: 6,
+			expected:    digest,
+		},
+		{
+			doc:      "digest only",
+			image:    "registry.example.com/myimage@" + digest,
+			expected: digest,
+		},
+		{
+			doc:   "tag only",
+			image: "myimage:mytag",
+			trunc: true,
+		},
+		{
+			doc:   "invalid reference",
+			image: "MyImage@" + digest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			ctx := &taskContext{
+				trunc:       tc.trunc,
+				truncLength: tc.truncLength,
+				task:        swarm.Task{Spec: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: tc.image}}},
+			}
+			assert.Check(t, is.Equal(ctx.ImageDigest(), tc.expected))
+		})
+	}
+
+	t.Run("no container spec", func(t *testing.T) {
+		assert.Check(t, is.Equal((&taskContext{}).ImageDigest(), ""))
+	})
+}
+
+func TestTaskContextReservations(t *testing.T) {
+	tests := []struct {
+		doc         string
+		resources   *swarm.ResourceRequirements
+		expectedCPU string
+		expectedMem string
+	}{
+		{
+			doc: "no resource spec",
+		},
+		{
+			doc:       "no reservations",
+			resources: &swarm.ResourceRequirements{Limits: &swarm.Limit{NanoCPUs: 1e9}},
+		},
+		{
+			doc:         "CPU and memory",
+			resources:   &swarm.ResourceRequirements{Reservations: &swarm.Resources{NanoCPUs: 5e8, MemoryBytes: 512 * 1024 * 1024}},
+			expectedCPU: "0.5",
+			expectedMem: "512MiB",
+		},
+		{
+			doc:         "CPU only",
+			resources:   &swarm.ResourceRequirements{Reservations: &swarm.Resources{NanoCPUs: 2e9}},
+			expectedCPU: "2",
+		},
+		{
+			doc:         "memory only",
+			resources:   &swarm.ResourceRequirements{Reservations: &swarm.Resources{MemoryBytes: 1536 * 1024 * 1024}},
+			expectedMem: "1.5GiB",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			ctx := &taskContext{task: swarm.Task{Spec: swarm.TaskSpec{Resources: tc.resources}}}
+			assert.Check(t, is.Equal(ctx.CPUReservation(), tc.expectedCPU))
+			assert.Check(t, is.Equal(ctx.MemoryReservation(), tc.expectedMem))
+		})
+	}
+}
+
+func TestTaskContextNode(t *testing.T) {
+	const nodeID = "q3bjb8hvplqjqzw2dbhgn8f7a"
+	tests := []struct {
+		doc         string
+		node        string
+		trunc       bool
+		truncLength int
+		expected    string
+	}{
+		{
+			doc:      "resolved",
+			node:     "node-name-bar",
+			trunc:    true,
+			expected: "node-name-bar",
+		},
+		{
+			doc:      "not resolved",
+			node:     nodeID,
+			expected: nodeID,
+		},
+		{
+			doc:      "not resolved, truncated",
+			node:     nodeID,
+			trunc:    true,
+			expected: "q3bjb8hvplqj",
+		},
+		{
+			doc:         "not resolved, truncated to length",
+			node:        nodeID,
+			trunc:       true,
+			truncLength: 30,
+			expected:    nodeID,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			ctx := &taskContext{
+				trunc:       tc.trunc,
+				truncLength: tc.truncLength,
+				task:        swarm.Task{NodeID: nodeID},
+				node:        tc.node,
+			}
+			assert.Check(t, is.Equal(ctx.Node(), tc.expected))
+		})
+	}
+}
+
+func TestTaskContextCurrentState(t *testing.T) {
+	timestamp := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	tests := []struct {
+		doc          string
+		timestamp    time.Time
+		absoluteTime bool
+		expected     string
+	}{
+		{doc: "relative", timestamp: timestamp, expected: "Running 2 hours ago"},
+		{doc: "absolute", timestamp: timestamp, absoluteTime: true, expected: "Running since " + timestamp.Local().Format(time.RFC3339)},
+		{doc: "future", timestamp: time.Now().Add(2*time.Hour + time.Minute), expected: "Running 2 hours from now"},
+		{doc: "zero time", expected: "Running"},
+		{doc: "zero time absolute", absoluteTime: true, expected: "Running"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			c := &taskContext{
+				absoluteTime: tc.absoluteTime,
+				task: swarm.Task{Status: swarm.TaskStatus{
+					State:     swarm.TaskStateRunning,
+					Timestamp: tc.timestamp,
+				}},
+			}
+			assert.Check(t, is.Equal(c.CurrentState(), tc.expected))
+		})
+	}
+}
+
+func TestTaskContextWritePortList(t *testing.T) {
+	tasks := client.TaskListResult{
+		Items: []swarm.Task{
+			{
+				ID: "taskID1",
+				Status: swarm.TaskStatus{
+					PortStatus: swarm.PortStatus{
+						Ports: []swarm.PortConfig{
+							{PublishedPort: 8080, TargetPort: 80, Protocol: "tcp"},
+							{PublishedPort: 5353, TargetPort: 53, Protocol: "udp"},
+						},
+					},
+				},
+			},
+			{ID: "taskID2"},
+		},
+	}
+	names := map[string]string{
+		"taskID1": "foobar_baz.1",
+		"taskID2": "foobar_bar.1",
+	}
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "{{.Name}}: {{.Ports}}",
+			expected: `foobar_baz.1: *:8080->80/tcp,*:5353->53/udp
+foobar_bar.1: 
+`,
+		},
+		{
+			format: "{{.Name}}:{{range .PortList}} {{.PublishedPort}}/{{.Protocol}}{{end}}",
+			expected: `foobar_baz.1: 8080/tcp 5353/udp
+foobar_bar.1:
+`,
+		},
+		{
+			format: "{{.Name}}: {{len .PortList}}",
+			expected: `foobar_baz.1: 2
+foobar_bar.1: 0
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			out := bytes.NewBufferString("")
+			err := formatWrite(formatter.Context{Format: newTaskFormat(tc.format, false), Output: out}, tasks, resolvedTasks{names: names}, formatOptions{})
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(out.String(), tc.expected))
+		})
+	}
+}
+
 func TestTaskContextWriteJSONField(t *testing.T) {
 	tasks := client.TaskListResult{
 		Items: []swarm.Task{
@@ -99,7 +386,7 @@ func TestTaskContextWriteJSONField(t *testing.T) {
 		"taskID2": "foobar_bar",
 	}
 	out := bytes.NewBufferString("")
-	err := formatWrite(formatter.Context{Format: "{{json .ID}}", Output: out}, tasks, names, map[string]string{})
+	err := formatWrite(formatter.Context{Format: "{{json .ID}}", Output: out}, tasks, resolvedTasks{names: names}, formatOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -111,3 +398,97 @@ func TestTaskContextWriteJSONField(t *testing.T) {
 		assert.Check(t, is.Equal(tasks.Items[i].ID, s))
 	}
 }
+
+// TestTaskContextWriteJSON verifies the fields that are printed for each task
+// with the "{{json .}}" template. These fields are used by scripts, and must
+// not be renamed or removed.
+func TestTaskContextWriteJSON(t *testing.T) {
+	tasks := client.TaskListResult{
+		Items: []swarm.Task{
+			{
+				ID:           "taskID1",
+				NodeID:       "nodeID1",
+				Slot:         1,
+				DesiredState: swarm.TaskStateRunning,
+				Spec: swarm.TaskSpec{
+					ContainerSpec: &swarm.ContainerSpec{
+						Image:  "myimage:mytag@sha256:4cfb2d5b6b8a8a3a2a1f2ce0d4b3bb6d4e6bbf6e4ad0b8e9b51f7e7b0c6b8fd5",
+						Labels: map[string]string{stackNamespaceLabel: "foobar"},
+					},
+				},
+				Status: swarm.TaskStatus{
+					State:     swarm.TaskStateRunning,
+					Timestamp: time.Now().Add(-2 * time.Hour),
+					PortStatus: swarm.PortStatus{
+						Ports: []swarm.PortConfig{{PublishedPort: 8080, TargetPort: 80, Protocol: "tcp"}},
+					},
+				},
+			},
+			{
+				ID:           "taskID2",
+				NodeID:       "nodeID2",
+				DesiredState: swarm.TaskStateShutdown,
+				Spec: swarm.TaskSpec{
+					ContainerSpec: &swarm.ContainerSpec{Image: "myimage:mytag"},
+				},
+				Status: swarm.TaskStatus{
+					State:     swarm.TaskStateFailed,
+					Timestamp: time.Now().Add(-2 * time.Hour),
+					Err:       "task: non-zero exit (1)",
+				},
+			},
+		},
+	}
+	names := map[string]string{
+		"taskID1": "foobar_baz.1",
+		"taskID2": "foobar_bar.1",
+	}
+	nodes := map[string]string{
+		"taskID1": "node1",
+		"taskID2": "nodeID2",
+	}
+	nodeInfo := map[string]swarm.Node{
+		"taskID1": {
+			Spec:   swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive},
+			Status: swarm.NodeStatus{State: swarm.NodeStateReady},
+		},
+	}
+	imageTags := map[string]string{
+		"myimage:mytag": "myimage:mytag",
+	}
+	out := bytes.NewBufferString("")
+	err := formatWrite(formatter.Context{Format: "{{json .}}", Output: out, Trunc: true}, tasks, resolvedTasks{names: names, nodes: nodes, nodeInfo: nodeInfo}, formatOptions{imageTags: imageTags})
+	assert.NilError(t, err)
+	golden.Assert(t, out.String(), "task-context-write-json.golden")
+}
+
+func TestTaskContextShortState(t *testing.T) {
+	testCases := []struct {
+		state    swarm.TaskState
+		expected string
+	}{
+		{state: swarm.TaskStateRunning, expected: "Run"},
+		{state: swarm.TaskStateFailed, expected: "Fail"},
+		{state: swarm.TaskStateShutdown, expected: "Shut"},
+		{state: swarm.TaskStateComplete, expected: "Done"},
+		{state: "unknown", expected: "Unknown"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.state), func(t *testing.T) {
+			ctx := taskContext{task: swarm.Task{Status: swarm.TaskStatus{State: tc.state}}}
+			assert.Check(t, is.Equal(ctx.ShortState(), tc.expected))
+		})
+	}
+
+	t.Run("all states", func(t *testing.T) {
+		for _, state := range []swarm.TaskState{
+			swarm.TaskStateNew, swarm.TaskStateAllocated, swarm.TaskStatePending, swarm.TaskStateAssigned,
+			swarm.TaskStateAccepted, swarm.TaskStatePreparing, swarm.TaskStateReady, swarm.TaskStateStarting,
+			swarm.TaskStateRunning, swarm.TaskStateComplete, swarm.TaskStateShutdown, swarm.TaskStateFailed,
+			swarm.TaskStateRejected, swarm.TaskStateRemove, swarm.TaskStateOrphaned,
+		} {
+			_, ok := shortStates[state]
+			assert.Check(t, ok, "no abbreviation for state %q", state)
+		}
+	})
+}
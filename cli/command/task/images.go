@@ -0,0 +1,70 @@
+package task
+
+import (
+	"context"
+	"sort"
+
+	"github.com/distribution/reference"
+	"github.com/moby/moby/client"
+)
+
+// ResolveImageTags returns a human-friendly tag for the images of the given
+// tasks, indexed by the image reference in the task spec. Tagged references
+// use their own tag. For references pinned by digest only, the tag is looked
+// up in the local image store, and an image is omitted if it is not present
+// locally, or has no tag for the same repository.
+func ResolveImageTags(ctx context.Context, apiClient client.ImageAPIClient, tasks client.TaskListResult) map[string]string {
+	tags := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, t := range tasks.Items {
+		if t.Spec.ContainerSpec == nil {
+			continue
+		}
+		image := t.Spec.ContainerSpec.Image
+		if seen[image] {
+			continue
+		}
+		seen[image] = true
+		if tag := resolveImageTag(ctx, apiClient, image); tag != "" {
+			tags[image] = tag
+		}
+	}
+	return tags
+}
+
+// resolveImageTag returns a familiar "name:tag" reference for image, or an
+// empty string if it cannot be resolved.
+func resolveImageTag(ctx context.Context, apiClient client.ImageAPIClient, image string) string {
+	ref, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	if tagged, ok := ref.(reference.NamedTagged); ok {
+		if namedTagged, err := reference.WithTag(reference.TrimNamed(tagged), tagged.Tag()); err == nil {
+			return reference.FamiliarString(namedTagged)
+		}
+		return ""
+	}
+	if _, ok := ref.(reference.Digested); !ok {
+		return ""
+	}
+	res, err := apiClient.ImageInspect(ctx, image)
+	if err != nil {
+		return ""
+	}
+	var candidates []string
+	for _, repoTag := range res.RepoTags {
+		tagged, err := reference.ParseNormalizedNamed(repoTag)
+		if err != nil || tagged.Name() != ref.Name() {
+			continue
+		}
+		if _, ok := tagged.(reference.NamedTagged); ok {
+			candidates = append(candidates, reference.FamiliarString(tagged))
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Strings(candidates)
+	return candidates[0]
+}
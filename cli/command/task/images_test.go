@@ -0,0 +1,55 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containerd/errdefs"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestResolveImageTags(t *testing.T) {
+	const (
+		pinned   = "nginx@sha256:4cfb2d5b2bcd6bcf0a3b8aafbbd2a3aa3e9d2a6ae1e8a8b0b16f8c1e1c3f4b5a"
+		tagged   = "redis:7-alpine@sha256:9e2b2a3aa3e9d2a6ae1e8a8b0b16f8c1e1c3f4b5a4cfb2d5b2bcd6bcf0a3b8aa"
+		missing  = "postgres@sha256:aa3e9d2a6ae1e8a8b0b16f8c1e1c3f4b5a4cfb2d5b2bcd6bcf0a3b8aafbbd2a3"
+		otherRep = "busybox@sha256:b16f8c1e1c3f4b5a4cfb2d5b2bcd6bcf0a3b8aafbbd2a3aa3e9d2a6ae1e8a8b0"
+		untagged = "alpine"
+	)
+	var inspected []string
+	apiClient := &fakeClient{
+		imageInspectFunc: func(img string) (client.ImageInspectResult, error) {
+			inspected = append(inspected, img)
+			switch img {
+			case pinned:
+				return client.ImageInspectResult{InspectResponse: image.InspectResponse{
+					RepoTags: []string{"nginx:latest", "nginx:1.25", "localhost:5000/nginx:1.25"},
+				}}, nil
+			case otherRep:
+				return client.ImageInspectResult{InspectResponse: image.InspectResponse{
+					RepoTags: []string{"mybusybox:latest"},
+				}}, nil
+			}
+			return client.ImageInspectResult{}, errdefs.ErrNotFound.WithMessage("no such image: " + img)
+		},
+	}
+	var tasks client.TaskListResult
+	for _, img := range []string{pinned, pinned, tagged, missing, otherRep, untagged} {
+		tasks.Items = append(tasks.Items, swarm.Task{
+			Spec: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: img}},
+		})
+	}
+	tasks.Items = append(tasks.Items, swarm.Task{})
+
+	tags := ResolveImageTags(context.Background(), apiClient, tasks)
+	assert.Check(t, is.DeepEqual(tags, map[string]string{
+		pinned: "nginx:1.25",
+		tagged: "redis:7-alpine",
+	}))
+	// images are inspected once, and only if pinned by digest without a tag
+	assert.Check(t, is.DeepEqual(inspected, []string{pinned, missing, otherRep}))
+}
@@ -0,0 +1,13 @@
+package task
+
+import "fmt"
+
+// LabelColumnsFormat returns the format of additional table columns for the
+// given labels of the services of tasks, to append to a table format.
+func LabelColumnsFormat(labels []string) string {
+	var format string
+	for _, l := range labels {
+		format += fmt.Sprintf("\t{{.Label %q}}", l)
+	}
+	return format
+}
@@ -0,0 +1,13 @@
+package task
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestLabelColumnsFormat(t *testing.T) {
+	assert.Check(t, is.Equal(LabelColumnsFormat(nil), ""))
+	assert.Check(t, is.Equal(LabelColumnsFormat([]string{"tier", "com.example.owner"}), `	{{.Label "tier"}}	{{.Label "com.example.owner"}}`))
+}
@@ -2,7 +2,10 @@
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"sort"
 
 	"github.com/docker/cli/cli/command"
@@ -12,6 +15,7 @@
 	"github.com/fvbommel/sortorder"
 	"github.com/moby/moby/api/types/swarm"
 	"github.com/moby/moby/client"
+	"go.yaml.in/yaml/v3"
 )
 
 type tasksSortable []swarm.Task
@@ -32,51 +36,279 @@ func (t tasksSortable) Less(i, j int) bool {
 	return t[j].Meta.CreatedAt.Before(t[i].CreatedAt)
 }
 
+// CSVFormatKey is the format to print tasks as CSV (RFC 4180), with a header
+// row and the same columns as the default table format.
+const CSVFormatKey = "csv"
+
+// YAMLFormatKey is the format to print tasks as a single YAML list, with the
+// same fields as the "json" format.
+const YAMLFormatKey = "yaml"
+
+// streamRows is the number of tasks after which the formatted tasks are
+// printed, so that the memory used for the output of large lists of tasks
+// stays bounded.
+const streamRows = 100
+
 // Print task information in a format.
 // Besides this, command `docker node ps <node>`
 // and `docker stack ps` will call this, too.
+//
+// When truncating, IDs and digests are truncated to the length set through
+// the "tasksTruncLength" option in the config file, if set.
 func Print(ctx context.Context, dockerCli command.Cli, tasks client.TaskListResult, resolver *idresolver.IDResolver, trunc, quiet bool, format string) error {
-	tasks, err := generateTaskNames(ctx, tasks, resolver)
-	if err != nil {
-		return err
-	}
+	return PrintWithOptions(ctx, dockerCli, tasks, resolver, PrintOptions{
+		Trunc:  trunc,
+		Quiet:  quiet,
+		Format: format,
+	})
+}
 
-	// First sort tasks, so that all tasks (including previous ones) of the same
-	// service and slot are together. This must be done first, to print "previous"
-	// tasks indented
-	sort.Stable(tasksSortable(tasks.Items))
+// PrintOptions holds the options for [PrintWithOptions].
+type PrintOptions struct {
+	// Trunc truncates IDs and digests, and Quiet only prints task IDs, as
+	// for [Print].
+	Trunc bool
+	Quiet bool
+
+	// NoHeader omits the header row of table formats.
+	NoHeader bool
 
-	names := map[string]string{}
-	nodes := map[string]string{}
+	// AbsoluteTime prints the time of the current state of each task as an
+	// absolute time, instead of relative to the current time.
+	AbsoluteTime bool
 
+	// Format is the format to print the tasks in.
+	Format string
+
+	// ImageTags holds the tags for the ImageTag field, as returned by
+	// [ResolveImageTags].
+	ImageTags map[string]string
+
+	// ServiceLabels holds the labels of each service, indexed by service ID,
+	// for the Label field.
+	ServiceLabels map[string]map[string]string
+}
+
+// PrintWithOptions is like [Print], but with the given options.
+func PrintWithOptions(ctx context.Context, dockerCli command.Cli, tasks client.TaskListResult, resolver *idresolver.IDResolver, opts PrintOptions) error {
+	truncLength := dockerCli.ConfigFile().TasksTruncLength
+	if opts.Format == CSVFormatKey {
+		return printCSV(ctx, dockerCli.Out(), tasks, resolver, opts.Trunc, truncLength, opts.Quiet, opts.AbsoluteTime)
+	}
+	if opts.Format == YAMLFormatKey {
+		return printYAML(ctx, dockerCli.Out(), tasks, resolver, opts.ImageTags, opts.Trunc, truncLength, opts.AbsoluteTime)
+	}
 	tasksCtx := formatter.Context{
-		Output: dockerCli.Out(),
-		Format: newTaskFormat(format, quiet),
-		Trunc:  trunc,
+		Output:     dockerCli.Out(),
+		Format:     newTaskFormat(opts.Format, opts.Quiet),
+		Trunc:      opts.Trunc,
+		Funcs:      templateFuncs,
+		NoHeader:   opts.NoHeader,
+		StreamRows: streamRows,
 	}
 
 	var indent string
 	if tasksCtx.Format.IsTable() {
 		indent = ` \_ `
 	}
+	tasks, info, err := resolveTasks(ctx, tasks, resolver, indent)
+	if err != nil {
+		return err
+	}
+	return formatWrite(tasksCtx, tasks, info, formatOptions{
+		imageTags:     opts.ImageTags,
+		serviceLabels: opts.ServiceLabels,
+		truncLength:   truncLength,
+		absoluteTime:  opts.AbsoluteTime,
+	})
+}
+
+// JSONRows returns the tasks in the same order and with the same fields as
+// printed by [PrintWithOptions] with the "json" format and the given options,
+// one JSON object per task.
+func JSONRows(ctx context.Context, dockerCli command.Cli, tasks client.TaskListResult, resolver *idresolver.IDResolver, opts PrintOptions) ([]json.RawMessage, error) {
+	tasks, info, err := resolveTasks(ctx, tasks, resolver, "")
+	if err != nil {
+		return nil, err
+	}
+	fmtOpts := formatOptions{
+		imageTags:     opts.ImageTags,
+		serviceLabels: opts.ServiceLabels,
+		truncLength:   dockerCli.ConfigFile().TasksTruncLength,
+		absoluteTime:  opts.AbsoluteTime,
+	}
+	rows := make([]json.RawMessage, 0, len(tasks.Items))
+	for _, task := range tasks.Items {
+		row, err := newTaskContext(task, info, opts.Trunc, fmtOpts).MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// csvColumns are the columns printed with the "csv" format, in the order of
+// the default table format.
+var csvColumns = []struct {
+	header string
+	value  func(*taskContext) string
+}{
+	{header: taskIDHeader, value: (*taskContext).ID},
+	{header: formatter.NameHeader, value: (*taskContext).Name},
+	{header: formatter.ImageHeader, value: (*taskContext).Image},
+	{header: nodeHeader, value: (*taskContext).Node},
+	{header: desiredStateHeader, value: (*taskContext).DesiredState},
+	{header: currentStateHeader, value: (*taskContext).CurrentState},
+	{header: formatter.ErrorHeader, value: (*taskContext).rawError},
+	{header: formatter.PortsHeader, value: (*taskContext).Ports},
+}
+
+// printCSV prints the tasks as CSV, with a header row. Values are quoted
+// as needed, so error messages and image names containing commas or quotes
+// are kept intact. If quiet is set, only the task IDs are printed, without
+// a header row.
+func printCSV(ctx context.Context, out io.Writer, tasks client.TaskListResult, resolver *idresolver.IDResolver, trunc bool, truncLength int, quiet, absoluteTime bool) error {
+	tasks, info, err := resolveTasks(ctx, tasks, resolver, "")
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(out)
+	if !quiet {
+		headers := make([]string, 0, len(csvColumns))
+		for _, col := range csvColumns {
+			headers = append(headers, col.header)
+		}
+		if err := w.Write(headers); err != nil {
+			return err
+		}
+	}
+	for _, task := range tasks.Items {
+		n, resolved := info.nodeInfo[task.ID]
+		tc := &taskContext{
+			trunc:        trunc,
+			truncLength:  truncLength,
+			absoluteTime: absoluteTime,
+			task:         task,
+			name:         info.names[task.ID],
+			node:         info.nodes[task.ID],
+			nodeInfo:     n,
+			nodeResolved: resolved,
+		}
+		if quiet {
+			if err := w.Write([]string{tc.ID()}); err != nil {
+				return err
+			}
+			continue
+		}
+		row := make([]string, 0, len(csvColumns))
+		for _, col := range csvColumns {
+			row = append(row, col.value(tc))
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// printYAML prints the tasks as a single YAML list. Each task has the same
+// fields as printed with the "json" format, as it's marshaled through the
+// same context, and is converted to YAML afterward.
+func printYAML(ctx context.Context, out io.Writer, tasks client.TaskListResult, resolver *idresolver.IDResolver, imageTags map[string]string, trunc bool, truncLength int, absoluteTime bool) error {
+	tasks, info, err := resolveTasks(ctx, tasks, resolver, "")
+	if err != nil {
+		return err
+	}
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, task := range tasks.Items {
+		n, resolved := info.nodeInfo[task.ID]
+		row, err := (&taskContext{
+			trunc:        trunc,
+			truncLength:  truncLength,
+			absoluteTime: absoluteTime,
+			task:         task,
+			name:         info.names[task.ID],
+			node:         info.nodes[task.ID],
+			nodeInfo:     n,
+			nodeResolved: resolved,
+			imageTags:    imageTags,
+		}).MarshalJSON()
+		if err != nil {
+			return err
+		}
+		// JSON is valid YAML; decoding it into a node preserves the order of
+		// the fields.
+		var doc yaml.Node
+		if err := yaml.Unmarshal(row, &doc); err != nil {
+			return err
+		}
+		resetStyle(doc.Content[0])
+		list.Content = append(list.Content, doc.Content[0])
+	}
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(list); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// resetStyle resets the style of a node decoded from JSON, and its children,
+// so that it's encoded in block style instead of as JSON.
+func resetStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		resetStyle(c)
+	}
+}
+
+// resolvedTasks holds the names and nodes of tasks, indexed by task ID.
+type resolvedTasks struct {
+	names    map[string]string
+	nodes    map[string]string
+	nodeInfo map[string]swarm.Node
+}
+
+// resolveTasks sorts the tasks, and resolves their names and nodes. Previous
+// tasks of the same slot are prefixed with the given indent.
+func resolveTasks(ctx context.Context, tasks client.TaskListResult, resolver *idresolver.IDResolver, indent string) (client.TaskListResult, resolvedTasks, error) {
+	tasks, err := generateTaskNames(ctx, tasks, resolver)
+	if err != nil {
+		return client.TaskListResult{}, resolvedTasks{}, err
+	}
+
+	// First sort tasks, so that all tasks (including previous ones) of the same
+	// service and slot are together. This must be done first, to print "previous"
+	// tasks indented
+	sort.Stable(tasksSortable(tasks.Items))
+
+	info := resolvedTasks{
+		names:    map[string]string{},
+		nodes:    map[string]string{},
+		nodeInfo: map[string]swarm.Node{},
+	}
 	prevName := ""
 	for _, task := range tasks.Items {
 		if task.Name == prevName {
 			// Indent previous tasks of the same slot
-			names[task.ID] = indent + task.Name
+			info.names[task.ID] = indent + task.Name
 		} else {
-			names[task.ID] = task.Name
+			info.names[task.ID] = task.Name
 		}
 		prevName = task.Name
 
 		nodeValue, err := resolver.Resolve(ctx, swarm.Node{}, task.NodeID)
 		if err != nil {
-			return err
+			return client.TaskListResult{}, resolvedTasks{}, err
+		}
+		info.nodes[task.ID] = nodeValue
+		if n, ok := resolver.Node(task.NodeID); ok {
+			info.nodeInfo[task.ID] = n
 		}
-		nodes[task.ID] = nodeValue
 	}
-
-	return formatWrite(tasksCtx, tasks, names, nodes)
+	return tasks, info, nil
 }
 
 // generateTaskNames generates names for the given tasks, and returns a copy of
@@ -115,3 +347,13 @@ func DefaultFormat(configFile *configfile.ConfigFile, quiet bool) string {
 	}
 	return formatter.TableFormatKey
 }
+
+// ValidateFormat validates the fields that are used in a format for printing
+// tasks with [Print], so that commands can return an error that lists the
+// valid fields before listing tasks, instead of failing when printing them.
+func ValidateFormat(format string, quiet bool) error {
+	if format == "" || format == CSVFormatKey || format == YAMLFormatKey {
+		return nil
+	}
+	return formatter.ValidateFields(newTaskFormat(format, quiet), &taskContext{}, templateFuncs)
+}
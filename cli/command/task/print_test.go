@@ -2,16 +2,20 @@
 
 import (
 	"context"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/docker/cli/cli/command/formatter"
 	"github.com/docker/cli/cli/command/idresolver"
+	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/cli/internal/test"
 	"github.com/docker/cli/internal/test/builders"
 	"github.com/moby/moby/api/types/swarm"
 	"github.com/moby/moby/client"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/golden"
 )
 
@@ -72,6 +76,91 @@ func TestTaskPrintWithQuietOption(t *testing.T) {
 	golden.Assert(t, cli.OutBuffer().String(), "task-print-with-quiet-option.golden")
 }
 
+func TestTaskPrintWithTruncLength(t *testing.T) {
+	const quiet = false
+	const noResolve = true
+	tasks := client.TaskListResult{
+		Items: []swarm.Task{
+			*builders.Task(builders.TaskID("yov6omdek8fg3k5stosyp2m50")),
+		},
+	}
+	tests := []struct {
+		doc         string
+		truncLength int
+		trunc       bool
+		expected    string
+	}{
+		{
+			doc:      "default length",
+			trunc:    true,
+			expected: "yov6omdek8fg\n",
+		},
+		{
+			doc:         "configured length",
+			truncLength: 5,
+			trunc:       true,
+			expected:    "yov6o\n",
+		},
+		{
+			doc:         "no-trunc",
+			truncLength: 5,
+			expected:    "yov6omdek8fg3k5stosyp2m50\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			apiClient := &fakeClient{}
+			cli := test.NewFakeCli(apiClient)
+			cli.SetConfigFile(&configfile.ConfigFile{TasksTruncLength: tc.truncLength})
+			err := Print(context.Background(), cli, tasks, idresolver.New(apiClient, noResolve), tc.trunc, quiet, "{{ .ID }}")
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected))
+		})
+	}
+}
+
+func TestJSONRows(t *testing.T) {
+	const noResolve = true
+	apiClient := &fakeClient{}
+	cli := test.NewFakeCli(apiClient)
+	cli.SetConfigFile(&configfile.ConfigFile{TasksTruncLength: 5})
+	tasks := client.TaskListResult{
+		Items: []swarm.Task{
+			*builders.Task(
+				builders.TaskID("yov6omdek8fg3k5stosyp2m50"),
+				builders.WithStatus(builders.Timestamp(time.Date(2024, 1, 2, 13, 23, 37, 0, time.UTC))),
+			),
+		},
+	}
+	opts := PrintOptions{Trunc: true, AbsoluteTime: true}
+
+	rows, err := JSONRows(context.Background(), cli, tasks, idresolver.New(apiClient, noResolve), opts)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(rows, 1))
+
+	// rows must have the same fields as printed with the "json" format
+	opts.Format = "json"
+	err = PrintWithOptions(context.Background(), cli, tasks, idresolver.New(apiClient, noResolve), opts)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(rows[0])+"\n", cli.OutBuffer().String()))
+	assert.Check(t, is.Contains(string(rows[0]), `"ID":"yov6o"`))
+}
+
+func TestTaskPrintWithEnvFunction(t *testing.T) {
+	t.Setenv("DEPLOY_ENV", "production")
+	const noResolve = true
+	apiClient := &fakeClient{}
+	cli := test.NewFakeCli(apiClient)
+	tasks := client.TaskListResult{
+		Items: []swarm.Task{
+			*builders.Task(builders.TaskID("id-foo")),
+		},
+	}
+	err := Print(context.Background(), cli, tasks, idresolver.New(apiClient, noResolve), false, false, `{{.ID}} {{env "DEPLOY_ENV"}} [{{env "NO_SUCH_VARIABLE"}}]`)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "id-foo production []\n"))
+}
+
 func TestTaskPrintWithNoTruncOption(t *testing.T) {
 	const quiet = false
 	const trunc = false
@@ -188,3 +277,113 @@ func TestTaskPrintWithResolution(t *testing.T) {
 	assert.NilError(t, err)
 	golden.Assert(t, cli.OutBuffer().String(), "task-print-with-resolution.golden")
 }
+
+func TestTaskPrintStreamsRows(t *testing.T) {
+	const noResolve = true
+	apiClient := &fakeClient{}
+	cli := test.NewFakeCli(apiClient)
+	var tasks client.TaskListResult
+	for i := 1; i <= streamRows+1; i++ {
+		// the service of the last task has the longest name, which is
+		// printed in a part of its own.
+		tasks.Items = append(tasks.Items, *builders.Task(
+			builders.TaskID("id-"+strconv.Itoa(i)),
+			builders.TaskServiceID("service-"+strings.Repeat("x", i)),
+			builders.TaskSlot(1),
+		))
+	}
+	err := Print(context.Background(), cli, tasks, idresolver.New(apiClient, noResolve), false, false, "table {{.Name}}\t{{.DesiredState}}")
+	assert.NilError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(cli.OutBuffer().String(), "\n"), "\n")
+	assert.Assert(t, is.Len(lines, streamRows+2))
+	column := strings.Index(lines[0], "DESIRED STATE")
+	for _, line := range lines[1:] {
+		assert.Check(t, is.Equal(strings.Index(line, "Ready"), column), line)
+	}
+}
+
+func TestTaskPrintCSV(t *testing.T) {
+	testCases := []struct {
+		doc    string
+		trunc  bool
+		quiet  bool
+		golden string
+	}{
+		{
+			doc:    "default",
+			trunc:  true,
+			golden: "task-print-csv.golden",
+		},
+		{
+			doc:    "no-trunc",
+			golden: "task-print-csv-no-trunc.golden",
+		},
+		{
+			doc:    "quiet",
+			trunc:  true,
+			quiet:  true,
+			golden: "task-print-csv-quiet.golden",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			apiClient := &fakeClient{
+				serviceInspectFunc: func(ref string, options client.ServiceInspectOptions) (client.ServiceInspectResult, error) {
+					return client.ServiceInspectResult{
+						Service: *builders.Service(builders.ServiceName("service-name-foo")),
+					}, nil
+				},
+				nodeInspectFunc: func(ref string) (client.NodeInspectResult, error) {
+					return client.NodeInspectResult{
+						Node: *builders.Node(builders.NodeName("node-name-bar")),
+					}, nil
+				},
+			}
+			cli := test.NewFakeCli(apiClient)
+			tasks := client.TaskListResult{
+				Items: []swarm.Task{
+					*builders.Task(
+						builders.TaskID("id-foo-0123456789abcdef"),
+						builders.TaskServiceID("service-id-foo"),
+						builders.TaskNodeID("id-node"),
+						builders.TaskSlot(1),
+						builders.WithTaskSpec(builders.TaskImage("myimage:mytag@sha256:4cfb2d5b6b8a8a3a2a1f2ce0d4b3bb6d4e6bbf6e4ad0b8e9b51f7e7b0c6b8fd5")),
+						builders.TaskDesiredState(swarm.TaskStateShutdown),
+						builders.WithStatus(
+							builders.TaskState(swarm.TaskStateFailed),
+							builders.Timestamp(time.Now().Add(-2*time.Hour)),
+							builders.StatusErr(`task: non-zero exit (1): "config, secrets" missing`),
+						),
+					),
+					*builders.Task(
+						builders.TaskID("id-bar-0123456789abcdef"),
+						builders.TaskServiceID("service-id-foo"),
+						builders.TaskNodeID("id-node"),
+						builders.TaskSlot(2),
+						builders.WithTaskSpec(builders.TaskImage("myimage:mytag")),
+						builders.TaskDesiredState(swarm.TaskStateRunning),
+						builders.WithStatus(
+							builders.TaskState(swarm.TaskStateRunning),
+							builders.Timestamp(time.Now().Add(-2*time.Hour)),
+							builders.PortStatus([]swarm.PortConfig{
+								{PublishedPort: 80, TargetPort: 8080, Protocol: "tcp"},
+								{PublishedPort: 443, TargetPort: 8443, Protocol: "tcp"},
+							}),
+						),
+					),
+				},
+			}
+			err := Print(context.Background(), cli, tasks, idresolver.New(apiClient, false), tc.trunc, tc.quiet, CSVFormatKey)
+			assert.NilError(t, err)
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+		})
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{"", "table", "raw", "json", "csv", "yaml", "table {{.ID}}\t{{.Namespace}}", `{{.Name}} {{env "HOME"}}`, ImageTagsTableFormat, NamespaceDigestsTableFormat} {
+		assert.Check(t, ValidateFormat(format, false), "format: %s", format)
+	}
+	assert.Check(t, is.ErrorContains(ValidateFormat("{{.Nmae}}", false), `invalid format: unknown field "Nmae"; valid fields are: CPUReservation, CurrentState, DesiredState, Error, ID, Image,`))
+}
@@ -65,6 +65,7 @@ type ConfigFile struct {
 	ServiceInspectFormat string                       `json:"serviceInspectFormat,omitempty"`
 	ServicesFormat       string                       `json:"servicesFormat,omitempty"`
 	TasksFormat          string                       `json:"tasksFormat,omitempty"`
+	TasksTruncLength     int                          `json:"tasksTruncLength,omitempty"`
 	SecretFormat         string                       `json:"secretFormat,omitempty"`
 	ConfigFormat         string                       `json:"configFormat,omitempty"`
 	NodesFormat          string                       `json:"nodesFormat,omitempty"`
@@ -97,6 +97,7 @@ type ClientOptions struct {
 	TLSOptions *tlsconfig.Options
 	Context    string
 	ConfigDir  string
+	AssumeYes  bool
 }
 
 // NewClientOptions returns a new ClientOptions.
@@ -116,6 +117,7 @@ func (o *ClientOptions) InstallFlags(flags *pflag.FlagSet) {
 		KeyFile:  filepath.Join(dockerCertPath, DefaultKeyFile),
 	}
 
+	flags.BoolVarP(&o.AssumeYes, "assume-yes", "y", false, "Automatically answer yes to confirmation prompts")
 	flags.StringVar(&o.ConfigDir, "config", configDir, "Location of client config files")
 	flags.BoolVarP(&o.Debug, "debug", "D", false, "Enable debug mode")
 	flags.StringVarP(&o.LogLevel, "log-level", "l", "info", `Set the logging level ("debug", "info", "warn", "error", "fatal")`)
@@ -553,3 +553,30 @@ func (LoadedWithNoSignersNotaryRepository) GetAllTargetMetadataByName(name strin
 func (LoadedWithNoSignersNotaryRepository) GetDelegationRoles() ([]data.Role, error) {
 	return []data.Role{}, nil
 }
+
+// GetPartiallyReadableNotaryRepository returns a PartiallyReadableNotaryRepository
+func GetPartiallyReadableNotaryRepository() (client.Repository, error) {
+	return PartiallyReadableNotaryRepository{}, nil
+}
+
+// PartiallyReadableNotaryRepository is a mock Notary repository that is
+// loaded like LoadedNotaryRepository, but of which the targets of the
+// targets/bob delegation cannot be read
+type PartiallyReadableNotaryRepository struct {
+	LoadedNotaryRepository
+}
+
+// ListTargets lists all targets for the current repository. The list of
+// roles should be passed in order from highest to lowest priority.
+func (p PartiallyReadableNotaryRepository) ListTargets(roles ...data.RoleName) ([]*client.TargetWithRole, error) {
+	if len(roles) == 0 || roles[0] == loadedBobRole.Name {
+		return nil, storage.ErrMetaNotFound{Resource: loadedBobRole.Name.String()}
+	}
+	return p.LoadedNotaryRepository.ListTargets(roles...)
+}
+
+// GetAllTargetMetadataByName fails, as the targets of the targets/bob
+// delegation cannot be read
+func (PartiallyReadableNotaryRepository) GetAllTargetMetadataByName(string) ([]client.TargetSignedStruct, error) {
+	return nil, storage.ErrMetaNotFound{Resource: loadedBobRole.Name.String()}
+}
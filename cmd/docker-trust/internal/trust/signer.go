@@ -0,0 +1,295 @@
+package trust
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/client"
+	"github.com/theupdateframework/notary/client/changelist"
+	"github.com/theupdateframework/notary/cryptoservice"
+	"github.com/theupdateframework/notary/storage"
+	"github.com/theupdateframework/notary/trustmanager"
+	"github.com/theupdateframework/notary/trustpinning"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+)
+
+// EnvSigningEndpoint is the name of the environment variable to set the URL
+// of an external signing service. Private keys that are not found in the
+// trust directory are used through the signing service.
+const EnvSigningEndpoint = "DOCKER_CONTENT_TRUST_SIGNING_ENDPOINT"
+
+// defaultSigningTimeout is the timeout for requests to the external signing
+// service if no timeout is set through DOCKER_CONTENT_TRUST_TIMEOUT.
+const defaultSigningTimeout = 30 * time.Second
+
+// maxSigningResponseSize is the maximum size of the body of a response of the
+// external signing service.
+const maxSigningResponseSize = 1 << 20
+
+// Signer signs data with private keys that are not stored in the trust
+// directory, for example, keys that are kept in a key management service
+// (KMS) or hardware security module (HSM).
+type Signer interface {
+	// Keys returns the signature algorithm of each private key that's
+	// available to the signer, indexed by (canonical) key ID.
+	Keys() (map[string]data.SigAlgorithm, error)
+
+	// Sign signs msg with the private key with the given ID.
+	Sign(keyID string, msg []byte) ([]byte, error)
+}
+
+// SigningEndpoint returns the URL of the external signing service, as set
+// through the DOCKER_CONTENT_TRUST_SIGNING_ENDPOINT environment variable. It
+// returns an empty string if no signing service is configured, in which case
+// only private keys from the trust directory are used.
+func SigningEndpoint() (string, error) {
+	s := os.Getenv(EnvSigningEndpoint)
+	if s == "" {
+		return "", nil
+	}
+	urlObj, err := url.Parse(s)
+	if err != nil || urlObj.Scheme != "https" {
+		return "", fmt.Errorf("valid https URL required for signing service, got %s", s)
+	}
+	return strings.TrimSuffix(s, "/"), nil
+}
+
+// newRepositoryWithSigner returns a notary repository like
+// [client.NewFileCachedRepository], but which uses the given signer for
+// private keys that are not found in the trust directory.
+func newRepositoryWithSigner(baseDir string, gun data.GUN, baseURL string, rt http.RoundTripper, retriever notary.PassRetriever, signer Signer) (client.Repository, error) {
+	repoDir := filepath.Join(baseDir, "tuf", filepath.FromSlash(gun.String()))
+	cache, err := storage.NewFileStore(filepath.Join(repoDir, "metadata"), "json")
+	if err != nil {
+		return nil, err
+	}
+	keyStore, err := trustmanager.NewKeyFileStore(baseDir, retriever)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create private key store in directory: %s", baseDir)
+	}
+	remoteStore, err := storage.NewHTTPStore(baseURL+"/v2/"+gun.String()+"/_trust/tuf/", "", "json", "key", rt)
+	if err != nil {
+		return nil, err
+	}
+	cl, err := changelist.NewFileChangelist(filepath.Join(repoDir, "changelist"))
+	if err != nil {
+		return nil, err
+	}
+	cs := &signerCryptoService{
+		CryptoService: cryptoservice.NewCryptoService(keyStore),
+		signer:        signer,
+	}
+	return client.NewRepository(gun, baseURL, remoteStore, cache, trustpinning.TrustPinConfig{}, cs, cl)
+}
+
+// signerCryptoService is a [signed.CryptoService] that uses the private keys
+// from the trust directory, and an external [Signer] for private keys that
+// are not found there.
+type signerCryptoService struct {
+	signed.CryptoService
+	signer Signer
+
+	// keys caches the keys available to the signer.
+	keys map[string]data.SigAlgorithm
+}
+
+func (cs *signerCryptoService) signerKeys() (map[string]data.SigAlgorithm, error) {
+	if cs.keys == nil {
+		keys, err := cs.signer.Keys()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list keys of signing service: %w", err)
+		}
+		cs.keys = keys
+	}
+	return cs.keys, nil
+}
+
+// GetPrivateKey returns the private key from the trust directory if present,
+// or otherwise a private key that signs through the external signer.
+func (cs *signerCryptoService) GetPrivateKey(keyID string) (data.PrivateKey, data.RoleName, error) {
+	privKey, role, err := cs.CryptoService.GetPrivateKey(keyID)
+	if !errors.As(err, &trustmanager.ErrKeyNotFound{}) {
+		return privKey, role, err
+	}
+	keys, err := cs.signerKeys()
+	if err != nil {
+		return nil, "", err
+	}
+	algorithm, ok := keys[keyID]
+	if !ok {
+		return nil, "", trustmanager.ErrKeyNotFound{KeyID: keyID}
+	}
+	return &signerKey{id: keyID, algorithm: algorithm, signer: cs.signer}, "", nil
+}
+
+// ListAllKeys returns the keys from the trust directory, and the keys that
+// are available to the external signer. Keys of the external signer are
+// not associated with a role.
+func (cs *signerCryptoService) ListAllKeys() map[string]data.RoleName {
+	allKeys := cs.CryptoService.ListAllKeys()
+	keys, err := cs.signerKeys()
+	if err != nil {
+		logrus.Debug(err)
+		return allKeys
+	}
+	if allKeys == nil {
+		allKeys = make(map[string]data.RoleName, len(keys))
+	}
+	for keyID := range keys {
+		if _, ok := allKeys[keyID]; !ok {
+			allKeys[keyID] = ""
+		}
+	}
+	return allKeys
+}
+
+// signerKey is a [data.PrivateKey] that signs through an external [Signer].
+// The private key material is not accessible.
+type signerKey struct {
+	id        string
+	algorithm data.SigAlgorithm
+	signer    Signer
+}
+
+func (k *signerKey) ID() string {
+	return k.id
+}
+
+// Algorithm returns the key algorithm that corresponds with the signature
+// algorithm of the key.
+func (k *signerKey) Algorithm() string {
+	switch k.algorithm {
+	case data.ECDSASignature:
+		return data.ECDSAKey
+	case data.EDDSASignature:
+		return data.ED25519Key
+	default:
+		return data.RSAKey
+	}
+}
+
+func (*signerKey) Public() []byte {
+	return nil
+}
+
+func (k *signerKey) Sign(_ io.Reader, msg []byte, _ crypto.SignerOpts) ([]byte, error) {
+	return k.signer.Sign(k.id, msg)
+}
+
+func (*signerKey) Private() []byte {
+	return nil
+}
+
+func (*signerKey) CryptoSigner() crypto.Signer {
+	return nil
+}
+
+func (k *signerKey) SignatureAlgorithm() data.SigAlgorithm {
+	return k.algorithm
+}
+
+// httpSigner is a [Signer] that signs through a signing service over HTTP.
+//
+// It lists the available keys through a "GET <endpoint>/keys" request, which
+// returns a JSON array of keys, for example:
+//
+//	[{"id": "<key ID>", "algorithm": "ecdsa"}]
+//
+// Data is signed through a "POST <endpoint>/keys/<key ID>/sign" request, with
+// the data to sign as request body. The response body contains the signature.
+type httpSigner struct {
+	endpoint string
+	client   *http.Client
+}
+
+// newHTTPSigner returns a signer for the signing service at endpoint. Requests
+// are sent in the same way as to the notary server, trusting the certificates
+// in the certificate directory of the signing service, and presenting the
+// client certificate of opts, if set. Each request is bound by the timeout of
+// opts, or [defaultSigningTimeout] if not set.
+func newHTTPSigner(endpoint string, opts NotaryOptions) (*httpSigner, error) {
+	cfg, err := newTLSConfig(endpoint, true, opts)
+	if err != nil {
+		return nil, err
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultSigningTimeout
+	}
+	return &httpSigner{
+		endpoint: endpoint,
+		client: &http.Client{
+			Transport: newTransport(cfg),
+			Timeout:   timeout,
+		},
+	}, nil
+}
+
+func (s *httpSigner) Keys() (map[string]data.SigAlgorithm, error) {
+	resp, err := s.client.Get(s.endpoint + "/keys")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status from signing service: %s", resp.Status)
+	}
+	body, err := readSigningResponse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var keys []struct {
+		ID        string            `json:"id"`
+		Algorithm data.SigAlgorithm `json:"algorithm"`
+	}
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil, fmt.Errorf("invalid response from signing service: %w", err)
+	}
+	result := make(map[string]data.SigAlgorithm, len(keys))
+	for _, k := range keys {
+		result[k.ID] = k.Algorithm
+	}
+	return result, nil
+}
+
+func (s *httpSigner) Sign(keyID string, msg []byte) ([]byte, error) {
+	resp, err := s.client.Post(s.endpoint+"/keys/"+url.PathEscape(keyID)+"/sign", "application/octet-stream", bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return readSigningResponse(resp.Body)
+	case http.StatusNotFound:
+		return nil, trustmanager.ErrKeyNotFound{KeyID: keyID}
+	default:
+		return nil, fmt.Errorf("failed to sign with key %s: unexpected status from signing service: %s", keyID, resp.Status)
+	}
+}
+
+// readSigningResponse reads the body of a response of the signing service,
+// which must not be larger than [maxSigningResponseSize].
+func readSigningResponse(body io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(body, maxSigningResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxSigningResponseSize {
+		return nil, fmt.Errorf("invalid response from signing service: larger than %d bytes", maxSigningResponseSize)
+	}
+	return b, nil
+}
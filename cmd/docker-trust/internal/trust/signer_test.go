@@ -0,0 +1,222 @@
+package trust
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/cli/config"
+
+	"github.com/theupdateframework/notary/cryptoservice"
+	"github.com/theupdateframework/notary/passphrase"
+	"github.com/theupdateframework/notary/trustmanager"
+	"github.com/theupdateframework/notary/tuf/data"
+	"github.com/theupdateframework/notary/tuf/signed"
+	"github.com/theupdateframework/notary/tuf/utils"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// fakeSigner is a [Signer] that signs with private keys kept in memory.
+type fakeSigner struct {
+	keys map[string]data.PrivateKey
+}
+
+func (s *fakeSigner) Keys() (map[string]data.SigAlgorithm, error) {
+	keys := make(map[string]data.SigAlgorithm, len(s.keys))
+	for id, k := range s.keys {
+		keys[id] = k.SignatureAlgorithm()
+	}
+	return keys, nil
+}
+
+func (s *fakeSigner) Sign(keyID string, msg []byte) ([]byte, error) {
+	k, ok := s.keys[keyID]
+	if !ok {
+		return nil, trustmanager.ErrKeyNotFound{KeyID: keyID}
+	}
+	return k.Sign(rand.Reader, msg, nil)
+}
+
+func TestSigningEndpoint(t *testing.T) {
+	tests := []struct {
+		doc         string
+		value       string
+		expected    string
+		expectedErr string
+	}{
+		{
+			doc: "not set",
+		},
+		{
+			doc:      "https",
+			value:    "https://signer.example.com/v1/",
+			expected: "https://signer.example.com/v1",
+		},
+		{
+			doc:         "http",
+			value:       "http://signer.example.com",
+			expectedErr: "valid https URL required for signing service, got http://signer.example.com",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			t.Setenv(EnvSigningEndpoint, tc.value)
+			endpoint, err := SigningEndpoint()
+			if tc.expectedErr != "" {
+				assert.Check(t, is.Error(err, tc.expectedErr))
+				return
+			}
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(endpoint, tc.expected))
+		})
+	}
+}
+
+func TestSignerCryptoService(t *testing.T) {
+	localKey, err := utils.GenerateECDSAKey(rand.Reader)
+	assert.NilError(t, err)
+	remoteKey, err := utils.GenerateECDSAKey(rand.Reader)
+	assert.NilError(t, err)
+	unknownKey, err := utils.GenerateECDSAKey(rand.Reader)
+	assert.NilError(t, err)
+
+	localCS := cryptoservice.NewCryptoService(trustmanager.NewKeyMemoryStore(passphrase.ConstantRetriever("password")))
+	assert.NilError(t, localCS.AddKey(data.CanonicalTargetsRole, "gun", localKey))
+	cs := &signerCryptoService{
+		CryptoService: localCS,
+		signer:        &fakeSigner{keys: map[string]data.PrivateKey{remoteKey.ID(): remoteKey}},
+	}
+
+	allKeys := cs.ListAllKeys()
+	assert.Check(t, is.Len(allKeys, 2))
+	assert.Check(t, is.Contains(allKeys, localKey.ID()))
+	assert.Check(t, is.Contains(allKeys, remoteKey.ID()))
+
+	k, _, err := cs.GetPrivateKey(localKey.ID())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(k.Private(), localKey.Private()), "expected local key to be used")
+
+	_, _, err = cs.GetPrivateKey(unknownKey.ID())
+	assert.Check(t, is.ErrorType(err, trustmanager.ErrKeyNotFound{}))
+
+	s, err := data.NewTargets().ToSigned()
+	assert.NilError(t, err)
+	remotePub := data.PublicKeyFromPrivate(remoteKey)
+	err = signed.Sign(cs, s, []data.PublicKey{remotePub}, 1, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(s.Signatures, 1))
+	assert.Check(t, is.Equal(s.Signatures[0].Method, data.ECDSASignature))
+	assert.Check(t, signed.VerifySignature(*s.Signed, &s.Signatures[0], remotePub))
+
+	err = signed.Sign(cs, s, []data.PublicKey{data.PublicKeyFromPrivate(unknownKey)}, 1, nil)
+	assert.Check(t, is.ErrorType(err, signed.ErrInsufficientSignatures{}))
+}
+
+func TestHTTPSigner(t *testing.T) {
+	key, err := utils.GenerateECDSAKey(rand.Reader)
+	assert.NilError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /keys", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]string{{"id": key.ID(), "algorithm": "ecdsa"}})
+	})
+	mux.HandleFunc("POST /keys/{id}/sign", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != key.ID() {
+			http.NotFound(w, r)
+			return
+		}
+		msg, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sig, err := key.Sign(rand.Reader, msg, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(sig)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	config.SetDir(t.TempDir())
+	signer, err := newHTTPSigner(srv.URL, NotaryOptions{})
+	assert.NilError(t, err)
+	keys, err := signer.Keys()
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(keys, map[string]data.SigAlgorithm{key.ID(): data.ECDSASignature}))
+
+	msg := []byte("hello")
+	sig, err := signer.Sign(key.ID(), msg)
+	assert.NilError(t, err)
+	err = signed.VerifySignature(msg, &data.Signature{KeyID: key.ID(), Method: data.ECDSASignature, Signature: sig}, data.PublicKeyFromPrivate(key))
+	assert.Check(t, err)
+
+	_, err = signer.Sign("unknown", msg)
+	assert.Check(t, is.ErrorType(err, trustmanager.ErrKeyNotFound{}))
+}
+
+func TestHTTPSignerResponseLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte{'x'}, maxSigningResponseSize+1))
+	}))
+	defer srv.Close()
+
+	config.SetDir(t.TempDir())
+	signer, err := newHTTPSigner(srv.URL, NotaryOptions{})
+	assert.NilError(t, err)
+
+	_, err = signer.Keys()
+	assert.Check(t, is.ErrorContains(err, "invalid response from signing service: larger than 1048576 bytes"))
+	_, err = signer.Sign("some-key", []byte("hello"))
+	assert.Check(t, is.ErrorContains(err, "invalid response from signing service: larger than 1048576 bytes"))
+}
+
+func TestHTTPSignerMutualTLS(t *testing.T) {
+	certFile, keyFile, clientCert := writeClientCertificate(t, t.TempDir())
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("[]"))
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: x509.NewCertPool()}
+	srv.TLS.ClientCAs.AddCert(clientCert)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	// Trust the certificate of the signing service through the certificate
+	// directory, as for the notary server.
+	config.SetDir(t.TempDir())
+	certDir, err := certificateDirectory(srv.URL)
+	assert.NilError(t, err)
+	assert.NilError(t, os.MkdirAll(certDir, 0o700))
+	serverCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	assert.NilError(t, os.WriteFile(filepath.Join(certDir, "ca.crt"), serverCert, 0o600))
+
+	t.Run("without client certificate", func(t *testing.T) {
+		signer, err := newHTTPSigner(srv.URL, NotaryOptions{})
+		assert.NilError(t, err)
+		_, err = signer.Keys()
+		assert.Check(t, IsTLSHandshakeError(err), "expected a TLS handshake error, got: %v", err)
+	})
+
+	t.Run("with client certificate", func(t *testing.T) {
+		signer, err := newHTTPSigner(srv.URL, NotaryOptions{TLSCert: certFile, TLSKey: keyFile})
+		assert.NilError(t, err)
+		keys, err := signer.Keys()
+		assert.NilError(t, err)
+		assert.Check(t, is.Len(keys, 0))
+	})
+}
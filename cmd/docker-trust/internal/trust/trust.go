@@ -2,6 +2,9 @@
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,11 +16,14 @@
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/containerd/errdefs"
 	"github.com/distribution/reference"
 	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cmd/docker-trust/internal/registry"
+	"github.com/docker/cli/cmd/docker-trust/internal/retry"
 	"github.com/docker/distribution/registry/client/auth"
 	"github.com/docker/distribution/registry/client/auth/challenge"
 	"github.com/docker/distribution/registry/client/transport"
@@ -61,8 +67,29 @@ func Enabled() bool {
 // NotaryServer is the endpoint serving the Notary trust server
 const NotaryServer = "https://notary.docker.io"
 
+// EnvNotaryTimeout is the name of the environment variable to set the
+// timeout for requests to the Notary trust server. It is independent of
+// any timeout used for connections with the Docker daemon.
+const EnvNotaryTimeout = "DOCKER_CONTENT_TRUST_TIMEOUT"
+
+// EnvTrustDir is the name of the environment variable to set the trust
+// directory, which holds the private keys and the cached trust metadata.
+// It defaults to the "trust" directory in the configuration directory.
+const EnvTrustDir = "DOCKER_TRUST_DIR"
+
+// EnvNotaryTLSCert and EnvNotaryTLSKey are the names of the environment
+// variables to set the client certificate and key to present to the Notary
+// trust server, for servers that require mutual TLS.
+const (
+	EnvNotaryTLSCert = "DOCKER_CONTENT_TRUST_TLS_CERT"
+	EnvNotaryTLSKey  = "DOCKER_CONTENT_TRUST_TLS_KEY"
+)
+
 // GetTrustDirectory returns the base trust directory name
 func GetTrustDirectory() string {
+	if dir := os.Getenv(EnvTrustDir); dir != "" {
+		return dir
+	}
 	return filepath.Join(config.Dir(), "trust")
 }
 
@@ -78,6 +105,50 @@ func certificateDirectory(server string) (string, error) {
 	return filepath.Join(config.Dir(), "tls", u.Host), nil
 }
 
+// newTLSConfig returns the TLS configuration for requests to the given server,
+// which trusts the certificates in the certificate directory of the server,
+// and presents the client certificate of opts, if set.
+func newTLSConfig(server string, secure bool, opts NotaryOptions) (*tls.Config, error) {
+	cfg := tlsconfig.ClientDefault()
+	cfg.InsecureSkipVerify = !secure
+
+	// Get certificate base directory
+	certDir, err := certificateDirectory(server)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Debugf("reading certificate directory: %s", certDir)
+
+	if err := registry.ReadCertsDirectory(cfg, certDir); err != nil {
+		return nil, err
+	}
+	clientCert, err := ClientCertificate(opts)
+	if err != nil {
+		return nil, err
+	}
+	if clientCert != nil {
+		// Prefer the configured client certificate over the ones in the
+		// certificate directory.
+		cfg.Certificates = append([]tls.Certificate{*clientCert}, cfg.Certificates...)
+	}
+	return cfg, nil
+}
+
+// newTransport returns the transport for requests to the notary server, and
+// the signing service, with the given TLS configuration.
+func newTransport(cfg *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig:     cfg,
+		DisableKeepAlives:   true,
+	}
+}
+
 // Server returns the base URL for the trust server.
 func Server(indexName string) (string, error) {
 	if s := os.Getenv("DOCKER_CONTENT_TRUST_SERVER"); s != "" {
@@ -94,6 +165,249 @@ func Server(indexName string) (string, error) {
 	return "https://" + indexName, nil
 }
 
+// Timeout returns the timeout for requests to the Notary trust server, as
+// set through the DOCKER_CONTENT_TRUST_TIMEOUT environment variable. A zero
+// value means that the default timeouts are used.
+func Timeout() (time.Duration, error) {
+	v := os.Getenv(EnvNotaryTimeout)
+	if v == "" {
+		return 0, nil
+	}
+	timeout, err := ParseTimeout(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %w", EnvNotaryTimeout, err)
+	}
+	return timeout, nil
+}
+
+// ParseTimeout parses a timeout for requests to the Notary trust server.
+func ParseTimeout(v string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(v)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("%q is not a valid timeout: must be a positive duration (for example, \"30s\")", v)
+	}
+	return timeout, nil
+}
+
+// NotaryOptions configures the notary repositories returned by
+// [NewNotaryRepository], and their requests to the Notary trust server.
+type NotaryOptions struct {
+	// Timeout bounds each request to the Notary trust server. The default
+	// timeouts are used if zero.
+	Timeout time.Duration
+
+	// Retry configures retries of requests that fail with a transient
+	// error. Requests are not retried if its Retries are zero.
+	Retry retry.Options
+
+	// TLSCert and TLSKey are the paths of the client certificate and key
+	// to present to the Notary trust server, for servers that require
+	// mutual TLS. No client certificate is presented if they are empty.
+	TLSCert string
+	TLSKey  string
+
+	// TrustDir is the directory of the keys and cached trust metadata. The
+	// directory returned by [GetTrustDirectory] is used if empty.
+	TrustDir string
+
+	// Progress, if set, is called with the name of each TUF role of which
+	// the metadata is downloaded from the notary server.
+	Progress func(role string)
+}
+
+// NotaryOptionsFromEnv returns the options that are set through the
+// DOCKER_CONTENT_TRUST_TIMEOUT, DOCKER_CONTENT_TRUST_TLS_CERT,
+// DOCKER_CONTENT_TRUST_TLS_KEY, DOCKER_CLI_RETRIES, and
+// DOCKER_CLI_RETRY_MAX_DELAY environment variables.
+func NotaryOptionsFromEnv() (NotaryOptions, error) {
+	timeout, err := Timeout()
+	if err != nil {
+		return NotaryOptions{}, err
+	}
+	opts := NotaryOptions{
+		Timeout: timeout,
+		TLSCert: os.Getenv(EnvNotaryTLSCert),
+		TLSKey:  os.Getenv(EnvNotaryTLSKey),
+	}
+	if (opts.TLSCert == "") != (opts.TLSKey == "") {
+		return NotaryOptions{}, fmt.Errorf("%s and %s must be set together", EnvNotaryTLSCert, EnvNotaryTLSKey)
+	}
+	if err := opts.Retry.LoadEnv(nil); err != nil {
+		return NotaryOptions{}, err
+	}
+	return opts, nil
+}
+
+// ClientCertificate returns the client certificate to present to the Notary
+// trust server, as set through opts, or nil if none is set.
+func ClientCertificate(opts NotaryOptions) (*tls.Certificate, error) {
+	if opts.TLSCert == "" && opts.TLSKey == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(opts.TLSCert, opts.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the client certificate for the notary server: %w", err)
+	}
+	return &cert, nil
+}
+
+// IsTLSHandshakeError returns whether err is caused by a failed TLS handshake
+// with the notary server, for example because the server requires a client
+// certificate, or because the certificate of the server cannot be verified.
+func IsTLSHandshakeError(err error) bool {
+	var netErr storage.NetworkError
+	if errors.As(err, &netErr) {
+		err = netErr.Wrapped
+	}
+	var (
+		recordErr  tls.RecordHeaderError
+		verifyErr  *tls.CertificateVerificationError
+		authErr    x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+		opErr      *net.OpError
+	)
+	switch {
+	case errors.As(err, &recordErr), errors.As(err, &verifyErr), errors.As(err, &authErr),
+		errors.As(err, &hostErr), errors.As(err, &invalidErr):
+		return true
+	case errors.As(err, &opErr) && opErr.Op == "remote error":
+		// TLS alerts sent by the server, such as "tls: certificate required".
+		return true
+	default:
+		return false
+	}
+}
+
+// timeoutTransport is a [http.RoundTripper] that bounds each request,
+// including reading the response body, to the given timeout.
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the request's context when the response body
+// is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// retryTransport is a [http.RoundTripper] that retries requests that fail
+// with a transient error, or with a response indicating that the server is
+// temporarily unavailable. Only GET and HEAD requests are retried, as other
+// requests may not be idempotent, or have a body that cannot be replayed.
+type retryTransport struct {
+	base http.RoundTripper
+	opts retry.Options
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.base.RoundTrip(req)
+	}
+	var resp *http.Response
+	err := retry.Do(req.Context(), t.opts, func() error {
+		if resp != nil {
+			// Discard the response of the previous attempt.
+			_ = resp.Body.Close()
+			resp = nil
+		}
+		var err error
+		resp, err = t.base.RoundTrip(req)
+		if err != nil {
+			return err
+		}
+		switch resp.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return errdefs.ErrUnavailable.WithMessage(resp.Status)
+		}
+		return nil
+	})
+	if resp != nil {
+		// Return the last response, also if the server was unavailable,
+		// so that the caller can handle it.
+		return resp, nil
+	}
+	return nil, err
+}
+
+// contextTransport is a [http.RoundTripper] that sends each request with the
+// given context. The notary client does not accept a context, so this is used
+// to cancel its requests, or to bound them to a deadline.
+type contextTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// progressTransport is a [http.RoundTripper] that reports the TUF role of
+// each metadata request to a callback, to show progress while the metadata
+// of a repository is downloaded.
+type progressTransport struct {
+	base     http.RoundTripper
+	progress func(role string)
+}
+
+func (t *progressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if role, ok := metadataRole(req.URL.Path); ok {
+		t.progress(role)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// metadataRole returns the TUF role of a request for the metadata of a role,
+// which has a "/v2/<gun>/_trust/tuf/<role>.json" path, or with consistent
+// snapshots, a "/v2/<gun>/_trust/tuf/<role>.<sha256>.json" path.
+func metadataRole(urlPath string) (string, bool) {
+	_, file, ok := strings.Cut(urlPath, "/_trust/tuf/")
+	if !ok {
+		return "", false
+	}
+	role, ok := strings.CutSuffix(file, ".json")
+	if !ok || role == "" {
+		return "", false
+	}
+	if base, checksum, ok := cutLast(role, "."); ok && isSHA256Hex(checksum) {
+		role = base
+	}
+	return role, true
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// isSHA256Hex returns whether s is a hex-encoded SHA-256 checksum.
+func isSHA256Hex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil && len(s) == 64
+}
+
 type simpleCredentialStore struct {
 	auth registrytypes.AuthConfig
 }
@@ -117,6 +431,22 @@ func (simpleCredentialStore) SetRefreshToken(*url.URL, string, string) {}
 // information needed to operate on a notary repository.
 // It creates an HTTP transport providing authentication support.
 func GetNotaryRepository(in io.Reader, out io.Writer, userAgent string, repoInfo *RepositoryInfo, authConfig *registrytypes.AuthConfig, actions ...string) (client.Repository, error) {
+	opts, err := NotaryOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewNotaryRepository(context.Background(), opts, in, out, userAgent, repoInfo, authConfig, actions...)
+}
+
+// NewNotaryRepository is like [GetNotaryRepository], but uses the given
+// options instead of the ones set through the environment. All requests to
+// the notary server, including those made by the returned repository, are
+// bound to ctx, for example to set a deadline for all of them.
+func NewNotaryRepository(ctx context.Context, opts NotaryOptions, in io.Reader, out io.Writer, userAgent string, repoInfo *RepositoryInfo, authConfig *registrytypes.AuthConfig, actions ...string) (client.Repository, error) {
+	trustDir := opts.TrustDir
+	if trustDir == "" {
+		trustDir = GetTrustDirectory()
+	}
 	server, err := Server(repoInfo.Index.Name)
 	if err != nil {
 		return nil, err
@@ -124,41 +454,37 @@ func GetNotaryRepository(in io.Reader, out io.Writer, userAgent string, repoInfo
 	if server == NotaryServer {
 		_, _ = fmt.Fprint(os.Stderr, dctDeprecation)
 	}
-
-	cfg := tlsconfig.ClientDefault()
-	cfg.InsecureSkipVerify = !repoInfo.Index.Secure
-
-	// Get certificate base directory
-	certDir, err := certificateDirectory(server)
+	timeout := opts.Timeout
+	signingEndpoint, err := SigningEndpoint()
 	if err != nil {
 		return nil, err
 	}
-	logrus.Debugf("reading certificate directory: %s", certDir)
 
-	if err := registry.ReadCertsDirectory(cfg, certDir); err != nil {
+	cfg, err := newTLSConfig(server, repoInfo.Index.Secure, opts)
+	if err != nil {
 		return nil, err
 	}
 
-	base := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		Dial: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).Dial,
-		TLSHandshakeTimeout: 10 * time.Second,
-		TLSClientConfig:     cfg,
-		DisableKeepAlives:   true,
+	var base http.RoundTripper = newTransport(cfg)
+	pingTimeout := 5 * time.Second
+	if timeout > 0 {
+		base = &timeoutTransport{base: base, timeout: timeout}
+		pingTimeout = min(pingTimeout, timeout)
 	}
+	if opts.Retry.Retries > 0 {
+		base = &retryTransport{base: base, opts: opts.Retry}
+	}
+	base = &contextTransport{base: base, ctx: ctx}
 
 	// Skip configuration headers since request is not going to Docker daemon
 	modifiers := registry.Headers(userAgent, http.Header{})
 	authTransport := transport.NewTransport(base, modifiers...)
 	pingClient := &http.Client{
 		Transport: authTransport,
-		Timeout:   5 * time.Second,
+		Timeout:   pingTimeout,
 	}
 	endpointStr := server + "/v2/"
-	req, err := http.NewRequest(http.MethodGet, endpointStr, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointStr, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -191,11 +517,46 @@ func GetNotaryRepository(in io.Reader, out io.Writer, userAgent string, repoInfo
 	basicHandler := auth.NewBasicHandler(simpleCredentialStore{auth: *authConfig})
 	modifiers = append(modifiers, auth.NewAuthorizer(challengeManager, tokenHandler, basicHandler))
 
+	var rt http.RoundTripper = transport.NewTransport(base, modifiers...)
+	if opts.Progress != nil {
+		rt = &progressTransport{base: rt, progress: opts.Progress}
+	}
+	if signingEndpoint != "" {
+		signer, err := newHTTPSigner(signingEndpoint, opts)
+		if err != nil {
+			return nil, err
+		}
+		return newRepositoryWithSigner(
+			trustDir,
+			data.GUN(repoInfo.Name.Name()),
+			server,
+			rt,
+			GetPassphraseRetriever(in, out),
+			signer)
+	}
 	return client.NewFileCachedRepository(
-		GetTrustDirectory(),
+		trustDir,
 		data.GUN(repoInfo.Name.Name()),
 		server,
-		transport.NewTransport(base, modifiers...),
+		rt,
+		GetPassphraseRetriever(in, out),
+		trustpinning.TrustPinConfig{})
+}
+
+// GetOfflineNotaryRepository returns a NotaryRepository that reads the
+// metadata of a repository from the given trust directory, without contacting
+// the notary server. The metadata may be out of date.
+func GetOfflineNotaryRepository(trustDir string, in io.Reader, out io.Writer, repoInfo *RepositoryInfo) (client.Repository, error) {
+	server, err := Server(repoInfo.Index.Name)
+	if err != nil {
+		return nil, err
+	}
+	// A nil RoundTripper makes the repository use an offline remote store.
+	return client.NewFileCachedRepository(
+		trustDir,
+		data.GUN(repoInfo.Name.Name()),
+		server,
+		nil,
 		GetPassphraseRetriever(in, out),
 		trustpinning.TrustPinConfig{})
 }
@@ -239,6 +600,9 @@ func NotaryError(repoName string, err error) error {
 	case trustmanager.ErrKeyNotFound:
 		return fmt.Errorf("error: signing keys for remote repository %s not found: %v", repoName, err)
 	case storage.NetworkError:
+		if IsTLSHandshakeError(err) {
+			return fmt.Errorf("error: TLS handshake with notary server failed: %v", err)
+		}
 		return fmt.Errorf("error: error contacting notary server: %v", err)
 	case storage.ErrMetaNotFound:
 		return fmt.Errorf("error: trust data missing for remote repository %s or remote repository not found: %v", repoName, err)
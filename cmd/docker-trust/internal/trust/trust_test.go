@@ -1,11 +1,31 @@
 package trust
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cmd/docker-trust/internal/retry"
+	registrytypes "github.com/moby/moby/api/types/registry"
 	"github.com/opencontainers/go-digest"
 	"github.com/theupdateframework/notary/client"
+	"github.com/theupdateframework/notary/storage"
 	"github.com/theupdateframework/notary/trustpinning"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
@@ -53,6 +73,14 @@ func TestGetSignableRolesError(t *testing.T) {
 	assert.Error(t, err, expected)
 }
 
+func TestGetTrustDirectory(t *testing.T) {
+	t.Setenv(EnvTrustDir, "")
+	assert.Check(t, is.Equal(GetTrustDirectory(), filepath.Join(config.Dir(), "trust")))
+
+	t.Setenv(EnvTrustDir, "/tmp/custom-trust")
+	assert.Check(t, is.Equal(GetTrustDirectory(), "/tmp/custom-trust"))
+}
+
 func TestENVTrustServer(t *testing.T) {
 	t.Setenv("DOCKER_CONTENT_TRUST_SERVER", "https://notary-test.example.com:5000")
 	output, err := Server("testserver")
@@ -85,3 +113,341 @@ func TestNonOfficialTrustServer(t *testing.T) {
 	assert.NilError(t, err)
 	assert.Equal(t, output, expected)
 }
+
+func TestTimeout(t *testing.T) {
+	t.Setenv(EnvNotaryTimeout, "")
+	timeout, err := Timeout()
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(timeout, time.Duration(0)))
+
+	t.Setenv(EnvNotaryTimeout, "1m30s")
+	timeout, err = Timeout()
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(timeout, 90*time.Second))
+
+	t.Setenv(EnvNotaryTimeout, "-1s")
+	_, err = Timeout()
+	assert.Check(t, is.ErrorContains(err, `invalid value for DOCKER_CONTENT_TRUST_TIMEOUT: "-1s" is not a valid timeout`))
+
+	t.Setenv(EnvNotaryTimeout, "soon")
+	_, err = Timeout()
+	assert.Check(t, is.ErrorContains(err, `invalid value for DOCKER_CONTENT_TRUST_TIMEOUT: "soon" is not a valid timeout`))
+}
+
+func TestNotaryTimeoutNonResponsiveServer(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// never respond
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(done) })
+
+	config.SetDir(t.TempDir())
+	t.Setenv("DOCKER_CONTENT_TRUST_SERVER", srv.URL)
+	t.Setenv(EnvNotaryTimeout, "200ms")
+
+	ref, err := reference.ParseNormalizedNamed("example.com/some/image")
+	assert.NilError(t, err)
+	repo, err := GetNotaryRepository(nil, io.Discard, "test-agent", &RepositoryInfo{
+		Name:  ref,
+		Index: &registrytypes.IndexInfo{Name: "example.com", Secure: false},
+	}, &registrytypes.AuthConfig{}, ActionsPullOnly...)
+	assert.NilError(t, err)
+
+	start := time.Now()
+	_, err = repo.ListTargets()
+	assert.Check(t, err != nil, "expected request to a non-responsive server to fail")
+	assert.Check(t, time.Since(start) < 5*time.Second, "expected request to time out promptly, took %s", time.Since(start))
+}
+
+func TestNotaryContextDeadline(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// never respond
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(done) })
+
+	t.Setenv("DOCKER_CONTENT_TRUST_SERVER", srv.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	ref, err := reference.ParseNormalizedNamed("example.com/some/image")
+	assert.NilError(t, err)
+	start := time.Now()
+	repo, err := NewNotaryRepository(ctx, NotaryOptions{TrustDir: t.TempDir()}, nil, io.Discard, "test-agent", &RepositoryInfo{
+		Name:  ref,
+		Index: &registrytypes.IndexInfo{Name: "example.com", Secure: false},
+	}, &registrytypes.AuthConfig{}, ActionsPullOnly...)
+	assert.NilError(t, err)
+
+	_, err = repo.ListTargets()
+	assert.Check(t, err != nil, "expected request to a non-responsive server to fail")
+	assert.Check(t, is.ErrorIs(ctx.Err(), context.DeadlineExceeded))
+	assert.Check(t, time.Since(start) < 5*time.Second, "expected requests to be canceled at the deadline, took %s", time.Since(start))
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		doc              string
+		method           string
+		statuses         []int
+		expectedStatus   int
+		expectedAttempts int
+	}{
+		{
+			doc:              "success after retry",
+			method:           http.MethodGet,
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			expectedStatus:   http.StatusOK,
+			expectedAttempts: 3,
+		},
+		{
+			doc:              "retries exhausted",
+			method:           http.MethodGet,
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 3,
+		},
+		{
+			doc:              "not retryable status",
+			method:           http.MethodGet,
+			statuses:         []int{http.StatusNotFound, http.StatusOK},
+			expectedStatus:   http.StatusNotFound,
+			expectedAttempts: 1,
+		},
+		{
+			doc:              "not an idempotent method",
+			method:           http.MethodPost,
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedAttempts: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			var attempts int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.statuses[attempts])
+				attempts++
+			}))
+			defer srv.Close()
+
+			rt := &retryTransport{
+				base: http.DefaultTransport,
+				opts: retry.Options{Retries: 2, InitialDelay: time.Millisecond},
+			}
+			req, err := http.NewRequest(tc.method, srv.URL, http.NoBody)
+			assert.NilError(t, err)
+			resp, err := rt.RoundTrip(req)
+			assert.NilError(t, err)
+			_ = resp.Body.Close()
+			assert.Check(t, is.Equal(resp.StatusCode, tc.expectedStatus))
+			assert.Check(t, is.Equal(attempts, tc.expectedAttempts))
+		})
+	}
+}
+
+func TestProgressTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	var roles []string
+	rt := &progressTransport{
+		base:     http.DefaultTransport,
+		progress: func(role string) { roles = append(roles, role) },
+	}
+	for _, p := range []string{
+		"/v2/",
+		"/v2/docker.io/library/alpine/_trust/tuf/root.json",
+		"/v2/docker.io/library/alpine/_trust/tuf/timestamp.json",
+		"/v2/docker.io/library/alpine/_trust/tuf/snapshot.6e2a5c4aeb8bbd6b2c4f5d1a1b3b7e0e8e38a7f0c8c45b5bd4b3d2e9d8f0c1a2.json",
+		"/v2/docker.io/library/alpine/_trust/tuf/targets/releases.json",
+	} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+p, http.NoBody)
+		assert.NilError(t, err)
+		resp, err := rt.RoundTrip(req)
+		assert.NilError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.Check(t, is.DeepEqual(roles, []string{"root", "timestamp", "snapshot", "targets/releases"}))
+}
+
+func TestMetadataRole(t *testing.T) {
+	tests := []struct {
+		path         string
+		expectedRole string
+		expectedOK   bool
+	}{
+		{path: "/v2/"},
+		{path: "/v2/docker.io/library/alpine/_trust/tuf/"},
+		{path: "/v2/docker.io/library/alpine/_trust/tuf/root.key"},
+		{path: "/v2/docker.io/library/alpine/_trust/tuf/root.json", expectedRole: "root", expectedOK: true},
+		{path: "/v2/docker.io/library/alpine/_trust/tuf/targets/releases.json", expectedRole: "targets/releases", expectedOK: true},
+		{path: "/v2/docker.io/library/alpine/_trust/tuf/root.1.json", expectedRole: "root.1", expectedOK: true},
+		{
+			path:         "/v2/docker.io/library/alpine/_trust/tuf/snapshot.6e2a5c4aeb8bbd6b2c4f5d1a1b3b7e0e8e38a7f0c8c45b5bd4b3d2e9d8f0c1a2.json",
+			expectedRole: "snapshot",
+			expectedOK:   true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			role, ok := metadataRole(tc.path)
+			assert.Check(t, is.Equal(role, tc.expectedRole))
+			assert.Check(t, is.Equal(ok, tc.expectedOK))
+		})
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key
+// to dir, and returns their paths and the certificate.
+func writeClientCertificate(t *testing.T, dir string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NilError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	assert.NilError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err)
+
+	certFile = filepath.Join(dir, "client.cert")
+	keyFile = filepath.Join(dir, "client.key")
+	assert.NilError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NilError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile, cert
+}
+
+func TestClientCertificate(t *testing.T) {
+	certFile, keyFile, expected := writeClientCertificate(t, t.TempDir())
+
+	cert, err := ClientCertificate(NotaryOptions{})
+	assert.NilError(t, err)
+	assert.Check(t, is.Nil(cert))
+
+	_, err = ClientCertificate(NotaryOptions{TLSCert: certFile, TLSKey: filepath.Join(t.TempDir(), "missing.key")})
+	assert.Check(t, is.ErrorContains(err, "failed to load the client certificate for the notary server"))
+
+	cert, err = ClientCertificate(NotaryOptions{TLSCert: certFile, TLSKey: keyFile})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(cert.Certificate, [][]byte{expected.Raw}))
+}
+
+func TestNotaryOptionsFromEnv(t *testing.T) {
+	t.Setenv(retry.EnvRetries, "")
+	t.Setenv(retry.EnvMaxDelay, "")
+	t.Setenv(EnvNotaryTimeout, "1m")
+	t.Setenv(EnvNotaryTLSCert, "client.cert")
+	t.Setenv(EnvNotaryTLSKey, "client.key")
+	opts, err := NotaryOptionsFromEnv()
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(opts, NotaryOptions{Timeout: time.Minute, TLSCert: "client.cert", TLSKey: "client.key"}))
+
+	t.Setenv(retry.EnvRetries, "3")
+	opts, err = NotaryOptionsFromEnv()
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(opts.Retry.Retries, 3))
+
+	t.Setenv(EnvNotaryTLSKey, "")
+	_, err = NotaryOptionsFromEnv()
+	assert.Check(t, is.Error(err, "DOCKER_CONTENT_TRUST_TLS_CERT and DOCKER_CONTENT_TRUST_TLS_KEY must be set together"))
+
+	t.Setenv(EnvNotaryTimeout, "soon")
+	_, err = NotaryOptionsFromEnv()
+	assert.Check(t, is.ErrorContains(err, `invalid value for DOCKER_CONTENT_TRUST_TIMEOUT: "soon" is not a valid timeout`))
+}
+
+func TestNotaryMutualTLS(t *testing.T) {
+	certFile, keyFile, clientCert := writeClientCertificate(t, t.TempDir())
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: x509.NewCertPool()}
+	srv.TLS.ClientCAs.AddCert(clientCert)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	config.SetDir(t.TempDir())
+	t.Setenv("DOCKER_CONTENT_TRUST_SERVER", srv.URL)
+	t.Setenv(EnvNotaryTimeout, "")
+
+	ref, err := reference.ParseNormalizedNamed("example.com/some/image")
+	assert.NilError(t, err)
+	listTargets := func() error {
+		opts, err := NotaryOptionsFromEnv()
+		assert.NilError(t, err)
+		opts.TrustDir = t.TempDir()
+		repo, err := NewNotaryRepository(context.Background(), opts, nil, io.Discard, "test-agent", &RepositoryInfo{
+			Name:  ref,
+			Index: &registrytypes.IndexInfo{Name: "example.com", Secure: false},
+		}, &registrytypes.AuthConfig{}, ActionsPullOnly...)
+		assert.NilError(t, err)
+		_, err = repo.ListTargets()
+		return err
+	}
+
+	t.Run("without client certificate", func(t *testing.T) {
+		t.Setenv(EnvNotaryTLSCert, "")
+		t.Setenv(EnvNotaryTLSKey, "")
+		err := listTargets()
+		assert.Check(t, is.ErrorType(err, storage.NetworkError{}))
+		assert.Check(t, IsTLSHandshakeError(err), "expected a TLS handshake error, got: %v", err)
+		assert.Check(t, is.ErrorContains(NotaryError("example.com/some/image", err), "error: TLS handshake with notary server failed"))
+	})
+
+	t.Run("with client certificate", func(t *testing.T) {
+		t.Setenv(EnvNotaryTLSCert, certFile)
+		t.Setenv(EnvNotaryTLSKey, keyFile)
+		err := listTargets()
+		assert.Check(t, is.ErrorType(err, client.ErrRepositoryNotExist{}))
+	})
+}
+
+func TestIsTLSHandshakeErrorConnectionRefused(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	srv.Close()
+
+	config.SetDir(t.TempDir())
+	t.Setenv("DOCKER_CONTENT_TRUST_SERVER", srv.URL)
+	t.Setenv(EnvNotaryTimeout, "")
+	t.Setenv(EnvNotaryTLSCert, "")
+	t.Setenv(EnvNotaryTLSKey, "")
+
+	ref, err := reference.ParseNormalizedNamed("example.com/some/image")
+	assert.NilError(t, err)
+	opts, err := NotaryOptionsFromEnv()
+	assert.NilError(t, err)
+	opts.TrustDir = t.TempDir()
+	repo, err := NewNotaryRepository(context.Background(), opts, nil, io.Discard, "test-agent", &RepositoryInfo{
+		Name:  ref,
+		Index: &registrytypes.IndexInfo{Name: "example.com", Secure: false},
+	}, &registrytypes.AuthConfig{}, ActionsPullOnly...)
+	assert.NilError(t, err)
+	_, err = repo.ListTargets()
+	assert.Check(t, is.ErrorType(err, storage.NetworkError{}))
+	assert.Check(t, !IsTLSHandshakeError(err), "expected a connection error, got: %v", err)
+	assert.Check(t, is.ErrorContains(NotaryError("example.com/some/image", err), "error: error contacting notary server"))
+}
@@ -0,0 +1,121 @@
+package trust
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/theupdateframework/notary/client"
+)
+
+// NotaryClientCache caches notary repositories by GUN, so that trust
+// operations that are performed in the same process, such as [Inspect]
+// followed by signing an image, reuse the repository instead of constructing
+// a new one for each operation. Use [WithNotaryClientCache] to use a cache
+// for the operations that are performed with a CLI.
+//
+// Repositories are cached separately for each trust directory and set of
+// actions, so that a repository that is only authorized to pull is not used
+// to push. The cache is invalidated for a GUN when trust data for that GUN
+// is published; use [NotaryClientCache.Invalidate] or
+// [NotaryClientCache.Clear] to refresh the trust data after it was changed
+// by another process.
+type NotaryClientCache struct {
+	mu    sync.Mutex
+	repos map[notaryClientCacheKey]client.Repository
+}
+
+type notaryClientCacheKey struct {
+	gun      string
+	trustDir string
+	actions  string
+}
+
+// NewNotaryClientCache returns an empty NotaryClientCache.
+func NewNotaryClientCache() *NotaryClientCache {
+	return &NotaryClientCache{repos: make(map[notaryClientCacheKey]client.Repository)}
+}
+
+// Invalidate removes the cached repositories for the given GUN, for example
+// "docker.io/library/alpine", so that its trust data is fetched again the
+// next time it's used.
+func (c *NotaryClientCache) Invalidate(gun string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.repos {
+		if key.gun == gun {
+			delete(c.repos, key)
+		}
+	}
+}
+
+// Clear removes all cached repositories.
+func (c *NotaryClientCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.repos)
+}
+
+// get returns the cached repository for the given key, or calls newRepo to
+// create it, and caches it if no error is returned.
+func (c *NotaryClientCache) get(key notaryClientCacheKey, newRepo func() (client.Repository, error)) (client.Repository, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if repo, ok := c.repos[key]; ok {
+		return repo, nil
+	}
+	repo, err := newRepo()
+	if err != nil {
+		return nil, err
+	}
+	c.repos[key] = repo
+	return repo, nil
+}
+
+// notaryClientCacheProvider is implemented by a CLI that caches notary
+// repositories, as returned by [WithNotaryClientCache].
+type notaryClientCacheProvider interface {
+	NotaryClientCache() *NotaryClientCache
+}
+
+// WithNotaryClientCache returns a CLI that uses the given cache for the notary
+// repositories that are used by trust operations performed with it, such as
+// [Inspect], and the sign, revoke, and signer commands.
+func WithNotaryClientCache(dockerCLI command.Cli, cache *NotaryClientCache) command.Cli {
+	return &cachingCli{Cli: dockerCLI, cache: cache}
+}
+
+type cachingCli struct {
+	command.Cli
+	cache *NotaryClientCache
+}
+
+func (c *cachingCli) NotaryClientCache() *NotaryClientCache {
+	return c.cache
+}
+
+// cachedNotaryClient returns the cached repository for the given GUN if cli
+// has a [NotaryClientCache], or calls newRepo to create it. Repositories are
+// only cached if ctx cannot be cancelled, as requests of a repository remain
+// bound to the context it was created with.
+func cachedNotaryClient(ctx context.Context, cli command.Streams, gun, trustDir string, actions []string, newRepo func() (client.Repository, error)) (client.Repository, error) {
+	ccp, ok := cli.(notaryClientCacheProvider)
+	if !ok || ccp.NotaryClientCache() == nil || ctx.Done() != nil {
+		return newRepo()
+	}
+	actions = slices.Clone(actions)
+	slices.Sort(actions)
+	key := notaryClientCacheKey{gun: gun, trustDir: trustDir, actions: strings.Join(actions, ",")}
+	return ccp.NotaryClientCache().get(key, newRepo)
+}
+
+// invalidateNotaryClient invalidates the cached repositories for the given
+// GUN, if cli has a [NotaryClientCache]. It's called after publishing trust
+// data for the GUN, after which cached repositories hold stale metadata.
+func invalidateNotaryClient(cli command.Streams, gun string) {
+	if ccp, ok := cli.(notaryClientCacheProvider); ok && ccp.NotaryClientCache() != nil {
+		ccp.NotaryClientCache().Invalidate(gun)
+	}
+}
@@ -0,0 +1,95 @@
+package trust
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cmd/docker-trust/internal/test"
+	notaryfake "github.com/docker/cli/cmd/docker-trust/internal/test/notary"
+	"github.com/docker/cli/cmd/docker-trust/internal/trust"
+	"github.com/theupdateframework/notary/client"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestNotaryClientCache(t *testing.T) {
+	cache := NewNotaryClientCache()
+	cli := WithNotaryClientCache(test.NewFakeCli(nil), cache)
+
+	var created int
+	newRepo := func() (client.Repository, error) {
+		created++
+		return notaryfake.GetOfflineNotaryRepository()
+	}
+	get := func(ctx context.Context, gun string, actions []string) {
+		t.Helper()
+		_, err := cachedNotaryClient(ctx, cli, gun, "/trust", actions, newRepo)
+		assert.NilError(t, err)
+	}
+
+	const alpine, busybox = "docker.io/library/alpine", "docker.io/library/busybox"
+	get(context.Background(), alpine, trust.ActionsPullOnly)
+	get(context.Background(), alpine, trust.ActionsPullOnly)
+	assert.Check(t, is.Equal(created, 1))
+
+	// repositories are cached separately for each set of actions.
+	get(context.Background(), alpine, trust.ActionsPushAndPull)
+	get(context.Background(), alpine, []string{"push", "pull"})
+	get(context.Background(), busybox, trust.ActionsPullOnly)
+	assert.Check(t, is.Equal(created, 3))
+
+	cache.Invalidate(alpine)
+	get(context.Background(), alpine, trust.ActionsPullOnly)
+	get(context.Background(), alpine, trust.ActionsPushAndPull)
+	get(context.Background(), busybox, trust.ActionsPullOnly)
+	assert.Check(t, is.Equal(created, 5))
+
+	cache.Clear()
+	get(context.Background(), busybox, trust.ActionsPullOnly)
+	assert.Check(t, is.Equal(created, 6))
+
+	// repositories are not cached for contexts that can be cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	get(ctx, busybox, trust.ActionsPullOnly)
+	assert.Check(t, is.Equal(created, 7))
+}
+
+func TestNotaryClientCacheNotSet(t *testing.T) {
+	var created int
+	newRepo := func() (client.Repository, error) {
+		created++
+		return notaryfake.GetOfflineNotaryRepository()
+	}
+	for _, cli := range []command.Streams{
+		test.NewFakeCli(nil),
+		WithNotaryClientCache(test.NewFakeCli(nil), nil),
+	} {
+		for range 2 {
+			_, err := cachedNotaryClient(context.Background(), cli, "docker.io/library/alpine", "/trust", trust.ActionsPullOnly, newRepo)
+			assert.NilError(t, err)
+		}
+	}
+	assert.Check(t, is.Equal(created, 4))
+}
+
+func TestNotaryClientCacheDummyClient(t *testing.T) {
+	fakeCLI := test.NewFakeCli(nil)
+	var calls int
+	fakeCLI.SetNotaryClient(func() (client.Repository, error) {
+		calls++
+		return notaryfake.GetOfflineNotaryRepository()
+	})
+	cache := NewNotaryClientCache()
+	cli := WithNotaryClientCache(fakeCLI, cache)
+
+	imgRefAndAuth, err := trust.GetImageReferencesAndAuth(context.Background(), authResolver(cli), "alpine")
+	assert.NilError(t, err)
+	for range 2 {
+		_, err := newNotaryClient(cli, imgRefAndAuth, trust.ActionsPullOnly)
+		assert.NilError(t, err)
+	}
+	assert.Check(t, is.Equal(calls, 2))
+	assert.Check(t, is.Len(cache.repos, 0))
+}
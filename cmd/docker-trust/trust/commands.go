@@ -1,20 +1,27 @@
 package trust
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/docker/cli-docs-tool/annotation"
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli-plugins/plugin"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/cli/cli/debug"
 	cliflags "github.com/docker/cli/cli/flags"
+	"github.com/docker/cli/cmd/docker-trust/internal/retry"
+	"github.com/docker/cli/cmd/docker-trust/internal/trust"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
 func NewRootCmd(name string, isPlugin bool, dockerCLI *command.DockerCli) *cobra.Command {
 	var opt rootOptions
+	trustCLI := withNotaryOptions(dockerCLI, &opt.notary)
 	cmd := &cobra.Command{
 		Use:   name,
 		Short: "Manage trust on Docker images",
@@ -41,9 +48,15 @@ func NewRootCmd(name string, isPlugin bool, dockerCLI *command.DockerCli) *cobra
 				options := cliflags.NewClientOptions()
 				options.InstallFlags(nflags)
 				options.SetDefaultOptions(nflags)
-				return dockerCLI.Initialize(options)
+				if err := dockerCLI.Initialize(options); err != nil {
+					return err
+				}
+			} else if err := plugin.PersistentPreRunE(cmd, args); err != nil {
+				return err
 			}
-			return plugin.PersistentPreRunE(cmd, args)
+			var err error
+			opt.notary, err = opt.notaryOptions(cmd.Flags(), dockerCLI.ConfigFile())
+			return err
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
@@ -65,17 +78,97 @@ func NewRootCmd(name string, isPlugin bool, dockerCLI *command.DockerCli) *cobra
 		cmd.DisableFlagsInUseLine = true
 	}
 
+	cmd.PersistentFlags().DurationVar(&opt.notaryTimeout, "notary-timeout", 0, "Timeout for requests to the Notary server (default: no timeout)")
+	cmd.PersistentFlags().StringVar(&opt.notaryTLSCert, "notary-tls-cert", "", "Path to the client certificate to present to the Notary server")
+	cmd.PersistentFlags().StringVar(&opt.notaryTLSKey, "notary-tls-key", "", "Path to the key of the client certificate to present to the Notary server")
+	retry.AddFlags(cmd.PersistentFlags(), &opt.retry)
+
 	cmd.AddCommand(
-		newRevokeCommand(dockerCLI),
-		newSignCommand(dockerCLI),
-		newTrustKeyCommand(dockerCLI),
-		newTrustSignerCommand(dockerCLI),
-		newInspectCommand(dockerCLI),
+		newRevokeCommand(trustCLI),
+		newSignCommand(trustCLI),
+		newTrustKeyCommand(trustCLI),
+		newTrustSignerCommand(trustCLI),
+		newInspectCommand(trustCLI),
 	)
 
 	return cmd
 }
 
 type rootOptions struct {
-	debug bool
+	debug         bool
+	notaryTimeout time.Duration
+	notaryTLSCert string
+	notaryTLSKey  string
+	retry         retry.Options
+
+	// notary holds the options for the Notary server that are set through
+	// the flags, environment, and config file, once the flags are parsed.
+	notary trust.NotaryOptions
+}
+
+// notaryOptions returns the options for the Notary server, as set through
+// the flags, which take precedence over the environment and config file.
+func (o *rootOptions) notaryOptions(flags *pflag.FlagSet, configFile *configfile.ConfigFile) (trust.NotaryOptions, error) {
+	if err := o.retry.LoadEnv(flags); err != nil {
+		return trust.NotaryOptions{}, err
+	}
+	timeout, err := notaryTimeout(configFile, o.notaryTimeout)
+	if err != nil {
+		return trust.NotaryOptions{}, err
+	}
+	certFile, keyFile, err := notaryClientCertificate(o.notaryTLSCert, o.notaryTLSKey)
+	if err != nil {
+		return trust.NotaryOptions{}, err
+	}
+	return trust.NotaryOptions{
+		Timeout: timeout,
+		Retry:   o.retry,
+		TLSCert: certFile,
+		TLSKey:  keyFile,
+	}, nil
+}
+
+// notaryTimeoutConfigKey is the name of the option in the "trust" section of
+// the "plugins" configuration in the CLI's config file to set the timeout for
+// requests to the Notary server.
+const notaryTimeoutConfigKey = "notary-timeout"
+
+// notaryTimeout returns the timeout for requests to the Notary server. The
+// --notary-timeout flag takes precedence over the DOCKER_CONTENT_TRUST_TIMEOUT
+// environment variable, which takes precedence over the config file. A zero
+// timeout keeps the default behavior.
+func notaryTimeout(configFile *configfile.ConfigFile, timeout time.Duration) (time.Duration, error) {
+	if timeout > 0 {
+		return timeout, nil
+	}
+	if _, ok := os.LookupEnv(trust.EnvNotaryTimeout); ok {
+		return trust.Timeout()
+	}
+	if v, ok := configFile.PluginConfig("trust", notaryTimeoutConfigKey); ok && v != "" {
+		timeout, err := trust.ParseTimeout(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s option in config file: %w", notaryTimeoutConfigKey, err)
+		}
+		return timeout, nil
+	}
+	return 0, nil
+}
+
+// notaryClientCertificate returns the client certificate and key to present
+// to the Notary server, for servers that require mutual TLS. The
+// --notary-tls-cert and --notary-tls-key flags take precedence over the
+// DOCKER_CONTENT_TRUST_TLS_CERT and DOCKER_CONTENT_TRUST_TLS_KEY environment
+// variables.
+func notaryClientCertificate(certFile, keyFile string) (string, string, error) {
+	if certFile == "" && keyFile == "" {
+		certFile, keyFile = os.Getenv(trust.EnvNotaryTLSCert), os.Getenv(trust.EnvNotaryTLSKey)
+		if (certFile == "") != (keyFile == "") {
+			return "", "", fmt.Errorf("%s and %s must be set together", trust.EnvNotaryTLSCert, trust.EnvNotaryTLSKey)
+		}
+		return certFile, keyFile, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return "", "", errors.New("--notary-tls-cert and --notary-tls-key must be used together")
+	}
+	return certFile, keyFile, nil
 }
@@ -0,0 +1,158 @@
+package trust
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cmd/docker-trust/internal/retry"
+	"github.com/docker/cli/cmd/docker-trust/internal/test"
+	"github.com/docker/cli/cmd/docker-trust/internal/trust"
+	"github.com/spf13/pflag"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestNotaryTimeout(t *testing.T) {
+	withConfig := func(timeout string) *configfile.ConfigFile {
+		cfg := configfile.New("")
+		if timeout != "" {
+			cfg.SetPluginConfig("trust", notaryTimeoutConfigKey, timeout)
+		}
+		return cfg
+	}
+
+	testCases := []struct {
+		doc         string
+		env         *string
+		config      string
+		flag        time.Duration
+		expected    time.Duration
+		expectedErr string
+	}{
+		{
+			doc: "default",
+		},
+		{
+			doc:      "flag",
+			flag:     10 * time.Second,
+			config:   "20s",
+			env:      ptr("30s"),
+			expected: 10 * time.Second,
+		},
+		{
+			doc:      "env",
+			config:   "20s",
+			env:      ptr("30s"),
+			expected: 30 * time.Second,
+		},
+		{
+			doc:      "config",
+			config:   "20s",
+			expected: 20 * time.Second,
+		},
+		{
+			doc:         "invalid config",
+			config:      "forever",
+			expectedErr: `invalid notary-timeout option in config file: "forever" is not a valid timeout`,
+		},
+		{
+			doc:         "invalid env",
+			env:         ptr("forever"),
+			expectedErr: `invalid value for DOCKER_CONTENT_TRUST_TIMEOUT: "forever" is not a valid timeout`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			// register cleanup to restore the original value
+			t.Setenv(trust.EnvNotaryTimeout, "")
+			if tc.env != nil {
+				t.Setenv(trust.EnvNotaryTimeout, *tc.env)
+			} else {
+				assert.NilError(t, os.Unsetenv(trust.EnvNotaryTimeout))
+			}
+
+			timeout, err := notaryTimeout(withConfig(tc.config), tc.flag)
+			if tc.expectedErr != "" {
+				assert.Check(t, is.ErrorContains(err, tc.expectedErr))
+				return
+			}
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(timeout, tc.expected))
+		})
+	}
+}
+
+func TestNotaryOptionsRetry(t *testing.T) {
+	t.Setenv(retry.EnvRetries, "3")
+	t.Setenv(retry.EnvMaxDelay, "5s")
+	t.Setenv(trust.EnvNotaryTimeout, "")
+	t.Setenv(trust.EnvNotaryTLSCert, "")
+	t.Setenv(trust.EnvNotaryTLSKey, "")
+
+	var opt rootOptions
+	flags := pflag.NewFlagSet("trust", pflag.ContinueOnError)
+	retry.AddFlags(flags, &opt.retry)
+	assert.NilError(t, flags.Parse([]string{"--retries=2"}))
+
+	opts, err := opt.notaryOptions(flags, configfile.New(""))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(opts.Retry.Retries, 2))
+	assert.Check(t, is.Equal(opts.Retry.MaxDelay, 5*time.Second))
+	assert.Check(t, is.Equal(os.Getenv(retry.EnvRetries), "3"), "the environment must not be changed")
+
+	t.Setenv(retry.EnvMaxDelay, "forever")
+	_, err = opt.notaryOptions(flags, configfile.New(""))
+	assert.Check(t, is.Error(err, `invalid value for DOCKER_CLI_RETRY_MAX_DELAY: "forever" is not a valid duration`))
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestNotaryClientCertificate(t *testing.T) {
+	t.Setenv(trust.EnvNotaryTLSCert, "env.cert")
+	t.Setenv(trust.EnvNotaryTLSKey, "env.key")
+
+	certFile, keyFile, err := notaryClientCertificate("", "")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(certFile, "env.cert"))
+	assert.Check(t, is.Equal(keyFile, "env.key"))
+
+	_, _, err = notaryClientCertificate("client.cert", "")
+	assert.Check(t, is.Error(err, "--notary-tls-cert and --notary-tls-key must be used together"))
+
+	certFile, keyFile, err = notaryClientCertificate("client.cert", "client.key")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(certFile, "client.cert"))
+	assert.Check(t, is.Equal(keyFile, "client.key"))
+	assert.Check(t, is.Equal(os.Getenv(trust.EnvNotaryTLSCert), "env.cert"), "the environment must not be changed")
+
+	t.Setenv(trust.EnvNotaryTLSKey, "")
+	_, _, err = notaryClientCertificate("", "")
+	assert.Check(t, is.Error(err, "DOCKER_CONTENT_TRUST_TLS_CERT and DOCKER_CONTENT_TRUST_TLS_KEY must be set together"))
+}
+
+func TestGetNotaryOptions(t *testing.T) {
+	t.Setenv(trust.EnvNotaryTimeout, "30s")
+	t.Setenv(trust.EnvNotaryTLSCert, "")
+	t.Setenv(trust.EnvNotaryTLSKey, "")
+
+	cli := test.NewFakeCli(&fakeClient{})
+	opts, err := getNotaryOptions(cli)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(opts.Timeout, 30*time.Second), "expected the options from the environment")
+
+	notaryOpts := trust.NotaryOptions{Timeout: 10 * time.Second}
+	trustCLI := withNotaryOptions(cli, &notaryOpts)
+	opts, err = getNotaryOptions(trustCLI)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(opts.Timeout, 10*time.Second))
+
+	// The options are read when they are used, after the flags are parsed.
+	notaryOpts.Timeout = 20 * time.Second
+	opts, err = getNotaryOptions(WithNotaryClientCache(trustCLI, NewNotaryClientCache()))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(opts.Timeout, 20*time.Second))
+}
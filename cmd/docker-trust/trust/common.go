@@ -4,7 +4,10 @@
 	"context"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/docker/cli/cli/command"
@@ -13,6 +16,7 @@
 	"github.com/docker/cli/cmd/docker-trust/internal/trust"
 	"github.com/fvbommel/sortorder"
 	registrytypes "github.com/moby/moby/api/types/registry"
+	"github.com/opencontainers/go-digest"
 	"github.com/sirupsen/logrus"
 	"github.com/theupdateframework/notary"
 	"github.com/theupdateframework/notary/client"
@@ -25,8 +29,9 @@ type trustTagKey struct {
 	Digest    string
 }
 
-// trustTagRow encodes all human-consumable information for a signed tag, including signers
-type trustTagRow struct {
+// ReleasedTag encodes all human-consumable information for a signed tag, including signers.
+// A tag is released if it was signed into the "targets" or "targets/releases" role.
+type ReleasedTag struct {
 	trustTagKey
 	Signers []string
 }
@@ -34,16 +39,31 @@ type trustTagRow struct {
 // trustRepo represents consumable information about a trusted repository
 type trustRepo struct {
 	Name               string
-	SignedTags         []trustTagRow
+	SignedTags         []ReleasedTag
 	Signers            []trustSigner
 	AdministrativeKeys []trustSigner
 }
 
+// Repository returns the name of the repository, for use in templates.
+func (r trustRepo) Repository() string {
+	return r.Name
+}
+
+// AdminRoles returns the administrative roles and their keys, for use in
+// templates.
+func (r trustRepo) AdminRoles() []trustSigner {
+	return r.AdministrativeKeys
+}
+
 // trustSigner represents a trusted signer in a trusted repository
 // a signer is defined by a name and list of trustKeys
 type trustSigner struct {
 	Name string     `json:",omitempty"`
 	Keys []trustKey `json:",omitempty"`
+	// Threshold is the number of keys of the signer that must sign. It is
+	// omitted for administrative keys, and for signers that group nested
+	// delegation roles.
+	Threshold int `json:",omitempty"`
 }
 
 // trustKey contains information about trusted keys
@@ -56,48 +76,279 @@ type notaryClientProvider interface {
 	NotaryClient() (client.Repository, error)
 }
 
+// getNotaryClientProvider returns the notaryClientProvider of cli, if any,
+// including that of a CLI that is wrapped by [WithNotaryClientCache], so
+// that dummy notary clients take precedence over cached ones.
+func getNotaryClientProvider(cli command.Streams) (notaryClientProvider, bool) {
+	if c, ok := cli.(*cachingCli); ok {
+		cli = c.Cli
+	}
+	ncp, ok := cli.(notaryClientProvider)
+	return ncp, ok
+}
+
+// notaryOptionsProvider is implemented by a CLI that holds the options for
+// the Notary server, as returned by [withNotaryOptions].
+type notaryOptionsProvider interface {
+	NotaryOptions() trust.NotaryOptions
+}
+
+// withNotaryOptions returns a CLI that provides the options for the Notary
+// server that opts points to. The options are read when a notary client is
+// created, so that they can be set after the subcommands are created, once
+// the flags are parsed.
+func withNotaryOptions(dockerCLI command.Cli, opts *trust.NotaryOptions) command.Cli {
+	return &notaryOptionsCli{Cli: dockerCLI, opts: opts}
+}
+
+type notaryOptionsCli struct {
+	command.Cli
+	opts *trust.NotaryOptions
+}
+
+func (c *notaryOptionsCli) NotaryOptions() trust.NotaryOptions {
+	return *c.opts
+}
+
+// getNotaryOptions returns the options for the Notary server of cli,
+// including that of a CLI that is wrapped by [WithNotaryClientCache], or the
+// options that are set through the environment if cli has none.
+func getNotaryOptions(cli command.Streams) (trust.NotaryOptions, error) {
+	if c, ok := cli.(*cachingCli); ok {
+		cli = c.Cli
+	}
+	if nop, ok := cli.(notaryOptionsProvider); ok {
+		return nop.NotaryOptions(), nil
+	}
+	return trust.NotaryOptionsFromEnv()
+}
+
 // newNotaryClient provides a Notary Repository to interact with signed metadata for an image.
 func newNotaryClient(cli command.Streams, imgRefAndAuth trust.ImageRefAndAuth, actions []string) (client.Repository, error) {
-	if ncp, ok := cli.(notaryClientProvider); ok {
+	return newNotaryClientInDir(cli, imgRefAndAuth, trust.GetTrustDirectory(), actions)
+}
+
+// newNotaryClientInDir is like newNotaryClient, but uses the given trust
+// directory for keys and cached metadata.
+func newNotaryClientInDir(cli command.Streams, imgRefAndAuth trust.ImageRefAndAuth, trustDir string, actions []string) (client.Repository, error) {
+	return newNotaryClientWithProgress(context.Background(), cli, imgRefAndAuth, trustDir, nil, actions)
+}
+
+// newNotaryClientWithProgress is like newNotaryClientInDir, but calls progress
+// with the name of each role of which the metadata is downloaded, if progress
+// is not nil. Requests to the notary server are bound to ctx.
+func newNotaryClientWithProgress(ctx context.Context, cli command.Streams, imgRefAndAuth trust.ImageRefAndAuth, trustDir string, progress func(role string), actions []string) (client.Repository, error) {
+	if ncp, ok := getNotaryClientProvider(cli); ok {
+		// notaryClientProvider is used in tests to provide a dummy notary client.
+		return ncp.NotaryClient()
+	}
+	opts, err := getNotaryOptions(cli)
+	if err != nil {
+		return nil, err
+	}
+	opts.TrustDir = trustDir
+	opts.Progress = progress
+	newRepo := func() (client.Repository, error) {
+		return trust.NewNotaryRepository(ctx, opts, cli.In(), cli.Out(), command.UserAgent(), imgRefAndAuth.RepoInfo(), imgRefAndAuth.AuthConfig(), actions...)
+	}
+	if progress != nil {
+		// The progress callback is bound to the repository, and specific
+		// to this call.
+		return newRepo()
+	}
+	return cachedNotaryClient(ctx, cli, imgRefAndAuth.RepoInfo().Name.Name(), trustDir, actions, newRepo)
+}
+
+// fetchProgress prints a single, updating line on a terminal with the role
+// of which the trust metadata of a repository is being downloaded.
+type fetchProgress struct {
+	out     io.Writer
+	remote  string
+	printed bool
+}
+
+// update replaces the progress line with the given role.
+func (p *fetchProgress) update(role string) {
+	_, _ = fmt.Fprintf(p.out, "\r\033[KFetching trust data for %s: %s", p.remote, role)
+	p.printed = true
+}
+
+// done clears the progress line, if anything was printed.
+func (p *fetchProgress) done() {
+	if p.printed {
+		_, _ = fmt.Fprint(p.out, "\r\033[K")
+		p.printed = false
+	}
+}
+
+// newOfflineNotaryClient provides a Notary Repository that reads the signed
+// metadata for an image from the cache in the given trust directory, without
+// contacting the server.
+func newOfflineNotaryClient(cli command.Streams, imgRefAndAuth trust.ImageRefAndAuth, trustDir string) (client.Repository, error) {
+	if ncp, ok := getNotaryClientProvider(cli); ok {
 		// notaryClientProvider is used in tests to provide a dummy notary client.
 		return ncp.NotaryClient()
 	}
-	return trust.GetNotaryRepository(cli.In(), cli.Out(), command.UserAgent(), imgRefAndAuth.RepoInfo(), imgRefAndAuth.AuthConfig(), actions...)
+	return trust.GetOfflineNotaryRepository(trustDir, cli.In(), cli.Out(), imgRefAndAuth.RepoInfo())
+}
+
+// InspectResult is the trust information of a repository, as returned by [Inspect].
+type InspectResult struct {
+	// Name is the name of the repository or image, as passed to Inspect.
+	Name string
+	// SignedTags are the released signatures, sorted by tag name.
+	SignedTags []ReleasedTag
+	// Signers are the key IDs of each signer, by name of the signer.
+	Signers map[string][]string
+	// AdminRoles are the administrative roles of the repository, and their keys.
+	AdminRoles []client.RoleWithSignatures
+	// Offline is set if the information was read from the local cache by
+	// [InspectOffline], in which case it may be out of date.
+	Offline bool
+	// UnreadableRoles are the roles of which the signed targets could not be
+	// read. Their signatures are missing from SignedTags.
+	UnreadableRoles []data.RoleName
+
+	delegationRoles []data.Role
+	trustDir        string
+	// signerFilter holds the signers that SignedTags were filtered by, if any.
+	signerFilter []string
+	// digest is the digest of the reference, if it's a digest reference, in
+	// which case SignedTags only holds the tags that point at it.
+	digest digest.Digest
+	// attestations holds the attestation manifests of the local image, if
+	// they were looked up.
+	attestations *attestationInfo
+}
+
+// bySigners returns the signers that SignedTags were filtered by, for
+// messages about missing signatures, for example " by alice or bob", or an
+// empty string if SignedTags were not filtered.
+func (r InspectResult) bySigners() string {
+	if len(r.signerFilter) == 0 {
+		return ""
+	}
+	return " by " + strings.Join(r.signerFilter, " or ")
+}
+
+// noSignatures returns a message stating that SignedTags is empty, for
+// example "no signatures by alice for example/repo", or "no released tag
+// points at sha256:... in example/repo" for a digest reference.
+func (r InspectResult) noSignatures() string {
+	if r.digest != "" {
+		return fmt.Sprintf("no released tag%s points at %s in %s", r.bySigners(), r.digest, repositoryName(r.Name))
+	}
+	return fmt.Sprintf("no signatures%s for %s", r.bySigners(), r.Name)
 }
 
-// lookupTrustInfo returns processed signature and role information about a notary repository.
-// This information is to be pretty printed or serialized into a machine-readable format.
-func lookupTrustInfo(ctx context.Context, cli command.Cli, remote string) ([]trustTagRow, []client.RoleWithSignatures, []data.Role, error) {
-	imgRefAndAuth, err := trust.GetImageReferencesAndAuth(ctx, authResolver(cli), remote)
+// GroupedSigners returns the key IDs of each signer, with the signers of
+// nested delegation roles below maxDepth grouped with their ancestor, for
+// example "docker/...". It is equal to Signers if maxDepth is zero.
+func (r InspectResult) GroupedSigners(maxDepth int) map[string][]string {
+	return getDelegationRoleToKeyMap(r.delegationRoles, maxDepth)
+}
+
+// signerThresholds returns the signing threshold of each signer, by name of
+// the signer, as grouped by [InspectResult.GroupedSigners]. Signers that
+// group nested delegation roles below maxDepth have no single threshold, and
+// are omitted.
+func (r InspectResult) signerThresholds(maxDepth int) map[string]int {
+	thresholds := make(map[string]int)
+	for _, role := range r.delegationRoles {
+		signer := NotaryRoleToSigner(role.Name)
+		if truncateSigner(signer, maxDepth) == signer {
+			thresholds[signer] = role.Threshold
+		}
+	}
+	return thresholds
+}
+
+// Inspect returns the signatures, signers and administrative keys of a
+// repository, or of a single tag if remote includes a tag. It is used by
+// "docker trust inspect" to print the information in either format.
+func Inspect(ctx context.Context, dockerCLI command.Cli, remote string) (InspectResult, error) {
+	return inspectTrustInfo(ctx, dockerCLI, remote, false, trust.GetTrustDirectory(), true)
+}
+
+// InspectOffline is like [Inspect], but reads the trust information from the
+// metadata that was cached in the local trust directory by earlier commands,
+// without contacting the notary server. It returns an error if no metadata
+// was cached for the repository.
+func InspectOffline(ctx context.Context, dockerCLI command.Cli, remote string) (InspectResult, error) {
+	return inspectTrustInfo(ctx, dockerCLI, remote, true, trust.GetTrustDirectory(), true)
+}
+
+// inspectTrustInfo returns the trust information of a repository, using the
+// keys and cached metadata in trustDir. If offline is set, the information is
+// only read from the cache. Otherwise, requests to the notary server are
+// bound to ctx, and if showProgress is set, a progress line is shown on
+// stderr if it is a terminal.
+func inspectTrustInfo(ctx context.Context, dockerCLI command.Cli, remote string, offline bool, trustDir string, showProgress bool) (InspectResult, error) {
+	imgRefAndAuth, err := trust.GetImageReferencesAndAuth(ctx, authResolver(dockerCLI), remote)
 	if err != nil {
-		return []trustTagRow{}, []client.RoleWithSignatures{}, []data.Role{}, err
+		return InspectResult{}, err
 	}
 	tag := imgRefAndAuth.Tag()
-	notaryRepo, err := newNotaryClient(cli, imgRefAndAuth, trust.ActionsPullOnly)
+	var notaryRepo client.Repository
+	if offline {
+		if !hasCachedTrustData(trustDir, remote) {
+			return InspectResult{}, fmt.Errorf("no cached trust data for %s: inspect it without --offline first to download its trust data", remote)
+		}
+		notaryRepo, err = newOfflineNotaryClient(dockerCLI, imgRefAndAuth, trustDir)
+	} else {
+		// Only show progress on a terminal, to not clutter logs and scripts.
+		var progress func(role string)
+		if showProgress && dockerCLI.Err().IsTerminal() {
+			p := &fetchProgress{out: dockerCLI.Err(), remote: remote}
+			defer p.done()
+			progress = p.update
+		}
+		notaryRepo, err = newNotaryClientWithProgress(ctx, dockerCLI, imgRefAndAuth, trustDir, progress, trust.ActionsPullOnly)
+	}
 	if err != nil {
-		return []trustTagRow{}, []client.RoleWithSignatures{}, []data.Role{}, trust.NotaryError(imgRefAndAuth.Reference().Name(), err)
+		return InspectResult{}, trust.NotaryError(imgRefAndAuth.Reference().Name(), err)
 	}
 
 	if err = clearChangeList(notaryRepo); err != nil {
-		return []trustTagRow{}, []client.RoleWithSignatures{}, []data.Role{}, err
+		return InspectResult{}, err
 	}
 	defer clearChangeList(notaryRepo)
 
-	// Retrieve all released signatures, match them, and pretty print them
+	// Retrieve all released signatures, and match them
+	var unreadableRoles []data.RoleName
 	allSignedTargets, err := notaryRepo.GetAllTargetMetadataByName(tag)
 	if err != nil {
 		logrus.Debug(trust.NotaryError(remote, err))
-		// print an empty table if we don't have signed targets, but have an initialized notary repo
+		// return no signatures if we don't have signed targets, but have an initialized notary repo
 		if _, ok := err.(client.ErrNoSuchTarget); !ok {
-			return []trustTagRow{}, []client.RoleWithSignatures{}, []data.Role{}, fmt.Errorf("no signatures or cannot access %s", remote)
+			// The targets of one of the roles may not be readable, so read
+			// them per role to return the signatures of the other roles.
+			allSignedTargets, unreadableRoles, err = getTargetsByRole(notaryRepo, tag)
+			if err != nil {
+				logrus.Debug(trust.NotaryError(remote, err))
+				if offline {
+					return InspectResult{}, fmt.Errorf("no signatures in cached trust data for %s", remote)
+				}
+				if connErr := notaryConnectionError(remote, err); connErr != nil {
+					return InspectResult{}, connErr
+				}
+				if isUninitialized(err) {
+					return InspectResult{}, ErrTrustUninitialized{Remote: remote, Err: err}
+				}
+				return InspectResult{}, fmt.Errorf("no signatures or cannot access %s", remote)
+			}
 		}
 	}
 	signatureRows := matchReleasedSignatures(allSignedTargets)
+	if dgst := imgRefAndAuth.Digest(); dgst != "" {
+		// Report which released tags point at the digest.
+		signatureRows = filterByDigest(signatureRows, dgst)
+	}
 
 	// get the administrative roles
 	adminRolesWithSigs, err := notaryRepo.ListRoles()
 	if err != nil {
-		return []trustTagRow{}, []client.RoleWithSignatures{}, []data.Role{}, fmt.Errorf("no signers for %s", remote)
+		return InspectResult{}, fmt.Errorf("no signers for %s", remote)
 	}
 
 	// get delegation roles with the canonical key IDs
@@ -106,46 +357,159 @@ func lookupTrustInfo(ctx context.Context, cli command.Cli, remote string) ([]tru
 		logrus.Debugf("no delegation roles found, or error fetching them for %s: %v", remote, err)
 	}
 
-	return signatureRows, adminRolesWithSigs, delegationRoles, nil
+	return InspectResult{
+		Name:            remote,
+		SignedTags:      signatureRows,
+		Signers:         getDelegationRoleToKeyMap(delegationRoles, 0),
+		AdminRoles:      adminRolesWithSigs,
+		Offline:         offline,
+		UnreadableRoles: unreadableRoles,
+		delegationRoles: delegationRoles,
+		trustDir:        trustDir,
+		digest:          imgRefAndAuth.Digest(),
+	}, nil
 }
 
 func formatAdminRole(roleWithSigs client.RoleWithSignatures) string {
 	adminKeyList := roleWithSigs.KeyIDs
 	sort.Strings(adminKeyList)
 
-	var role string
-	switch roleWithSigs.Name {
+	role := adminRoleName(roleWithSigs.Name)
+	if role == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:\t%s\n", role, strings.Join(adminKeyList, ", "))
+}
+
+// adminRoleName returns the display name of an administrative role, or an
+// empty string if the role is not an administrative role.
+func adminRoleName(role data.RoleName) string {
+	switch role {
 	case data.CanonicalTargetsRole:
-		role = "Repository Key"
+		return "Repository Key"
 	case data.CanonicalRootRole:
-		role = "Root Key"
+		return "Root Key"
 	default:
 		return ""
 	}
-	return fmt.Sprintf("%s:\t%s\n", role, strings.Join(adminKeyList, ", "))
 }
 
-func getDelegationRoleToKeyMap(rawDelegationRoles []data.Role) map[string][]string {
+// getDelegationRoleToKeyMap returns the key IDs of each signer. If maxDepth
+// is larger than zero, signers of nested delegation roles below that depth
+// are grouped with their ancestor at maxDepth; see [truncateSigner].
+func getDelegationRoleToKeyMap(rawDelegationRoles []data.Role, maxDepth int) map[string][]string {
 	signerRoleToKeyIDs := make(map[string][]string)
 	for _, delRole := range rawDelegationRoles {
 		switch delRole.Name {
 		case trust.ReleasesRole, data.CanonicalRootRole, data.CanonicalSnapshotRole, data.CanonicalTargetsRole, data.CanonicalTimestampRole:
 			continue
 		default:
-			signerRoleToKeyIDs[notaryRoleToSigner(delRole.Name)] = delRole.KeyIDs
+			signer := truncateSigner(NotaryRoleToSigner(delRole.Name), maxDepth)
+			for _, keyID := range delRole.KeyIDs {
+				if !slices.Contains(signerRoleToKeyIDs[signer], keyID) {
+					signerRoleToKeyIDs[signer] = append(signerRoleToKeyIDs[signer], keyID)
+				}
+			}
 		}
 	}
 	return signerRoleToKeyIDs
 }
 
+// truncatedSignerSuffix is the suffix of the name of a signer that groups
+// nested delegation roles below the maximum depth.
+const truncatedSignerSuffix = "/..."
+
+// truncateSigner truncates the slash-separated name of a signer of a nested
+// delegation role to maxDepth levels, and annotates it with "/..." if it was
+// truncated. For example, "docker/signer" is truncated to "docker/..." with
+// a maximum depth of 1. Names are not truncated if maxDepth is zero.
+func truncateSigner(signer string, maxDepth int) string {
+	if maxDepth <= 0 {
+		return signer
+	}
+	parts := strings.SplitN(signer, "/", maxDepth+1)
+	if len(parts) <= maxDepth {
+		return signer
+	}
+	return strings.Join(parts[:maxDepth], "/") + truncatedSignerSuffix
+}
+
+// signerDepth returns the depth of a signer in the hierarchy of delegation
+// roles, for example "1" for "alice", and "2" for "docker/signer". For
+// signers that were truncated, it returns the depth of the nested roles,
+// for example "2+" for "docker/...".
+func signerDepth(signer string) string {
+	if base, ok := strings.CutSuffix(signer, truncatedSignerSuffix); ok {
+		return strconv.Itoa(strings.Count(base, "/")+2) + "+"
+	}
+	return strconv.Itoa(strings.Count(signer, "/") + 1)
+}
+
 // aggregate all signers for a "released" hash+tagname pair. To be "released," the tag must have been
 // signed into the "targets" or "targets/releases" role. Output is sorted by tag name
-func matchReleasedSignatures(allTargets []client.TargetSignedStruct) []trustTagRow {
-	signatureRows := []trustTagRow{}
+// getTargetsByRole returns the targets with the given name, or all targets if
+// name is empty, of the targets role and of each delegation role separately,
+// and the roles of which the targets could not be read. It's used if the
+// targets of all roles cannot be read at once, and returns an error if the
+// targets of none of the roles could be read.
+func getTargetsByRole(notaryRepo client.Repository, name string) ([]client.TargetSignedStruct, []data.RoleName, error) {
+	delegationRoles, err := notaryRepo.GetDelegationRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+	roles := []data.RoleName{data.CanonicalTargetsRole}
+	for _, role := range delegationRoles {
+		roles = append(roles, role.Name)
+	}
+
+	type targetKey struct {
+		role   data.RoleName
+		name   string
+		digest string
+	}
+	var (
+		targets  []client.TargetSignedStruct
+		seen     = make(map[targetKey]bool)
+		failed   []data.RoleName
+		firstErr error
+	)
+	for _, role := range roles {
+		roleTargets, err := notaryRepo.ListTargets(role)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"role": role, "error": err}).Debug("failed to read the targets of role")
+			failed = append(failed, role)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, tgt := range roleTargets {
+			if name != "" && tgt.Name != name {
+				continue
+			}
+			key := targetKey{role: tgt.Role, name: tgt.Name, digest: hex.EncodeToString(tgt.Hashes[notary.SHA256])}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			targets = append(targets, client.TargetSignedStruct{
+				Role:   data.DelegationRole{BaseRole: data.BaseRole{Name: tgt.Role}},
+				Target: tgt.Target,
+			})
+		}
+	}
+	if len(failed) == len(roles) {
+		return nil, nil, firstErr
+	}
+	return targets, failed, nil
+}
+
+func matchReleasedSignatures(allTargets []client.TargetSignedStruct) []ReleasedTag {
+	signatureRows := []ReleasedTag{}
 	// do a first pass to get filter on tags signed into "targets" or "targets/releases"
 	releasedTargetRows := map[trustTagKey][]string{}
 	for _, tgt := range allTargets {
-		if isReleasedTarget(tgt.Role.Name) {
+		if IsReleasedTarget(tgt.Role.Name) {
 			releasedKey := trustTagKey{tgt.Target.Name, hex.EncodeToString(tgt.Target.Hashes[notary.SHA256])}
 			releasedTargetRows[releasedKey] = []string{}
 		}
@@ -155,14 +519,14 @@ func matchReleasedSignatures(allTargets []client.TargetSignedStruct) []trustTagR
 	for _, tgt := range allTargets {
 		targetKey := trustTagKey{tgt.Target.Name, hex.EncodeToString(tgt.Target.Hashes[notary.SHA256])}
 		// only considered released targets
-		if _, ok := releasedTargetRows[targetKey]; ok && !isReleasedTarget(tgt.Role.Name) {
-			releasedTargetRows[targetKey] = append(releasedTargetRows[targetKey], notaryRoleToSigner(tgt.Role.Name))
+		if _, ok := releasedTargetRows[targetKey]; ok && !IsReleasedTarget(tgt.Role.Name) {
+			releasedTargetRows[targetKey] = append(releasedTargetRows[targetKey], NotaryRoleToSigner(tgt.Role.Name))
 		}
 	}
 
 	// compile the final output as a sorted slice
 	for targetKey, signers := range releasedTargetRows {
-		signatureRows = append(signatureRows, trustTagRow{targetKey, signers})
+		signatureRows = append(signatureRows, ReleasedTag{targetKey, signers})
 	}
 	sort.Slice(signatureRows, func(i, j int) bool {
 		return sortorder.NaturalLess(signatureRows[i].SignedTag, signatureRows[j].SignedTag)
@@ -170,6 +534,37 @@ func matchReleasedSignatures(allTargets []client.TargetSignedStruct) []trustTagR
 	return signatureRows
 }
 
+// filterBySigners returns the signed tags that were signed by any of the
+// given signers. Tags that are only signed with the repository key match
+// the "Repo Admin" signer.
+func filterBySigners(signatureRows []ReleasedTag, signers []string) []ReleasedTag {
+	filtered := []ReleasedTag{}
+	for _, row := range signatureRows {
+		rowSigners := row.Signers
+		if len(rowSigners) == 0 {
+			rowSigners = []string{releasedRoleName}
+		}
+		for _, signer := range signers {
+			if slices.Contains(rowSigners, signer) {
+				filtered = append(filtered, row)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// filterByDigest returns the signed tags that point at the given digest.
+func filterByDigest(signatureRows []ReleasedTag, dgst digest.Digest) []ReleasedTag {
+	filtered := []ReleasedTag{}
+	for _, row := range signatureRows {
+		if row.Digest == dgst.Encoded() {
+			filtered = append(filtered, row)
+		}
+	}
+	return filtered
+}
+
 // authResolver returns an auth resolver function from a [config.Provider].
 func authResolver(dockerCLI config.Provider) func(ctx context.Context, index *registrytypes.IndexInfo) registrytypes.AuthConfig {
 	return func(ctx context.Context, index *registrytypes.IndexInfo) registrytypes.AuthConfig {
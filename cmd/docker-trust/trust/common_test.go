@@ -1,10 +1,18 @@
 package trust
 
 import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
 	"testing"
 
 	"github.com/docker/cli/cmd/docker-trust/internal/trust"
+	"github.com/opencontainers/go-digest"
 	"github.com/theupdateframework/notary/client"
+	"github.com/theupdateframework/notary/storage"
 	"github.com/theupdateframework/notary/tuf/data"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
@@ -31,3 +39,101 @@ func TestMatchReleasedSignaturesSortOrder(t *testing.T) {
 	}
 	assert.Check(t, is.DeepEqual(expected, targetNames))
 }
+
+func TestFilterByDigest(t *testing.T) {
+	dgst := digest.Digest("sha256:" + strings.Repeat("a", 64))
+	rows := []ReleasedTag{
+		{trustTagKey: trustTagKey{SignedTag: "1.0", Digest: dgst.Encoded()}},
+		{trustTagKey: trustTagKey{SignedTag: "latest", Digest: dgst.Encoded()}},
+		{trustTagKey: trustTagKey{SignedTag: "2.0", Digest: strings.Repeat("b", 64)}},
+	}
+	var tags []string
+	for _, row := range filterByDigest(rows, dgst) {
+		tags = append(tags, row.SignedTag)
+	}
+	assert.Check(t, is.DeepEqual(tags, []string{"1.0", "latest"}))
+	assert.Check(t, is.Len(filterByDigest(rows, "sha256:"+digest.Digest(strings.Repeat("c", 64))), 0))
+}
+
+func TestNoSignatures(t *testing.T) {
+	dgst := digest.Digest("sha256:" + strings.Repeat("a", 64))
+	testCases := []struct {
+		info     InspectResult
+		expected string
+	}{
+		{
+			info:     InspectResult{Name: "example/repo"},
+			expected: "no signatures for example/repo",
+		},
+		{
+			info:     InspectResult{Name: "example/repo", signerFilter: []string{"alice", "bob"}},
+			expected: "no signatures by alice or bob for example/repo",
+		},
+		{
+			info:     InspectResult{Name: "example/repo@" + dgst.String(), digest: dgst},
+			expected: "no released tag points at " + dgst.String() + " in example/repo",
+		},
+	}
+	for _, tc := range testCases {
+		assert.Check(t, is.Equal(tc.info.noSignatures(), tc.expected))
+	}
+}
+
+func TestFetchProgress(t *testing.T) {
+	var out bytes.Buffer
+	p := &fetchProgress{out: &out, remote: "alpine"}
+	p.done()
+	assert.Check(t, is.Equal(out.String(), ""))
+
+	p.update("root")
+	p.update("targets")
+	p.done()
+	expected := "\r\033[KFetching trust data for alpine: root" +
+		"\r\033[KFetching trust data for alpine: targets" +
+		"\r\033[K"
+	assert.Check(t, is.Equal(out.String(), expected))
+}
+
+func TestNotaryConnectionError(t *testing.T) {
+	testCases := []struct {
+		doc      string
+		err      error
+		expected string
+	}{
+		{
+			doc: "not a network error",
+			err: storage.ErrMetaNotFound{Resource: "targets"},
+		},
+		{
+			doc:      "offline",
+			err:      storage.ErrOffline{},
+			expected: "no signatures or cannot access signed-repo",
+		},
+		{
+			doc:      "connection refused",
+			err:      storage.NetworkError{Wrapped: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+			expected: "cannot access signed-repo: cannot connect to the notary server: dial tcp: connection refused",
+		},
+		{
+			doc:      "certificate required",
+			err:      storage.NetworkError{Wrapped: &net.OpError{Op: "remote error", Err: errors.New("tls: certificate required")}},
+			expected: "cannot access signed-repo: TLS handshake with the notary server failed: remote error: tls: certificate required (if the server requires a client certificate, set --notary-tls-cert and --notary-tls-key)",
+		},
+		{
+			doc:      "unknown authority",
+			err:      storage.NetworkError{Wrapped: x509.UnknownAuthorityError{}},
+			expected: "cannot access signed-repo: TLS handshake with the notary server failed: x509: certificate signed by unknown authority (if the server requires a client certificate, set --notary-tls-cert and --notary-tls-key)",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			err := notaryConnectionError("signed-repo", tc.err)
+			if tc.expected == "" {
+				assert.Check(t, is.Nil(err))
+				return
+			}
+			assert.Check(t, is.Error(err, tc.expected))
+			assert.Check(t, errors.As(err, &ErrTrustOffline{}))
+		})
+	}
+}
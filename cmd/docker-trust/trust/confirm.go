@@ -0,0 +1,30 @@
+package trust
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/internal/prompt"
+)
+
+// envAssumeYes is the environment variable to automatically answer yes to
+// confirmation prompts. The docker CLI sets it when using the "--assume-yes"
+// global option.
+const envAssumeYes = "DOCKER_CLI_ASSUME_YES"
+
+// confirm requests confirmation from the user, using the given message. It
+// returns true without prompting if DOCKER_CLI_ASSUME_YES is set, and false
+// without prompting if stdin is not a terminal.
+func confirm(ctx context.Context, dockerCLI command.Streams, message string) (bool, error) {
+	if assumeYes, _ := strconv.ParseBool(os.Getenv(envAssumeYes)); assumeYes {
+		return true, nil
+	}
+	if !dockerCLI.In().IsTerminal() {
+		_, _ = fmt.Fprintf(dockerCLI.Err(), "%s\nNot prompting for confirmation as stdin is not a terminal; use --assume-yes (-y) or set %s=1 to confirm.\n", message, envAssumeYes)
+		return false, nil
+	}
+	return prompt.Confirm(ctx, dockerCLI.In(), dockerCLI.Out(), message)
+}
@@ -1,8 +1,10 @@
 package trust
 
 import (
+	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/docker/cli/cli/command/formatter"
 )
@@ -15,24 +17,44 @@
 	defaultSignerInfoTableFormat = "table {{.Signer}}\t{{.Keys}}"
 	signerNameHeader             = "SIGNER"
 	keysHeader                   = "KEYS"
+
+	signerInfoWithTimesTableFormat = "table {{.Signer}}\t{{.Keys}}\t{{.LastSigned}}"
+	lastSignedHeader               = "LAST SIGNED"
+
+	signerInfoVerboseTableFormat          = "table {{.Signer}}\t{{.Keys}}\t{{.Threshold}}"
+	signerInfoVerboseWithTimesTableFormat = "table {{.Signer}}\t{{.Keys}}\t{{.Threshold}}\t{{.LastSigned}}"
+	thresholdHeader                       = "THRESHOLD"
+
+	depthHeader = "DEPTH"
+
+	repositoryTrustTagTableFormat = "table {{.Repository}}\t{{.SignedTag}}\t{{.Digest}}\t{{.Signers}}"
+	repositoryHeader              = "REPOSITORY"
 )
 
 // signedTagInfo represents all formatted information needed to describe a signed tag:
+// Repository: name of the repository, if tags of multiple repositories are printed
 // Name: name of the signed tag
 // Digest: hex encoded digest of the contents
 // Signers: list of entities who signed the tag
+// RepoAdminOnly: whether the tag is only signed with the repository key
 type signedTagInfo struct {
-	Name    string
-	Digest  string
-	Signers []string
+	Repository    string
+	Name          string
+	Digest        string
+	Signers       []string
+	RepoAdminOnly bool
 }
 
 // signerInfo represents all formatted information needed to describe a signer:
 // Name: name of the signer role
 // Keys: the keys associated with the signer
+// Threshold: the number of keys that must sign, if known
+// LastSigned: the (estimated) time the signer last signed, if known
 type signerInfo struct {
-	Name string
-	Keys []string
+	Name       string
+	Keys       []string
+	Threshold  int
+	LastSigned time.Time
 }
 
 // tagWrite writes the context
@@ -40,9 +62,10 @@ func tagWrite(fmtCtx formatter.Context, signedTagInfoList []signedTagInfo) error
 	trustTagCtx := &trustTagContext{
 		HeaderContext: formatter.HeaderContext{
 			Header: formatter.SubHeaderContext{
-				"SignedTag": signedTagNameHeader,
-				"Digest":    trustedDigestHeader,
-				"Signers":   signersHeader,
+				"Repository": repositoryHeader,
+				"SignedTag":  signedTagNameHeader,
+				"Digest":     trustedDigestHeader,
+				"Signers":    signersHeader,
 			},
 		},
 	}
@@ -61,6 +84,11 @@ type trustTagContext struct {
 	s signedTagInfo
 }
 
+// Repository returns the name of the repository of the signed tag
+func (c *trustTagContext) Repository() string {
+	return c.s.Repository
+}
+
 // SignedTag returns the name of the signed tag
 func (c *trustTagContext) SignedTag() string {
 	return c.s.Name
@@ -79,17 +107,20 @@ func (c *trustTagContext) Signers() string {
 
 // signerInfoWrite writes the context.
 func signerInfoWrite(fmtCtx formatter.Context, signerInfoList []signerInfo) error {
-	signerInfoCtx := &signerInfoContext{
+	signerInfoCtx := &SignerInfoContext{
 		HeaderContext: formatter.HeaderContext{
 			Header: formatter.SubHeaderContext{
-				"Signer": signerNameHeader,
-				"Keys":   keysHeader,
+				"Signer":     signerNameHeader,
+				"Depth":      depthHeader,
+				"Keys":       keysHeader,
+				"Threshold":  thresholdHeader,
+				"LastSigned": lastSignedHeader,
 			},
 		},
 	}
 	return fmtCtx.Write(signerInfoCtx, func(format func(subContext formatter.SubContext) error) error {
 		for _, info := range signerInfoList {
-			if err := format(&signerInfoContext{
+			if err := format(&SignerInfoContext{
 				trunc: fmtCtx.Trunc,
 				s:     info,
 			}); err != nil {
@@ -100,26 +131,61 @@ func signerInfoWrite(fmtCtx formatter.Context, signerInfoList []signerInfo) erro
 	})
 }
 
-type signerInfoContext struct {
+// SignerInfoContext is the context for formatting signers with a Go template,
+// as used for the signer table of "docker trust inspect --pretty".
+type SignerInfoContext struct {
 	formatter.HeaderContext
 	trunc bool
 	s     signerInfo
 }
 
 // Keys returns the sorted list of keys associated with the signer
-func (c *signerInfoContext) Keys() string {
+func (c *SignerInfoContext) Keys() signerKeys {
 	sort.Strings(c.s.Keys)
-	truncatedKeys := []string{}
 	if c.trunc {
+		truncatedKeys := make(signerKeys, 0, len(c.s.Keys))
 		for _, keyID := range c.s.Keys {
 			truncatedKeys = append(truncatedKeys, formatter.TruncateID(keyID))
 		}
-		return strings.Join(truncatedKeys, ", ")
+		return truncatedKeys
 	}
-	return strings.Join(c.s.Keys, ", ")
+	return c.s.Keys
+}
+
+// signerKeys is a list of key IDs. It's printed as a comma-separated list,
+// and can be used with the "join" template function.
+type signerKeys []string
+
+func (k signerKeys) String() string {
+	return strings.Join(k, ", ")
 }
 
 // Signer returns the name of the signer
-func (c *signerInfoContext) Signer() string {
+func (c *SignerInfoContext) Signer() string {
 	return c.s.Name
 }
+
+// Depth returns the depth of the signer in the hierarchy of delegation roles,
+// for example "2" for "docker/signer", or "2+" for signers of nested roles
+// that were grouped with --max-depth.
+func (c *SignerInfoContext) Depth() string {
+	return signerDepth(c.s.Name)
+}
+
+// Threshold returns the number of keys of the signer that must sign, for
+// example "2 of 3 keys", or "-" if unknown, such as for signers that group
+// nested delegation roles.
+func (c *SignerInfoContext) Threshold() string {
+	if c.s.Threshold == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d of %s", c.s.Threshold, pluralize(len(c.s.Keys), "key", "keys"))
+}
+
+// LastSigned returns the time the signer last signed, or "-" if unknown
+func (c *SignerInfoContext) LastSigned() string {
+	if c.s.LastSigned.IsZero() {
+		return "-"
+	}
+	return c.s.LastSigned.UTC().Format(time.RFC3339)
+}
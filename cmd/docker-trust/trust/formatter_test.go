@@ -3,6 +3,7 @@
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/docker/cli/cli/command/formatter"
 	"github.com/docker/cli/cmd/docker-trust/internal/test"
@@ -242,3 +243,16 @@ func TestSignerInfoContextWrite(t *testing.T) {
 		})
 	}
 }
+
+func TestSignerInfoContextWriteWithTimes(t *testing.T) {
+	signerInfo := []signerInfo{
+		{Name: "alice", Keys: []string{"key11"}, LastSigned: time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60))},
+		{Name: "bob", Keys: []string{"key21"}},
+	}
+	var out bytes.Buffer
+	assert.NilError(t, signerInfoWrite(formatter.Context{Output: &out, Format: signerInfoWithTimesTableFormat}, signerInfo))
+	assert.Equal(t, out.String(), `SIGNER    KEYS      LAST SIGNED
+alice     key11     2024-05-01T10:30:00Z
+bob       key21     -
+`)
+}
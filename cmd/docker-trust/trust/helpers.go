@@ -13,16 +13,22 @@
 	releasesRoleTUFName = "targets/releases"
 )
 
-// isReleasedTarget checks if a role name is "released":
-// either targets/releases or targets TUF roles
-func isReleasedTarget(role data.RoleName) bool {
+// IsReleasedTarget checks if a role name is "released": either the
+// "targets/releases" or the "targets" TUF role. Tags signed into these roles
+// are listed by "docker trust inspect"; delegation roles nested below
+// "targets/releases" are not released roles.
+func IsReleasedTarget(role data.RoleName) bool {
 	return role == data.CanonicalTargetsRole || role == trust.ReleasesRole
 }
 
-// notaryRoleToSigner converts TUF role name to a human-understandable signer name
-func notaryRoleToSigner(tufRole data.RoleName) string {
+// NotaryRoleToSigner converts a TUF role name to a human-understandable
+// signer name, as printed by "docker trust inspect". Released roles (see
+// [IsReleasedTarget]) are shown as "Repo Admin", and the "targets/" prefix
+// is removed from delegation roles, for example "docker/signer" for
+// "targets/docker/signer". Other role names are returned unmodified.
+func NotaryRoleToSigner(tufRole data.RoleName) string {
 	//  don't show a signer for "targets" or "targets/releases"
-	if isReleasedTarget(data.RoleName(tufRole.String())) {
+	if IsReleasedTarget(data.RoleName(tufRole.String())) {
 		return releasedRoleName
 	}
 	return strings.TrimPrefix(tufRole.String(), "targets/")
@@ -6,21 +6,68 @@
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"slices"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/formatter"
 	"github.com/docker/cli/cli/command/inspect"
+	"github.com/docker/cli/cmd/docker-trust/internal/trust"
+	"github.com/docker/cli/internal/tui"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/theupdateframework/notary/tuf/data"
 )
 
 type inspectOptions struct {
-	remotes []string
-	// FIXME(n4ss): this is consistent with `docker service inspect` but we should provide
-	// a `--format` flag too. (format and pretty-print should be exclusive)
+	remotes     []string
+	format      string
 	prettyPrint bool
+	showTimes   bool
+	verbose     bool
+	noSummary   bool
+	// tree is set to print the signed tags grouped by the delegation roles
+	// that signed them, instead of a table.
+	tree bool
+
+	showExpired  bool
+	expiryWindow time.Duration
+
+	attestations bool
+	verifyLocal  bool
+
+	maxDepth    int
+	offline     bool
+	timeout     time.Duration
+	trustDir    string
+	compose     string
+	signers     []string
+	keysOnly    bool
+	quiet       bool
+	// diff is set to compare the signatures of two references.
+	diff bool
+
+	// bulk is set if the references were read from stdin or from a Compose
+	// file, in which case they are printed as a combined report.
+	bulk bool
+
+	requireSignatures bool
+	// unsigned collects the references without signatures, for --require-signatures.
+	unsigned *[]string
+
+	// concurrency is the maximum number of references that are inspected
+	// concurrently.
+	concurrency int
+	// errOut, if set, receives the warnings of inspectRemote instead of
+	// stderr, and disables the progress line.
+	errOut io.Writer
 }
 
 func newInspectCommand(dockerCLI command.Cli) *cobra.Command {
@@ -28,9 +75,40 @@ func newInspectCommand(dockerCLI command.Cli) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "inspect IMAGE[:TAG] [IMAGE[:TAG]...]",
 		Short: "Return low-level information about keys and signatures",
-		Args:  cli.RequiresMinArgs(1),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		Args: func(cmd *cobra.Command, args []string) error {
+			if options.compose != "" {
+				if len(args) > 0 {
+					return errors.New("--compose cannot be combined with image references")
+				}
+				return nil
+			}
+			return cli.RequiresMinArgs(1)(cmd, args)
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
 			options.remotes = args
+			return validateInspectOptions(cmd.Flags(), options)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.trustDir == "" {
+				options.trustDir = trust.GetTrustDirectory()
+			} else if fi, err := os.Stat(options.trustDir); err != nil || !fi.IsDir() {
+				return fmt.Errorf("invalid value for --trust-dir: %s: directory does not exist", options.trustDir)
+			}
+
+			if len(options.remotes) == 1 && options.remotes[0] == "-" {
+				remotes, err := readReferences(dockerCLI.In())
+				if err != nil {
+					return err
+				}
+				options.remotes, options.bulk = remotes, true
+			}
+			if options.compose != "" {
+				remotes, err := readComposeReferences(options.compose, dockerCLI.Err())
+				if err != nil {
+					return err
+				}
+				options.remotes, options.bulk = remotes, true
+			}
 
 			return runInspect(cmd.Context(), dockerCLI, options)
 		},
@@ -39,40 +117,341 @@ func newInspectCommand(dockerCLI command.Cli) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.BoolVar(&options.prettyPrint, "pretty", false, "Print the information in a human friendly format")
+	flags.StringVar(&options.format, "format", "", `Format the information of each repository using a Go template, or print it as a single-line JSON object ("json"); with --pretty, the template formats the signer table`)
+	flags.BoolVar(&options.showTimes, "show-times", false, "Show when each signer last signed (with --pretty)")
+	flags.BoolVar(&options.verbose, "verbose", false, "Show the signing threshold of each signer (with --pretty)")
+	flags.BoolVar(&options.tree, "tree", false, "Show the signed tags grouped by the delegation roles that signed them, as a tree (with --pretty)")
+	flags.BoolVar(&options.noSummary, "no-summary", false, "Do not print a summary line (with --pretty)")
+	flags.BoolVar(&options.showExpired, "show-expired", false, "Show when the metadata of the administrative roles expires, and warn if it expired or expires soon (with --pretty)")
+	flags.BoolVar(&options.attestations, "attestations", false, "Summarize the attestation manifests of the local image (with --pretty)")
+	flags.BoolVar(&options.verifyLocal, "verify-local", false, "Compare the signed digest of each tag with the digest of the local image of the tag")
+	flags.DurationVar(&options.expiryWindow, "expiry-window", defaultExpiryWindow, "Warn about metadata that expires within this period (with --show-expired)")
+	flags.BoolVar(&options.offline, "offline", false, "Read the trust data from the local cache, without contacting the notary server")
+	flags.DurationVar(&options.timeout, "timeout", 0, "Maximum time to wait for the notary server for each repository (0 for no limit)")
+	flags.StringVar(&options.trustDir, "trust-dir", "", "Directory holding the trust data (default \"~/.docker/trust\", or $DOCKER_TRUST_DIR)")
+	flags.StringVar(&options.compose, "compose", "", "Inspect the images of the services in a Compose file")
+	flags.BoolVar(&options.keysOnly, "keys-only", false, "Only print the key IDs of the signers and administrative roles")
+	flags.BoolVarP(&options.quiet, "quiet", "q", false, "Only print the digests of the signed tags")
+	flags.BoolVar(&options.diff, "diff", false, fmt.Sprintf("Compare the digests and signers of two tags, and exit with status %d if they differ", exitCodeDiffers))
+	flags.StringSliceVar(&options.signers, "signer", nil, "Only show tags signed by this signer (can be specified multiple times)")
+	flags.BoolVar(&options.requireSignatures, "require-signatures", false, fmt.Sprintf("Exit with status %d if a repository or tag has no signatures", exitCodeNoSignatures))
+	flags.IntVar(&options.concurrency, "concurrency", 1, "Maximum number of references to inspect concurrently")
+	flags.IntVar(&options.maxDepth, "max-depth", 0, `Group signers of nested delegation roles below this depth, for example "docker/..." (0 for no limit)`)
 
 	return cmd
 }
 
+// inspectDependencies are the options of docker trust inspect that can only
+// be used together with another option.
+var inspectDependencies = []struct {
+	option, requires string
+}{
+	{option: "show-times", requires: "pretty"},
+	{option: "verbose", requires: "pretty"},
+	{option: "tree", requires: "pretty"},
+	{option: "no-summary", requires: "pretty"},
+	{option: "show-expired", requires: "pretty"},
+	{option: "attestations", requires: "pretty"},
+}
+
+// inspectConflicts are the options of docker trust inspect that cannot be
+// used together, in the order in which they're checked. Options are flag
+// names, optionally with the value that conflicts, such as "format=json";
+// without a value, any value other than the default conflicts.
+var inspectConflicts = []struct {
+	option, other string
+}{
+	{option: "timeout", other: "offline"},
+	{option: "format=" + formatter.JSONFormatKey, other: "pretty"},
+	{option: "keys-only", other: "pretty"},
+	{option: "keys-only", other: "signer"},
+	{option: "keys-only", other: "require-signatures"},
+	{option: "keys-only", other: "compose"},
+	{option: "keys-only", other: "verify-local"},
+	{option: "quiet", other: "pretty"},
+	{option: "quiet", other: "format"},
+	{option: "quiet", other: "keys-only"},
+	{option: "diff", other: "compose"},
+	{option: "diff", other: "pretty"},
+	{option: "diff", other: "format"},
+	{option: "diff", other: "keys-only"},
+	{option: "diff", other: "quiet"},
+}
+
+// bulkConflicts are the options of docker trust inspect that cannot be used
+// when inspecting the images of a Compose file, or references read from
+// stdin, as their output is printed as a combined report.
+var bulkConflicts = []string{"show-times", "show-expired", "attestations"}
+
+// isOptionSet returns whether the option, as used in inspectConflicts and
+// inspectDependencies, is set.
+func isOptionSet(flags *pflag.FlagSet, option string) bool {
+	name, value, hasValue := strings.Cut(option, "=")
+	f := flags.Lookup(name)
+	if hasValue {
+		return f.Value.String() == value
+	}
+	return f.Value.String() != f.DefValue
+}
+
+// validateInspectOptions validates the options of docker trust inspect.
+func validateInspectOptions(flags *pflag.FlagSet, opts inspectOptions) error {
+	for _, d := range inspectDependencies {
+		if isOptionSet(flags, d.option) && !isOptionSet(flags, d.requires) {
+			return fmt.Errorf("--%s can only be used with --%s", d.option, d.requires)
+		}
+	}
+	if flags.Changed("expiry-window") && !opts.showExpired {
+		return errors.New("--expiry-window can only be used with --show-expired")
+	}
+	if opts.expiryWindow < 0 {
+		return fmt.Errorf("invalid value for --expiry-window: %s: must be a positive duration", opts.expiryWindow)
+	}
+	if opts.maxDepth < 0 {
+		return fmt.Errorf("invalid value for --max-depth: %d: must be a positive number", opts.maxDepth)
+	}
+	if opts.concurrency < 1 {
+		return fmt.Errorf("invalid value for --concurrency: %d: must be a positive number", opts.concurrency)
+	}
+	if opts.timeout < 0 {
+		return fmt.Errorf("invalid value for --timeout: %s: must be a positive duration", opts.timeout)
+	}
+	for _, c := range inspectConflicts {
+		if isOptionSet(flags, c.option) && isOptionSet(flags, c.other) {
+			return fmt.Errorf("conflicting options: --%s and --%s cannot be used together", c.option, c.other)
+		}
+	}
+
+	stdin := slices.Contains(opts.remotes, "-")
+	if opts.keysOnly {
+		if opts.format != "" && opts.format != formatter.JSONFormatKey {
+			return errors.New("conflicting options: --keys-only and --format with a template cannot be used together")
+		}
+		if stdin {
+			return errors.New("--keys-only cannot be used when reading references from stdin")
+		}
+	}
+	if opts.diff && len(opts.remotes) != 2 {
+		return errors.New("--diff requires exactly 2 references")
+	}
+	if stdin && len(opts.remotes) > 1 {
+		return errors.New(`"-" cannot be combined with other references`)
+	}
+	var bulkSource string
+	switch {
+	case opts.compose != "":
+		bulkSource = "with --compose"
+	case stdin:
+		bulkSource = "when reading references from stdin"
+	default:
+		return nil
+	}
+	for _, option := range bulkConflicts {
+		if isOptionSet(flags, option) {
+			return fmt.Errorf("--%s cannot be used %s", option, bulkSource)
+		}
+	}
+	if opts.prettyPrint && opts.format != "" {
+		return fmt.Errorf("--format with a template cannot be used %s", bulkSource)
+	}
+	return nil
+}
+
+// defaultExpiryWindow is the default period within which metadata is
+// considered to expire soon with --show-expired.
+const defaultExpiryWindow = 30 * 24 * time.Hour
+
+// exitCodeNoSignatures is the exit status used with --require-signatures if
+// a repository or tag has no signatures.
+const exitCodeNoSignatures = 2
+
 func runInspect(ctx context.Context, dockerCLI command.Cli, opts inspectOptions) error {
-	if opts.prettyPrint {
-		var err error
+	opts.unsigned = &[]string{}
+	if err := inspectRemotes(ctx, dockerCLI, opts); err != nil {
+		return err
+	}
+	if opts.requireSignatures && len(*opts.unsigned) > 0 {
+		return cli.StatusError{
+			StatusCode: exitCodeNoSignatures,
+			Cause:      ErrNoSignatures{Remotes: *opts.unsigned},
+		}
+	}
+	return nil
+}
 
-		for index, remote := range opts.remotes {
-			if err = prettyPrintTrustInfo(ctx, dockerCLI, remote); err != nil {
-				return err
+func inspectRemotes(ctx context.Context, dockerCLI command.Cli, opts inspectOptions) error {
+	if opts.diff {
+		return runDiff(ctx, dockerCLI, opts)
+	}
+	if opts.bulk {
+		switch {
+		case opts.format == formatter.JSONFormatKey:
+			return printBulkTrustInfoJSON(ctx, dockerCLI, opts)
+		case opts.prettyPrint:
+			return printBulkTrustInfo(ctx, dockerCLI, opts)
+		}
+	}
+
+	if opts.quiet {
+		return inspectEach(ctx, dockerCLI, opts, func(_ int, _ string, info InspectResult) error {
+			printDigests(dockerCLI.Out(), info)
+			return nil
+		})
+	}
+
+	if opts.keysOnly {
+		return inspectEach(ctx, dockerCLI, opts, func(index int, remote string, info InspectResult) error {
+			if opts.format == formatter.JSONFormatKey {
+				return printKeysJSON(dockerCLI.Out(), info, opts.maxDepth)
+			}
+			if len(opts.remotes) > 1 {
+				if index > 0 {
+					_, _ = fmt.Fprintln(dockerCLI.Out())
+				}
+				_, _ = fmt.Fprintf(dockerCLI.Out(), "Keys for %s\n\n", remote)
 			}
+			return printKeys(dockerCLI.Out(), info, opts.maxDepth)
+		})
+	}
+
+	if opts.format == formatter.JSONFormatKey {
+		return inspectEach(ctx, dockerCLI, opts, func(_ int, _ string, info InspectResult) error {
+			return printTrustInfoJSON(dockerCLI.Out(), info, opts.maxDepth)
+		})
+	}
 
+	if opts.prettyPrint {
+		out := tui.NewOutput(dockerCLI.Out())
+		return inspectEach(ctx, dockerCLI, opts, func(index int, remote string, info InspectResult) error {
 			// Additional separator between the inspection output of each image
-			if index < len(opts.remotes)-1 {
+			if index > 0 {
 				_, _ = fmt.Fprint(dockerCLI.Out(), "\n\n")
 			}
+			if err := prettyPrintTrustInfo(out, info, prettyPrintOptions{
+				signerFormat: opts.format,
+				maxDepth:     opts.maxDepth,
+				showTimes:    opts.showTimes,
+				verbose:      opts.verbose,
+				showExpired:  opts.showExpired,
+				tree:         opts.tree,
+				summary:      !opts.noSummary,
+			}); err != nil {
+				return err
+			}
+			if opts.showExpired {
+				warnExpiringMetadata(dockerCLI.Err(), info.trustDir, remote, opts.expiryWindow)
+			}
+			return nil
+		})
+	}
+
+	results := make(map[string]*remoteInspection, len(opts.remotes))
+	remotes := make([]string, 0, len(opts.remotes))
+	for _, res := range inspectAll(ctx, dockerCLI, opts) {
+		results[res.remote] = res
+		remotes = append(remotes, res.remote)
+	}
+	getRefFunc := func(ref string) (any, []byte, error) {
+		res := results[ref]
+		_, _ = io.Copy(dockerCLI.Err(), &res.warnings)
+		if res.err != nil {
+			return nil, []byte{}, res.err
 		}
+		return marshalTrustInfo(res.remote, res.info, opts.maxDepth)
+	}
+	return inspect.Inspect(dockerCLI.Out(), remotes, opts.format, getRefFunc)
+}
 
-		return err
+// printDigests prints the digest of each signed tag of a repository, one per
+// line, as printed with "docker trust inspect --quiet". Nothing is printed if
+// the repository has no signed tags.
+func printDigests(out io.Writer, info InspectResult) {
+	for _, tag := range info.SignedTags {
+		_, _ = fmt.Fprintln(out, "sha256:"+tag.Digest)
 	}
+}
 
-	getRefFunc := func(ref string) (any, []byte, error) {
-		i, err := getRepoTrustInfo(ctx, dockerCLI, ref)
-		return nil, i, err
+// inspectRemote returns the trust information of a repository, as read from
+// the notary server, or from the local cache in the trust directory with
+// --offline, bounded by the --timeout of opts. The signed tags are narrowed
+// down to the --signer options, and completed with the local images for
+// --verify-local and --attestations. Warnings, for example about cached or
+// unreadable metadata, are written to opts.errOut, or stderr if not set, and
+// references without signatures are collected in opts.unsigned.
+func inspectRemote(ctx context.Context, dockerCLI command.Cli, remote string, opts inspectOptions) (InspectResult, error) {
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	errOut := opts.errOut
+	if errOut == nil {
+		errOut = dockerCLI.Err()
+	}
+	info, err := inspectTrustInfo(ctx, dockerCLI, remote, opts.offline, opts.trustDir, opts.errOut == nil)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// The notary client does not return the context's error, but
+			// reports it as a missing or inaccessible repository.
+			return InspectResult{}, fmt.Errorf("timed out after %s waiting for the notary server for %s", opts.timeout, remote)
+		}
+		return InspectResult{}, err
+	}
+	if len(opts.signers) > 0 {
+		info.SignedTags = filterBySigners(info.SignedTags, opts.signers)
+		info.signerFilter = opts.signers
+	}
+	if opts.verifyLocal {
+		if err := verifyLocalImages(ctx, dockerCLI.Client(), remote, info.SignedTags, errOut); err != nil {
+			return InspectResult{}, err
+		}
+	}
+	if opts.attestations {
+		info.attestations, err = lookupAttestations(ctx, dockerCLI.Client(), remote)
+		if err != nil {
+			return InspectResult{}, err
+		}
+	}
+	if len(info.SignedTags) == 0 && opts.unsigned != nil {
+		*opts.unsigned = append(*opts.unsigned, remote)
+	}
+	if opts.offline {
+		_, _ = fmt.Fprintf(errOut, "WARNING: showing cached trust data for %s, which may be out of date\n", remote)
+	}
+	if len(info.UnreadableRoles) > 0 {
+		roles := make([]string, 0, len(info.UnreadableRoles))
+		for _, role := range info.UnreadableRoles {
+			roles = append(roles, role.String())
+		}
+		_, _ = fmt.Fprintf(errOut, "WARNING: could not read the signatures of %s for %s; showing the signatures of the other roles\n", strings.Join(roles, ", "), remote)
+	}
+	return info, nil
+}
+
+func getRepoTrustInfo(ctx context.Context, dockerCLI command.Cli, remote string, opts inspectOptions) (any, []byte, error) {
+	info, err := inspectRemote(ctx, dockerCLI, remote, opts)
+	if err != nil {
+		return nil, []byte{}, err
 	}
-	return inspect.Inspect(dockerCLI.Out(), opts.remotes, "", getRefFunc)
+	return marshalTrustInfo(remote, info, opts.maxDepth)
 }
 
-func getRepoTrustInfo(ctx context.Context, dockerCLI command.Cli, remote string) ([]byte, error) {
-	signatureRows, adminRolesWithSigs, delegationRoles, err := lookupTrustInfo(ctx, dockerCLI, remote)
+// marshalTrustInfo returns the trust information of a repository, and its
+// JSON representation, as printed by "docker trust inspect" without options.
+// The trust information is the context of a template passed with --format.
+func marshalTrustInfo(remote string, info InspectResult, maxDepth int) (any, []byte, error) {
+	repo := newTrustRepo(remote, info, maxDepth)
+	raw, err := json.Marshal(repo)
 	if err != nil {
-		return []byte{}, err
+		return nil, nil, err
 	}
+	return repo, raw, nil
+}
+
+// newTrustRepo returns the trust information of a repository, with the
+// signers of nested delegation roles below maxDepth grouped.
+func newTrustRepo(remote string, info InspectResult, maxDepth int) trustRepo {
+	signatureRows := info.SignedTags
 	// process the signatures to include repo admin if signed by the base targets role
 	for idx, sig := range signatureRows {
 		if len(sig.Signers) == 0 {
@@ -82,39 +461,40 @@ func getRepoTrustInfo(ctx context.Context, dockerCLI command.Cli, remote string)
 
 	signerList, adminList := []trustSigner{}, []trustSigner{}
 
-	signerRoleToKeyIDs := getDelegationRoleToKeyMap(delegationRoles)
+	signerRoleToKeyIDs := info.GroupedSigners(maxDepth)
+	thresholds := info.signerThresholds(maxDepth)
 
 	for signerName, signerKeys := range signerRoleToKeyIDs {
 		signerKeyList := []trustKey{}
 		for _, keyID := range signerKeys {
 			signerKeyList = append(signerKeyList, trustKey{ID: keyID})
 		}
-		signerList = append(signerList, trustSigner{signerName, signerKeyList})
+		signerList = append(signerList, trustSigner{Name: signerName, Keys: signerKeyList, Threshold: thresholds[signerName]})
 	}
 	sort.Slice(signerList, func(i, j int) bool { return signerList[i].Name > signerList[j].Name })
 
-	for _, adminRole := range adminRolesWithSigs {
+	for _, adminRole := range info.AdminRoles {
 		switch adminRole.Name {
 		case data.CanonicalRootRole:
 			rootKeys := []trustKey{}
 			for _, keyID := range adminRole.KeyIDs {
 				rootKeys = append(rootKeys, trustKey{ID: keyID})
 			}
-			adminList = append(adminList, trustSigner{"Root", rootKeys})
+			adminList = append(adminList, trustSigner{Name: "Root", Keys: rootKeys})
 		case data.CanonicalTargetsRole:
 			targetKeys := []trustKey{}
 			for _, keyID := range adminRole.KeyIDs {
 				targetKeys = append(targetKeys, trustKey{ID: keyID})
 			}
-			adminList = append(adminList, trustSigner{"Repository", targetKeys})
+			adminList = append(adminList, trustSigner{Name: "Repository", Keys: targetKeys})
 		}
 	}
 	sort.Slice(adminList, func(i, j int) bool { return adminList[i].Name > adminList[j].Name })
 
-	return json.Marshal(trustRepo{
+	return trustRepo{
 		Name:               remote,
 		SignedTags:         signatureRows,
 		Signers:            signerList,
 		AdministrativeKeys: adminList,
-	})
+	}
 }
@@ -0,0 +1,93 @@
+package trust
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/client"
+)
+
+// attestationInfo holds the attestation manifests of the local image of a
+// reference, as shown with "docker trust inspect --attestations".
+type attestationInfo struct {
+	// localImage is set if the image is present in the local image store.
+	localImage   bool
+	attestations []attestation
+}
+
+// attestation is an attestation manifest, such as an SBOM or provenance
+// attestation, and the platform of the image manifest it is for.
+type attestation struct {
+	Digest   string
+	Platform string
+}
+
+// lookupAttestations returns the attestation manifests of the local image of
+// the given reference. It does not return an error if the image is not
+// present in the local image store; only images that are pulled with the
+// containerd image store have attestation manifests.
+func lookupAttestations(ctx context.Context, apiClient client.ImageAPIClient, remote string) (*attestationInfo, error) {
+	res, err := apiClient.ImageInspect(ctx, remote, client.ImageInspectWithManifests(true))
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return &attestationInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to inspect the attestations of %s: %w", remote, err)
+	}
+
+	platforms := make(map[string]string)
+	for _, m := range res.Manifests {
+		if m.Kind == image.ManifestKindImage && m.ImageData != nil {
+			platforms[m.ID] = formatPlatform(m.ImageData)
+		}
+	}
+	info := &attestationInfo{localImage: true}
+	for _, m := range res.Manifests {
+		if m.Kind != image.ManifestKindAttestation || m.AttestationData == nil {
+			continue
+		}
+		platform, ok := platforms[m.AttestationData.For.String()]
+		if !ok {
+			platform = "unknown"
+		}
+		info.attestations = append(info.attestations, attestation{Digest: m.ID, Platform: platform})
+	}
+	sort.Slice(info.attestations, func(i, j int) bool {
+		if info.attestations[i].Platform != info.attestations[j].Platform {
+			return info.attestations[i].Platform < info.attestations[j].Platform
+		}
+		return info.attestations[i].Digest < info.attestations[j].Digest
+	})
+	return info, nil
+}
+
+// formatPlatform formats the platform of an image manifest as
+// "os/architecture[/variant]".
+func formatPlatform(imageData *image.ImageProperties) string {
+	p := imageData.Platform
+	platform := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		platform += "/" + p.Variant
+	}
+	return platform
+}
+
+// printAttestations prints the attestation manifests of the local image of a
+// reference, or a line stating that it has none.
+func printAttestations(out io.Writer, remote string, info *attestationInfo) {
+	switch {
+	case !info.localImage:
+		_, _ = fmt.Fprintf(out, "\nNo local image for %s; pull the image to show its attestations\n", remote)
+	case len(info.attestations) == 0:
+		_, _ = fmt.Fprintf(out, "\nNo attestations for %s\n", remote)
+	default:
+		_, _ = fmt.Fprintf(out, "\nAttestations for %s\n\n", remote)
+		for _, a := range info.attestations {
+			_, _ = fmt.Fprintf(out, "  %s:\t%s\n", a.Platform, a.Digest)
+		}
+	}
+}
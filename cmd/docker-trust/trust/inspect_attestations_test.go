@@ -0,0 +1,128 @@
+package trust
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/cli/cmd/docker-trust/internal/test"
+	"github.com/docker/cli/cmd/docker-trust/internal/test/notary"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/client"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/golden"
+)
+
+func imageManifest(id string, platform ocispec.Platform) image.ManifestSummary {
+	return image.ManifestSummary{
+		ID:        id,
+		Kind:      image.ManifestKindImage,
+		ImageData: &image.ImageProperties{Platform: platform},
+	}
+}
+
+func attestationManifest(id, imageID string) image.ManifestSummary {
+	return image.ManifestSummary{
+		ID:              id,
+		Kind:            image.ManifestKindAttestation,
+		AttestationData: &image.AttestationProperties{For: digest.Digest(imageID)},
+	}
+}
+
+func TestLookupAttestations(t *testing.T) {
+	apiClient := &fakeClient{
+		imageInspectFunc: func(string, ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+			return client.ImageInspectResult{InspectResponse: image.InspectResponse{
+				Manifests: []image.ManifestSummary{
+					imageManifest("sha256:arm64", ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}),
+					imageManifest("sha256:amd64", ocispec.Platform{OS: "linux", Architecture: "amd64"}),
+					attestationManifest("sha256:att-arm64", "sha256:arm64"),
+					attestationManifest("sha256:att-amd64", "sha256:amd64"),
+					attestationManifest("sha256:att-other", "sha256:other"),
+				},
+			}}, nil
+		},
+	}
+	info, err := lookupAttestations(t.Context(), apiClient, "signed-repo:red")
+	assert.NilError(t, err)
+	assert.Check(t, info.localImage)
+	assert.Check(t, is.DeepEqual(info.attestations, []attestation{
+		{Digest: "sha256:att-amd64", Platform: "linux/amd64"},
+		{Digest: "sha256:att-arm64", Platform: "linux/arm64/v8"},
+		{Digest: "sha256:att-other", Platform: "unknown"},
+	}))
+}
+
+func TestLookupAttestationsErrors(t *testing.T) {
+	apiClient := &fakeClient{
+		imageInspectFunc: func(imageID string, _ ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+			if imageID == "missing" {
+				return client.ImageInspectResult{}, fmt.Errorf("no such image: %s: %w", imageID, cerrdefs.ErrNotFound)
+			}
+			return client.ImageInspectResult{}, errors.New("connection refused")
+		},
+	}
+	info, err := lookupAttestations(t.Context(), apiClient, "missing")
+	assert.NilError(t, err)
+	assert.Check(t, !info.localImage)
+
+	_, err = lookupAttestations(t.Context(), apiClient, "signed-repo")
+	assert.Check(t, is.Error(err, "failed to inspect the attestations of signed-repo: connection refused"))
+}
+
+func TestTrustInspectPrettyCommandAttestations(t *testing.T) {
+	testCases := []struct {
+		doc       string
+		manifests []image.ManifestSummary
+		err       error
+		golden    string
+	}{
+		{
+			doc: "attestations",
+			manifests: []image.ManifestSummary{
+				imageManifest("sha256:amd64", ocispec.Platform{OS: "linux", Architecture: "amd64"}),
+				attestationManifest("sha256:att-amd64", "sha256:amd64"),
+			},
+			golden: "trust-inspect-pretty-attestations.golden",
+		},
+		{
+			doc: "no attestations",
+			manifests: []image.ManifestSummary{
+				imageManifest("sha256:amd64", ocispec.Platform{OS: "linux", Architecture: "amd64"}),
+			},
+			golden: "trust-inspect-pretty-no-attestations.golden",
+		},
+		{
+			doc:    "no local image",
+			err:    cerrdefs.ErrNotFound,
+			golden: "trust-inspect-pretty-attestations-no-local-image.golden",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{
+				imageInspectFunc: func(string, ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+					return client.ImageInspectResult{InspectResponse: image.InspectResponse{Manifests: tc.manifests}}, tc.err
+				},
+			})
+			cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs([]string{"--pretty", "--no-summary", "--attestations", "signed-repo:red"})
+			assert.NilError(t, cmd.Execute())
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+		})
+	}
+}
+
+func TestTrustInspectAttestationsWithoutPretty(t *testing.T) {
+	cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"--attestations", "signed-repo"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "--attestations can only be used with --pretty")
+}
@@ -0,0 +1,108 @@
+package trust
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/internal/tui"
+)
+
+// readReferences reads newline-separated image references from r, as used
+// for "docker trust inspect -". Empty lines are ignored.
+func readReferences(r io.Reader) ([]string, error) {
+	var refs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if ref := strings.TrimSpace(scanner.Text()); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read references from stdin: %w", err)
+	}
+	if len(refs) == 0 {
+		return nil, errors.New("no references read from stdin")
+	}
+	return refs, nil
+}
+
+// printBulkTrustInfo prints the signed tags of all references in a single
+// table, with a REPOSITORY column. References that cannot be inspected are
+// reported on stderr, without aborting the other references.
+func printBulkTrustInfo(ctx context.Context, dockerCLI command.Cli, opts inspectOptions) error {
+	var (
+		signedTags []signedTagInfo
+		failed     int
+	)
+	err := forEachInspection(ctx, dockerCLI, opts, func(res *remoteInspection) error {
+		if res.err != nil {
+			_, _ = fmt.Fprintf(dockerCLI.Err(), "%s: %v\n", res.remote, res.err)
+			failed++
+			return nil
+		}
+		if len(res.info.SignedTags) == 0 {
+			if res.info.digest != "" {
+				_, _ = fmt.Fprintf(dockerCLI.Err(), "%s: %s\n", res.remote, res.info.noSignatures())
+				return nil
+			}
+			_, _ = fmt.Fprintf(dockerCLI.Err(), "%s: no signatures%s\n", res.remote, res.info.bySigners())
+			return nil
+		}
+		repository := repositoryName(res.remote)
+		for _, sigRow := range res.info.SignedTags {
+			signedTags = append(signedTags, newSignedTagInfo(repository, sigRow))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeSignatures(tui.NewOutput(dockerCLI.Out()), repositoryTrustTagTableFormat, signedTags); err != nil {
+		return err
+	}
+	return bulkError(failed, len(opts.remotes))
+}
+
+// printBulkTrustInfoJSON prints the trust information of each reference as
+// a single-line JSON object, as printed with "--format=json". References
+// that cannot be inspected are reported on stderr, without aborting the
+// other references.
+func printBulkTrustInfoJSON(ctx context.Context, dockerCLI command.Cli, opts inspectOptions) error {
+	var failed int
+	err := forEachInspection(ctx, dockerCLI, opts, func(res *remoteInspection) error {
+		if res.err != nil {
+			_, _ = fmt.Fprintf(dockerCLI.Err(), "%s: %v\n", res.remote, res.err)
+			failed++
+			return nil
+		}
+		return printTrustInfoJSON(dockerCLI.Out(), res.info, opts.maxDepth)
+	})
+	if err != nil {
+		return err
+	}
+	return bulkError(failed, len(opts.remotes))
+}
+
+// bulkError returns an error if any of the references failed.
+func bulkError(failed, total int) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to inspect %d of %d references", failed, total)
+}
+
+// repositoryName returns the familiar name of the repository of an image
+// reference, for example "alpine" for "docker.io/library/alpine:latest".
+func repositoryName(remote string) string {
+	named, err := reference.ParseNormalizedNamed(remote)
+	if err != nil {
+		return remote
+	}
+	return reference.FamiliarName(named)
+}
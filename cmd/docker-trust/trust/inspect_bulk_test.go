@@ -0,0 +1,109 @@
+package trust
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/docker/cli/cli/streams"
+	"github.com/docker/cli/cmd/docker-trust/internal/test"
+	"github.com/docker/cli/cmd/docker-trust/internal/test/notary"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/golden"
+)
+
+func TestReadReferences(t *testing.T) {
+	refs, err := readReferences(strings.NewReader("alpine:latest\n\n  busybox  \nexample/repo"))
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(refs, []string{"alpine:latest", "busybox", "example/repo"}))
+
+	_, err = readReferences(strings.NewReader("\n \n"))
+	assert.Check(t, is.Error(err, "no references read from stdin"))
+
+	_, err = readReferences(iotest.ErrReader(io.ErrUnexpectedEOF))
+	assert.Check(t, is.Error(err, "failed to read references from stdin: unexpected EOF"))
+}
+
+func TestRepositoryName(t *testing.T) {
+	assert.Check(t, is.Equal(repositoryName("alpine:latest"), "alpine"))
+	assert.Check(t, is.Equal(repositoryName("docker.io/library/alpine"), "alpine"))
+	assert.Check(t, is.Equal(repositoryName("example.com/repo:tag"), "example.com/repo"))
+}
+
+const bulkReferences = "signed-repo:green\nALPINE\nsigned-repo:unsigned\nsigned-repo\n"
+
+func TestTrustInspectBulk(t *testing.T) {
+	testCases := []struct {
+		doc            string
+		args           []string
+		golden         string
+		expectedStderr string
+	}{
+		{
+			doc:    "pretty",
+			args:   []string{"--pretty", "-"},
+			golden: "trust-inspect-bulk-pretty.golden",
+			expectedStderr: "ALPINE: invalid reference format: repository name (library/ALPINE) must be lowercase\n" +
+				"signed-repo:unsigned: no signatures\n",
+		},
+		{
+			doc:            "json",
+			args:           []string{"--format", "json", "-"},
+			golden:         "trust-inspect-bulk-json.golden",
+			expectedStderr: "ALPINE: invalid reference format: repository name (library/ALPINE) must be lowercase\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader(bulkReferences))))
+			cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), "failed to inspect 1 of 4 references")
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+			assert.Check(t, is.Equal(cli.ErrBuffer().String(), tc.expectedStderr))
+		})
+	}
+}
+
+func TestTrustInspectBulkErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{"-", "alpine"},
+			expectedError: `"-" cannot be combined with other references`,
+		},
+		{
+			args:          []string{"--pretty", "--show-times", "-"},
+			expectedError: "--show-times cannot be used when reading references from stdin",
+		},
+		{
+			args:          []string{"--pretty", "--show-expired", "-"},
+			expectedError: "--show-expired cannot be used when reading references from stdin",
+		},
+		{
+			args:          []string{"--pretty", "--format", "{{.Signer}}", "-"},
+			expectedError: "--format with a template cannot be used when reading references from stdin",
+		},
+		{
+			args:          []string{"-"},
+			expectedError: "no references read from stdin",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expectedError, func(t *testing.T) {
+			cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expectedError)
+		})
+	}
+}
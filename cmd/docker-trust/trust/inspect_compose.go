@@ -0,0 +1,97 @@
+package trust
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/cli/compose/loader"
+	"github.com/docker/cli/cli/compose/schema"
+	composetypes "github.com/docker/cli/cli/compose/types"
+)
+
+// readComposeReferences reads the image references of the services in a
+// Compose file, as used for "docker trust inspect --compose". Services that
+// are built locally, and have no image, are skipped, and reported on stderr.
+// References are returned in the order of the service names, and references
+// that are used by multiple services are only returned once.
+func readComposeReferences(filename string, stderr io.Writer) ([]string, error) {
+	config, err := loadComposeConfig(filename)
+	if err != nil {
+		return nil, err
+	}
+	services := config.Services
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+	var refs []string
+	seen := make(map[string]bool)
+	for _, svc := range services {
+		if svc.Image == "" {
+			_, _ = fmt.Fprintf(stderr, "skipping service %s: no image specified\n", svc.Name)
+			continue
+		}
+		if !seen[svc.Image] {
+			seen[svc.Image] = true
+			refs = append(refs, svc.Image)
+		}
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no image references found in %s", filename)
+	}
+	return refs, nil
+}
+
+// loadComposeConfig loads a Compose file, interpolating variables from the
+// environment.
+func loadComposeConfig(filename string) (*composetypes.Config, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Compose file: %w", err)
+	}
+	dict, err := loader.ParseYAML(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Compose file %s: %w", filename, err)
+	}
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	config, err := loader.Load(composetypes.ConfigDetails{
+		WorkingDir:  filepath.Dir(absPath),
+		ConfigFiles: []composetypes.ConfigFile{{Filename: filename, Config: dict}},
+		Version:     schema.Version(dict),
+		Environment: environment(os.Environ()),
+	})
+	if err != nil {
+		var fpe *loader.ForbiddenPropertiesError
+		if errors.As(err, &fpe) {
+			return nil, fmt.Errorf("failed to load Compose file %s: unsupported properties: %s", filename, strings.Join(propertyNames(fpe.Properties), ", "))
+		}
+		return nil, fmt.Errorf("failed to load Compose file %s: %w", filename, err)
+	}
+	return config, nil
+}
+
+// environment returns the given "KEY=value" environment variables as a map.
+func environment(env []string) map[string]string {
+	result := make(map[string]string, len(env))
+	for _, s := range env {
+		if k, v, ok := strings.Cut(s, "="); ok && k != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func propertyNames(properties map[string]string) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
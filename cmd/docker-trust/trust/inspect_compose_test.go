@@ -0,0 +1,102 @@
+package trust
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/docker/cli/cmd/docker-trust/internal/test"
+	"github.com/docker/cli/cmd/docker-trust/internal/test/notary"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+	"gotest.tools/v3/golden"
+)
+
+func TestReadComposeReferences(t *testing.T) {
+	var stderr bytes.Buffer
+	refs, err := readComposeReferences("testdata/compose.yml", &stderr)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(refs, []string{"signed-repo:unsigned", "signed-repo:green"}))
+	assert.Check(t, is.Equal(stderr.String(), "skipping service app: no image specified\n"))
+}
+
+func TestReadComposeReferencesErrors(t *testing.T) {
+	testCases := []struct {
+		doc           string
+		content       string
+		expectedError string
+	}{
+		{
+			doc:           "no images",
+			content:       "services:\n  app:\n    build: .\n",
+			expectedError: "no image references found in ",
+		},
+		{
+			doc:           "invalid yaml",
+			content:       "services: [",
+			expectedError: "failed to parse Compose file ",
+		},
+		{
+			doc:           "invalid service",
+			content:       "services:\n  app:\n    image: 42\n    unknown: true\n",
+			expectedError: "failed to load Compose file ",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			f := fs.NewFile(t, "compose.yml", fs.WithContent(tc.content))
+			_, err := readComposeReferences(f.Path(), io.Discard)
+			assert.Check(t, is.ErrorContains(err, tc.expectedError))
+		})
+	}
+
+	_, err := readComposeReferences("testdata/no-such-file.yml", io.Discard)
+	assert.Check(t, is.ErrorContains(err, "failed to read Compose file: "))
+}
+
+func TestTrustInspectCompose(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"--pretty", "--compose", "testdata/compose.yml"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-compose-pretty.golden")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "skipping service app: no image specified\n"+
+		"signed-repo:unsigned: no signatures\n"))
+}
+
+func TestTrustInspectComposeErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{"--compose", "testdata/compose.yml", "alpine"},
+			expectedError: "--compose cannot be combined with image references",
+		},
+		{
+			args:          []string{"--pretty", "--show-times", "--compose", "testdata/compose.yml"},
+			expectedError: "--show-times cannot be used with --compose",
+		},
+		{
+			args:          []string{"--pretty", "--show-expired", "--compose", "testdata/compose.yml"},
+			expectedError: "--show-expired cannot be used with --compose",
+		},
+		{
+			args:          []string{"--pretty", "--format", "{{.Signer}}", "--compose", "testdata/compose.yml"},
+			expectedError: "--format with a template cannot be used with --compose",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expectedError, func(t *testing.T) {
+			cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expectedError)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package trust
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/fvbommel/sortorder"
+)
+
+// exitCodeDiffers is the exit status used with --diff if the signatures of
+// the references differ. It differs from the status of other errors, and from
+// [exitCodeNoSignatures], so that scripts can tell them apart.
+const exitCodeDiffers = 3
+
+// signatureSummary holds the digests that a reference points at, and the
+// signers that signed it, as compared with --diff.
+type signatureSummary struct {
+	remote  string
+	digests []string
+	signers []string
+}
+
+// newSignatureSummary returns the digests and signers of the signed tags of
+// the given reference. Tags that are only signed with the repository key are
+// signed by the "(Repo Admin)" signer, as printed with --pretty.
+func newSignatureSummary(remote string, info InspectResult) signatureSummary {
+	s := signatureSummary{remote: remote}
+	for _, tag := range info.SignedTags {
+		if dgst := "sha256:" + tag.Digest; !slices.Contains(s.digests, dgst) {
+			s.digests = append(s.digests, dgst)
+		}
+		signers := tag.Signers
+		if len(signers) == 0 {
+			signers = []string{"(" + releasedRoleName + ")"}
+		}
+		for _, signer := range signers {
+			if !slices.Contains(s.signers, signer) {
+				s.signers = append(s.signers, signer)
+			}
+		}
+	}
+	sort.Strings(s.digests)
+	sort.Slice(s.signers, func(i, j int) bool {
+		return sortorder.NaturalLess(s.signers[i], s.signers[j])
+	})
+	return s
+}
+
+// runDiff inspects the two references in opts.remotes, and prints the
+// differences between the digests they point at, and the signers that signed
+// them. It returns a [cli.StatusError] with [exitCodeDiffers] if they differ.
+func runDiff(ctx context.Context, dockerCLI command.Cli, opts inspectOptions) error {
+	var summaries [2]signatureSummary
+	for i, remote := range opts.remotes {
+		if err := validateDiffReference(remote); err != nil {
+			return err
+		}
+		info, err := inspectRemote(ctx, dockerCLI, remote, opts)
+		if err != nil {
+			return err
+		}
+		summaries[i] = newSignatureSummary(remote, info)
+	}
+	if !printSignatureDiff(dockerCLI.Out(), summaries[0], summaries[1]) {
+		return nil
+	}
+	return cli.StatusError{StatusCode: exitCodeDiffers}
+}
+
+// validateDiffReference validates that remote references a tag, as the
+// signatures of whole repositories can't be compared.
+func validateDiffReference(remote string) error {
+	named, err := reference.ParseNormalizedNamed(remote)
+	if err != nil {
+		return err
+	}
+	if _, ok := named.(reference.Tagged); !ok {
+		return fmt.Errorf("invalid reference for --diff: %s: must reference a tag, for example %s:latest", remote, remote)
+	}
+	return nil
+}
+
+// printSignatureDiff prints the digests and signers of a and b if they
+// differ, and returns whether they differ.
+func printSignatureDiff(out io.Writer, a, b signatureSummary) bool {
+	sameDigests := slices.Equal(a.digests, b.digests)
+	sameSigners := slices.Equal(a.signers, b.signers)
+	if sameDigests && sameSigners {
+		_, _ = fmt.Fprintf(out, "%s and %s point at the same digest, and are signed by the same signers\n", a.remote, b.remote)
+		return false
+	}
+
+	_, _ = fmt.Fprintf(out, "%s and %s differ\n", a.remote, b.remote)
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	if !sameDigests {
+		_, _ = fmt.Fprint(w, "\nDigest\n")
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", a.remote, joinOrNone(a.digests))
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", b.remote, joinOrNone(b.digests))
+	}
+	if !sameSigners {
+		_, _ = fmt.Fprint(w, "\nSigners\n")
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", a.remote, joinOrNone(a.signers))
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", b.remote, joinOrNone(b.signers))
+	}
+	_ = w.Flush()
+	return true
+}
+
+// joinOrNone joins the given values, or returns "(none)" if there are none,
+// for example if a tag is not signed.
+func joinOrNone(values []string) string {
+	if len(values) == 0 {
+		return "(none)"
+	}
+	return strings.Join(values, ", ")
+}
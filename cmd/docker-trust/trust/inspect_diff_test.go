@@ -0,0 +1,107 @@
+package trust
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cmd/docker-trust/internal/test"
+	"github.com/docker/cli/cmd/docker-trust/internal/test/notary"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/golden"
+)
+
+func TestTrustInspectDiffErrors(t *testing.T) {
+	testCases := []struct {
+		doc           string
+		args          []string
+		expectedError string
+	}{
+		{
+			doc:           "one reference",
+			args:          []string{"--diff", "signed-repo:red"},
+			expectedError: "--diff requires exactly 2 references",
+		},
+		{
+			doc:           "three references",
+			args:          []string{"--diff", "signed-repo:red", "signed-repo:blue", "signed-repo:green"},
+			expectedError: "--diff requires exactly 2 references",
+		},
+		{
+			doc:           "pretty",
+			args:          []string{"--diff", "--pretty", "signed-repo:red", "signed-repo:blue"},
+			expectedError: "conflicting options: --diff and --pretty cannot be used together",
+		},
+		{
+			doc:           "format",
+			args:          []string{"--diff", "--format", "{{.Name}}", "signed-repo:red", "signed-repo:blue"},
+			expectedError: "conflicting options: --diff and --format cannot be used together",
+		},
+		{
+			doc:           "quiet",
+			args:          []string{"--diff", "--quiet", "signed-repo:red", "signed-repo:blue"},
+			expectedError: "conflicting options: --diff and --quiet cannot be used together",
+		},
+		{
+			doc:           "repository",
+			args:          []string{"--diff", "signed-repo", "signed-repo:blue"},
+			expectedError: "invalid reference for --diff: signed-repo: must reference a tag, for example signed-repo:latest",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.ErrorContains(t, cmd.Execute(), tc.expectedError)
+		})
+	}
+}
+
+func TestTrustInspectDiff(t *testing.T) {
+	testCases := []struct {
+		doc      string
+		args     []string
+		expected string
+		golden   string
+	}{
+		{
+			doc:      "same",
+			args:     []string{"signed-repo:red", "signed-repo:red"},
+			expected: "signed-repo:red and signed-repo:red point at the same digest, and are signed by the same signers\n",
+		},
+		{
+			doc:    "different digests and signers",
+			args:   []string{"signed-repo:red", "signed-repo:blue"},
+			golden: "trust-inspect-diff.golden",
+		},
+		{
+			doc:    "unsigned tag",
+			args:   []string{"signed-repo:green", "signed-repo:unsigned"},
+			golden: "trust-inspect-diff-unsigned.golden",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			fakeCLI := test.NewFakeCli(&fakeClient{})
+			fakeCLI.SetNotaryClient(notary.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(fakeCLI)
+			cmd.SetArgs(append([]string{"--diff"}, tc.args...))
+			err := cmd.Execute()
+			if tc.golden == "" {
+				assert.NilError(t, err)
+				assert.Check(t, is.Equal(fakeCLI.OutBuffer().String(), tc.expected))
+				return
+			}
+			var statusErr cli.StatusError
+			assert.Assert(t, errors.As(err, &statusErr))
+			assert.Check(t, is.Equal(statusErr.StatusCode, exitCodeDiffers))
+			golden.Assert(t, fakeCLI.OutBuffer().String(), tc.golden)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package trust
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cmd/docker-trust/internal/trust"
+	"github.com/theupdateframework/notary/client"
+	"github.com/theupdateframework/notary/storage"
+)
+
+// ErrTrustOffline is returned when inspecting the trust data of a repository
+// if the notary server cannot be reached.
+type ErrTrustOffline struct {
+	// Remote is the inspected reference.
+	Remote string
+	// Err is the error returned by the notary client.
+	Err error
+}
+
+// Error describes why the notary server could not be reached, distinguishing
+// a failed TLS handshake from a failure to connect.
+func (e ErrTrustOffline) Error() string {
+	var netErr storage.NetworkError
+	if !errors.As(e.Err, &netErr) {
+		return "no signatures or cannot access " + e.Remote
+	}
+	if trust.IsTLSHandshakeError(netErr) {
+		return fmt.Sprintf("cannot access %s: TLS handshake with the notary server failed: %v (if the server requires a client certificate, set --notary-tls-cert and --notary-tls-key)", e.Remote, netErr)
+	}
+	return fmt.Sprintf("cannot access %s: cannot connect to the notary server: %v", e.Remote, netErr)
+}
+
+func (e ErrTrustOffline) Unwrap() error {
+	return e.Err
+}
+
+// ErrTrustUninitialized is returned when inspecting the trust data of a
+// repository that was never signed, and therefore has no trust data on the
+// notary server.
+type ErrTrustUninitialized struct {
+	// Remote is the inspected reference.
+	Remote string
+	// Err is the error returned by the notary client.
+	Err error
+}
+
+func (e ErrTrustUninitialized) Error() string {
+	return "no signatures or cannot access " + e.Remote
+}
+
+func (e ErrTrustUninitialized) Unwrap() error {
+	return e.Err
+}
+
+// ErrNoSignatures is returned with --require-signatures if some of the
+// inspected repositories or tags have no signatures.
+type ErrNoSignatures struct {
+	// Remotes are the inspected references without signatures.
+	Remotes []string
+}
+
+func (e ErrNoSignatures) Error() string {
+	return "no signatures for " + strings.Join(e.Remotes, ", ")
+}
+
+// notaryConnectionError returns an [ErrTrustOffline] if err indicates that the
+// notary server could not be reached to inspect remote, or nil otherwise.
+func notaryConnectionError(remote string, err error) error {
+	var netErr storage.NetworkError
+	if errors.As(err, &netErr) || errors.As(err, &storage.ErrOffline{}) {
+		return ErrTrustOffline{Remote: remote, Err: err}
+	}
+	return nil
+}
+
+// isUninitialized returns whether err indicates that the repository has no
+// trust data on the notary server.
+func isUninitialized(err error) bool {
+	return errors.As(err, &client.ErrRepositoryNotExist{}) || errors.As(err, &storage.ErrMetaNotFound{})
+}
@@ -0,0 +1,80 @@
+package trust
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/fvbommel/sortorder"
+	"github.com/theupdateframework/notary/client"
+)
+
+// jsonTrustInfo is the trust information of a repository as printed with
+// "docker trust inspect --format json". It holds the same information as
+// printed with "--pretty".
+type jsonTrustInfo struct {
+	Name               string
+	SignedTags         []ReleasedTag
+	Signers            []jsonSigner
+	AdministrativeKeys []jsonAdminKey
+}
+
+// jsonSigner is a signer and the IDs of its keys.
+type jsonSigner struct {
+	Name string
+	Keys []string
+}
+
+// jsonAdminKey is an administrative role and the IDs of its keys, with the
+// role named as in the "--pretty" output, for example "Root Key".
+type jsonAdminKey struct {
+	Role string
+	Keys []string
+}
+
+// printTrustInfoJSON prints the trust information of a repository as a
+// single-line JSON object. Lists are always printed as arrays, and never
+// omitted or printed as null, so that a repository without signed tags has
+// an empty "SignedTags" array.
+func printTrustInfoJSON(out io.Writer, info InspectResult, maxDepth int) error {
+	signatureRows := info.SignedTags
+	if signatureRows == nil {
+		signatureRows = []ReleasedTag{}
+	}
+	return json.NewEncoder(out).Encode(jsonTrustInfo{
+		Name:               info.Name,
+		SignedTags:         signatureRows,
+		Signers:            jsonSigners(info.GroupedSigners(maxDepth)),
+		AdministrativeKeys: jsonAdminKeys(info.AdminRoles),
+	})
+}
+
+func jsonSigners(roleToKeyIDs map[string][]string) []jsonSigner {
+	signers := make([]jsonSigner, 0, len(roleToKeyIDs))
+	for name, keyIDs := range roleToKeyIDs {
+		keys := append([]string{}, keyIDs...)
+		sort.Strings(keys)
+		signers = append(signers, jsonSigner{Name: name, Keys: keys})
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return sortorder.NaturalLess(signers[i].Name, signers[j].Name)
+	})
+	return signers
+}
+
+// jsonAdminKeys returns the administrative keys in the same order as printed
+// with "--pretty".
+func jsonAdminKeys(adminRoles []client.RoleWithSignatures) []jsonAdminKey {
+	sort.Slice(adminRoles, func(i, j int) bool { return adminRoles[i].Name > adminRoles[j].Name })
+	adminKeys := make([]jsonAdminKey, 0, len(adminRoles))
+	for _, adminRole := range adminRoles {
+		role := adminRoleName(adminRole.Name)
+		if role == "" {
+			continue
+		}
+		keys := append([]string{}, adminRole.KeyIDs...)
+		sort.Strings(keys)
+		adminKeys = append(adminKeys, jsonAdminKey{Role: role, Keys: keys})
+	}
+	return adminKeys
+}
@@ -0,0 +1,41 @@
+package trust
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// printKeys prints the key IDs of the signers and administrative roles of a
+// repository, as printed with "docker trust inspect --keys-only". Signers are
+// printed first, sorted by name, followed by the administrative keys in the
+// same order as printed with "--pretty".
+func printKeys(out io.Writer, info InspectResult, maxDepth int) error {
+	w := tabwriter.NewWriter(out, 0, 4, 1, ' ', 0)
+	for _, signer := range jsonSigners(info.GroupedSigners(maxDepth)) {
+		_, _ = fmt.Fprintf(w, "%s:\t%s\n", signer.Name, strings.Join(signer.Keys, ", "))
+	}
+	sort.Slice(info.AdminRoles, func(i, j int) bool { return info.AdminRoles[i].Name > info.AdminRoles[j].Name })
+	for _, adminRole := range info.AdminRoles {
+		_, _ = fmt.Fprint(w, formatAdminRole(adminRole))
+	}
+	return w.Flush()
+}
+
+// printKeysJSON prints the key IDs of the signers and administrative roles of
+// a repository as a single-line JSON object, mapping the name of each signer
+// and administrative role to the IDs of its keys, as printed with
+// "docker trust inspect --keys-only --format json".
+func printKeysJSON(out io.Writer, info InspectResult, maxDepth int) error {
+	keys := make(map[string][]string)
+	for _, signer := range jsonSigners(info.GroupedSigners(maxDepth)) {
+		keys[signer.Name] = signer.Keys
+	}
+	for _, adminKey := range jsonAdminKeys(info.AdminRoles) {
+		keys[adminKey.Role] = adminKey.Keys
+	}
+	return json.NewEncoder(out).Encode(keys)
+}
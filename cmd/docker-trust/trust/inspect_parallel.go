@@ -0,0 +1,108 @@
+package trust
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/docker/cli/cli/command"
+)
+
+// remoteInspection is the outcome of inspecting a single reference.
+type remoteInspection struct {
+	remote string
+	info   InspectResult
+	err    error
+
+	// warnings holds the warnings of a concurrent inspection, which are
+	// printed once all references are inspected.
+	warnings bytes.Buffer
+}
+
+// forEachInspection inspects the references in opts.remotes as described in
+// [inspectAll], and calls fn with the outcome of each once all references
+// are inspected, in the order of opts.remotes, so that the output does not
+// depend on opts.concurrency, or on the order in which the inspections
+// complete. The warnings of each reference are printed on stderr before
+// calling fn for it.
+func forEachInspection(ctx context.Context, dockerCLI command.Cli, opts inspectOptions, fn func(*remoteInspection) error) error {
+	for _, res := range inspectAll(ctx, dockerCLI, opts) {
+		_, _ = io.Copy(dockerCLI.Err(), &res.warnings)
+		if err := fn(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inspectEach calls fn with the trust information of each reference in
+// opts.remotes, and the number of references for which fn was called before.
+// References are inspected as described in [forEachInspection]. References
+// that cannot be inspected are skipped, and reported on stderr at the end.
+// If a single reference is inspected, its error is returned as-is.
+func inspectEach(ctx context.Context, dockerCLI command.Cli, opts inspectOptions, fn func(index int, remote string, info InspectResult) error) error {
+	var (
+		index  int
+		failed []*remoteInspection
+	)
+	err := forEachInspection(ctx, dockerCLI, opts, func(res *remoteInspection) error {
+		if res.err != nil {
+			failed = append(failed, res)
+			return nil
+		}
+		if err := fn(index, res.remote, res.info); err != nil {
+			return err
+		}
+		index++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(opts.remotes) == 1 && len(failed) == 1 {
+		return failed[0].err
+	}
+	for _, res := range failed {
+		_, _ = fmt.Fprintf(dockerCLI.Err(), "%s: %v\n", res.remote, res.err)
+	}
+	return bulkError(len(failed), len(opts.remotes))
+}
+
+// inspectAll inspects the references in opts.remotes with up to
+// opts.concurrency workers, and returns the outcomes in the order of
+// opts.remotes.
+// If references are inspected concurrently, the warnings of each reference
+// are buffered, and no progress is shown, to not interleave the output of
+// concurrent inspections. References without signatures are added to
+// opts.unsigned in the same order.
+func inspectAll(ctx context.Context, dockerCLI command.Cli, opts inspectOptions) []*remoteInspection {
+	results := make([]*remoteInspection, len(opts.remotes))
+	sem := make(chan struct{}, max(opts.concurrency, 1))
+	var wg sync.WaitGroup
+	for i, remote := range opts.remotes {
+		res := &remoteInspection{remote: remote}
+		results[i] = res
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			workerOpts := opts
+			workerOpts.unsigned = nil
+			if opts.concurrency > 1 {
+				workerOpts.errOut = &res.warnings
+			}
+			res.info, res.err = inspectRemote(ctx, dockerCLI, remote, workerOpts)
+		})
+	}
+	wg.Wait()
+
+	if opts.unsigned != nil {
+		for _, res := range results {
+			if res.err == nil && len(res.info.SignedTags) == 0 {
+				*opts.unsigned = append(*opts.unsigned, res.remote)
+			}
+		}
+	}
+	return results
+}
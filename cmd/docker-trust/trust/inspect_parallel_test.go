@@ -0,0 +1,120 @@
+package trust
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli/streams"
+	"github.com/docker/cli/cmd/docker-trust/internal/test"
+	"github.com/docker/cli/cmd/docker-trust/internal/test/notary"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/golden"
+)
+
+func TestTrustInspectConcurrency(t *testing.T) {
+	remotes := []string{"signed-repo:red", "signed-repo:blue", "signed-repo", "signed-repo:green"}
+
+	testCases := []struct {
+		doc  string
+		args []string
+	}{
+		{doc: "Default"},
+		{doc: "JSON", args: []string{"--format=json"}},
+		{doc: "Pretty", args: []string{"--pretty"}},
+		{doc: "Quiet", args: []string{"--quiet"}},
+		{doc: "KeysOnly", args: []string{"--keys-only"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			// The output of concurrent inspections is in the order of the
+			// references, which must match the output of inspecting the
+			// references one by one.
+			expected := test.NewFakeCli(&fakeClient{})
+			expected.SetNotaryClient(notary.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(expected)
+			cmd.SetArgs(append(append([]string{}, tc.args...), remotes...))
+			assert.NilError(t, cmd.Execute())
+
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+			cmd = newInspectCommand(cli)
+			cmd.SetArgs(append(append([]string{"--concurrency", "3"}, tc.args...), remotes...))
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), expected.OutBuffer().String()))
+		})
+	}
+}
+
+func TestTrustInspectArgumentOrder(t *testing.T) {
+	for _, concurrency := range []string{"1", "2"} {
+		t.Run(concurrency, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs([]string{"--concurrency", concurrency, "--format", "{{.Repository}}", "signed-repo:red", "signed-repo:blue", "signed-repo"})
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), "signed-repo:red\nsigned-repo:blue\nsigned-repo\n"))
+		})
+	}
+}
+
+func TestTrustInspectConcurrencyErrors(t *testing.T) {
+	testCases := []struct {
+		doc  string
+		args []string
+	}{
+		{doc: "JSON", args: []string{"--format=json"}},
+		{doc: "Pretty", args: []string{"--pretty"}},
+		{doc: "Quiet", args: []string{"--quiet"}},
+	}
+	for _, tc := range testCases {
+		// Errors are handled the same, whether references are inspected
+		// concurrently or not.
+		for _, concurrency := range []string{"1", "2"} {
+			t.Run(tc.doc+"/"+concurrency, func(t *testing.T) {
+				expected := test.NewFakeCli(&fakeClient{})
+				expected.SetNotaryClient(notary.GetLoadedNotaryRepository)
+				cmd := newInspectCommand(expected)
+				cmd.SetArgs(append(append([]string{}, tc.args...), "signed-repo:red", "signed-repo:blue"))
+				assert.NilError(t, cmd.Execute())
+
+				cli := test.NewFakeCli(&fakeClient{})
+				cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+				cmd = newInspectCommand(cli)
+				cmd.SetArgs(append(append([]string{"--concurrency", concurrency}, tc.args...), "signed-repo:red", "Invalid-Repo", "signed-repo:blue"))
+				assert.Error(t, cmd.Execute(), "failed to inspect 1 of 3 references")
+				assert.Check(t, is.Equal(cli.OutBuffer().String(), expected.OutBuffer().String()))
+				assert.Check(t, is.Contains(cli.ErrBuffer().String(), "Invalid-Repo: "))
+			})
+		}
+	}
+}
+
+func TestTrustInspectConcurrencyRequireSignatures(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"--concurrency", "2", "--quiet", "--require-signatures", "signed-repo:unsigned", "signed-repo:red", "signed-repo:also-unsigned"})
+	assert.Error(t, cmd.Execute(), "no signatures for signed-repo:unsigned, signed-repo:also-unsigned")
+}
+
+func TestTrustInspectInvalidConcurrency(t *testing.T) {
+	cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"--concurrency", "0", "signed-repo"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "invalid value for --concurrency: 0: must be a positive number")
+}
+
+func TestTrustInspectBulkConcurrency(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader(bulkReferences))))
+	cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"--concurrency", "4", "--format", "json", "-"})
+	assert.Error(t, cmd.Execute(), "failed to inspect 1 of 4 references")
+	golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-bulk-json-concurrency.golden")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "ALPINE: invalid reference format: repository name (library/ALPINE) must be lowercase\n"))
+}
@@ -1,90 +1,329 @@
 package trust
 
 import (
-	"context"
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"path"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/docker/cli/cli/command"
+	"github.com/distribution/reference"
 	"github.com/docker/cli/cli/command/formatter"
+	"github.com/docker/cli/internal/tui"
 	"github.com/fvbommel/sortorder"
+	"github.com/morikuni/aec"
+	"github.com/theupdateframework/notary"
 	"github.com/theupdateframework/notary/client"
+	"github.com/theupdateframework/notary/tuf/data"
 )
 
-func prettyPrintTrustInfo(ctx context.Context, dockerCLI command.Cli, remote string) error {
-	signatureRows, adminRolesWithSigs, delegationRoles, err := lookupTrustInfo(ctx, dockerCLI, remote)
-	if err != nil {
-		return err
-	}
+// prettyPrintOptions holds the options for [prettyPrintTrustInfo].
+type prettyPrintOptions struct {
+	// signerFormat is the template to format the signer table with. The
+	// default table is printed if empty.
+	signerFormat string
+
+	// maxDepth is the depth below which the signers of nested delegation
+	// roles are grouped, or 0 for no limit.
+	maxDepth int
+
+	// showTimes shows when each signer last signed, and verbose shows the
+	// signing threshold of each signer.
+	showTimes bool
+	verbose   bool
+
+	// showExpired shows when the metadata of the administrative roles
+	// expires.
+	showExpired bool
 
-	if len(signatureRows) > 0 {
-		_, _ = fmt.Fprintf(dockerCLI.Out(), "\nSignatures for %s\n\n", remote)
+	// tree prints the signed tags as a tree of the delegation roles that
+	// signed them, instead of a table.
+	tree bool
 
-		if err := printSignatures(dockerCLI.Out(), signatureRows); err != nil {
+	// summary prints a summary line at the end.
+	summary bool
+}
+
+// prettyPrintTrustInfo prints the trust information of a repository in a
+// human friendly format, as printed with "docker trust inspect --pretty".
+func prettyPrintTrustInfo(out tui.Output, info InspectResult, opts prettyPrintOptions) error {
+	remote := info.Name
+	if len(info.SignedTags) > 0 {
+		_, _ = fmt.Fprintf(out, "\nSignatures for %s\n\n", remote)
+
+		if opts.tree {
+			printSignerTree(out, newSignerTree(info.SignedTags, opts.maxDepth))
+		} else if err := printSignatures(out, info.SignedTags); err != nil {
 			return err
 		}
 	} else {
-		_, _ = fmt.Fprintf(dockerCLI.Out(), "\nNo signatures for %s\n\n", remote)
+		msg := info.noSignatures()
+		_, _ = fmt.Fprintf(out, "\n%s%s\n\n", strings.ToUpper(msg[:1]), msg[1:])
 	}
-	signerRoleToKeyIDs := getDelegationRoleToKeyMap(delegationRoles)
+	signerRoleToKeyIDs := info.GroupedSigners(opts.maxDepth)
 
 	// If we do not have additional signers, do not display
 	if len(signerRoleToKeyIDs) > 0 {
-		_, _ = fmt.Fprintf(dockerCLI.Out(), "\nList of signers and their keys for %s\n\n", remote)
-		if err := printSignerInfo(dockerCLI.Out(), signerRoleToKeyIDs); err != nil {
+		_, _ = fmt.Fprintf(out, "\nList of signers and their keys for %s\n\n", remote)
+		var err error
+		if !opts.showTimes && !opts.verbose && opts.signerFormat == "" {
+			err = RenderSignerTable(out, signerRoleToKeyIDs)
+		} else {
+			var signingTimes map[string]time.Time
+			if opts.showTimes {
+				signingTimes = lookupSigningTimes(info.trustDir, remote, signerRoleToKeyIDs)
+			}
+			thresholds := info.signerThresholds(opts.maxDepth)
+			err = printSignerInfo(out, signerRoleToKeyIDs, thresholds, signingTimes, opts.signerFormat, opts.verbose)
+		}
+		if err != nil {
 			return err
 		}
 	}
 
 	// This will always have the root and targets information
-	_, _ = fmt.Fprintf(dockerCLI.Out(), "\nAdministrative keys for %s\n\n", remote)
-	printSortedAdminKeys(dockerCLI.Out(), adminRolesWithSigs)
+	_, _ = fmt.Fprintf(out, "\nAdministrative keys for %s\n\n", remote)
+	var expiries map[data.RoleName]time.Time
+	if opts.showExpired {
+		expiries = lookupExpiries(info.trustDir, remote)
+	}
+	printSortedAdminKeys(out, info.AdminRoles, expiries)
+
+	if info.attestations != nil {
+		printAttestations(out, remote, info.attestations)
+	}
+
+	if opts.summary {
+		_, _ = fmt.Fprintf(out, "\n%s\n", formatSummary(info.trustDir, remote, info.SignedTags, signerRoleToKeyIDs, info.AdminRoles))
+	}
 	return nil
 }
 
-func printSortedAdminKeys(out io.Writer, adminRoles []client.RoleWithSignatures) {
+// formatSummary returns a one-line summary of the trust data of a repository,
+// for example "3 signed tags, 2 signers, root key present, targets expires
+// 2030-01-01". The expiry of the targets metadata is taken from the local
+// TUF cache, and omitted if not available.
+func formatSummary(trustDir, remote string, signatureRows []ReleasedTag, signers map[string][]string, adminRoles []client.RoleWithSignatures) string {
+	parts := []string{
+		pluralize(len(signatureRows), "signed tag", "signed tags"),
+		pluralize(len(signers), "signer", "signers"),
+	}
+	rootKey := "no root key"
+	for _, adminRole := range adminRoles {
+		if adminRole.Name == data.CanonicalRootRole && len(adminRole.KeyIDs) > 0 {
+			rootKey = "root key present"
+			break
+		}
+	}
+	parts = append(parts, rootKey)
+	if metadataDir, ok := localMetadataDir(trustDir, remote); ok {
+		if expires, ok := metadataExpiry(metadataDir, data.CanonicalTargetsRole.String()); ok {
+			parts = append(parts, "targets expires "+expires.UTC().Format(time.DateOnly))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return strconv.Itoa(n) + " " + plural
+}
+
+// printSortedAdminKeys prints the keys of the administrative roles. If
+// expiries is not nil, the expiry of each role is added, followed by the
+// expiry of the snapshot and timestamp metadata, which have no keys of their
+// own that are shown.
+func printSortedAdminKeys(out io.Writer, adminRoles []client.RoleWithSignatures, expiries map[data.RoleName]time.Time) {
 	sort.Slice(adminRoles, func(i, j int) bool { return adminRoles[i].Name > adminRoles[j].Name })
 	for _, adminRole := range adminRoles {
-		if formattedAdminRole := formatAdminRole(adminRole); formattedAdminRole != "" {
-			_, _ = fmt.Fprintf(out, "  %s", formattedAdminRole)
+		formattedAdminRole := formatAdminRole(adminRole)
+		if formattedAdminRole == "" {
+			continue
+		}
+		if expires, ok := expiries[adminRole.Name]; ok {
+			formattedAdminRole = strings.TrimSuffix(formattedAdminRole, "\n") + "\t" + formatExpiry(expires) + "\n"
+		}
+		_, _ = fmt.Fprintf(out, "  %s", formattedAdminRole)
+	}
+	for _, role := range []data.RoleName{data.CanonicalSnapshotRole, data.CanonicalTimestampRole} {
+		if expires, ok := expiries[role]; ok {
+			_, _ = fmt.Fprintf(out, "  %s:\t%s\n", metadataRoleName(role), formatExpiry(expires))
 		}
 	}
 }
 
-// pretty print with ordered rows
-func printSignatures(out io.Writer, signatureRows []trustTagRow) error {
-	trustTagCtx := formatter.Context{
-		Output: out,
-		Format: defaultTrustTagTableFormat,
+// expiryRoles are the roles of which the expiry of the metadata is shown
+// with --show-expired.
+var expiryRoles = []data.RoleName{
+	data.CanonicalRootRole,
+	data.CanonicalTargetsRole,
+	data.CanonicalSnapshotRole,
+	data.CanonicalTimestampRole,
+}
+
+// lookupExpiries returns the expiry of the metadata of the administrative
+// roles of the repository in the local TUF cache. Roles without (readable)
+// metadata are omitted.
+func lookupExpiries(trustDir, remote string) map[data.RoleName]time.Time {
+	expiries := make(map[data.RoleName]time.Time)
+	metadataDir, ok := localMetadataDir(trustDir, remote)
+	if !ok {
+		return expiries
 	}
+	for _, role := range expiryRoles {
+		if expires, ok := metadataExpiry(metadataDir, role.String()); ok {
+			expiries[role] = expires
+		}
+	}
+	return expiries
+}
+
+// formatExpiry formats the expiry of metadata as "(expires 2030-01-02)", or
+// as "(expired 2020-01-02)" if it has expired.
+func formatExpiry(expires time.Time) string {
+	if !expires.After(time.Now()) {
+		return "(expired " + expires.UTC().Format(time.DateOnly) + ")"
+	}
+	return "(expires " + expires.UTC().Format(time.DateOnly) + ")"
+}
+
+// metadataRoleName returns the display name of the snapshot and timestamp
+// roles.
+func metadataRoleName(role data.RoleName) string {
+	switch role {
+	case data.CanonicalSnapshotRole:
+		return "Snapshot"
+	case data.CanonicalTimestampRole:
+		return "Timestamp"
+	default:
+		return role.String()
+	}
+}
+
+// warnExpiringMetadata prints a warning to out for each administrative role
+// of which the metadata in the local TUF cache has expired, or expires within
+// the given window.
+func warnExpiringMetadata(out io.Writer, trustDir, remote string, window time.Duration) {
+	expiries := lookupExpiries(trustDir, remote)
+	now := time.Now()
+	for _, role := range expiryRoles {
+		expires, ok := expiries[role]
+		switch {
+		case !ok:
+		case !expires.After(now):
+			_, _ = fmt.Fprintf(out, "WARNING: the %s metadata of %s expired on %s\n", role, remote, expires.UTC().Format(time.DateOnly))
+		case expires.Before(now.Add(window)):
+			_, _ = fmt.Fprintf(out, "WARNING: the %s metadata of %s expires soon, on %s\n", role, remote, expires.UTC().Format(time.DateOnly))
+		}
+	}
+}
+
+// pretty print with ordered rows. If out is a terminal, released tags that
+// have no signers other than the repository key are highlighted.
+func printSignatures(out tui.Output, signatureRows []ReleasedTag) error {
 	// convert the formatted type before printing
 	formattedTags := []signedTagInfo{}
 	for _, sigRow := range signatureRows {
-		formattedSigners := sigRow.Signers
-		if len(formattedSigners) == 0 {
-			formattedSigners = append(formattedSigners, fmt.Sprintf("(%s)", releasedRoleName))
+		formattedTags = append(formattedTags, newSignedTagInfo("", sigRow))
+	}
+	return writeSignatures(out, defaultTrustTagTableFormat, formattedTags)
+}
+
+// newSignedTagInfo converts a signed tag of the given repository to the
+// formatted type for printing.
+func newSignedTagInfo(repository string, sigRow ReleasedTag) signedTagInfo {
+	formattedSigners := sigRow.Signers
+	if len(formattedSigners) == 0 {
+		formattedSigners = append(formattedSigners, fmt.Sprintf("(%s)", releasedRoleName))
+	}
+	return signedTagInfo{
+		Repository:    repository,
+		Name:          sigRow.SignedTag,
+		Digest:        sigRow.Digest,
+		Signers:       formattedSigners,
+		RepoAdminOnly: len(sigRow.Signers) == 0,
+	}
+}
+
+// writeSignatures prints the signed tags using the given table format. If
+// out is a terminal, the rows are colored depending on whether the tag has
+// signers.
+func writeSignatures(out tui.Output, format formatter.Format, signedTags []signedTagInfo) error {
+	var buf bytes.Buffer
+	trustTagCtx := formatter.Context{
+		Output: &buf,
+		Format: format,
+	}
+	if err := tagWrite(trustTagCtx, signedTags); err != nil {
+		return err
+	}
+
+	// Colorize the rows after formatting the table, so that the escape
+	// sequences do not affect the alignment of the columns. The first
+	// line is the header, followed by a line for each signed tag.
+	lines := strings.SplitAfter(buf.String(), "\n")
+	for i, line := range lines {
+		if i > 0 && i <= len(signedTags) {
+			clr := signedColor
+			if signedTags[i-1].RepoAdminOnly {
+				clr = unsignedColor
+			}
+			line = out.Color(clr).Apply(strings.TrimSuffix(line, "\n")) + "\n"
 		}
-		formattedTags = append(formattedTags, signedTagInfo{
-			Name:    sigRow.SignedTag,
-			Digest:  sigRow.Digest,
-			Signers: formattedSigners,
-		})
+		_, _ = io.WriteString(out, line)
 	}
-	return tagWrite(trustTagCtx, formattedTags)
+	return nil
 }
 
-func printSignerInfo(out io.Writer, roleToKeyIDs map[string][]string) error {
+var (
+	// signedColor is the color of tags that were signed by a signer.
+	signedColor = aec.GreenF
+	// unsignedColor is the color of tags that were only signed with the
+	// repository key.
+	unsignedColor = tui.ColorWarning
+)
+
+// RenderSignerTable writes a table of the signers in roleToKeyIDs and their
+// keys to w, sorted by signer name in natural order, as printed by
+// "docker trust inspect --pretty".
+func RenderSignerTable(w io.Writer, roleToKeyIDs map[string][]string) error {
+	return printSignerInfo(w, roleToKeyIDs, nil, nil, "", false)
+}
+
+// printSignerInfo prints the signers and their keys, sorted by signer name.
+// The time each signer last signed is included if signingTimes is non-nil.
+// The signers are printed using the given Go template if format is set.
+func printSignerInfo(out io.Writer, roleToKeyIDs map[string][]string, thresholds map[string]int, signingTimes map[string]time.Time, format string, verbose bool) error {
 	signerInfoCtx := formatter.Context{
 		Output: out,
 		Format: defaultSignerInfoTableFormat,
 		Trunc:  true,
 	}
+	switch {
+	case format != "":
+		signerInfoCtx.Format = formatter.Format(format)
+	case signingTimes != nil && verbose:
+		signerInfoCtx.Format = signerInfoVerboseWithTimesTableFormat
+	case signingTimes != nil:
+		signerInfoCtx.Format = signerInfoWithTimesTableFormat
+	case verbose:
+		signerInfoCtx.Format = signerInfoVerboseTableFormat
+	}
 	formattedSignerInfo := []signerInfo{}
 	for name, keyIDs := range roleToKeyIDs {
 		formattedSignerInfo = append(formattedSignerInfo, signerInfo{
-			Name: name,
-			Keys: keyIDs,
+			Name:       name,
+			Keys:       keyIDs,
+			Threshold:  thresholds[name],
+			LastSigned: signingTimes[name],
 		})
 	}
 	sort.Slice(formattedSignerInfo, func(i, j int) bool {
@@ -92,3 +331,70 @@ func printSignerInfo(out io.Writer, roleToKeyIDs map[string][]string) error {
 	})
 	return signerInfoWrite(signerInfoCtx, formattedSignerInfo)
 }
+
+// lookupSigningTimes returns the estimated time each signer last signed,
+// based on the delegation metadata of the repository in the local TUF cache.
+// Notary does not record when metadata was signed, but sets the expiry of
+// delegation metadata to a fixed period after signing, from which the signing
+// time is derived. Signers without (readable) metadata are omitted.
+func lookupSigningTimes(trustDir, remote string, signers map[string][]string) map[string]time.Time {
+	signingTimes := make(map[string]time.Time)
+	metadataDir, ok := localMetadataDir(trustDir, remote)
+	if !ok {
+		return signingTimes
+	}
+	for signer := range signers {
+		if t, ok := signingTime(metadataDir, signer); ok {
+			signingTimes[signer] = t
+		}
+	}
+	return signingTimes
+}
+
+// signingTime returns the estimated time the given signer last signed, based
+// on the expiry of the signer's delegation metadata in metadataDir.
+func signingTime(metadataDir, signer string) (time.Time, bool) {
+	expires, ok := metadataExpiry(metadataDir, path.Join(data.CanonicalTargetsRole.String(), signer))
+	if !ok {
+		return time.Time{}, false
+	}
+	return expires.Add(-notary.NotaryTargetsExpiry), true
+}
+
+// localMetadataDir returns the directory holding the metadata of the given
+// repository in the TUF cache in trustDir.
+func localMetadataDir(trustDir, remote string) (string, bool) {
+	named, err := reference.ParseNormalizedNamed(remote)
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(trustDir, "tuf", filepath.FromSlash(named.Name()), "metadata"), true
+}
+
+// hasCachedTrustData returns whether the local TUF cache holds the root
+// metadata of the given repository, which is needed to verify the other
+// cached metadata.
+func hasCachedTrustData(trustDir, remote string) bool {
+	metadataDir, ok := localMetadataDir(trustDir, remote)
+	if !ok {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(metadataDir, data.CanonicalRootRole.String()+".json"))
+	return err == nil
+}
+
+// metadataExpiry returns the expiry of the metadata of the given role in
+// metadataDir.
+func metadataExpiry(metadataDir, role string) (time.Time, bool) {
+	raw, err := os.ReadFile(filepath.Join(metadataDir, filepath.FromSlash(role)+".json"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var meta struct {
+		Signed data.SignedCommon `json:"signed"`
+	}
+	if err := json.Unmarshal(raw, &meta); err != nil || meta.Signed.Expires.IsZero() {
+		return time.Time{}, false
+	}
+	return meta.Signed.Expires, true
+}
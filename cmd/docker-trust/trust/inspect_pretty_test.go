@@ -4,10 +4,17 @@
 	"bytes"
 	"context"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cmd/docker-trust/internal/test"
 	notaryfake "github.com/docker/cli/cmd/docker-trust/internal/test/notary"
 	"github.com/docker/cli/cmd/docker-trust/internal/trust"
@@ -24,6 +31,7 @@
 
 type fakeClient struct {
 	client.Client
+	imageInspectFunc func(string, ...client.ImageInspectOption) (client.ImageInspectResult, error)
 }
 
 type fakeStreamResult struct {
@@ -38,7 +46,10 @@ func (*fakeClient) Info(context.Context, client.InfoOptions) (client.SystemInfoR
 	return client.SystemInfoResult{}, nil
 }
 
-func (*fakeClient) ImageInspect(context.Context, string, ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+func (c *fakeClient) ImageInspect(_ context.Context, imageID string, opts ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+	if c.imageInspectFunc != nil {
+		return c.imageInspectFunc(imageID, opts...)
+	}
 	return client.ImageInspectResult{}, nil
 }
 
@@ -79,6 +90,16 @@ func TestTrustInspectPrettyCommandErrors(t *testing.T) {
 	}
 }
 
+func TestTrustInspectPrettyCommandDigestReference(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notaryfake.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	dgst := "sha256:" + strings.Repeat("a", 64)
+	cmd.SetArgs([]string{"--pretty", "--no-summary", "signed-repo@" + dgst})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "No released tag points at "+dgst+" in signed-repo\n"))
+}
+
 func TestTrustInspectPrettyCommandOfflineErrors(t *testing.T) {
 	cli := test.NewFakeCli(&fakeClient{})
 	cli.SetNotaryClient(notaryfake.GetOfflineNotaryRepository)
@@ -148,6 +169,7 @@ func TestTrustInspectPrettyCommandFullRepoWithoutSigners(t *testing.T) {
 	cli.SetNotaryClient(notaryfake.GetLoadedWithNoSignersNotaryRepository)
 	cmd := newInspectCommand(cli)
 	assert.NilError(t, cmd.Flags().Set("pretty", "true"))
+	assert.NilError(t, cmd.Flags().Set("no-summary", "true"))
 	cmd.SetArgs([]string{"signed-repo"})
 	assert.NilError(t, cmd.Execute())
 
@@ -159,6 +181,7 @@ func TestTrustInspectPrettyCommandOneTagWithoutSigners(t *testing.T) {
 	cli.SetNotaryClient(notaryfake.GetLoadedWithNoSignersNotaryRepository)
 	cmd := newInspectCommand(cli)
 	assert.NilError(t, cmd.Flags().Set("pretty", "true"))
+	assert.NilError(t, cmd.Flags().Set("no-summary", "true"))
 	cmd.SetArgs([]string{"signed-repo:green"})
 	assert.NilError(t, cmd.Execute())
 
@@ -170,48 +193,143 @@ func TestTrustInspectPrettyCommandFullRepoWithSigners(t *testing.T) {
 	cli.SetNotaryClient(notaryfake.GetLoadedNotaryRepository)
 	cmd := newInspectCommand(cli)
 	assert.NilError(t, cmd.Flags().Set("pretty", "true"))
+	assert.NilError(t, cmd.Flags().Set("no-summary", "true"))
 	cmd.SetArgs([]string{"signed-repo"})
 	assert.NilError(t, cmd.Execute())
 
 	golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-full-repo-with-signers.golden")
 }
 
+func TestTrustInspectPrettyCommandVerbose(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notaryfake.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"--pretty", "--no-summary", "--verbose", "signed-repo"})
+	assert.NilError(t, cmd.Execute())
+
+	golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-full-repo-verbose.golden")
+}
+
+func TestTrustInspectPrettyCommandColors(t *testing.T) {
+	tests := []struct {
+		doc      string
+		isTerm   bool
+		noColor  bool
+		expected string
+	}{
+		{
+			doc:      "terminal",
+			isTerm:   true,
+			expected: "trust-inspect-pretty-full-repo-with-signers-color.golden",
+		},
+		{
+			doc:      "terminal with NO_COLOR",
+			isTerm:   true,
+			noColor:  true,
+			expected: "trust-inspect-pretty-full-repo-with-signers.golden",
+		},
+		{
+			doc:      "no terminal",
+			expected: "trust-inspect-pretty-full-repo-with-signers.golden",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			if tc.noColor {
+				t.Setenv("NO_COLOR", "1")
+			} else {
+				t.Setenv("NO_COLOR", "")
+			}
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.Out().SetIsTerminal(tc.isTerm)
+			cli.SetNotaryClient(notaryfake.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs([]string{"--pretty", "--no-summary", "signed-repo"})
+			assert.NilError(t, cmd.Execute())
+
+			golden.Assert(t, cli.OutBuffer().String(), tc.expected)
+		})
+	}
+}
+
 func TestTrustInspectPrettyCommandUnsignedTagInSignedRepo(t *testing.T) {
 	cli := test.NewFakeCli(&fakeClient{})
 	cli.SetNotaryClient(notaryfake.GetLoadedNotaryRepository)
 	cmd := newInspectCommand(cli)
 	assert.NilError(t, cmd.Flags().Set("pretty", "true"))
+	assert.NilError(t, cmd.Flags().Set("no-summary", "true"))
 	cmd.SetArgs([]string{"signed-repo:unsigned"})
 	assert.NilError(t, cmd.Execute())
 
 	golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-unsigned-tag-with-signers.golden")
 }
 
+func TestTrustInspectPrettyCommandSummary(t *testing.T) {
+	configDir := config.Dir()
+	t.Cleanup(func() { config.SetDir(configDir) })
+	config.SetDir(t.TempDir())
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notaryfake.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	assert.NilError(t, cmd.Flags().Set("pretty", "true"))
+	cmd.SetArgs([]string{"signed-repo"})
+	assert.NilError(t, cmd.Execute())
+
+	golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-summary.golden")
+}
+
+func TestTrustInspectNoSummaryWithoutPretty(t *testing.T) {
+	cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"--no-summary", "signed-repo"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "--no-summary can only be used with --pretty")
+}
+
+func TestFormatSummary(t *testing.T) {
+	trustDir := t.TempDir()
+	metadataDir, ok := localMetadataDir(trustDir, "example/repo")
+	assert.Assert(t, ok)
+	assert.NilError(t, os.MkdirAll(metadataDir, 0o755))
+	assert.NilError(t, os.WriteFile(filepath.Join(metadataDir, "targets.json"), []byte(`{"signed":{"_type":"Targets","expires":"2030-01-02T03:04:05Z"}}`), 0o644))
+
+	summary := formatSummary(trustDir, "example/repo",
+		[]ReleasedTag{{trustTagKey: trustTagKey{SignedTag: "v1"}}},
+		map[string][]string{"alice": {"A"}, "bob": {"B"}},
+		[]notaryclient.RoleWithSignatures{{Role: data.Role{RootRole: data.RootRole{KeyIDs: []string{"rootID"}}, Name: data.CanonicalRootRole}}},
+	)
+	assert.Check(t, is.Equal(summary, "1 signed tag, 2 signers, root key present, targets expires 2030-01-02"))
+
+	summary = formatSummary(trustDir, "example/other-repo", nil, nil, nil)
+	assert.Check(t, is.Equal(summary, "0 signed tags, 0 signers, no root key"))
+}
+
 func TestNotaryRoleToSigner(t *testing.T) {
-	assert.Check(t, is.Equal(releasedRoleName, notaryRoleToSigner(data.CanonicalTargetsRole)))
-	assert.Check(t, is.Equal(releasedRoleName, notaryRoleToSigner(trust.ReleasesRole)))
-	assert.Check(t, is.Equal("signer", notaryRoleToSigner("targets/signer")))
-	assert.Check(t, is.Equal("docker/signer", notaryRoleToSigner("targets/docker/signer")))
+	assert.Check(t, is.Equal(releasedRoleName, NotaryRoleToSigner(data.CanonicalTargetsRole)))
+	assert.Check(t, is.Equal(releasedRoleName, NotaryRoleToSigner(trust.ReleasesRole)))
+	assert.Check(t, is.Equal("signer", NotaryRoleToSigner("targets/signer")))
+	assert.Check(t, is.Equal("docker/signer", NotaryRoleToSigner("targets/docker/signer")))
 
 	// It's nonsense for other base roles to have signed off on a target, but this function leaves role names intact
 	for _, role := range data.BaseRoles {
 		if role == data.CanonicalTargetsRole {
 			continue
 		}
-		assert.Check(t, is.Equal(role.String(), notaryRoleToSigner(role)))
+		assert.Check(t, is.Equal(role.String(), NotaryRoleToSigner(role)))
 	}
-	assert.Check(t, is.Equal("notarole", notaryRoleToSigner("notarole")))
+	assert.Check(t, is.Equal("notarole", NotaryRoleToSigner("notarole")))
 }
 
 // check if a role name is "released": either targets/releases or targets TUF roles
 func TestIsReleasedTarget(t *testing.T) {
-	assert.Check(t, isReleasedTarget(trust.ReleasesRole))
+	assert.Check(t, IsReleasedTarget(trust.ReleasesRole))
 	for _, role := range data.BaseRoles {
-		assert.Check(t, is.Equal(role == data.CanonicalTargetsRole, isReleasedTarget(role)))
+		assert.Check(t, is.Equal(role == data.CanonicalTargetsRole, IsReleasedTarget(role)))
 	}
-	assert.Check(t, !isReleasedTarget("targets/not-releases"))
-	assert.Check(t, !isReleasedTarget("random"))
-	assert.Check(t, !isReleasedTarget("targets/releases/subrole"))
+	assert.Check(t, !IsReleasedTarget("targets/not-releases"))
+	assert.Check(t, !IsReleasedTarget("random"))
+	assert.Check(t, !IsReleasedTarget("targets/releases/subrole"))
 }
 
 // creates a mock delegation with a given name and no keys
@@ -407,10 +525,68 @@ func TestGetSignerRolesWithKeyIDs(t *testing.T) {
 		"bob":   {"key71", "key72"},
 	}
 
-	signerRoleToKeyIDs := getDelegationRoleToKeyMap(roles)
+	signerRoleToKeyIDs := getDelegationRoleToKeyMap(roles, 0)
 	assert.Check(t, is.DeepEqual(expectedSignerRoleToKeyIDs, signerRoleToKeyIDs))
 }
 
+func TestGetDelegationRoleToKeyMapMaxDepth(t *testing.T) {
+	roles := []data.Role{
+		{RootRole: data.RootRole{KeyIDs: []string{"key11"}}, Name: "targets/alice"},
+		{RootRole: data.RootRole{KeyIDs: []string{"key21"}}, Name: "targets/docker"},
+		{RootRole: data.RootRole{KeyIDs: []string{"key31", "key32"}}, Name: "targets/docker/signer"},
+		{RootRole: data.RootRole{KeyIDs: []string{"key32", "key41"}}, Name: "targets/docker/signer/nested"},
+		{RootRole: data.RootRole{KeyIDs: []string{"key51"}}, Name: "targets/releases"},
+	}
+
+	testCases := []struct {
+		maxDepth int
+		expected map[string][]string
+	}{
+		{
+			maxDepth: 0,
+			expected: map[string][]string{
+				"alice":                {"key11"},
+				"docker":               {"key21"},
+				"docker/signer":        {"key31", "key32"},
+				"docker/signer/nested": {"key32", "key41"},
+			},
+		},
+		{
+			maxDepth: 1,
+			expected: map[string][]string{
+				"alice":      {"key11"},
+				"docker":     {"key21"},
+				"docker/...": {"key31", "key32", "key41"},
+			},
+		},
+		{
+			maxDepth: 2,
+			expected: map[string][]string{
+				"alice":             {"key11"},
+				"docker":            {"key21"},
+				"docker/signer":     {"key31", "key32"},
+				"docker/signer/...": {"key32", "key41"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(strconv.Itoa(tc.maxDepth), func(t *testing.T) {
+			assert.Check(t, is.DeepEqual(getDelegationRoleToKeyMap(roles, tc.maxDepth), tc.expected))
+		})
+	}
+}
+
+func TestSignerDepth(t *testing.T) {
+	for signer, expected := range map[string]string{
+		"alice":             "1",
+		"docker/signer":     "2",
+		"docker/...":        "2+",
+		"docker/signer/...": "3+",
+	} {
+		assert.Check(t, is.Equal(signerDepth(signer), expected), signer)
+	}
+}
+
 func TestFormatAdminRole(t *testing.T) {
 	aliceRole := data.Role{
 		RootRole: data.RootRole{
@@ -480,6 +656,270 @@ func TestPrintSignerInfoSortOrder(t *testing.T) {
 signer10-foo   C
 `
 	buf := new(bytes.Buffer)
-	assert.NilError(t, printSignerInfo(buf, roleToKeyIDs))
+	assert.NilError(t, RenderSignerTable(buf, roleToKeyIDs))
+	assert.Check(t, is.Equal(expected, buf.String()))
+}
+
+func TestPrintSignerInfoCustomFormat(t *testing.T) {
+	roleToKeyIDs := map[string][]string{
+		"signer2-foo":  {"B"},
+		"signer10-foo": {"C2", "C1"},
+		"signer1-foo":  {"A"},
+	}
+	signingTimes := map[string]time.Time{
+		"signer1-foo": time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		doc      string
+		format   string
+		times    map[string]time.Time
+		expected string
+	}{
+		{
+			doc:    "template",
+			format: `{{.Signer}} {{join .Keys ","}}`,
+			expected: `signer1-foo A
+signer2-foo B
+signer10-foo C1,C2
+`,
+		},
+		{
+			doc:    "table",
+			format: `table {{.Signer}}\t{{join .Keys ","}}`,
+			expected: `SIGNER         KEYS
+signer1-foo    A
+signer2-foo    B
+signer10-foo   C1,C2
+`,
+		},
+		{
+			doc:    "with times",
+			format: `{{.Signer}}: {{.LastSigned}}`,
+			times:  signingTimes,
+			expected: `signer1-foo: 2024-05-01T12:30:00Z
+signer2-foo: -
+signer10-foo: -
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			assert.NilError(t, printSignerInfo(buf, roleToKeyIDs, nil, tc.times, tc.format, false))
+			assert.Check(t, is.Equal(buf.String(), tc.expected))
+		})
+	}
+}
+
+func TestPrintSignerInfoVerbose(t *testing.T) {
+	roleToKeyIDs := map[string][]string{
+		"alice":      {"A1", "A2", "A3"},
+		"bob":        {"B"},
+		"docker/...": {"C", "D"},
+	}
+	thresholds := map[string]int{
+		"alice": 2,
+		"bob":   1,
+	}
+	signingTimes := map[string]time.Time{
+		"alice": time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		doc      string
+		format   string
+		times    map[string]time.Time
+		verbose  bool
+		expected string
+	}{
+		{
+			doc:     "verbose",
+			verbose: true,
+			expected: `SIGNER       KEYS         THRESHOLD
+alice        A1, A2, A3   2 of 3 keys
+bob          B            1 of 1 key
+docker/...   C, D         -
+`,
+		},
+		{
+			doc:     "verbose with times",
+			times:   signingTimes,
+			verbose: true,
+			expected: `SIGNER       KEYS         THRESHOLD     LAST SIGNED
+alice        A1, A2, A3   2 of 3 keys   2024-05-01T12:00:00Z
+bob          B            1 of 1 key    -
+docker/...   C, D         -             -
+`,
+		},
+		{
+			doc:    "template",
+			format: `{{.Signer}}: {{.Threshold}}`,
+			expected: `alice: 2 of 3 keys
+bob: 1 of 1 key
+docker/...: -
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			assert.NilError(t, printSignerInfo(buf, roleToKeyIDs, thresholds, tc.times, tc.format, tc.verbose))
+			assert.Check(t, is.Equal(buf.String(), tc.expected))
+		})
+	}
+}
+
+func TestSigningTime(t *testing.T) {
+	metadataDir := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(metadataDir, "targets"), 0o700))
+
+	expires := time.Date(2027, 5, 1, 12, 0, 0, 0, time.UTC)
+	meta := fmt.Sprintf(`{"signed":{"_type":"Targets","expires":%q,"version":2},"signatures":[]}`, expires.Format(time.RFC3339))
+	assert.NilError(t, os.WriteFile(filepath.Join(metadataDir, "targets", "alice.json"), []byte(meta), 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(metadataDir, "targets", "eve.json"), []byte("invalid"), 0o600))
+
+	signed, ok := signingTime(metadataDir, "alice")
+	assert.Check(t, ok)
+	assert.Check(t, is.Equal(signed, expires.Add(-notary.NotaryTargetsExpiry)))
+
+	_, ok = signingTime(metadataDir, "bob")
+	assert.Check(t, !ok, "expected no signing time for signer without metadata")
+
+	_, ok = signingTime(metadataDir, "eve")
+	assert.Check(t, !ok, "expected no signing time for signer with invalid metadata")
+}
+
+func TestPrintSignerInfoWithTimes(t *testing.T) {
+	roleToKeyIDs := map[string][]string{
+		"alice": {"A"},
+		"bob":   {"B"},
+	}
+	signingTimes := map[string]time.Time{
+		"alice": time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	expected := `SIGNER    KEYS      LAST SIGNED
+alice     A         2024-05-01T12:00:00Z
+bob       B         -
+`
+	buf := new(bytes.Buffer)
+	assert.NilError(t, printSignerInfo(buf, roleToKeyIDs, nil, signingTimes, "", false))
 	assert.Check(t, is.Equal(expected, buf.String()))
 }
+
+func TestTrustInspectPrettyCommandSigner(t *testing.T) {
+	testCases := []struct {
+		doc     string
+		signers []string
+		golden  string
+	}{
+		{
+			doc:     "single signer",
+			signers: []string{"bob"},
+			golden:  "trust-inspect-pretty-signer-bob.golden",
+		},
+		{
+			doc:     "multiple signers",
+			signers: []string{"bob", "Repo Admin"},
+			golden:  "trust-inspect-pretty-signer-bob-repo-admin.golden",
+		},
+		{
+			doc:     "no matching signatures",
+			signers: []string{"carol", "dave"},
+			golden:  "trust-inspect-pretty-signer-no-match.golden",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetNotaryClient(notaryfake.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(cli)
+			args := []string{"--pretty", "--no-summary"}
+			for _, signer := range tc.signers {
+				args = append(args, "--signer", signer)
+			}
+			cmd.SetArgs(append(args, "signed-repo"))
+			assert.NilError(t, cmd.Execute())
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+		})
+	}
+}
+
+func writeMetadataExpiry(t *testing.T, metadataDir string, role data.RoleName, expires time.Time) {
+	t.Helper()
+	meta := fmt.Sprintf(`{"signed":{"expires":%q}}`, expires.Format(time.RFC3339))
+	assert.NilError(t, os.WriteFile(filepath.Join(metadataDir, role.String()+".json"), []byte(meta), 0o600))
+}
+
+func TestTrustInspectPrettyCommandShowExpired(t *testing.T) {
+	configDir := config.Dir()
+	t.Cleanup(func() { config.SetDir(configDir) })
+	config.SetDir(t.TempDir())
+
+	metadataDir, ok := localMetadataDir(trust.GetTrustDirectory(), "signed-repo")
+	assert.Assert(t, ok)
+	assert.NilError(t, os.MkdirAll(metadataDir, 0o700))
+	writeMetadataExpiry(t, metadataDir, data.CanonicalRootRole, time.Date(2099, 1, 2, 0, 0, 0, 0, time.UTC))
+	writeMetadataExpiry(t, metadataDir, data.CanonicalTargetsRole, time.Date(2099, 3, 4, 0, 0, 0, 0, time.UTC))
+	writeMetadataExpiry(t, metadataDir, data.CanonicalTimestampRole, time.Date(2001, 5, 6, 0, 0, 0, 0, time.UTC))
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notaryfake.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"--pretty", "--no-summary", "--show-expired", "signed-repo"})
+	assert.NilError(t, cmd.Execute())
+
+	golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-show-expired.golden")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: the timestamp metadata of signed-repo expired on 2001-05-06\n"))
+}
+
+func TestWarnExpiringMetadata(t *testing.T) {
+	trustDir := t.TempDir()
+	metadataDir, ok := localMetadataDir(trustDir, "example/repo")
+	assert.Assert(t, ok)
+	assert.NilError(t, os.MkdirAll(metadataDir, 0o700))
+	soon := time.Now().Add(48 * time.Hour)
+	writeMetadataExpiry(t, metadataDir, data.CanonicalRootRole, time.Date(2001, 5, 6, 0, 0, 0, 0, time.UTC))
+	writeMetadataExpiry(t, metadataDir, data.CanonicalTargetsRole, time.Now().Add(10*24*time.Hour))
+	writeMetadataExpiry(t, metadataDir, data.CanonicalSnapshotRole, soon)
+
+	buf := new(bytes.Buffer)
+	warnExpiringMetadata(buf, trustDir, "example/repo", 72*time.Hour)
+	expected := "WARNING: the root metadata of example/repo expired on 2001-05-06\n" +
+		"WARNING: the snapshot metadata of example/repo expires soon, on " + soon.UTC().Format(time.DateOnly) + "\n"
+	assert.Check(t, is.Equal(buf.String(), expected))
+
+	buf.Reset()
+	warnExpiringMetadata(buf, trustDir, "example/other-repo", 72*time.Hour)
+	assert.Check(t, is.Equal(buf.String(), ""))
+}
+
+func TestTrustInspectShowExpiredErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{"--show-expired", "signed-repo"},
+			expectedError: "--show-expired can only be used with --pretty",
+		},
+		{
+			args:          []string{"--pretty", "--expiry-window", "24h", "signed-repo"},
+			expectedError: "--expiry-window can only be used with --show-expired",
+		},
+		{
+			args:          []string{"--pretty", "--show-expired", "--expiry-window", "-1h", "signed-repo"},
+			expectedError: "invalid value for --expiry-window: -1h0m0s: must be a positive duration",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expectedError, func(t *testing.T) {
+			cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expectedError)
+		})
+	}
+}
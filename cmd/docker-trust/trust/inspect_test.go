@@ -1,13 +1,25 @@
 package trust
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/config"
 	"github.com/docker/cli/cmd/docker-trust/internal/test"
 	"github.com/docker/cli/cmd/docker-trust/internal/test/notary"
+	"github.com/docker/cli/cmd/docker-trust/internal/trust"
 	"github.com/theupdateframework/notary/client"
+	"github.com/theupdateframework/notary/tuf/data"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/golden"
 )
 
@@ -138,6 +150,85 @@ func TestTrustInspectCommand(t *testing.T) {
 			notaryRepository: notary.GetLoadedNotaryRepository,
 			golden:           "trust-inspect-unsigned-tag-with-signers.golden",
 		},
+		{
+			doc:              "FullRepoWithSignerFilter",
+			args:             []string{"--signer", "alice", "signed-repo"},
+			notaryRepository: notary.GetLoadedNotaryRepository,
+			golden:           "trust-inspect-full-repo-signer-alice.golden",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetNotaryClient(tc.notaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs(tc.args)
+			assert.NilError(t, cmd.Execute())
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+		})
+	}
+}
+
+func TestTrustInspectKeysOnly(t *testing.T) {
+	testCases := []struct {
+		doc    string
+		args   []string
+		golden string
+	}{
+		{
+			doc:    "FullRepoWithSigners",
+			args:   []string{"--keys-only", "signed-repo"},
+			golden: "trust-inspect-keys-only.golden",
+		},
+		{
+			doc:    "MultipleRepos",
+			args:   []string{"--keys-only", "signed-repo:green", "signed-repo:unsigned"},
+			golden: "trust-inspect-keys-only-multiple-repos.golden",
+		},
+		{
+			doc:    "JSON",
+			args:   []string{"--keys-only", "--format", "json", "signed-repo:green", "signed-repo:unsigned"},
+			golden: "trust-inspect-keys-only-json.golden",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs(tc.args)
+			assert.NilError(t, cmd.Execute())
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+		})
+	}
+}
+
+func TestTrustInspectQuiet(t *testing.T) {
+	testCases := []struct {
+		doc              string
+		args             []string
+		notaryRepository func() (client.Repository, error)
+		golden           string
+	}{
+		{
+			doc:              "FullRepo",
+			args:             []string{"--quiet", "signed-repo"},
+			notaryRepository: notary.GetLoadedNotaryRepository,
+			golden:           "trust-inspect-quiet-full-repo.golden",
+		},
+		{
+			doc:              "MultipleTags",
+			args:             []string{"-q", "signed-repo:green", "signed-repo:unsigned", "signed-repo:red"},
+			notaryRepository: notary.GetLoadedNotaryRepository,
+			golden:           "trust-inspect-quiet-multiple-tags.golden",
+		},
+		{
+			doc:              "EmptyRepo",
+			args:             []string{"--quiet", "reg/img:unsigned-tag"},
+			notaryRepository: notary.GetEmptyTargetsNotaryRepository,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -147,7 +238,494 @@ func TestTrustInspectCommand(t *testing.T) {
 			cmd := newInspectCommand(cli)
 			cmd.SetArgs(tc.args)
 			assert.NilError(t, cmd.Execute())
+			if tc.golden == "" {
+				assert.Check(t, is.Equal(cli.OutBuffer().String(), ""))
+				return
+			}
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+		})
+	}
+}
+
+func TestTrustInspectShowTimesRequiresPretty(t *testing.T) {
+	cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"--show-times", "alpine"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "--show-times can only be used with --pretty")
+}
+
+func TestTrustInspectVerboseRequiresPretty(t *testing.T) {
+	cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"--verbose", "alpine"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "--verbose can only be used with --pretty")
+}
+
+func TestTrustInspectOptionErrors(t *testing.T) {
+	// values of the flags of conflicts that aren't booleans
+	values := map[string]string{
+		"compose": "compose.yaml",
+		"format":  "{{.Name}}",
+		"signer":  "alice",
+		"timeout": "1s",
+	}
+	flagArgs := func(option string) []string {
+		name, value, hasValue := strings.Cut(option, "=")
+		if !hasValue {
+			value = cmp.Or(values[name], "true")
+		}
+		return []string{"--" + name + "=" + value}
+	}
+	run := func(t *testing.T, args []string) error {
+		t.Helper()
+		if !slices.ContainsFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "--compose=") }) {
+			args = append(args, "signed-repo")
+		}
+		cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+		cmd.SetArgs(args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		return cmd.Execute()
+	}
+	for _, d := range inspectDependencies {
+		t.Run(d.option+" without "+d.requires, func(t *testing.T) {
+			err := run(t, flagArgs(d.option))
+			assert.Check(t, is.Error(err, "--"+d.option+" can only be used with --"+d.requires))
+		})
+	}
+	for _, c := range inspectConflicts {
+		t.Run(c.option+" and "+c.other, func(t *testing.T) {
+			err := run(t, slices.Concat(flagArgs(c.option), flagArgs(c.other)))
+			assert.Check(t, is.Error(err, "conflicting options: --"+c.option+" and --"+c.other+" cannot be used together"))
+		})
+	}
+}
+
+func TestTrustInspectJSONCommand(t *testing.T) {
+	testCases := []struct {
+		doc              string
+		args             []string
+		notaryRepository func() (client.Repository, error)
+		golden           string
+	}{
+		{
+			doc:              "EmptyNotaryRepo",
+			args:             []string{"reg/img:unsigned-tag"},
+			notaryRepository: notary.GetEmptyTargetsNotaryRepository,
+			golden:           "trust-inspect-json-empty-repo.golden",
+		},
+		{
+			doc:              "FullRepoWithSigners",
+			args:             []string{"signed-repo"},
+			notaryRepository: notary.GetLoadedNotaryRepository,
+			golden:           "trust-inspect-json-full-repo-with-signers.golden",
+		},
+		{
+			doc:              "MultipleFullReposWithSigners",
+			args:             []string{"signed-repo:green", "signed-repo:unsigned"},
+			notaryRepository: notary.GetLoadedNotaryRepository,
+			golden:           "trust-inspect-json-multiple-repos-with-signers.golden",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetNotaryClient(tc.notaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs(append([]string{"--format", "json"}, tc.args...))
+			assert.NilError(t, cmd.Execute())
+			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+		})
+	}
+}
+
+func TestTrustInspectReportFormat(t *testing.T) {
+	testCases := []struct {
+		doc      string
+		args     []string
+		expected string
+	}{
+		{
+			doc:  "report",
+			args: []string{"--format", `{{.Repository}}:{{range .SignedTags}} {{.SignedTag}}={{.Digest}}{{end}}; signers:{{range .Signers}} {{.Name}}{{end}}; admin:{{range .AdminRoles}} {{.Name}}={{range .Keys}}{{.ID}}{{end}}{{end}}`, "signed-repo"},
+			expected: "signed-repo: blue=626c75652d646967657374 green=677265656e2d646967657374 red=7265642d646967657374; signers: bob alice; admin: Root=rootID Repository=targetsID\n",
+		},
+		{
+			doc:      "fields of the JSON output",
+			args:     []string{"--format", `{{.Name}} {{len .AdministrativeKeys}} {{json .Signers}}`, "signed-repo:red"},
+			expected: `signed-repo:red 2 [{"Name":"bob","Keys":[{"ID":"B"}],"Threshold":1},{"Name":"alice","Keys":[{"ID":"A"}],"Threshold":1}]` + "\n",
+		},
+		{
+			doc:      "multiple references",
+			args:     []string{"--format", `{{.Repository}} {{len .SignedTags}}`, "signed-repo:red", "signed-repo"},
+			expected: "signed-repo:red 1\nsigned-repo 3\n",
+		},
+		{
+			doc:      "concurrency",
+			args:     []string{"--concurrency", "2", "--format", `{{.Repository}} {{len .SignedTags}}`, "signed-repo:red", "signed-repo"},
+			expected: "signed-repo:red 1\nsigned-repo 3\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs(tc.args)
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected))
+		})
+	}
+}
+
+func TestTrustInspectReportFormatInvalid(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"--format", "{{.Repository", "signed-repo"})
+	assert.Check(t, is.ErrorContains(cmd.Execute(), "template parsing error"))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), ""))
+}
+
+func TestTrustInspectFormatErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{"--format", "json", "--pretty", "alpine"},
+			expectedError: "conflicting options: --format=json and --pretty cannot be used together",
+		},
+		{
+			args:          []string{"--keys-only", "--format", "{{.Name}}", "alpine"},
+			expectedError: "conflicting options: --keys-only and --format with a template cannot be used together",
+		},
+		{
+			args:          []string{"--max-depth", "-1", "alpine"},
+			expectedError: "invalid value for --max-depth: -1: must be a positive number",
+		},
+		{
+			args:          []string{"--keys-only", "--pretty", "alpine"},
+			expectedError: "conflicting options: --keys-only and --pretty cannot be used together",
+		},
+		{
+			args:          []string{"--keys-only", "--signer", "alice", "alpine"},
+			expectedError: "conflicting options: --keys-only and --signer cannot be used together",
+		},
+		{
+			args:          []string{"--keys-only", "--verify-local", "alpine"},
+			expectedError: "conflicting options: --keys-only and --verify-local cannot be used together",
+		},
+		{
+			args:          []string{"--keys-only", "-"},
+			expectedError: "--keys-only cannot be used when reading references from stdin",
+		},
+		{
+			args:          []string{"--quiet", "--pretty", "alpine"},
+			expectedError: "conflicting options: --quiet and --pretty cannot be used together",
+		},
+		{
+			args:          []string{"-q", "--format", "json", "alpine"},
+			expectedError: "conflicting options: --quiet and --format cannot be used together",
+		},
+		{
+			args:          []string{"-q", "--keys-only", "alpine"},
+			expectedError: "conflicting options: --quiet and --keys-only cannot be used together",
+		},
+		{
+			args:          []string{"--timeout", "-1s", "alpine"},
+			expectedError: "invalid value for --timeout: -1s: must be a positive duration",
+		},
+		{
+			args:          []string{"--timeout", "10s", "--offline", "alpine"},
+			expectedError: "conflicting options: --timeout and --offline cannot be used together",
+		},
+		{
+			args:          []string{"--trust-dir", "/no/such/dir", "alpine"},
+			expectedError: "invalid value for --trust-dir: /no/such/dir: directory does not exist",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expectedError, func(t *testing.T) {
+			cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expectedError)
+		})
+	}
+}
+
+func TestTrustInspectPrettyCommandSignerFormat(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"--pretty", "--no-summary", "--format", `{{.Signer}}={{join .Keys ","}}`, "signed-repo"})
+	assert.NilError(t, cmd.Execute())
+	golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-signer-format.golden")
+}
+
+func TestInspect(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+	info, err := Inspect(context.Background(), cli, "signed-repo:green")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(info.Name, "signed-repo:green"))
+	assert.Assert(t, is.Len(info.SignedTags, 1))
+	assert.Check(t, is.Equal(info.SignedTags[0].SignedTag, "green"))
+	assert.Check(t, is.Equal(info.SignedTags[0].Digest, "677265656e2d646967657374"))
+	assert.Check(t, is.Len(info.SignedTags[0].Signers, 0))
+	assert.Check(t, is.DeepEqual(info.Signers, map[string][]string{"alice": {"A"}, "bob": {"B"}}))
+	assert.Check(t, is.DeepEqual(info.GroupedSigners(1), info.Signers))
+
+	var adminRoles []string
+	for _, role := range info.AdminRoles {
+		adminRoles = append(adminRoles, role.Name.String())
+	}
+	assert.Check(t, is.Contains(adminRoles, "root"))
+	assert.Check(t, is.Contains(adminRoles, "targets"))
+}
+
+func TestInspectErrors(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notary.GetUninitializedNotaryRepository)
+	_, err := Inspect(context.Background(), cli, "reg/unsigned-img")
+	assert.Error(t, err, "no signatures or cannot access reg/unsigned-img")
+}
+
+func TestInspectErrorTypes(t *testing.T) {
+	t.Run("offline", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetNotaryClient(notary.GetOfflineNotaryRepository)
+		_, err := Inspect(context.Background(), cli, "nonexistent-reg-name.io/image")
+		var offlineErr ErrTrustOffline
+		assert.Assert(t, errors.As(err, &offlineErr))
+		assert.Check(t, is.Equal(offlineErr.Remote, "nonexistent-reg-name.io/image"))
+		assert.Check(t, !errors.As(err, &ErrTrustUninitialized{}))
+	})
+
+	t.Run("uninitialized", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetNotaryClient(notary.GetUninitializedNotaryRepository)
+		_, err := Inspect(context.Background(), cli, "reg/unsigned-img")
+		var uninitializedErr ErrTrustUninitialized
+		assert.Assert(t, errors.As(err, &uninitializedErr))
+		assert.Check(t, is.Equal(uninitializedErr.Remote, "reg/unsigned-img"))
+		assert.Check(t, errors.As(err, &client.ErrRepositoryNotExist{}))
+		assert.Check(t, !errors.As(err, &ErrTrustOffline{}))
+	})
+}
+
+func TestTrustInspectOffline(t *testing.T) {
+	configDir := config.Dir()
+	t.Cleanup(func() { config.SetDir(configDir) })
+	config.SetDir(t.TempDir())
+
+	t.Run("no cache", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetNotaryClient(notary.GetOfflineNotaryRepository)
+		cmd := newInspectCommand(cli)
+		cmd.SetArgs([]string{"--offline", "signed-repo"})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.Error(t, cmd.Execute(), "no cached trust data for signed-repo: inspect it without --offline first to download its trust data")
+	})
+
+	metadataDir, ok := localMetadataDir(trust.GetTrustDirectory(), "signed-repo")
+	assert.Assert(t, ok)
+	assert.NilError(t, os.MkdirAll(metadataDir, 0o700))
+	assert.NilError(t, os.WriteFile(filepath.Join(metadataDir, "root.json"), []byte("{}"), 0o600))
+
+	t.Run("cached", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+		cmd := newInspectCommand(cli)
+		cmd.SetArgs([]string{"--offline", "--pretty", "--no-summary", "signed-repo"})
+		assert.NilError(t, cmd.Execute())
+		golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-full-repo-with-signers.golden")
+		assert.Check(t, is.Contains(cli.ErrBuffer().String(), "WARNING: showing cached trust data for signed-repo, which may be out of date"))
+	})
+
+	t.Run("cached without signatures", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetNotaryClient(notary.GetOfflineNotaryRepository)
+		cmd := newInspectCommand(cli)
+		cmd.SetArgs([]string{"--offline", "signed-repo"})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.Error(t, cmd.Execute(), "no signatures in cached trust data for signed-repo")
+	})
+}
+
+func TestTrustInspectUnreadableRoles(t *testing.T) {
+	testCases := []struct {
+		doc    string
+		args   []string
+		golden string
+	}{
+		{
+			doc:    "FullRepo",
+			args:   []string{"--pretty", "--no-summary", "signed-repo"},
+			golden: "trust-inspect-pretty-unreadable-role.golden",
+		},
+		{
+			doc:    "SingleTag",
+			args:   []string{"--pretty", "--no-summary", "signed-repo:red"},
+			golden: "trust-inspect-pretty-unreadable-role-tag.golden",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{})
+			cli.SetNotaryClient(notary.GetPartiallyReadableNotaryRepository)
+			cmd := newInspectCommand(cli)
+			cmd.SetArgs(tc.args)
+			assert.NilError(t, cmd.Execute())
 			golden.Assert(t, cli.OutBuffer().String(), tc.golden)
+			assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: could not read the signatures of targets/bob for "+tc.args[len(tc.args)-1]+"; showing the signatures of the other roles\n"))
 		})
 	}
 }
+
+func TestInspectUnreadableRoles(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notary.GetPartiallyReadableNotaryRepository)
+	info, err := Inspect(context.Background(), cli, "signed-repo:red")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(info.UnreadableRoles, []data.RoleName{"targets/bob"}))
+	assert.Assert(t, is.Len(info.SignedTags, 1))
+	assert.Check(t, is.DeepEqual(info.SignedTags[0].Signers, []string{"alice"}))
+}
+
+func TestTrustInspectTrustDir(t *testing.T) {
+	trustDir := t.TempDir()
+	metadataDir, ok := localMetadataDir(trustDir, "signed-repo")
+	assert.Assert(t, ok)
+	assert.NilError(t, os.MkdirAll(metadataDir, 0o700))
+	assert.NilError(t, os.WriteFile(filepath.Join(metadataDir, "root.json"), []byte("{}"), 0o600))
+
+	t.Run("flag", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+		cmd := newInspectCommand(cli)
+		cmd.SetArgs([]string{"--offline", "--trust-dir", trustDir, "--pretty", "--no-summary", "signed-repo"})
+		assert.NilError(t, cmd.Execute())
+		golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-full-repo-with-signers.golden")
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv(trust.EnvTrustDir, trustDir)
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+		cmd := newInspectCommand(cli)
+		cmd.SetArgs([]string{"--offline", "--pretty", "--no-summary", "signed-repo"})
+		assert.NilError(t, cmd.Execute())
+		golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-full-repo-with-signers.golden")
+	})
+
+	t.Run("flag overrides env", func(t *testing.T) {
+		t.Setenv(trust.EnvTrustDir, trustDir)
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+		cmd := newInspectCommand(cli)
+		cmd.SetArgs([]string{"--offline", "--trust-dir", t.TempDir(), "signed-repo"})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.ErrorContains(t, cmd.Execute(), "no cached trust data for signed-repo")
+	})
+}
+
+func TestInspectOffline(t *testing.T) {
+	configDir := config.Dir()
+	t.Cleanup(func() { config.SetDir(configDir) })
+	config.SetDir(t.TempDir())
+
+	metadataDir, ok := localMetadataDir(trust.GetTrustDirectory(), "signed-repo")
+	assert.Assert(t, ok)
+	assert.NilError(t, os.MkdirAll(metadataDir, 0o700))
+	assert.NilError(t, os.WriteFile(filepath.Join(metadataDir, "root.json"), []byte("{}"), 0o600))
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+	info, err := InspectOffline(context.Background(), cli, "signed-repo")
+	assert.NilError(t, err)
+	assert.Check(t, info.Offline)
+	assert.Check(t, is.Len(info.SignedTags, 3))
+}
+
+func TestTrustInspectRequireSignatures(t *testing.T) {
+	testCases := []struct {
+		doc              string
+		args             []string
+		notaryRepository func() (client.Repository, error)
+		expectedError    string
+	}{
+		{
+			doc:              "signed repo",
+			args:             []string{"signed-repo"},
+			notaryRepository: notary.GetLoadedNotaryRepository,
+		},
+		{
+			doc:              "signed tag",
+			args:             []string{"signed-repo:green"},
+			notaryRepository: notary.GetLoadedNotaryRepository,
+		},
+		{
+			doc:              "unsigned tag",
+			args:             []string{"signed-repo:unsigned"},
+			notaryRepository: notary.GetLoadedNotaryRepository,
+			expectedError:    "no signatures for signed-repo:unsigned",
+		},
+		{
+			doc:              "empty repo",
+			args:             []string{"reg/img:unsigned-tag"},
+			notaryRepository: notary.GetEmptyTargetsNotaryRepository,
+			expectedError:    "no signatures for reg/img:unsigned-tag",
+		},
+		{
+			doc:              "multiple references",
+			args:             []string{"signed-repo:unsigned", "signed-repo:green", "signed-repo:other"},
+			notaryRepository: notary.GetLoadedNotaryRepository,
+			expectedError:    "no signatures for signed-repo:unsigned, signed-repo:other",
+		},
+	}
+	for _, tc := range testCases {
+		for _, format := range []string{"", "--pretty", "--format=json"} {
+			t.Run(tc.doc+format, func(t *testing.T) {
+				fakeCLI := test.NewFakeCli(&fakeClient{})
+				fakeCLI.SetNotaryClient(tc.notaryRepository)
+				args := []string{"--require-signatures"}
+				if format != "" {
+					args = append(args, format)
+				}
+				cmd := newInspectCommand(fakeCLI)
+				cmd.SetArgs(append(args, tc.args...))
+				cmd.SetOut(io.Discard)
+				cmd.SetErr(io.Discard)
+				err := cmd.Execute()
+				if tc.expectedError == "" {
+					assert.NilError(t, err)
+					return
+				}
+				assert.Check(t, is.Error(err, tc.expectedError))
+				var statusErr cli.StatusError
+				assert.Assert(t, errors.As(err, &statusErr))
+				assert.Check(t, is.Equal(statusErr.StatusCode, exitCodeNoSignatures))
+				var noSigErr ErrNoSignatures
+				assert.Check(t, errors.As(err, &noSigErr))
+				assert.Check(t, fakeCLI.OutBuffer().Len() > 0, "expected the trust information to be printed")
+			})
+		}
+	}
+
+	t.Run("without flag", func(t *testing.T) {
+		fakeCLI := test.NewFakeCli(&fakeClient{})
+		fakeCLI.SetNotaryClient(notary.GetEmptyTargetsNotaryRepository)
+		cmd := newInspectCommand(fakeCLI)
+		cmd.SetArgs([]string{"--pretty", "reg/img:unsigned-tag"})
+		assert.NilError(t, cmd.Execute())
+	})
+}
@@ -0,0 +1,111 @@
+package trust
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fvbommel/sortorder"
+	"github.com/theupdateframework/notary/tuf/data"
+)
+
+// signerTree is a delegation role in the hierarchy of delegation roles, with
+// the tags that were signed by it, as printed with "docker trust inspect
+// --pretty --tree".
+type signerTree struct {
+	name     string
+	tags     []ReleasedTag
+	children []*signerTree
+}
+
+// newSignerTree groups the signed tags under the delegation roles that signed
+// them, starting at the "targets" role. Tags that were only signed by the
+// repository admin are grouped under "(Repo Admin)", and tags with multiple
+// signers are listed under each of them. Signers of nested delegation roles
+// below maxDepth are grouped as with [InspectResult.GroupedSigners].
+func newSignerTree(signedTags []ReleasedTag, maxDepth int) *signerTree {
+	root := &signerTree{name: data.CanonicalTargetsRole.String()}
+	for _, tag := range signedTags {
+		if len(tag.Signers) == 0 {
+			node := root.child("(" + releasedRoleName + ")")
+			node.tags = append(node.tags, tag)
+			continue
+		}
+		for _, signer := range tag.Signers {
+			node := root
+			for name := range strings.SplitSeq(truncateSigner(signer, maxDepth), "/") {
+				node = node.child(name)
+			}
+			// Grouped signers can have signed the same tag.
+			if !slices.ContainsFunc(node.tags, func(t ReleasedTag) bool { return t.SignedTag == tag.SignedTag }) {
+				node.tags = append(node.tags, tag)
+			}
+		}
+	}
+	root.sort()
+	return root
+}
+
+// child returns the child role with the given name, adding it if it doesn't
+// exist yet.
+func (t *signerTree) child(name string) *signerTree {
+	for _, c := range t.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &signerTree{name: name}
+	t.children = append(t.children, c)
+	return c
+}
+
+// sort sorts the child roles by name, in natural order, which puts the
+// "(Repo Admin)" role first.
+func (t *signerTree) sort() {
+	sort.Slice(t.children, func(i, j int) bool {
+		return sortorder.NaturalLess(t.children[i].name, t.children[j].name)
+	})
+	for _, c := range t.children {
+		c.sort()
+	}
+}
+
+// printSignerTree prints the signed tags grouped by the delegation roles that
+// signed them, as a tree. The tags of a role are printed before its child
+// roles, with their digests aligned.
+func printSignerTree(out io.Writer, root *signerTree) {
+	_, _ = fmt.Fprintln(out, root.name)
+	root.printChildren(out, "")
+}
+
+func (t *signerTree) printChildren(out io.Writer, prefix string) {
+	var width int
+	for _, tag := range t.tags {
+		width = max(width, len(tag.SignedTag))
+	}
+	remaining := len(t.tags) + len(t.children)
+	for _, tag := range t.tags {
+		remaining--
+		_, _ = fmt.Fprintf(out, "%s%s%-*s   %s\n", prefix, treeBranch(remaining == 0), width, tag.SignedTag, tag.Digest)
+	}
+	for _, c := range t.children {
+		remaining--
+		_, _ = fmt.Fprintf(out, "%s%s%s\n", prefix, treeBranch(remaining == 0), c.name)
+		if remaining == 0 {
+			c.printChildren(out, prefix+"   ")
+		} else {
+			c.printChildren(out, prefix+"│  ")
+		}
+	}
+}
+
+// treeBranch returns the branch that precedes an item of a tree, depending
+// on whether it's the last item of its parent.
+func treeBranch(last bool) string {
+	if last {
+		return "└─ "
+	}
+	return "├─ "
+}
@@ -0,0 +1,85 @@
+package trust
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/docker/cli/cmd/docker-trust/internal/test"
+	notaryfake "github.com/docker/cli/cmd/docker-trust/internal/test/notary"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/golden"
+)
+
+func TestSignerTree(t *testing.T) {
+	signedTags := []ReleasedTag{
+		{trustTagKey: trustTagKey{SignedTag: "1.0", Digest: "aaaa"}},
+		{trustTagKey: trustTagKey{SignedTag: "blue", Digest: "bbbb"}, Signers: []string{"docker/alice", "docker/team/bob"}},
+		{trustTagKey: trustTagKey{SignedTag: "green", Digest: "cccc"}, Signers: []string{"docker/alice"}},
+		{trustTagKey: trustTagKey{SignedTag: "red", Digest: "dddd"}, Signers: []string{"carol", "docker"}},
+	}
+	testCases := []struct {
+		doc      string
+		maxDepth int
+		expected string
+	}{
+		{
+			doc: "all levels",
+			expected: `targets
+├─ (Repo Admin)
+│  └─ 1.0   aaaa
+├─ carol
+│  └─ red   dddd
+└─ docker
+   ├─ red   dddd
+   ├─ alice
+   │  ├─ blue    bbbb
+   │  └─ green   cccc
+   └─ team
+      └─ bob
+         └─ blue   bbbb
+`,
+		},
+		{
+			doc:      "max depth",
+			maxDepth: 1,
+			expected: `targets
+├─ (Repo Admin)
+│  └─ 1.0   aaaa
+├─ carol
+│  └─ red   dddd
+└─ docker
+   ├─ red   dddd
+   └─ ...
+      ├─ blue    bbbb
+      └─ green   cccc
+`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			var out bytes.Buffer
+			printSignerTree(&out, newSignerTree(signedTags, tc.maxDepth))
+			assert.Check(t, is.Equal(out.String(), tc.expected))
+		})
+	}
+}
+
+func TestTrustInspectPrettyCommandTree(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notaryfake.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"--pretty", "--no-summary", "--tree", "signed-repo"})
+	assert.NilError(t, cmd.Execute())
+
+	golden.Assert(t, cli.OutBuffer().String(), "trust-inspect-pretty-tree.golden")
+}
+
+func TestTrustInspectTreeWithoutPretty(t *testing.T) {
+	cmd := newInspectCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"--tree", "signed-repo"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "--tree can only be used with --pretty")
+}
@@ -0,0 +1,63 @@
+package trust
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+	"github.com/moby/moby/client"
+)
+
+// verifyLocalImages compares the digest of each signed tag with the digest of
+// the local image of the tag, as shown with "docker trust inspect
+// --verify-local", and prints the result to out. Tags without a local image
+// are skipped. It only returns an error if the local images cannot be
+// inspected.
+func verifyLocalImages(ctx context.Context, apiClient client.ImageAPIClient, remote string, tags []ReleasedTag, out io.Writer) error {
+	named, err := reference.ParseNormalizedNamed(remote)
+	if err != nil {
+		return err
+	}
+	repo := reference.TrimNamed(named)
+	for _, tag := range tags {
+		local := reference.FamiliarName(repo) + ":" + tag.SignedTag
+		res, err := apiClient.ImageInspect(ctx, local)
+		if err != nil {
+			if cerrdefs.IsNotFound(err) {
+				_, _ = fmt.Fprintf(out, "No local image for %s; skipping the digest verification\n", local)
+				continue
+			}
+			return fmt.Errorf("failed to inspect the local image of %s: %w", local, err)
+		}
+		signed := "sha256:" + tag.Digest
+		digests := repoDigests(repo, res.RepoDigests)
+		switch {
+		case len(digests) == 0:
+			_, _ = fmt.Fprintf(out, "WARNING: cannot verify the local image of %s: it has no repository digest; pull the image to verify it\n", local)
+		case slices.Contains(digests, signed):
+			_, _ = fmt.Fprintf(out, "The local image of %s matches the signed digest %s\n", local, signed)
+		default:
+			_, _ = fmt.Fprintf(out, "WARNING: the local image of %s (%s) does not match the signed digest %s\n", local, digests[0], signed)
+		}
+	}
+	return nil
+}
+
+// repoDigests returns the digests of the repository digests ("name@digest")
+// of a local image that belong to the given repository.
+func repoDigests(repo reference.Named, refs []string) []string {
+	var digests []string
+	for _, ref := range refs {
+		named, err := reference.ParseNormalizedNamed(ref)
+		if err != nil || named.Name() != repo.Name() {
+			continue
+		}
+		if canonical, ok := named.(reference.Canonical); ok {
+			digests = append(digests, canonical.Digest().String())
+		}
+	}
+	return digests
+}
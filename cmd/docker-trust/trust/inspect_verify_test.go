@@ -0,0 +1,83 @@
+package trust
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/docker/cli/cmd/docker-trust/internal/test"
+	"github.com/docker/cli/cmd/docker-trust/internal/test/notary"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/client"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+var (
+	signedDigest = strings.Repeat("a", 64)
+	otherDigest  = strings.Repeat("b", 64)
+)
+
+func TestVerifyLocalImages(t *testing.T) {
+	apiClient := &fakeClient{
+		imageInspectFunc: func(imageID string, _ ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+			var repoDigests []string
+			switch imageID {
+			case "example/repo:match":
+				repoDigests = []string{"other/repo@sha256:" + otherDigest, "example/repo@sha256:" + signedDigest}
+			case "example/repo:mismatch":
+				repoDigests = []string{"example/repo@sha256:" + otherDigest}
+			case "example/repo:other-repo":
+				repoDigests = []string{"other/repo@sha256:" + signedDigest}
+			case "example/repo:missing":
+				return client.ImageInspectResult{}, fmt.Errorf("no such image: %s: %w", imageID, cerrdefs.ErrNotFound)
+			}
+			return client.ImageInspectResult{InspectResponse: image.InspectResponse{RepoDigests: repoDigests}}, nil
+		},
+	}
+	var tags []ReleasedTag
+	for _, tag := range []string{"match", "mismatch", "other-repo", "missing"} {
+		tags = append(tags, ReleasedTag{trustTagKey: trustTagKey{SignedTag: tag, Digest: signedDigest}})
+	}
+
+	var out strings.Builder
+	assert.NilError(t, verifyLocalImages(t.Context(), apiClient, "docker.io/example/repo", tags, &out))
+	assert.Check(t, is.Equal(out.String(), `The local image of example/repo:match matches the signed digest sha256:`+signedDigest+`
+WARNING: the local image of example/repo:mismatch (sha256:`+otherDigest+`) does not match the signed digest sha256:`+signedDigest+`
+WARNING: cannot verify the local image of example/repo:other-repo: it has no repository digest; pull the image to verify it
+No local image for example/repo:missing; skipping the digest verification
+`))
+}
+
+func TestVerifyLocalImagesError(t *testing.T) {
+	apiClient := &fakeClient{
+		imageInspectFunc: func(string, ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+			return client.ImageInspectResult{}, errors.New("connection refused")
+		},
+	}
+	tags := []ReleasedTag{{trustTagKey: trustTagKey{SignedTag: "red", Digest: signedDigest}}}
+	err := verifyLocalImages(t.Context(), apiClient, "signed-repo", tags, &strings.Builder{})
+	assert.Check(t, is.Error(err, "failed to inspect the local image of signed-repo:red: connection refused"))
+}
+
+func TestTrustInspectVerifyLocal(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		imageInspectFunc: func(imageID string, _ ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+			if imageID != "signed-repo:red" {
+				return client.ImageInspectResult{}, cerrdefs.ErrNotFound
+			}
+			return client.ImageInspectResult{InspectResponse: image.InspectResponse{
+				RepoDigests: []string{"signed-repo@sha256:" + otherDigest},
+			}}, nil
+		},
+	})
+	cli.SetNotaryClient(notary.GetLoadedNotaryRepository)
+	cmd := newInspectCommand(cli)
+	cmd.SetArgs([]string{"--verify-local", "signed-repo"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(), "WARNING: the local image of signed-repo:red (sha256:"+otherDigest+") does not match the signed digest sha256:"))
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(), "No local image for signed-repo:blue; skipping the digest verification"))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), `"Name": "signed-repo"`))
+}
@@ -1,22 +1,28 @@
 package trust
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cmd/docker-trust/internal/trust"
 	"github.com/spf13/cobra"
 	"github.com/theupdateframework/notary"
+	"github.com/theupdateframework/notary/passphrase"
 	"github.com/theupdateframework/notary/storage"
 	"github.com/theupdateframework/notary/trustmanager"
 	tufutils "github.com/theupdateframework/notary/tuf/utils"
+	"golang.org/x/crypto/pkcs12" //nolint:staticcheck // SA1019: pkcs12 is frozen, but sufficient for extracting keys.
 )
 
 const (
@@ -24,7 +30,8 @@
 )
 
 type keyLoadOptions struct {
-	keyName string
+	keyName         string
+	p12PasswordFile string
 }
 
 func newKeyLoadCommand(dockerCLI command.Streams) *cobra.Command {
@@ -40,6 +47,7 @@ func newKeyLoadCommand(dockerCLI command.Streams) *cobra.Command {
 	}
 	flags := cmd.Flags()
 	flags.StringVar(&options.keyName, "name", "signer", "Name for the loaded key")
+	flags.StringVar(&options.p12PasswordFile, "p12-password-file", "", "Read the password of a PKCS#12 bundle from a file")
 	return cmd
 }
 
@@ -63,6 +71,17 @@ func loadPrivKey(streams command.Streams, keyPath string, options keyLoadOptions
 	if err != nil {
 		return fmt.Errorf("refusing to load key from %s: %w", keyPath, err)
 	}
+	if isPKCS12(keyPath, keyBytes) {
+		password, err := getPKCS12Password(streams, keyPath, options.p12PasswordFile)
+		if err != nil {
+			return err
+		}
+		if keyBytes, err = decodePKCS12(keyBytes, password); err != nil {
+			return fmt.Errorf("error importing key from %s: %w", keyPath, err)
+		}
+	} else if options.p12PasswordFile != "" {
+		return fmt.Errorf("refusing to load key from %s: --p12-password-file can only be used with PKCS#12 bundles", keyPath)
+	}
 	if err := loadPrivKeyBytesToStore(keyBytes, privKeyImporters, keyPath, options.keyName, passRet); err != nil {
 		return fmt.Errorf("error importing key from %s: %w", keyPath, err)
 	}
@@ -117,3 +136,81 @@ func decodePrivKeyIfNecessary(privPemBytes []byte, passRet notary.PassRetriever)
 	}
 	return privPemBytes, nil
 }
+
+// isPKCS12 returns whether the key file is a PKCS#12 (".p12" or ".pfx")
+// bundle, either based on its extension, or because it is DER encoded
+// instead of PEM encoded.
+func isPKCS12(keyPath string, keyBytes []byte) bool {
+	switch strings.ToLower(filepath.Ext(keyPath)) {
+	case ".p12", ".pfx":
+		return true
+	}
+	if block, _ := pem.Decode(keyBytes); block != nil {
+		return false
+	}
+	// PKCS#12 bundles are a DER encoded ASN.1 SEQUENCE.
+	return len(keyBytes) > 0 && keyBytes[0] == 0x30
+}
+
+// getPKCS12Password reads the password of a PKCS#12 bundle from the given
+// password file, or prompts for the password if no file is provided.
+func getPKCS12Password(streams command.Streams, keyPath, passwordFile string) (string, error) {
+	if passwordFile != "" {
+		password, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read PKCS#12 password file: %w", err)
+		}
+		return trimLineEnding(string(password)), nil
+	}
+	_, _ = fmt.Fprintf(streams.Out(), "Enter password for PKCS#12 bundle %s: ", keyPath)
+	password, err := passphrase.GetPassphrase(bufio.NewReader(streams.In()))
+	_, _ = fmt.Fprintln(streams.Out())
+	if err != nil {
+		return "", err
+	}
+	return trimLineEnding(string(password)), nil
+}
+
+// trimLineEnding removes the line ending from a password that's read from a
+// file or prompt. Other whitespace is kept, as it may be part of the password.
+func trimLineEnding(password string) string {
+	return strings.TrimRight(password, "\r\n")
+}
+
+// decodePKCS12 extracts the private key from a PKCS#12 bundle, and returns
+// it as a PEM encoded PKCS#8 key. Certificates in the bundle are ignored.
+func decodePKCS12(p12Bytes []byte, password string) ([]byte, error) {
+	blocks, err := pkcs12.ToPEM(p12Bytes, password)
+	if err != nil {
+		if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+			return nil, errors.New("incorrect password for PKCS#12 bundle")
+		}
+		return nil, fmt.Errorf("invalid PKCS#12 bundle: %w", err)
+	}
+	var privKey any
+	for _, block := range blocks {
+		if block.Type != "PRIVATE KEY" {
+			continue
+		}
+		if privKey != nil {
+			return nil, errors.New("PKCS#12 bundle contains more than one private key")
+		}
+		// Although the PEM type is "PRIVATE KEY", the bytes are encoded
+		// as PKCS#1 (RSA) or SEC 1 (ECDSA), not as PKCS#8.
+		if rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			privKey = rsaKey
+		} else if ecKey, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+			privKey = ecKey
+		} else {
+			return nil, errors.New("PKCS#12 bundle contains an unsupported private key type")
+		}
+	}
+	if privKey == nil {
+		return nil, errors.New("PKCS#12 bundle does not contain a private key")
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -7,9 +7,11 @@
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/streams"
 	"github.com/docker/cli/cmd/docker-trust/internal/test"
 	"github.com/theupdateframework/notary"
 	"github.com/theupdateframework/notary/storage"
@@ -222,3 +224,117 @@ func TestLoadPubKeyFailure(t *testing.T) {
 	expected := fmt.Sprintf("provided file %s is not a supported private key - to add a signer's public key use docker trust signer add", pubKeyFilepath)
 	assert.Error(t, err, expected)
 }
+
+func TestIsPKCS12(t *testing.T) {
+	p12Bytes, err := os.ReadFile(filepath.Join("testdata", "signer.p12"))
+	assert.NilError(t, err)
+
+	assert.Check(t, isPKCS12("signer.p12", nil))
+	assert.Check(t, isPKCS12("signer.PFX", nil))
+	assert.Check(t, isPKCS12("signer", p12Bytes))
+	assert.Check(t, !isPKCS12("signer.pem", ecPrivKeyFixture))
+	assert.Check(t, !isPKCS12("signer.pem", []byte("not a key")))
+}
+
+func TestDecodePKCS12(t *testing.T) {
+	p12Bytes, err := os.ReadFile(filepath.Join("testdata", "signer.p12"))
+	assert.NilError(t, err)
+
+	keyBytes, err := decodePKCS12(p12Bytes, "password")
+	assert.NilError(t, err)
+	keyPEM, _ := pem.Decode(keyBytes)
+	assert.Assert(t, keyPEM != nil)
+	assert.Check(t, is.Equal("PRIVATE KEY", keyPEM.Type))
+	assert.Check(t, is.Len(keyPEM.Headers, 0))
+
+	_, err = decodePKCS12(p12Bytes, "wrong-password")
+	assert.Check(t, is.Error(err, "incorrect password for PKCS#12 bundle"))
+
+	_, err = decodePKCS12([]byte{0x30, 0x00}, "password")
+	assert.Check(t, is.ErrorContains(err, "invalid PKCS#12 bundle"))
+}
+
+func TestTrustKeyLoadPKCS12(t *testing.T) {
+	skip.If(t, runtime.GOOS == "windows")
+	p12Bytes, err := os.ReadFile(filepath.Join("testdata", "signer.p12"))
+	assert.NilError(t, err)
+
+	tmpDir := t.TempDir()
+	p12File := filepath.Join(tmpDir, "signer.p12")
+	assert.NilError(t, os.WriteFile(p12File, p12Bytes, notary.PrivNoExecPerms))
+	passwordFile := filepath.Join(tmpDir, "password.txt")
+	assert.NilError(t, os.WriteFile(passwordFile, []byte("password\n"), notary.PrivNoExecPerms))
+	wrongPasswordFile := filepath.Join(tmpDir, "wrong-password.txt")
+	assert.NilError(t, os.WriteFile(wrongPasswordFile, []byte("wrong-password\n"), notary.PrivNoExecPerms))
+	pemFile := filepath.Join(tmpDir, "privkey.pem")
+	assert.NilError(t, os.WriteFile(pemFile, ecPrivKeyFixture, notary.PrivNoExecPerms))
+
+	configDir := t.TempDir()
+	config.SetDir(configDir)
+	t.Setenv("DOCKER_CONTENT_TRUST_REPOSITORY_PASSPHRASE", testPass)
+
+	t.Run("wrong password", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cmd := newKeyLoadCommand(cli)
+		cmd.SetArgs([]string{"--p12-password-file", wrongPasswordFile, p12File})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.Check(t, is.Error(cmd.Execute(), "error importing key from "+p12File+": incorrect password for PKCS#12 bundle"))
+	})
+
+	t.Run("password file with PEM key", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cmd := newKeyLoadCommand(cli)
+		cmd.SetArgs([]string{"--p12-password-file", passwordFile, pemFile})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.Check(t, is.Error(cmd.Execute(), "refusing to load key from "+pemFile+": --p12-password-file can only be used with PKCS#12 bundles"))
+	})
+
+	t.Run("password file", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cmd := newKeyLoadCommand(cli)
+		cmd.SetArgs([]string{"--name", "p12-signer", "--p12-password-file", passwordFile, p12File})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.NilError(t, cmd.Execute())
+		assert.Check(t, is.Contains(cli.OutBuffer().String(), "Successfully imported key from "+p12File))
+
+		keyFiles, err := filepath.Glob(filepath.Join(configDir, "trust", notary.PrivDir, "*."+notary.KeyExtension))
+		assert.NilError(t, err)
+		assert.Assert(t, is.Len(keyFiles, 1))
+		keyBytes, err := os.ReadFile(keyFiles[0])
+		assert.NilError(t, err)
+		keyPEM, _ := pem.Decode(keyBytes)
+		assert.Check(t, is.Equal("p12-signer", keyPEM.Headers["role"]))
+		assert.Check(t, is.Equal("ENCRYPTED PRIVATE KEY", keyPEM.Type))
+	})
+
+	t.Run("prompt", func(t *testing.T) {
+		cli := test.NewFakeCli(&fakeClient{})
+		cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader("password\n"))))
+		cmd := newKeyLoadCommand(cli)
+		cmd.SetArgs([]string{"--name", "p12-prompt", p12File})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.NilError(t, cmd.Execute())
+		assert.Check(t, is.Contains(cli.OutBuffer().String(), "Enter password for PKCS#12 bundle "+p12File+": "))
+		assert.Check(t, is.Contains(cli.OutBuffer().String(), "Successfully imported key from "+p12File))
+	})
+}
+
+func TestGetPKCS12PasswordWhitespace(t *testing.T) {
+	const password = " pass word "
+
+	passwordFile := filepath.Join(t.TempDir(), "password.txt")
+	assert.NilError(t, os.WriteFile(passwordFile, []byte(password+"\r\n"), notary.PrivNoExecPerms))
+	fromFile, err := getPKCS12Password(test.NewFakeCli(&fakeClient{}), "key.p12", passwordFile)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(fromFile, password))
+
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader(password + "\n"))))
+	fromPrompt, err := getPKCS12Password(cli, "key.p12", "")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(fromPrompt, password))
+}
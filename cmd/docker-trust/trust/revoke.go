@@ -8,7 +8,6 @@
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cmd/docker-trust/internal/trust"
-	"github.com/docker/cli/internal/prompt"
 	"github.com/spf13/cobra"
 	"github.com/theupdateframework/notary/client"
 	"github.com/theupdateframework/notary/tuf/data"
@@ -44,7 +43,7 @@ func revokeTrust(ctx context.Context, dockerCLI command.Cli, remote string, opti
 		return errors.New("cannot use a digest reference for IMAGE:TAG")
 	}
 	if imgRefAndAuth.Tag() == "" && !options.forceYes {
-		deleteRemote, err := prompt.Confirm(ctx, dockerCLI.In(), dockerCLI.Out(), fmt.Sprintf("Confirm you would like to delete all signature data for %s?", remote))
+		deleteRemote, err := confirm(ctx, dockerCLI, fmt.Sprintf("Confirm you would like to delete all signature data for %s?", remote))
 		if err != nil {
 			return err
 		}
@@ -65,6 +64,7 @@ func revokeTrust(ctx context.Context, dockerCLI command.Cli, remote string, opti
 	if err := revokeSignature(notaryRepo, tag); err != nil {
 		return fmt.Errorf("could not remove signature for %s: %w", remote, err)
 	}
+	invalidateNotaryClient(dockerCLI, imgRefAndAuth.RepoInfo().Name.Name())
 	_, _ = fmt.Fprintf(dockerCLI.Out(), "Successfully deleted signature for %s\n", remote)
 	return nil
 }
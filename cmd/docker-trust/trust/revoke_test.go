@@ -68,7 +68,7 @@ func TestTrustRevokeCommand(t *testing.T) {
 			doc:              "OfflineErrors_Confirm",
 			notaryRepository: notary.GetOfflineNotaryRepository,
 			args:             []string{"reg-name.io/image"},
-			expectedMessage:  "Confirm you would like to delete all signature data for reg-name.io/image? [y/N] ",
+			expectedMessage:  "Confirm you would like to delete all signature data for reg-name.io/image?\nNot prompting for confirmation as stdin is not a terminal",
 			expectedErr:      revokeCancelledError,
 		},
 		{
@@ -87,7 +87,7 @@ func TestTrustRevokeCommand(t *testing.T) {
 			doc:              "UninitializedErrors_Confirm",
 			notaryRepository: notary.GetUninitializedNotaryRepository,
 			args:             []string{"reg-name.io/image"},
-			expectedMessage:  "Confirm you would like to delete all signature data for reg-name.io/image? [y/N] ",
+			expectedMessage:  "Confirm you would like to delete all signature data for reg-name.io/image?\nNot prompting for confirmation as stdin is not a terminal",
 			expectedErr:      revokeCancelledError,
 		},
 		{
@@ -106,7 +106,7 @@ func TestTrustRevokeCommand(t *testing.T) {
 			doc:              "EmptyNotaryRepo_Confirm",
 			notaryRepository: notary.GetEmptyTargetsNotaryRepository,
 			args:             []string{"reg-name.io/image"},
-			expectedMessage:  "Confirm you would like to delete all signature data for reg-name.io/image? [y/N] ",
+			expectedMessage:  "Confirm you would like to delete all signature data for reg-name.io/image?\nNot prompting for confirmation as stdin is not a terminal",
 			expectedErr:      revokeCancelledError,
 		},
 		{
@@ -125,7 +125,7 @@ func TestTrustRevokeCommand(t *testing.T) {
 			doc:              "AllSigConfirmation",
 			notaryRepository: notary.GetEmptyTargetsNotaryRepository,
 			args:             []string{"alpine"},
-			expectedMessage:  "Confirm you would like to delete all signature data for alpine? [y/N] ",
+			expectedMessage:  "Confirm you would like to delete all signature data for alpine?\nNot prompting for confirmation as stdin is not a terminal",
 			expectedErr:      revokeCancelledError,
 		},
 	}
@@ -143,11 +143,23 @@ func TestTrustRevokeCommand(t *testing.T) {
 			} else {
 				assert.NilError(t, cmd.Execute())
 			}
-			assert.Check(t, is.Contains(cli.OutBuffer().String(), tc.expectedMessage))
+			assert.Check(t, is.Contains(cli.ErrBuffer().String(), tc.expectedMessage))
 		})
 	}
 }
 
+func TestTrustRevokeCommandAssumeYes(t *testing.T) {
+	t.Setenv(envAssumeYes, "1")
+	cli := test.NewFakeCli(&fakeClient{})
+	cli.SetNotaryClient(notary.GetOfflineNotaryRepository)
+	cmd := newRevokeCommand(cli)
+	cmd.SetArgs([]string{"reg-name.io/image"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.ErrorContains(t, cmd.Execute(), "could not remove signature for reg-name.io/image: client is offline")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), ""))
+}
+
 func TestRevokeTrustPromptTermination(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	t.Cleanup(cancel)
@@ -60,6 +60,9 @@ func runSignImage(ctx context.Context, dockerCLI command.Cli, options signOption
 		return err
 	}
 	defer clearChangeList(notaryRepo)
+	// Signing may initialize the repository and publish trust data on
+	// multiple paths, so invalidate cached repositories for all of them.
+	defer invalidateNotaryClient(dockerCLI, imgRefAndAuth.RepoInfo().Name.Name())
 
 	// get the latest repository metadata so we can figure out which roles to sign
 	if _, err = notaryRepo.ListTargets(); err != nil {
@@ -169,26 +172,26 @@ func getSignedManifestHashAndSize(notaryRepo notaryclient.Repository, tag string
 
 func getReleasedTargetHashAndSize(targets []notaryclient.TargetSignedStruct, tag string) (data.Hashes, int64, error) {
 	for _, tgt := range targets {
-		if isReleasedTarget(tgt.Role.Name) {
+		if IsReleasedTarget(tgt.Role.Name) {
 			return tgt.Target.Hashes, tgt.Target.Length, nil
 		}
 	}
 	return nil, 0, notaryclient.ErrNoSuchTarget(tag)
 }
 
-func getExistingSignatureInfoForReleasedTag(notaryRepo notaryclient.Repository, tag string) (trustTagRow, error) {
+func getExistingSignatureInfoForReleasedTag(notaryRepo notaryclient.Repository, tag string) (ReleasedTag, error) {
 	targets, err := notaryRepo.GetAllTargetMetadataByName(tag)
 	if err != nil {
-		return trustTagRow{}, err
+		return ReleasedTag{}, err
 	}
 	releasedTargetInfoList := matchReleasedSignatures(targets)
 	if len(releasedTargetInfoList) == 0 {
-		return trustTagRow{}, nil
+		return ReleasedTag{}, nil
 	}
 	return releasedTargetInfoList[0], nil
 }
 
-func prettyPrintExistingSignatureInfo(out io.Writer, existingSigInfo trustTagRow) {
+func prettyPrintExistingSignatureInfo(out io.Writer, existingSigInfo ReleasedTag) {
 	sort.Strings(existingSigInfo.Signers)
 	joinedSigners := strings.Join(existingSigInfo.Signers, ", ")
 	_, _ = fmt.Fprintf(out, "Existing signatures for tag %s digest %s from:\n%s\n", existingSigInfo.SignedTag, existingSigInfo.Digest, joinedSigners)
@@ -221,7 +224,7 @@ func initNotaryRepoWithSigners(notaryRepo notaryclient.Repository, newSigner dat
 func getOrGenerateNotaryKey(notaryRepo notaryclient.Repository, role data.RoleName) (data.PublicKey, error) {
 	// use the signer name in the PEM headers if this is a delegation key
 	if data.IsDelegation(role) {
-		role = data.RoleName(notaryRoleToSigner(role))
+		role = data.RoleName(NotaryRoleToSigner(role))
 	}
 	keys := notaryRepo.GetCryptoService().ListKeys(role)
 	var err error
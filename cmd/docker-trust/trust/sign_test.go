@@ -244,7 +244,7 @@ func TestGetExistingSignatureInfoForReleasedTag(t *testing.T) {
 func TestPrettyPrintExistingSignatureInfo(t *testing.T) {
 	buf := bytes.NewBuffer(nil)
 	signers := []string{"Bob", "Alice", "Carol"}
-	existingSig := trustTagRow{trustTagKey{"tagName", "abc123"}, signers}
+	existingSig := ReleasedTag{trustTagKey{"tagName", "abc123"}, signers}
 	prettyPrintExistingSignatureInfo(buf, existingSig)
 
 	assert.Check(t, is.Contains(buf.String(), "Existing signatures for tag tagName digest abc123 from:\nAlice, Bob, Carol"))
@@ -109,7 +109,11 @@ func addSignerToRepo(ctx context.Context, dockerCLI command.Cli, signerName stri
 		return fmt.Errorf("could not add signer to repo: %s: %w", strings.TrimPrefix(newSignerRoleName.String(), "targets/"), err)
 	}
 
-	return notaryRepo.Publish()
+	if err := notaryRepo.Publish(); err != nil {
+		return err
+	}
+	invalidateNotaryClient(dockerCLI, imgRefAndAuth.RepoInfo().Name.Name())
+	return nil
 }
 
 func ingestPublicKeys(pubKeyPaths []string) ([]data.PublicKey, error) {
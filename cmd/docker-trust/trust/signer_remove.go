@@ -9,7 +9,6 @@
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cmd/docker-trust/internal/trust"
-	"github.com/docker/cli/internal/prompt"
 	"github.com/spf13/cobra"
 	"github.com/theupdateframework/notary/client"
 	"github.com/theupdateframework/notary/tuf/data"
@@ -83,7 +82,7 @@ func maybePromptForSignerRemoval(ctx context.Context, dockerCLI command.Cli, rep
 			"Are you sure you want to continue?",
 			signerName, repoName, repoName,
 		)
-		return prompt.Confirm(ctx, dockerCLI.In(), dockerCLI.Out(), message)
+		return confirm(ctx, dockerCLI, message)
 	}
 	return false, nil
 }
@@ -146,6 +145,7 @@ func removeSingleSigner(ctx context.Context, dockerCLI command.Cli, repoName, si
 	if err := notaryRepo.Publish(); err != nil {
 		return false, err
 	}
+	invalidateNotaryClient(dockerCLI, imgRefAndAuth.RepoInfo().Name.Name())
 
 	_, _ = fmt.Fprintf(dockerCLI.Out(), "Successfully removed %s from %s\n\n", signerName, repoName)
 
@@ -110,10 +110,11 @@ func TestRemoveLastSignerWarning(t *testing.T) {
 
 	err := removeSigner(ctx, cli, signerRemoveOptions{signer: "alice", repos: []string{"signed-repo"}, forceYes: false})
 	assert.NilError(t, err)
-	assert.Check(t, is.Contains(cli.OutBuffer().String(),
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(),
 		"The signer \"alice\" signed the last released version of signed-repo. "+
 			"Removing this signer will make signed-repo unpullable. "+
-			"Are you sure you want to continue? [y/N]"))
+			"Are you sure you want to continue?\n"+
+			"Not prompting for confirmation as stdin is not a terminal"))
 }
 
 func TestIsLastSignerForReleases(t *testing.T) {
@@ -18,27 +18,33 @@
 	keyBuilderAlias: {},
 }
 
-func processAliases(dockerCli command.Cli, cmd *cobra.Command, args, osArgs []string) ([]string, []string, []string, error) {
-	var err error
-	var envs []string
+// processAliases processes build commands as described in [processBuilder],
+// and replaces the aliases in args. It returns the arguments and environment
+// variables to run the command with, and the resolution of the builder.
+func processAliases(dockerCli command.Cli, cmd *cobra.Command, args, osArgs []string) ([]string, []string, []string, builderResolution, error) {
+	var (
+		err  error
+		envs []string
+		res  builderResolution
+	)
 	aliasMap := dockerCli.ConfigFile().Aliases
 	aliases := make([][2][]string, 0, len(aliasMap))
 
 	for k, v := range aliasMap {
 		if _, ok := allowedAliases[k]; !ok {
-			return args, osArgs, envs, fmt.Errorf("not allowed to alias %q (allowed: %#v)", k, allowedAliases)
+			return args, osArgs, envs, res, fmt.Errorf("not allowed to alias %q (allowed: %#v)", k, allowedAliases)
 		}
 		if c, _, err := cmd.Find(strings.Split(v, " ")); err == nil {
 			if !pluginmanager.IsPluginCommand(c) {
-				return args, osArgs, envs, fmt.Errorf("not allowed to alias with builtin %q as target", v)
+				return args, osArgs, envs, res, fmt.Errorf("not allowed to alias with builtin %q as target", v)
 			}
 		}
 		aliases = append(aliases, [2][]string{{k}, {v}})
 	}
 
-	args, osArgs, envs, err = processBuilder(dockerCli, cmd, args, os.Args)
+	args, osArgs, envs, res, err = processBuilder(dockerCli, cmd, args, os.Args)
 	if err != nil {
-		return args, os.Args, envs, err
+		return args, os.Args, envs, res, err
 	}
 
 	for _, al := range aliases {
@@ -50,5 +56,5 @@ func processAliases(dockerCli command.Cli, cmd *cobra.Command, args, osArgs []st
 		}
 	}
 
-	return args, osArgs, envs, nil
+	return args, osArgs, envs, res, nil
 }
@@ -1,24 +1,43 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/containerd/errdefs"
 	pluginmanager "github.com/docker/cli/cli-plugins/manager"
 	"github.com/docker/cli/cli-plugins/metadata"
 	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/debug"
+	"github.com/docker/cli/opts"
 	"github.com/moby/moby/api/types/build"
+	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
 const (
 	builderDefaultPlugin = "buildx"
+
+	// builderEnvFileFlag is the flag to set environment variables of the
+	// builder plugin from a file. It's handled by the CLI, and not passed
+	// to the plugin.
+	builderEnvFileFlag = "env-file"
+
+	// endpointCheckTimeout is the time to wait for the Docker endpoint of
+	// the current context to respond, before warning that it's unreachable.
+	endpointCheckTimeout = 2 * time.Second
+
 	buildxMissingWarning = `DEPRECATED: The legacy builder is deprecated and will be removed in a future release.
             Install the buildx component to build images with BuildKit:
             https://docs.docker.com/go/buildx/`
@@ -27,6 +46,14 @@
             BuildKit is currently disabled; enable it by removing the DOCKER_BUILDKIT=0
             environment-variable.`
 
+	legacyMultiPlatformWarning = `WARNING: Building for multiple platforms (%s) is not supported by the legacy builder.
+         Building images for multiple platforms requires BuildKit and the buildx component:
+         https://docs.docker.com/go/buildx/`
+
+	legacyBuildxOnlyFlagsWarning = `WARNING: The following flags require BuildKit and are not supported by the legacy builder: %s
+         Enable BuildKit and install the buildx component to use these flags:
+         https://docs.docker.com/go/buildx/`
+
 	buildxMissingError = `ERROR: BuildKit is enabled but the buildx component is missing or broken.
        Install the buildx component to build images with BuildKit:
        https://docs.docker.com/go/buildx/`
@@ -46,22 +73,65 @@ func newBuilderError(errorMsg string, pluginLoadErr error) error {
 	return errors.New(errorMsg)
 }
 
-//nolint:gocyclo
-func processBuilder(dockerCli command.Cli, cmd *cobra.Command, args, osargs []string) ([]string, []string, []string, error) {
-	var buildKitDisabled, useBuilder, useAlias bool
-	var envs []string
+// legacyReason describes why the legacy builder is used.
+type legacyReason string
+
+const (
+	legacyReasonDaemon   legacyReason = "daemon does not prefer BuildKit"
+	legacyReasonDisabled legacyReason = "DOCKER_BUILDKIT is disabled"
+	legacyReasonPlugin   legacyReason = "builder plugin is missing or broken"
+)
+
+// builderResolution is the outcome of resolving how a build command is
+// handled: whether it's forwarded to the builder plugin, and which builder
+// is used.
+type builderResolution struct {
+	// forwarded is set if the command is a build command that is handled
+	// by the builder plugin if BuildKit is enabled.
+	forwarded bool
+	// buildKit is set if the command is forwarded to the builder plugin.
+	buildKit bool
+	// reason describes why the legacy builder is used if BuildKit is not.
+	reason legacyReason
+	// enforced is set if the builder plugin is required, either through
+	// DOCKER_BUILDKIT=1 or through a builder alias.
+	enforced bool
+	// alias is set if the builder plugin is configured as an alias.
+	alias bool
+
+	pluginName string
+	plugin     *pluginmanager.Plugin
+	pluginErr  error
+	// endpointUsed is set if the Docker endpoint of the current context
+	// was queried to resolve the builder.
+	endpointUsed bool
+
+	// builder and source are the name of the builder and where it was set.
+	// They're only resolved if the command is forwarded to the default
+	// builder plugin.
+	builder string
+	source  command.BuilderSource
+
+	fwargs, fwosargs, fwcmdpath []string
+}
+
+// resolveBuilder resolves how the given command is handled, without
+// printing warnings or invoking the builder.
+func resolveBuilder(dockerCli command.Cli, cmd *cobra.Command, args, osargs []string) (builderResolution, error) {
+	var buildKitDisabled bool
+	res := builderResolution{pluginName: builderDefaultPlugin}
 
 	// check DOCKER_BUILDKIT env var is not empty
 	// if it is assume we want to use the builder component
 	if v := os.Getenv("DOCKER_BUILDKIT"); v != "" {
 		enabled, err := strconv.ParseBool(v)
 		if err != nil {
-			return args, osargs, nil, fmt.Errorf("DOCKER_BUILDKIT environment variable expects boolean value: %w", err)
+			return res, fmt.Errorf("DOCKER_BUILDKIT environment variable expects boolean value: %w", err)
 		}
 		if !enabled {
 			buildKitDisabled = true
 		} else {
-			useBuilder = true
+			res.enforced = true
 		}
 	}
 	// docker bake always requires buildkit; ignore "DOCKER_BUILDKIT=0".
@@ -71,62 +141,115 @@ func processBuilder(dockerCli command.Cli, cmd *cobra.Command, args, osargs []st
 
 	// if a builder alias is defined, use it instead
 	// of the default one
-	builderAlias := builderDefaultPlugin
 	aliasMap := dockerCli.ConfigFile().Aliases
 	if v, ok := aliasMap[keyBuilderAlias]; ok {
-		useBuilder = true
-		useAlias = true
-		builderAlias = v
+		res.enforced = true
+		res.alias = true
+		res.pluginName = v
 	}
 
 	// is this a build that should be forwarded to the builder?
-	fwargs, fwosargs, fwcmdpath, forwarded := forwardBuilder(builderAlias, args, osargs)
-	if !forwarded {
-		return args, osargs, nil, nil
+	res.fwargs, res.fwosargs, res.fwcmdpath, res.forwarded = forwardBuilder(res.pluginName, args, osargs)
+	if !res.forwarded {
+		return res, nil
 	}
 
-	if !useBuilder {
+	if !res.enforced {
 		// Builder is not explicitly configured as an alias for buildx.
 		// Detect whether we should use BuildKit, or fallback to the
 		// legacy builder.
+		res.endpointUsed = true
 		if si := dockerCli.ServerInfo(); si.BuildkitVersion != build.BuilderBuildKit && si.OSType == "windows" {
 			// The daemon didn't advertise BuildKit as the preferred builder,
 			// so use the legacy builder, which is still the default for
 			// Windows / WCOW.
-			return args, osargs, nil, nil
+			res.reason = legacyReasonDaemon
+			return res, nil
 		}
 	}
 
 	if buildKitDisabled {
+		res.reason = legacyReasonDisabled
+		return res, nil
+	}
+
+	// check plugin is available if cmd forwarded
+	res.plugin, res.pluginErr = pluginmanager.GetPlugin(res.pluginName, dockerCli, cmd.Root())
+	if res.pluginErr == nil && res.plugin != nil {
+		res.pluginErr = res.plugin.Err
+	}
+	if res.pluginErr != nil {
+		res.reason = legacyReasonPlugin
+		return res, nil
+	}
+	res.buildKit = true
+
+	if !res.alias {
+		res.builder, res.source = command.ResolvedBuilderName(dockerCli, args, os.Environ())
+	}
+	return res, nil
+}
+
+// processBuilder processes build commands, forwarding them to the builder
+// plugin if BuildKit is used. It returns the arguments and environment
+// variables to run the command with, and how the builder was resolved, which
+// is not marked as forwarded if the command is not a build command.
+func processBuilder(dockerCli command.Cli, cmd *cobra.Command, args, osargs []string) ([]string, []string, []string, builderResolution, error) {
+	var envs []string
+
+	// "docker builder which" is handled by the CLI itself, to diagnose
+	// how a build would be handled.
+	if isBuilderWhich(args) {
+		return args, osargs, nil, builderResolution{}, nil
+	}
+
+	res, err := resolveBuilder(dockerCli, cmd, args, osargs)
+	if err != nil {
+		return args, osargs, nil, res, err
+	}
+	if !res.forwarded {
+		return args, osargs, nil, res, nil
+	}
+
+	if res.reason == legacyReasonDaemon || res.reason == legacyReasonDisabled {
+		logrus.WithFields(logrus.Fields{
+			"event":    "builder.buildkit",
+			"buildkit": false,
+			"reason":   string(res.reason),
+		}).Debug("using the legacy builder")
 		// When using a Linux daemon, print a warning that the legacy builder
 		// is deprecated. For Windows / WCOW, BuildKit is still experimental,
 		// so we don't print this warning, even if the daemon advertised that
 		// it supports BuildKit.
-		if dockerCli.ServerInfo().OSType != "windows" {
+		if res.reason == legacyReasonDisabled && dockerCli.ServerInfo().OSType != "windows" {
 			_, _ = fmt.Fprintf(dockerCli.Err(), "%s\n\n", buildkitDisabledWarning)
 		}
-		return args, osargs, nil, nil
+		warnLegacyBuilderFlags(dockerCli, args)
+		return args, osargs, nil, res, nil
 	}
 
-	// check plugin is available if cmd forwarded
-	plugin, perr := pluginmanager.GetPlugin(builderAlias, dockerCli, cmd.Root())
-	if perr == nil && plugin != nil {
-		perr = plugin.Err
-	}
-	if perr != nil {
+	logBuilderPlugin(res.pluginName, res.plugin, res.pluginErr)
+	if res.pluginErr != nil {
 		// Using bake without buildx installed is always an error.
 		if len(args) > 0 && args[0] == "bake" {
-			return args, osargs, nil, newBuilderError(bakeMissingError, perr)
+			return args, osargs, nil, res, withEndpointDetails(dockerCli, res, newBuilderError(bakeMissingError, res.pluginErr))
 		}
 		// if builder is enforced with DOCKER_BUILDKIT=1, cmd must fail
 		// if the plugin is missing or broken.
-		if useBuilder {
-			return args, osargs, nil, newBuilderError(buildxMissingError, perr)
+		if res.enforced {
+			return args, osargs, nil, res, withEndpointDetails(dockerCli, res, newBuilderError(buildxMissingError, res.pluginErr))
 		}
 		// otherwise, display warning and continue
-		_, _ = fmt.Fprintf(dockerCli.Err(), "%s\n\n", newBuilderError(buildxMissingWarning, perr))
-		return args, osargs, nil, nil
+		_, _ = fmt.Fprintf(dockerCli.Err(), "%s\n\n", newBuilderError(buildxMissingWarning, res.pluginErr))
+		warnLegacyBuilderFlags(dockerCli, args)
+		return args, osargs, nil, res, nil
 	}
+	logrus.WithFields(logrus.Fields{
+		"event":    "builder.buildkit",
+		"buildkit": true,
+		"plugin":   res.pluginName,
+	}).Debug("forwarding to the builder plugin")
+	warnShadowedPlugin(dockerCli, res.plugin)
 
 	// If build subcommand is forwarded, user would expect "docker build" to
 	// always create a local docker image (default context builder). This is
@@ -136,14 +259,319 @@ func processBuilder(dockerCli command.Cli, cmd *cobra.Command, args, osargs []st
 	// is not being set in the command line or in the environment before
 	// setting the default context and keep "buildx install" behavior if being
 	// set (builder alias).
-	if forwarded && !useAlias && !hasBuilderName(args, os.Environ()) {
-		envs = append([]string{"BUILDX_BUILDER=" + dockerCli.CurrentContext()}, envs...)
+	if !res.alias {
+		logrus.WithFields(logrus.Fields{
+			"event":   "builder.source",
+			"builder": res.builder,
+			"source":  string(res.source),
+			"from":    describeBuilderSource(dockerCli, res.source),
+		}).Debug("resolved builder")
+		switch res.source {
+		case command.BuilderSourceProject, command.BuilderSourceContext, command.BuilderSourceDefault:
+			envs = append([]string{"BUILDX_BUILDER=" + res.builder}, envs...)
+		}
+	}
+
+	var envFiles []string
+	res.fwargs, envFiles, err = removeEnvFileFlags(res.fwargs)
+	if err != nil {
+		return args, osargs, nil, res, err
+	}
+	if len(envFiles) > 0 {
+		res.fwosargs, _, _ = removeEnvFileFlags(res.fwosargs)
+		fileEnvs, err := readEnvFiles(envFiles, envs)
+		if err != nil {
+			return args, osargs, nil, res, err
+		}
+		envs = append(envs, fileEnvs...)
+	}
+
+	// Checking the endpoint adds a round-trip to the daemon, so it's only
+	// done in debug mode.
+	if debug.IsEnabled() {
+		warnUnreachableEndpoint(dockerCli)
 	}
 
 	// overwrite the command path for this plugin using the alias name.
-	cmd.Annotations[metadata.CommandAnnotationPluginCommandPath] = strings.Join(append([]string{cmd.CommandPath()}, fwcmdpath...), " ")
+	cmd.Annotations[metadata.CommandAnnotationPluginCommandPath] = strings.Join(append([]string{cmd.CommandPath()}, res.fwcmdpath...), " ")
+
+	return res.fwargs, res.fwosargs, envs, res, nil
+}
+
+// removeEnvFileFlags removes the --env-file flags from args, which are
+// handled by the CLI instead of the builder plugin, and returns the remaining
+// args and the files. Flags after "--" are not removed.
+func removeEnvFileFlags(args []string) ([]string, []string, error) {
+	const flag = "--" + builderEnvFileFlag
+	var files []string
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			return append(out, args[i:]...), files, nil
+		case arg == flag:
+			if i+1 == len(args) {
+				return nil, nil, errors.New("flag needs an argument: " + flag)
+			}
+			i++
+			files = append(files, args[i])
+		case strings.HasPrefix(arg, flag+"="):
+			files = append(files, strings.TrimPrefix(arg, flag+"="))
+		default:
+			out = append(out, arg)
+		}
+	}
+	return out, files, nil
+}
+
+// readEnvFiles reads the environment variables in the given files, to pass
+// them to the builder plugin. Variables that are set in envs, or in the
+// environment of the CLI, take precedence, and are omitted.
+func readEnvFiles(files []string, envs []string) ([]string, error) {
+	fileEnvs, err := opts.ReadKVStrings(files, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(fileEnvs))
+	for _, env := range fileEnvs {
+		key, _, _ := strings.Cut(env, "=")
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if slices.ContainsFunc(envs, func(e string) bool { return strings.HasPrefix(e, key+"=") }) {
+			continue
+		}
+		out = append(out, env)
+	}
+	return out, nil
+}
+
+// isBuilderWhich checks if args invoke "docker builder which".
+func isBuilderWhich(args []string) bool {
+	return len(args) > 1 && args[0] == "builder" && args[1] == "which"
+}
+
+// newBuilderWhichCommand returns the "docker builder which" command, which
+// prints how "docker build" is handled for the given arguments, without
+// building. It's hidden, as it's meant for diagnostics.
+func newBuilderWhichCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:                "which [BUILD OPTIONS]",
+		Short:              "Show how a build is handled",
+		Hidden:             true,
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			buildArgs := append([]string{"build"}, args...)
+			res, err := resolveBuilder(dockerCli, cmd, buildArgs, buildArgs)
+			if err != nil {
+				return err
+			}
+			printBuilderResolution(dockerCli, res)
+			return nil
+		},
+	}
+}
+
+// printBuilderResolution prints a human-readable summary of res.
+func printBuilderResolution(dockerCli command.Cli, res builderResolution) {
+	out := dockerCli.Out()
+	switch {
+	case res.buildKit:
+		_, _ = fmt.Fprintln(out, "BuildKit: enabled")
+	case res.enforced && res.pluginErr != nil:
+		_, _ = fmt.Fprintln(out, "BuildKit: required, but the builder plugin is missing or broken; the build fails")
+	default:
+		_, _ = fmt.Fprintf(out, "BuildKit: disabled (%s); using the legacy builder\n", res.reason)
+	}
+
+	switch {
+	case res.pluginErr != nil && errdefs.IsNotFound(res.pluginErr):
+		_, _ = fmt.Fprintf(out, "Plugin:   %s (not found)\n", res.pluginName)
+	case res.pluginErr != nil:
+		_, _ = fmt.Fprintf(out, "Plugin:   %s (broken: %v)\n", res.pluginName, res.pluginErr)
+	case res.plugin != nil:
+		_, _ = fmt.Fprintf(out, "Plugin:   %s %s (%s)\n", res.pluginName, res.plugin.Version, res.plugin.Path)
+	default:
+		_, _ = fmt.Fprintf(out, "Plugin:   %s (not checked)\n", res.pluginName)
+	}
+
+	if !res.buildKit {
+		return
+	}
+	if res.alias {
+		_, _ = fmt.Fprintf(out, "Builder:  selected by the %q alias\n", keyBuilderAlias)
+		return
+	}
+	_, _ = fmt.Fprintf(out, "Builder:  %s (from %s)\n", res.builder, describeBuilderSource(dockerCli, res.source))
+}
+
+// logBuilderPlugin logs whether the metadata of the builder plugin was
+// fetched. Unlike the warnings printed when the plugin is missing or broken,
+// it's only logged at debug level, to capture the decision path in logs.
+func logBuilderPlugin(name string, plugin *pluginmanager.Plugin, err error) {
+	fields := logrus.Fields{
+		"event":  "builder.plugin",
+		"plugin": name,
+	}
+	if err != nil {
+		fields["error"] = err
+		logrus.WithFields(fields).Debug("builder plugin not available")
+		return
+	}
+	if plugin != nil {
+		fields["path"] = plugin.Path
+		fields["version"] = plugin.Version
+	}
+	logrus.WithFields(fields).Debug("builder plugin available")
+}
+
+// warnShadowedPlugin prints a warning if multiple builder plugins were found
+// in the extra plugin directories of the config file, naming the plugin that
+// is used and the plugins that it shadows, as the selection is otherwise
+// silent.
+func warnShadowedPlugin(dockerCli command.Cli, plugin *pluginmanager.Plugin) {
+	if plugin == nil || len(plugin.ShadowedPaths) == 0 {
+		return
+	}
+	var inExtraDirs int
+	for _, p := range append([]string{plugin.Path}, plugin.ShadowedPaths...) {
+		if slices.ContainsFunc(dockerCli.ConfigFile().CLIPluginsExtraDirs, func(dir string) bool {
+			return filepath.Clean(dir) == filepath.Dir(p)
+		}) {
+			inExtraDirs++
+		}
+	}
+	if inExtraDirs < 2 {
+		return
+	}
+	_, _ = fmt.Fprintf(dockerCli.Err(), "WARNING: multiple %s plugins found; using %s, which shadows %s\n\n", plugin.Name, plugin.Path, strings.Join(plugin.ShadowedPaths, ", "))
+}
+
+// warnUnreachableEndpoint prints a warning if the Docker endpoint of the
+// current context doesn't respond, in which case the builder is not able
+// to connect to it either.
+func warnUnreachableEndpoint(dockerCli command.Cli) {
+	ctx, cancel := context.WithTimeout(context.Background(), endpointCheckTimeout)
+	defer cancel()
+	if _, err := dockerCli.Client().Ping(ctx, client.PingOptions{}); err != nil {
+		_, _ = fmt.Fprintf(dockerCli.Err(), "WARNING: the Docker endpoint of context %q (%s) is not reachable: %v\n\n", dockerCli.CurrentContext(), dockerCli.DockerEndpoint().Host, err)
+	}
+}
 
-	return fwargs, fwosargs, envs, nil
+// withEndpointDetails adds the name and the host of the Docker endpoint of
+// the current context to err if the endpoint was used to resolve the builder,
+// and is obviously invalid, such as a socket that doesn't exist. Healthy
+// endpoints are not checked again, as err is returned unchanged for them.
+func withEndpointDetails(dockerCli command.Cli, res builderResolution, err error) error {
+	if !res.endpointUsed {
+		return err
+	}
+	host := dockerCli.DockerEndpoint().Host
+	problem := invalidEndpoint(host)
+	if problem == "" {
+		return err
+	}
+	return fmt.Errorf("%w\n\nThe Docker endpoint of context %q (%s) is not usable: %s", err, dockerCli.CurrentContext(), host, problem)
+}
+
+// invalidEndpoint returns why the given Docker host is obviously invalid, or
+// an empty string if it looks usable. It only checks local sockets, without
+// connecting to them.
+func invalidEndpoint(host string) string {
+	path, ok := strings.CutPrefix(host, "unix://")
+	if !ok {
+		return ""
+	}
+	fi, err := os.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "the socket does not exist"
+	case err != nil:
+		return err.Error()
+	case fi.Mode()&os.ModeSocket == 0:
+		return "it is not a socket"
+	default:
+		return ""
+	}
+}
+
+// buildFlag is a flag of "docker build", with its optional shorthand.
+type buildFlag struct {
+	name      string
+	shorthand string
+	isBool    bool
+}
+
+// legacyBuildFlags are the flags of "docker build" that are supported by the
+// legacy builder. They are declared when parsing the arguments to warn about
+// unsupported flags, so that their values are not mistaken for flags.
+var legacyBuildFlags = []buildFlag{
+	{name: "add-host"},
+	{name: "build-arg"},
+	{name: "cache-from"},
+	{name: "cgroup-parent"},
+	{name: "compress", isBool: true},
+	{name: "cpu-period"},
+	{name: "cpu-quota"},
+	{name: "cpu-shares", shorthand: "c"},
+	{name: "cpuset-cpus"},
+	{name: "cpuset-mems"},
+	{name: "disable-content-trust", isBool: true},
+	{name: "file", shorthand: "f"},
+	{name: "force-rm", isBool: true},
+	{name: "iidfile"},
+	{name: "isolation"},
+	{name: "label"},
+	{name: "memory", shorthand: "m"},
+	{name: "memory-swap"},
+	{name: "network"},
+	{name: "no-cache", isBool: true},
+	{name: "platform"},
+	{name: "pull", isBool: true},
+	{name: "quiet", shorthand: "q", isBool: true},
+	{name: "rm", isBool: true},
+	{name: "security-opt"},
+	{name: "shm-size"},
+	{name: "squash", isBool: true},
+	{name: "tag", shorthand: "t"},
+	{name: "target"},
+	{name: "ulimit"},
+}
+
+// buildxOnlyFlags are the flags of "docker build" that are only supported
+// when building with BuildKit, and that are rejected by the legacy builder.
+// The --platform flag is not included, as the legacy builder supports it,
+// except with multiple platforms; see [legacyMultiPlatformWarning].
+var buildxOnlyFlags = []buildFlag{
+	{name: "output", shorthand: "o"},
+	{name: "secret"},
+	{name: "ssh"},
+	{name: "cache-to"},
+	{name: "push", isBool: true},
+	{name: "load", isBool: true},
+	{name: "attest"},
+	{name: "sbom"},
+	{name: "provenance"},
+	{name: "build-context"},
+	{name: "progress"},
+	{name: builderEnvFileFlag},
+}
+
+// describeBuilderSource returns a description of where the name of the
+// builder was set, for debug logs.
+func describeBuilderSource(dockerCli command.Cli, source command.BuilderSource) string {
+	switch source {
+	case command.BuilderSourceFlag:
+		return "the --builder flag"
+	case command.BuilderSourceEnv:
+		return "the BUILDX_BUILDER environment variable"
+	case command.BuilderSourceProject:
+		return "the " + command.ProjectBuilderFile + " file of the project"
+	case command.BuilderSourceContext:
+		return fmt.Sprintf("the %s field of context %q", command.ContextBuilderField, dockerCli.CurrentContext())
+	default:
+		return "the current context"
+	}
 }
 
 func forwardBuilder(alias string, args, osargs []string) ([]string, []string, []string, bool) {
@@ -179,21 +607,60 @@ func forwardBuilder(alias string, args, osargs []string) ([]string, []string, []
 	return args, osargs, nil, false
 }
 
-// hasBuilderName checks if a builder name is defined in args or env vars
-func hasBuilderName(args []string, envs []string) bool {
-	var builder string
-	flagset := pflag.NewFlagSet("buildx", pflag.ContinueOnError)
+// warnLegacyBuilderFlags prints a warning if flags are set that are not
+// supported by the legacy builder.
+func warnLegacyBuilderFlags(dockerCli command.Cli, args []string) {
+	flags := parseBuildFlags(args)
+	if platforms := multiPlatformFlag(flags); platforms != "" {
+		_, _ = fmt.Fprintf(dockerCli.Err(), legacyMultiPlatformWarning+"\n\n", platforms)
+	}
+	if names := getBuildxOnlyFlags(flags); len(names) > 0 {
+		_, _ = fmt.Fprintf(dockerCli.Err(), legacyBuildxOnlyFlagsWarning+"\n\n", strings.Join(names, ", "))
+	}
+}
+
+// parseBuildFlags parses the "docker build" flags in args. Both the flags of
+// the legacy builder and [buildxOnlyFlags] are declared, so that the values
+// of flags are not mistaken for flags. Other flags are ignored.
+func parseBuildFlags(args []string) *pflag.FlagSet {
+	flagset := pflag.NewFlagSet("build", pflag.ContinueOnError)
 	flagset.Usage = func() {}
 	flagset.SetOutput(io.Discard)
-	flagset.StringVar(&builder, "builder", "", "")
+	flagset.ParseErrorsAllowlist.UnknownFlags = true
+	for _, f := range slices.Concat(legacyBuildFlags, buildxOnlyFlags) {
+		if f.isBool {
+			flagset.BoolP(f.name, f.shorthand, false, "")
+		} else {
+			flagset.StringArrayP(f.name, f.shorthand, nil, "")
+		}
+	}
 	_ = flagset.Parse(args)
-	if builder != "" {
-		return true
+	return flagset
+}
+
+// multiPlatformFlag returns the value of the --platform flag if it sets
+// multiple, comma-separated platforms, which the legacy builder cannot build
+// for. It returns an empty string otherwise.
+func multiPlatformFlag(flags *pflag.FlagSet) string {
+	values, _ := flags.GetStringArray("platform")
+	if len(values) == 0 {
+		return ""
 	}
-	for _, e := range envs {
-		if strings.HasPrefix(e, "BUILDX_BUILDER=") && e != "BUILDX_BUILDER=" {
-			return true
+	platforms := values[len(values)-1]
+	if !strings.Contains(platforms, ",") {
+		return ""
+	}
+	return platforms
+}
+
+// getBuildxOnlyFlags returns the flags that are set, and that are only
+// supported when building with BuildKit, in the order of [buildxOnlyFlags].
+func getBuildxOnlyFlags(flags *pflag.FlagSet) []string {
+	var found []string
+	for _, f := range buildxOnlyFlags {
+		if flags.Changed(f.name) {
+			found = append(found, "--"+f.name)
 		}
 	}
-	return false
+	return found
 }
@@ -6,8 +6,13 @@
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/docker/cli/cli/command"
@@ -15,7 +20,9 @@
 	"github.com/docker/cli/cli/flags"
 	"github.com/docker/cli/internal/test/output"
 	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 	"gotest.tools/v3/fs"
 )
 
@@ -25,11 +32,16 @@ func TestBuildWithBuilder(t *testing.T) {
 	ctx := t.Context()
 
 	testcases := []struct {
-		name         string
-		context      string
-		builder      string
-		alias        bool
-		expectedEnvs []string
+		name           string
+		context        string
+		metadata       map[string]any
+		builder        string
+		project        string
+		args           []string
+		alias          bool
+		debug          bool
+		expectedEnvs   []string
+		expectedSource string
 	}{
 		{
 			name:         "default",
@@ -43,6 +55,13 @@ func TestBuildWithBuilder(t *testing.T) {
 			alias:        false,
 			expectedEnvs: []string{"BUILDX_BUILDER=foo"},
 		},
+		{
+			name:         "custom context with default builder",
+			context:      "foo",
+			metadata:     map[string]any{"com.docker.default-builder": "mybuilder"},
+			alias:        false,
+			expectedEnvs: []string{"BUILDX_BUILDER=mybuilder"},
+		},
 		{
 			name:         "custom builder name",
 			builder:      "mybuilder",
@@ -54,6 +73,51 @@ func TestBuildWithBuilder(t *testing.T) {
 			alias:        true,
 			expectedEnvs: nil,
 		},
+		{
+			name:           "debug context",
+			context:        "foo",
+			debug:          true,
+			expectedEnvs:   []string{"BUILDX_BUILDER=foo"},
+			expectedSource: `the current context`,
+		},
+		{
+			name:           "debug context with default builder",
+			context:        "foo",
+			metadata:       map[string]any{"com.docker.default-builder": "mybuilder"},
+			debug:          true,
+			expectedEnvs:   []string{"BUILDX_BUILDER=mybuilder"},
+			expectedSource: `the com.docker.default-builder field of context "foo"`,
+		},
+		{
+			name:           "debug builder flag and env var",
+			builder:        "mybuilder",
+			args:           []string{"--builder", "otherbuilder"},
+			debug:          true,
+			expectedEnvs:   nil,
+			expectedSource: `the --builder flag`,
+		},
+		{
+			name:           "debug builder env var",
+			builder:        "mybuilder",
+			debug:          true,
+			expectedEnvs:   nil,
+			expectedSource: `the BUILDX_BUILDER environment variable`,
+		},
+		{
+			name:           "debug project file",
+			context:        "foo",
+			metadata:       map[string]any{"com.docker.default-builder": "mybuilder"},
+			project:        "projectbuilder",
+			debug:          true,
+			expectedEnvs:   []string{"BUILDX_BUILDER=projectbuilder"},
+			expectedSource: `the .docker/builder file of the project`,
+		},
+		{
+			name:         "builder env var overrides project file",
+			builder:      "mybuilder",
+			project:      "projectbuilder",
+			expectedEnvs: nil,
+		},
 	}
 
 	dir := fs.NewDir(t, t.Name(),
@@ -70,6 +134,17 @@ func TestBuildWithBuilder(t *testing.T) {
 			if tc.builder != "" {
 				t.Setenv("BUILDX_BUILDER", tc.builder)
 			}
+			if tc.debug {
+				t.Setenv("DEBUG", "1")
+			} else {
+				t.Setenv("DEBUG", "")
+			}
+
+			if tc.project != "" {
+				projectDir := fs.NewDir(t, t.Name(), fs.WithDir(".docker", fs.WithFile("builder", tc.project)))
+				defer projectDir.Remove()
+				t.Chdir(projectDir.Path())
+			}
 
 			var b bytes.Buffer
 			dockerCli, err := command.NewDockerCli(
@@ -84,7 +159,8 @@ func TestBuildWithBuilder(t *testing.T) {
 			if tc.context != "" {
 				if tc.context != command.DefaultContextName {
 					assert.NilError(t, dockerCli.ContextStore().CreateOrUpdate(store.Metadata{
-						Name: tc.context,
+						Name:     tc.context,
+						Metadata: command.DockerContext{AdditionalFields: tc.metadata},
 						Endpoints: map[string]any{
 							"docker": map[string]any{
 								"host": "unix://" + filepath.Join(t.TempDir(), "docker.sock"),
@@ -102,33 +178,206 @@ func TestBuildWithBuilder(t *testing.T) {
 				dockerCli.ConfigFile().Aliases = map[string]string{"builder": "buildx"}
 			}
 
+			buildArgs := append(append([]string{"build"}, tc.args...), ".")
 			tcmd := newDockerCommand(dockerCli)
-			tcmd.SetArgs([]string{"build", "."})
+			tcmd.SetArgs(buildArgs)
 
 			cmd, args, err := tcmd.HandleGlobalFlags()
 			assert.NilError(t, err)
 
+			// set up logging after handling the global flags, which set the log level
+			var logs bytes.Buffer
+			logger := logrus.StandardLogger()
+			out, level, formatter := logger.Out, logger.GetLevel(), logger.Formatter
+			logger.SetOutput(&logs)
+			logger.SetLevel(logrus.DebugLevel)
+			logger.SetFormatter(&logrus.JSONFormatter{DisableTimestamp: true})
+			defer func() {
+				logger.SetOutput(out)
+				logger.SetLevel(level)
+				logger.SetFormatter(formatter)
+			}()
+
 			var envs []string
-			args, os.Args, envs, err = processBuilder(dockerCli, cmd, args, os.Args)
+			args, os.Args, envs, _, err = processBuilder(dockerCli, cmd, args, os.Args)
 			assert.NilError(t, err)
-			assert.DeepEqual(t, []string{builderDefaultPlugin, "build", "."}, args)
+			assert.DeepEqual(t, append([]string{builderDefaultPlugin}, buildArgs...), args)
 			if tc.expectedEnvs != nil {
 				assert.DeepEqual(t, tc.expectedEnvs, envs)
 			} else {
 				assert.Check(t, len(envs) == 0)
 			}
+			if tc.expectedSource != "" {
+				assert.Check(t, is.Equal(builderSourceLog(t, logs.String()), tc.expectedSource))
+			}
+			// the builder is only logged, and not printed
+			assert.Check(t, !strings.Contains(b.String(), "DEBUG"))
 		})
 	}
 }
 
+// builderSourceLog returns the "from" field of the "builder.source" event
+// in the given JSON logs.
+func builderSourceLog(t *testing.T, logs string) string {
+	t.Helper()
+	for _, line := range strings.Split(strings.TrimSpace(logs), "\n") {
+		var entry map[string]any
+		assert.NilError(t, json.Unmarshal([]byte(line), &entry))
+		if entry["event"] == "builder.source" {
+			from, _ := entry["from"].(string)
+			return from
+		}
+	}
+	return ""
+}
+
 type fakeClient struct {
 	client.Client
+	pingErr error
 }
 
-func (*fakeClient) Ping(context.Context, client.PingOptions) (client.PingResult, error) {
+func (c *fakeClient) Ping(context.Context, client.PingOptions) (client.PingResult, error) {
+	if c.pingErr != nil {
+		return client.PingResult{}, c.pingErr
+	}
 	return client.PingResult{OSType: "linux"}, nil
 }
 
+func TestBuildWithEnvFile(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile(pluginFilename, `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"Docker Inc.","Version":"v0.6.3","ShortDescription":"Build with BuildKit"}'`, fs.WithMode(0o777)),
+		fs.WithFile("build.env", "# comment\nFOO=foo\nBUILDX_BUILDER=mybuilder\nEXISTING=from-file\n"),
+		fs.WithFile("other.env", "BAR=bar\n"),
+		fs.WithFile("invalid.env", "FOO BAR=foo\n"),
+	)
+	defer dir.Remove()
+	t.Setenv("EXISTING", "from-env")
+
+	testCases := []struct {
+		doc          string
+		args         []string
+		expectedArgs []string
+		expectedEnvs []string
+		expectedErr  string
+	}{
+		{
+			doc:          "env file",
+			args:         []string{"build", "--env-file", dir.Join("build.env"), "."},
+			expectedArgs: []string{builderDefaultPlugin, "build", "."},
+			expectedEnvs: []string{"BUILDX_BUILDER=default", "FOO=foo"},
+		},
+		{
+			doc:          "multiple env files",
+			args:         []string{"build", "--env-file=" + dir.Join("build.env"), "--env-file", dir.Join("other.env"), "."},
+			expectedArgs: []string{builderDefaultPlugin, "build", "."},
+			expectedEnvs: []string{"BUILDX_BUILDER=default", "FOO=foo", "BAR=bar"},
+		},
+		{
+			doc:          "after double dash",
+			args:         []string{"build", ".", "--", "--env-file", dir.Join("build.env")},
+			expectedArgs: []string{builderDefaultPlugin, "build", ".", "--", "--env-file", dir.Join("build.env")},
+			expectedEnvs: []string{"BUILDX_BUILDER=default"},
+		},
+		{
+			doc:         "malformed env file",
+			args:        []string{"build", "--env-file", dir.Join("invalid.env"), "."},
+			expectedErr: "invalid env file (" + dir.Join("invalid.env") + "): variable 'FOO BAR' contains whitespaces",
+		},
+		{
+			doc:         "missing value",
+			args:        []string{"build", ".", "--env-file"},
+			expectedErr: "flag needs an argument: --env-file",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			var b bytes.Buffer
+			dockerCli, err := command.NewDockerCli(
+				command.WithBaseContext(t.Context()),
+				command.WithAPIClient(&fakeClient{}),
+				command.WithInputStream(discard),
+				command.WithCombinedStreams(&b),
+			)
+			assert.NilError(t, err)
+			assert.NilError(t, dockerCli.Initialize(flags.NewClientOptions()))
+			dockerCli.ConfigFile().CLIPluginsExtraDirs = []string{dir.Path()}
+
+			tcmd := newDockerCommand(dockerCli)
+			tcmd.SetArgs(tc.args)
+			cmd, args, err := tcmd.HandleGlobalFlags()
+			assert.NilError(t, err)
+
+			args, osArgs, envs, _, err := processBuilder(dockerCli, cmd, args, append([]string{"docker"}, tc.args...))
+			if tc.expectedErr != "" {
+				assert.Check(t, is.Error(err, tc.expectedErr))
+				return
+			}
+			assert.NilError(t, err)
+			assert.Check(t, is.DeepEqual(tc.expectedArgs, args))
+			assert.Check(t, is.DeepEqual(append([]string{"docker"}, tc.expectedArgs...), osArgs))
+			assert.Check(t, is.DeepEqual(tc.expectedEnvs, envs))
+		})
+	}
+}
+
+func TestBuildWithUnreachableEndpoint(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile(pluginFilename, `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"Docker Inc.","Version":"v0.6.3","ShortDescription":"Build with BuildKit"}'`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	for _, debugEnabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("debug=%t", debugEnabled), func(t *testing.T) {
+			if debugEnabled {
+				t.Setenv("DEBUG", "1")
+			} else {
+				t.Setenv("DEBUG", "")
+			}
+
+			var b bytes.Buffer
+			dockerCli, err := command.NewDockerCli(
+				command.WithBaseContext(t.Context()),
+				command.WithAPIClient(&fakeClient{pingErr: errors.New("connection refused")}),
+				command.WithInputStream(discard),
+				command.WithCombinedStreams(&b),
+			)
+			assert.NilError(t, err)
+			assert.NilError(t, dockerCli.Initialize(flags.NewClientOptions()))
+
+			host := "unix://" + filepath.Join(t.TempDir(), "docker.sock")
+			assert.NilError(t, dockerCli.ContextStore().CreateOrUpdate(store.Metadata{
+				Name:     "foo",
+				Metadata: command.DockerContext{},
+				Endpoints: map[string]any{
+					"docker": map[string]any{"host": host},
+				},
+			}))
+			opts := flags.NewClientOptions()
+			opts.Context = "foo"
+			assert.NilError(t, dockerCli.Initialize(opts))
+			dockerCli.ConfigFile().CLIPluginsExtraDirs = []string{dir.Path()}
+
+			tcmd := newDockerCommand(dockerCli)
+			tcmd.SetArgs([]string{"build", "."})
+			cmd, args, err := tcmd.HandleGlobalFlags()
+			assert.NilError(t, err)
+
+			args, os.Args, _, _, err = processBuilder(dockerCli, cmd, args, os.Args)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []string{builderDefaultPlugin, "build", "."}, args)
+
+			expected := fmt.Sprintf(`WARNING: the Docker endpoint of context "foo" (%s) is not reachable: connection refused`, host)
+			if debugEnabled {
+				assert.Check(t, is.Contains(b.String(), expected))
+			} else {
+				assert.Check(t, !strings.Contains(b.String(), "WARNING"))
+			}
+		})
+	}
+}
+
 func TestBuildkitDisabled(t *testing.T) {
 	ctx := t.Context()
 
@@ -157,15 +406,103 @@ func TestBuildkitDisabled(t *testing.T) {
 	cmd, args, err := tcmd.HandleGlobalFlags()
 	assert.NilError(t, err)
 
-	var envs []string
-	args, os.Args, envs, err = processBuilder(dockerCli, cmd, args, os.Args)
+	var (
+		envs []string
+		res  builderResolution
+	)
+	args, os.Args, envs, res, err = processBuilder(dockerCli, cmd, args, os.Args)
 	assert.NilError(t, err)
 	assert.DeepEqual(t, []string{"build", "."}, args)
 	assert.Check(t, len(envs) == 0)
+	assert.Check(t, res.forwarded)
+	assert.Check(t, !res.buildKit)
+	assert.Check(t, is.Equal(res.reason, legacyReasonDisabled))
+
+	output.Assert(t, b.String(), map[int]func(string) error{
+		0: output.Suffix("DEPRECATED: The legacy builder is deprecated and will be removed in a future release."),
+		1: output.Suffix("BuildKit is currently disabled; enable it by removing the DOCKER_BUILDKIT=0"),
+	})
+}
+
+func TestBuildkitDisabledWithMultiPlatform(t *testing.T) {
+	ctx := t.Context()
+
+	t.Setenv("DOCKER_BUILDKIT", "0")
+
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile(pluginFilename, `#!/bin/sh exit 1`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	b := bytes.NewBuffer(nil)
+
+	dockerCli, err := command.NewDockerCli(
+		command.WithBaseContext(ctx),
+		command.WithAPIClient(&fakeClient{}),
+		command.WithInputStream(discard),
+		command.WithCombinedStreams(b),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, dockerCli.Initialize(flags.NewClientOptions()))
+	dockerCli.ConfigFile().CLIPluginsExtraDirs = []string{dir.Path()}
+
+	tcmd := newDockerCommand(dockerCli)
+	tcmd.SetArgs([]string{"build", "--platform=linux/arm64,linux/amd64", "."})
+
+	cmd, args, err := tcmd.HandleGlobalFlags()
+	assert.NilError(t, err)
+
+	var envs []string
+	args, os.Args, envs, _, err = processBuilder(dockerCli, cmd, args, os.Args)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"build", "--platform=linux/arm64,linux/amd64", "."}, args)
+	assert.Check(t, len(envs) == 0)
+
+	output.Assert(t, b.String(), map[int]func(string) error{
+		0: output.Suffix("DEPRECATED: The legacy builder is deprecated and will be removed in a future release."),
+		1: output.Suffix("BuildKit is currently disabled; enable it by removing the DOCKER_BUILDKIT=0"),
+		4: output.Prefix("WARNING: Building for multiple platforms (linux/arm64,linux/amd64) is not supported by the legacy builder."),
+	})
+}
+
+func TestBuildkitDisabledWithBuildxOnlyFlags(t *testing.T) {
+	ctx := t.Context()
+
+	t.Setenv("DOCKER_BUILDKIT", "0")
+
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile(pluginFilename, `#!/bin/sh exit 1`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	b := bytes.NewBuffer(nil)
+
+	dockerCli, err := command.NewDockerCli(
+		command.WithBaseContext(ctx),
+		command.WithAPIClient(&fakeClient{}),
+		command.WithInputStream(discard),
+		command.WithCombinedStreams(b),
+	)
+	assert.NilError(t, err)
+	assert.NilError(t, dockerCli.Initialize(flags.NewClientOptions()))
+	dockerCli.ConfigFile().CLIPluginsExtraDirs = []string{dir.Path()}
+
+	tcmd := newDockerCommand(dockerCli)
+	tcmd.SetArgs([]string{"build", "--secret", "id=foo", "-o", "type=local,dest=out", "."})
+
+	cmd, args, err := tcmd.HandleGlobalFlags()
+	assert.NilError(t, err)
+
+	var envs []string
+	args, os.Args, envs, _, err = processBuilder(dockerCli, cmd, args, os.Args)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"build", "--secret", "id=foo", "-o", "type=local,dest=out", "."}, args)
+	assert.Check(t, len(envs) == 0)
 
 	output.Assert(t, b.String(), map[int]func(string) error{
 		0: output.Suffix("DEPRECATED: The legacy builder is deprecated and will be removed in a future release."),
 		1: output.Suffix("BuildKit is currently disabled; enable it by removing the DOCKER_BUILDKIT=0"),
+		4: output.Equals("WARNING: The following flags require BuildKit and are not supported by the legacy builder: --output, --secret"),
 	})
 }
 
@@ -196,7 +533,7 @@ func TestBuilderBroken(t *testing.T) {
 	assert.NilError(t, err)
 
 	var envs []string
-	args, os.Args, envs, err = processBuilder(dockerCli, cmd, args, os.Args)
+	args, os.Args, envs, _, err = processBuilder(dockerCli, cmd, args, os.Args)
 	assert.NilError(t, err)
 	assert.DeepEqual(t, []string{"build", "."}, args)
 	assert.Check(t, len(envs) == 0)
@@ -207,6 +544,86 @@ func TestBuilderBroken(t *testing.T) {
 	})
 }
 
+func TestBuilderDecisionLogs(t *testing.T) {
+	testCases := []struct {
+		name           string
+		buildkit       string
+		expectedEvents []string
+		expectedStderr map[int]func(string) error
+	}{
+		{
+			name:     "buildkit disabled",
+			buildkit: "0",
+			expectedEvents: []string{
+				`"buildkit":false,"event":"builder.buildkit","level":"debug","msg":"using the legacy builder","reason":"DOCKER_BUILDKIT is disabled"`,
+			},
+			expectedStderr: map[int]func(string) error{
+				0: output.Suffix("DEPRECATED: The legacy builder is deprecated and will be removed in a future release."),
+			},
+		},
+		{
+			name: "builder broken",
+			expectedEvents: []string{
+				`"event":"builder.plugin","level":"debug","msg":"builder plugin not available","plugin":"buildx"`,
+			},
+			expectedStderr: map[int]func(string) error{
+				0: output.Prefix("failed to fetch metadata:"),
+				2: output.Suffix("DEPRECATED: The legacy builder is deprecated and will be removed in a future release."),
+			},
+		},
+	}
+
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile(pluginFilename, `#!/bin/sh exit 1`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("DOCKER_BUILDKIT", tc.buildkit)
+
+			b := bytes.NewBuffer(nil)
+			dockerCli, err := command.NewDockerCli(
+				command.WithBaseContext(t.Context()),
+				command.WithAPIClient(&fakeClient{}),
+				command.WithInputStream(discard),
+				command.WithCombinedStreams(b),
+			)
+			assert.NilError(t, err)
+			assert.NilError(t, dockerCli.Initialize(flags.NewClientOptions()))
+			dockerCli.ConfigFile().CLIPluginsExtraDirs = []string{dir.Path()}
+
+			tcmd := newDockerCommand(dockerCli)
+			tcmd.SetArgs([]string{"build", "."})
+			cmd, args, err := tcmd.HandleGlobalFlags()
+			assert.NilError(t, err)
+
+			// set up logging after handling the global flags, which set the log level
+			var logs bytes.Buffer
+			logger := logrus.StandardLogger()
+			out, level, formatter := logger.Out, logger.GetLevel(), logger.Formatter
+			logger.SetOutput(&logs)
+			logger.SetLevel(logrus.DebugLevel)
+			logger.SetFormatter(&logrus.JSONFormatter{DisableTimestamp: true})
+			defer func() {
+				logger.SetOutput(out)
+				logger.SetLevel(level)
+				logger.SetFormatter(formatter)
+			}()
+
+			_, os.Args, _, _, err = processBuilder(dockerCli, cmd, args, os.Args)
+			assert.NilError(t, err)
+
+			for _, event := range tc.expectedEvents {
+				assert.Check(t, is.Contains(logs.String(), event))
+			}
+			// the events are only logged, and not printed with the warnings
+			assert.Check(t, !strings.Contains(b.String(), "builder."))
+			output.Assert(t, b.String(), tc.expectedStderr)
+		})
+	}
+}
+
 func TestBuilderBrokenEnforced(t *testing.T) {
 	t.Setenv("DOCKER_BUILDKIT", "1")
 	ctx := t.Context()
@@ -235,7 +652,7 @@ func TestBuilderBrokenEnforced(t *testing.T) {
 	assert.NilError(t, err)
 
 	var envs []string
-	args, os.Args, envs, err = processBuilder(dockerCli, cmd, args, os.Args)
+	args, os.Args, envs, _, err = processBuilder(dockerCli, cmd, args, os.Args)
 	assert.DeepEqual(t, []string{"build", "."}, args)
 	assert.Check(t, len(envs) == 0)
 
@@ -250,47 +667,345 @@ func TestBuilderBrokenEnforced(t *testing.T) {
 	})
 }
 
-func TestHasBuilderName(t *testing.T) {
-	cases := []struct {
+func TestBuilderBrokenWithInvalidEndpoint(t *testing.T) {
+	dir := fs.NewDir(t, t.Name(),
+		fs.WithFile(pluginFilename, `#!/bin/sh exit 1`, fs.WithMode(0o777)),
+	)
+	defer dir.Remove()
+
+	testCases := []struct {
+		name        string
+		enforced    bool
+		listen      bool
+		expectedErr bool
+	}{
+		{
+			name:        "dead socket",
+			expectedErr: true,
+		},
+		{
+			name:   "healthy socket",
+			listen: true,
+		},
+		{
+			name:     "endpoint not used",
+			enforced: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.enforced {
+				t.Setenv("DOCKER_BUILDKIT", "1")
+			}
+
+			var b bytes.Buffer
+			dockerCli, err := command.NewDockerCli(
+				command.WithBaseContext(t.Context()),
+				command.WithAPIClient(&fakeClient{}),
+				command.WithInputStream(discard),
+				command.WithCombinedStreams(&b),
+			)
+			assert.NilError(t, err)
+			assert.NilError(t, dockerCli.Initialize(flags.NewClientOptions()))
+
+			socket := filepath.Join(t.TempDir(), "docker.sock")
+			if tc.listen {
+				l, err := net.Listen("unix", socket)
+				assert.NilError(t, err)
+				defer l.Close()
+			}
+			host := "unix://" + socket
+			assert.NilError(t, dockerCli.ContextStore().CreateOrUpdate(store.Metadata{
+				Name:     "foo",
+				Metadata: command.DockerContext{},
+				Endpoints: map[string]any{
+					"docker": map[string]any{"host": host},
+				},
+			}))
+			opts := flags.NewClientOptions()
+			opts.Context = "foo"
+			assert.NilError(t, dockerCli.Initialize(opts))
+			dockerCli.ConfigFile().CLIPluginsExtraDirs = []string{dir.Path()}
+
+			tcmd := newDockerCommand(dockerCli)
+			tcmd.SetArgs([]string{"bake"})
+			cmd, args, err := tcmd.HandleGlobalFlags()
+			assert.NilError(t, err)
+
+			_, os.Args, _, _, err = processBuilder(dockerCli, cmd, args, os.Args)
+			assert.Check(t, is.ErrorContains(err, "docker bake requires the buildx component"))
+
+			expected := fmt.Sprintf(`The Docker endpoint of context "foo" (%s) is not usable: the socket does not exist`, host)
+			if tc.expectedErr {
+				assert.Check(t, is.ErrorContains(err, expected))
+			} else {
+				assert.Check(t, !strings.Contains(err.Error(), "Docker endpoint"))
+			}
+		})
+	}
+}
+
+func TestBuilderShadowedPlugin(t *testing.T) {
+	const plugin = `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"Docker Inc.","Version":"v0.6.3","ShortDescription":"Build with BuildKit"}'`
+
+	testCases := []struct {
 		name     string
-		args     []string
-		envs     []string
+		dirs     int
 		expected bool
 	}{
 		{
-			name:     "no args",
-			args:     []string{"docker", "build", "."},
-			envs:     []string{"FOO=bar"},
-			expected: false,
+			name: "single plugin",
+			dirs: 1,
 		},
 		{
-			name:     "env var",
-			args:     []string{"docker", "build", "."},
-			envs:     []string{"BUILDX_BUILDER=foo"},
+			name:     "multiple plugins",
+			dirs:     2,
 			expected: true,
 		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var dirs []string
+			for i := range tc.dirs {
+				dir := fs.NewDir(t, fmt.Sprintf("%s-%d", t.Name(), i), fs.WithFile(pluginFilename, plugin, fs.WithMode(0o777)))
+				defer dir.Remove()
+				dirs = append(dirs, dir.Path())
+			}
+
+			var b bytes.Buffer
+			dockerCli, err := command.NewDockerCli(
+				command.WithBaseContext(t.Context()),
+				command.WithAPIClient(&fakeClient{}),
+				command.WithInputStream(discard),
+				command.WithCombinedStreams(&b),
+			)
+			assert.NilError(t, err)
+			assert.NilError(t, dockerCli.Initialize(flags.NewClientOptions()))
+			dockerCli.ConfigFile().CLIPluginsExtraDirs = dirs
+
+			tcmd := newDockerCommand(dockerCli)
+			tcmd.SetArgs([]string{"build", "."})
+			cmd, args, err := tcmd.HandleGlobalFlags()
+			assert.NilError(t, err)
+
+			args, os.Args, _, _, err = processBuilder(dockerCli, cmd, args, os.Args)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, []string{builderDefaultPlugin, "build", "."}, args)
+
+			if tc.expected {
+				expected := fmt.Sprintf("WARNING: multiple buildx plugins found; using %s, which shadows %s",
+					filepath.Join(dirs[0], pluginFilename), filepath.Join(dirs[1], pluginFilename))
+				assert.Check(t, is.Contains(b.String(), expected))
+			} else {
+				assert.Check(t, !strings.Contains(b.String(), "WARNING"))
+			}
+		})
+	}
+}
+
+func TestBuilderWhich(t *testing.T) {
+	testCases := []struct {
+		name     string
+		plugin   string
+		buildkit string
+		alias    bool
+		args     []string
+		expected []string
+	}{
 		{
-			name:     "empty env var",
-			args:     []string{"docker", "build", "."},
-			envs:     []string{"BUILDX_BUILDER="},
-			expected: false,
+			name: "buildkit",
+			plugin: `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"Docker Inc.","Version":"v0.6.3","ShortDescription":"Build with BuildKit"}'`,
+			args: []string{"--builder", "mybuilder"},
+			expected: []string{
+				"BuildKit: enabled",
+				"Plugin:   buildx v0.6.3 (",
+				`Builder:  mybuilder (from the --builder flag)`,
+			},
 		},
 		{
-			name:     "flag",
-			args:     []string{"docker", "build", "--builder", "foo", "."},
-			envs:     []string{"FOO=bar"},
-			expected: true,
+			name: "buildkit with alias",
+			plugin: `#!/bin/sh
+echo '{"SchemaVersion":"0.1.0","Vendor":"Docker Inc.","Version":"v0.6.3","ShortDescription":"Build with BuildKit"}'`,
+			alias: true,
+			expected: []string{
+				"BuildKit: enabled",
+				`Builder:  selected by the "builder" alias`,
+			},
 		},
 		{
-			name:     "both",
-			args:     []string{"docker", "build", "--builder", "foo", "."},
-			envs:     []string{"BUILDX_BUILDER=foo"},
-			expected: true,
+			name:     "buildkit disabled",
+			plugin:   `#!/bin/sh exit 1`,
+			buildkit: "0",
+			expected: []string{
+				"BuildKit: disabled (DOCKER_BUILDKIT is disabled); using the legacy builder",
+				"Plugin:   buildx (not checked)",
+			},
+		},
+		{
+			name:   "broken plugin",
+			plugin: `#!/bin/sh exit 1`,
+			expected: []string{
+				"BuildKit: disabled (builder plugin is missing or broken); using the legacy builder",
+				"Plugin:   buildx (broken: failed to fetch metadata:",
+			},
+		},
+		{
+			name:     "broken plugin enforced",
+			plugin:   `#!/bin/sh exit 1`,
+			buildkit: "1",
+			expected: []string{
+				"BuildKit: required, but the builder plugin is missing or broken; the build fails",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("DOCKER_BUILDKIT", tc.buildkit)
+			t.Setenv("BUILDX_BUILDER", "")
+
+			dir := fs.NewDir(t, t.Name(), fs.WithFile(pluginFilename, tc.plugin, fs.WithMode(0o777)))
+			defer dir.Remove()
+
+			var b bytes.Buffer
+			dockerCli, err := command.NewDockerCli(
+				command.WithBaseContext(t.Context()),
+				command.WithAPIClient(&fakeClient{}),
+				command.WithInputStream(discard),
+				command.WithCombinedStreams(&b),
+			)
+			assert.NilError(t, err)
+			assert.NilError(t, dockerCli.Initialize(flags.NewClientOptions()))
+			dockerCli.ConfigFile().CLIPluginsExtraDirs = []string{dir.Path()}
+			if tc.alias {
+				dockerCli.ConfigFile().Aliases = map[string]string{"builder": "buildx"}
+			}
+
+			tcmd := newDockerCommand(dockerCli)
+			tcmd.SetArgs(append([]string{"builder", "which"}, tc.args...))
+
+			cmd, args, err := tcmd.HandleGlobalFlags()
+			assert.NilError(t, err)
+
+			// "docker builder which" must not be forwarded to the builder.
+			args, _, envs, _, err := processBuilder(dockerCli, cmd, args, os.Args)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, append([]string{"builder", "which"}, tc.args...), args)
+			assert.Check(t, len(envs) == 0)
+
+			whichCmd, whichArgs, err := cmd.Find(args)
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(whichCmd.Name(), "which"))
+			assert.NilError(t, whichCmd.RunE(whichCmd, whichArgs))
+			for _, expected := range tc.expected {
+				assert.Check(t, is.Contains(b.String(), expected))
+			}
+		})
+	}
+}
+
+func TestMultiPlatformFlag(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "no flag",
+			args: []string{"build", "."},
+		},
+		{
+			name: "single platform",
+			args: []string{"build", "--platform", "linux/arm64", "."},
+		},
+		{
+			name:     "multiple platforms with separate value",
+			args:     []string{"build", "--platform", "linux/arm64,linux/amd64", "."},
+			expected: "linux/arm64,linux/amd64",
+		},
+		{
+			name:     "multiple platforms with value",
+			args:     []string{"build", "-t", "foo", "--platform=linux/arm64,linux/amd64", "."},
+			expected: "linux/arm64,linux/amd64",
+		},
+		{
+			name: "similar flag",
+			args: []string{"build", "--platforms", "linux/arm64,linux/amd64", "."},
+		},
+		{
+			name: "after end of flags",
+			args: []string{"build", "--", "--platform=linux/arm64,linux/amd64"},
+		},
+		{
+			name: "value of other flag",
+			args: []string{"build", "--label", "--platform=linux/arm64,linux/amd64", "."},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, multiPlatformFlag(parseBuildFlags(tc.args)))
+		})
+	}
+}
+
+func TestGetBuildxOnlyFlags(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			name: "no flags",
+			args: []string{"build", "--platform", "linux/arm64", "-t", "foo", "."},
+		},
+		{
+			name:     "flags with separate value",
+			args:     []string{"build", "--secret", "id=foo", "--output", "type=local,dest=out", "."},
+			expected: []string{"--output", "--secret"},
+		},
+		{
+			name:     "flags with value",
+			args:     []string{"build", "--cache-to=type=inline", "--push", "."},
+			expected: []string{"--cache-to", "--push"},
+		},
+		{
+			name:     "shorthand",
+			args:     []string{"build", "-o", "out", "."},
+			expected: []string{"--output"},
+		},
+		{
+			name:     "shorthand with value",
+			args:     []string{"build", "-oout", "."},
+			expected: []string{"--output"},
+		},
+		{
+			name: "similar flag",
+			args: []string{"build", "--outputs", "--pushed", "."},
+		},
+		{
+			name: "after end of flags",
+			args: []string{"build", "--", "--output", "-o"},
+		},
+		{
+			name:     "combined shorthands",
+			args:     []string{"build", "-qo", "out", "."},
+			expected: []string{"--output"},
+		},
+		{
+			name: "value of other flag",
+			args: []string{"build", "--build-arg", "--push=1", "-t", "-ofoo", "."},
+		},
+		{
+			name:     "unknown flags",
+			args:     []string{"build", "--no-such-flag", "--call=check", "--push", "."},
+			expected: []string{"--push"},
 		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			assert.Equal(t, tc.expected, hasBuilderName(tc.args, tc.envs))
+			assert.DeepEqual(t, tc.expected, getBuildxOnlyFlags(parseBuildFlags(tc.args)))
 		})
 	}
 }
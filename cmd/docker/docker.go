@@ -185,6 +185,9 @@ func newDockerCommand(dockerCli *command.DockerCli) *cli.TopLevelCommand {
 
 	cmd.SetOut(dockerCli.Out())
 	commands.AddCommands(cmd, dockerCli)
+	if builderCmd, _, err := cmd.Find([]string{"builder"}); err == nil && builderCmd != cmd {
+		builderCmd.AddCommand(newBuilderWhichCommand(dockerCli))
+	}
 
 	visitAll(cmd, setValidateArgs(dockerCli))
 
@@ -337,6 +340,12 @@ func tryPluginRun(ctx context.Context, dockerCli command.Cli, cmd *cobra.Command
 		}()
 	}
 
+	// Pass the --assume-yes global option to the plugin, which is executed
+	// as a separate process.
+	if command.AssumeYes(dockerCli) {
+		plugincmd.Env = append(plugincmd.Env, command.EnvAssumeYes+"=1")
+	}
+
 	// Set additional environment variables specified by the caller.
 	plugincmd.Env = append(plugincmd.Env, envs...)
 
@@ -472,7 +481,16 @@ func restoreTerminal(streams command.Streams) {
 }
 
 //nolint:gocyclo
-func runDocker(ctx context.Context, dockerCli *command.DockerCli) error {
+func runDocker(ctx context.Context, dockerCli *command.DockerCli) (retErr error) {
+	lifecycle, err := newLifecycleLogger(dockerCli.Err())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		lifecycle.commandEnd(retErr)
+		_ = lifecycle.Close()
+	}()
+
 	tcmd := newDockerCommand(dockerCli)
 
 	cmd, args, err := tcmd.HandleGlobalFlags()
@@ -483,6 +501,7 @@ func runDocker(ctx context.Context, dockerCli *command.DockerCli) error {
 	if err := tcmd.Initialize(command.WithEnableGlobalMeterProvider(), command.WithEnableGlobalTracerProvider()); err != nil {
 		return err
 	}
+	lifecycle.commandStart(append([]string{cmd.Name()}, args...), dockerCli.CurrentContext())
 
 	mp := dockerCli.MeterProvider()
 	if mp, ok := mp.(command.MeterProvider); ok {
@@ -497,11 +516,17 @@ func runDocker(ctx context.Context, dockerCli *command.DockerCli) error {
 
 	dockerCli.InstrumentCobraCommands(ctx, cmd)
 
-	var envs []string
-	args, os.Args, envs, err = processAliases(dockerCli, cmd, args, os.Args)
+	var (
+		envs    []string
+		builder builderResolution
+	)
+	args, os.Args, envs, builder, err = processAliases(dockerCli, cmd, args, os.Args)
 	if err != nil {
 		return err
 	}
+	if builder.forwarded {
+		lifecycle.builderSelected(builder, envs)
+	}
 
 	if hasCompletionArg(args) {
 		// We add plugin command stubs early only for completion. We don't
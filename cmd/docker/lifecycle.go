@@ -0,0 +1,128 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// envLogJSON enables emitting JSON log lines for the command lifecycle
+	// (command start and end, selected context, builder, and errors).
+	envLogJSON = "DOCKER_CLI_LOG_JSON"
+
+	// envLogJSONFile optionally configures the file to write lifecycle
+	// log lines to. Log lines are written to stderr if not set.
+	envLogJSONFile = "DOCKER_CLI_LOG_JSON_FILE"
+)
+
+// lifecycleLogger emits structured (JSON) log lines for the lifecycle of
+// a CLI command. A nil lifecycleLogger is valid, and discards all events,
+// so that callers don't have to check if logging is enabled.
+type lifecycleLogger struct {
+	logger  *logrus.Logger
+	closer  io.Closer
+	command string
+	started time.Time
+}
+
+// newLifecycleLogger returns a lifecycleLogger if enabled through the
+// DOCKER_CLI_LOG_JSON environment variable, or nil otherwise. Log lines are
+// written to the file set in DOCKER_CLI_LOG_JSON_FILE, or to stderr if no
+// file is configured.
+func newLifecycleLogger(stderr io.Writer) (*lifecycleLogger, error) {
+	if enabled, _ := strconv.ParseBool(os.Getenv(envLogJSON)); !enabled {
+		return nil, nil
+	}
+
+	l := &lifecycleLogger{
+		logger: &logrus.Logger{
+			Out:       stderr,
+			Formatter: &logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano},
+			Hooks:     make(logrus.LevelHooks),
+			Level:     logrus.InfoLevel,
+		},
+	}
+	if fileName := os.Getenv(envLogJSONFile); fileName != "" {
+		f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		l.logger.Out = f
+		l.closer = f
+	}
+	return l, nil
+}
+
+// commandStart logs the start of the given command, and the context it uses.
+func (l *lifecycleLogger) commandStart(command []string, contextName string) {
+	if l == nil {
+		return
+	}
+	l.command = strings.Join(command, " ")
+	l.started = time.Now()
+	l.logger.WithFields(logrus.Fields{
+		"event":   "command.start",
+		"command": l.command,
+		"context": contextName,
+	}).Info("command started")
+}
+
+// builderSelected logs the builder that was selected to handle a build
+// command: the builder plugin if BuildKit is used, or the legacy builder,
+// and why.
+func (l *lifecycleLogger) builderSelected(res builderResolution, envs []string) {
+	if l == nil {
+		return
+	}
+	fields := logrus.Fields{
+		"event":    "builder.selected",
+		"buildkit": res.buildKit,
+	}
+	if res.buildKit {
+		fields["builder"] = res.pluginName
+	} else {
+		fields["builder"] = "legacy"
+		fields["reason"] = string(res.reason)
+	}
+	for _, e := range envs {
+		if v, ok := strings.CutPrefix(e, "BUILDX_BUILDER="); ok {
+			fields["buildx_builder"] = v
+		}
+	}
+	l.logger.WithFields(fields).Info("builder selected")
+}
+
+// commandEnd logs the end of the command that was started, including its
+// duration, exit-code, and error (if any).
+func (l *lifecycleLogger) commandEnd(err error) {
+	if l == nil {
+		return
+	}
+	fields := logrus.Fields{
+		"event":     "command.end",
+		"command":   l.command,
+		"exit_code": getExitCode(err),
+	}
+	if !l.started.IsZero() {
+		fields["duration_ms"] = time.Since(l.started).Milliseconds()
+	}
+	if err != nil {
+		fields["error"] = cmdErrorMessage(err)
+		l.logger.WithFields(fields).Error("command failed")
+		return
+	}
+	l.logger.WithFields(fields).Info("command completed")
+}
+
+// Close closes the log file, if any.
+func (l *lifecycleLogger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dockercli "github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func readLifecycleEvents(t *testing.T, r io.Reader) []map[string]any {
+	t.Helper()
+	var events []map[string]any
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var ev map[string]any
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), &ev), "invalid JSON log line: %s", scanner.Text())
+		events = append(events, ev)
+	}
+	assert.NilError(t, scanner.Err())
+	return events
+}
+
+func TestLifecycleLoggerDisabled(t *testing.T) {
+	t.Setenv(envLogJSON, "")
+	var buf bytes.Buffer
+	l, err := newLifecycleLogger(&buf)
+	assert.NilError(t, err)
+	assert.Check(t, l == nil)
+
+	// a nil logger must be safe to use.
+	l.commandStart([]string{"docker", "ps"}, "default")
+	l.builderSelected(builderResolution{forwarded: true, buildKit: true, pluginName: "buildx"}, nil)
+	l.commandEnd(errors.New("some error"))
+	assert.Check(t, l.Close())
+	assert.Check(t, is.Equal(buf.String(), ""))
+}
+
+func TestLifecycleLoggerEvents(t *testing.T) {
+	t.Setenv(envLogJSON, "1")
+	var buf bytes.Buffer
+	l, err := newLifecycleLogger(&buf)
+	assert.NilError(t, err)
+	assert.Assert(t, l != nil)
+
+	l.commandStart([]string{"docker", "build", "."}, "my-context")
+	l.builderSelected(builderResolution{forwarded: true, buildKit: true, pluginName: "buildx"}, []string{"BUILDX_BUILDER=my-context"})
+	l.commandEnd(dockercli.StatusError{StatusCode: 42})
+	assert.NilError(t, l.Close())
+
+	events := readLifecycleEvents(t, &buf)
+	assert.Assert(t, is.Len(events, 3))
+
+	assert.Check(t, is.Equal(events[0]["event"], "command.start"))
+	assert.Check(t, is.Equal(events[0]["command"], "docker build ."))
+	assert.Check(t, is.Equal(events[0]["context"], "my-context"))
+
+	assert.Check(t, is.Equal(events[1]["event"], "builder.selected"))
+	assert.Check(t, is.Equal(events[1]["builder"], "buildx"))
+	assert.Check(t, is.Equal(events[1]["buildkit"], true))
+	assert.Check(t, is.Equal(events[1]["buildx_builder"], "my-context"))
+
+	assert.Check(t, is.Equal(events[2]["event"], "command.end"))
+	assert.Check(t, is.Equal(events[2]["command"], "docker build ."))
+	assert.Check(t, is.Equal(events[2]["exit_code"], float64(42)))
+	assert.Check(t, is.Equal(events[2]["error"], "exited with code 42"))
+	assert.Check(t, is.Equal(events[2]["level"], "error"))
+	_, ok := events[2]["duration_ms"]
+	assert.Check(t, ok, "expected duration_ms to be set")
+}
+
+func TestLifecycleLoggerLegacyBuilder(t *testing.T) {
+	t.Setenv(envLogJSON, "1")
+	var buf bytes.Buffer
+	l, err := newLifecycleLogger(&buf)
+	assert.NilError(t, err)
+
+	l.builderSelected(builderResolution{forwarded: true, reason: legacyReasonDisabled, pluginName: "buildx"}, nil)
+	assert.NilError(t, l.Close())
+
+	events := readLifecycleEvents(t, &buf)
+	assert.Assert(t, is.Len(events, 1))
+	assert.Check(t, is.Equal(events[0]["event"], "builder.selected"))
+	assert.Check(t, is.Equal(events[0]["builder"], "legacy"))
+	assert.Check(t, is.Equal(events[0]["buildkit"], false))
+	assert.Check(t, is.Equal(events[0]["reason"], string(legacyReasonDisabled)))
+	_, ok := events[0]["buildx_builder"]
+	assert.Check(t, !ok, "expected no buildx_builder for the legacy builder")
+}
+
+func TestLifecycleLoggerFile(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "cli.log")
+	t.Setenv(envLogJSON, "true")
+	t.Setenv(envLogJSONFile, logFile)
+
+	var buf bytes.Buffer
+	l, err := newLifecycleLogger(&buf)
+	assert.NilError(t, err)
+	l.commandStart([]string{"docker", "ps"}, "default")
+	l.commandEnd(nil)
+	assert.NilError(t, l.Close())
+	assert.Check(t, is.Equal(buf.String(), ""), "expected nothing to be written to stderr")
+
+	f, err := os.Open(logFile)
+	assert.NilError(t, err)
+	defer f.Close()
+
+	events := readLifecycleEvents(t, f)
+	assert.Assert(t, is.Len(events, 2))
+	assert.Check(t, is.Equal(events[1]["event"], "command.end"))
+	assert.Check(t, is.Equal(events[1]["exit_code"], float64(0)))
+	assert.Check(t, is.Equal(events[1]["level"], "info"))
+}
+
+func TestRunDockerLifecycleEvents(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	t.Setenv(envLogJSON, "1")
+
+	origArgs := os.Args
+	t.Cleanup(func() { os.Args = origArgs })
+	os.Args = []string{"docker", "invalid"}
+
+	var stdout, stderr bytes.Buffer
+	cli, err := command.NewDockerCli(
+		command.WithBaseContext(t.Context()),
+		command.WithInputStream(discard),
+		command.WithOutputStream(&stdout),
+		command.WithErrorStream(&stderr),
+	)
+	assert.NilError(t, err)
+
+	err = runDocker(t.Context(), cli)
+	assert.Check(t, is.ErrorContains(err, "docker: unknown command: docker invalid"))
+	assert.Check(t, is.Equal(stdout.String(), ""), "lifecycle events must not be written to stdout")
+
+	events := readLifecycleEvents(t, &stderr)
+	assert.Assert(t, is.Len(events, 2))
+	assert.Check(t, is.Equal(events[0]["event"], "command.start"))
+	assert.Check(t, is.Equal(events[0]["command"], "docker invalid"))
+	assert.Check(t, is.Equal(events[0]["context"], "default"))
+	assert.Check(t, is.Equal(events[1]["event"], "command.end"))
+	assert.Check(t, is.Equal(events[1]["exit_code"], float64(1)))
+}
@@ -0,0 +1,177 @@
+// Package retry provides retries with exponential backoff for operations that
+// may fail because of transient errors, and the options to configure them.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// EnvRetries is the name of the environment variable to set the
+	// number of retries. It's used if the "--retries" flag is not set.
+	EnvRetries = "DOCKER_CLI_RETRIES"
+
+	// EnvMaxDelay is the name of the environment variable to set the
+	// maximum delay between retries. It's used if the "--retry-max-delay"
+	// flag is not set.
+	EnvMaxDelay = "DOCKER_CLI_RETRY_MAX_DELAY"
+
+	// DefaultMaxDelay is the default maximum delay between retries.
+	DefaultMaxDelay = 10 * time.Second
+
+	// DefaultInitialDelay is the default delay before the first retry.
+	DefaultInitialDelay = 500 * time.Millisecond
+)
+
+const (
+	flagRetries  = "retries"
+	flagMaxDelay = "retry-max-delay"
+)
+
+// Options configures retries.
+type Options struct {
+	// Retries is the number of times to retry after the first attempt
+	// failed. Retries are disabled if zero.
+	Retries int
+
+	// InitialDelay is the delay before the first retry. The delay is
+	// doubled for each following retry, up to MaxDelay. DefaultInitialDelay
+	// is used if zero.
+	InitialDelay time.Duration
+
+	// MaxDelay is the maximum delay between retries. DefaultMaxDelay is
+	// used if zero.
+	MaxDelay time.Duration
+
+	// Retryable returns whether an operation that failed with the given
+	// error should be retried. IsRetryable is used if nil.
+	Retryable func(error) bool
+}
+
+// AddFlags adds the "--retries" and "--retry-max-delay" flags to the given
+// flag set.
+func AddFlags(flags *pflag.FlagSet, opts *Options) {
+	flags.IntVar(&opts.Retries, flagRetries, 0, "Number of times to retry operations that fail with a transient error")
+	flags.DurationVar(&opts.MaxDelay, flagMaxDelay, DefaultMaxDelay, "Maximum delay between retries")
+}
+
+// LoadEnv sets the options that were not set through flags from the
+// DOCKER_CLI_RETRIES and DOCKER_CLI_RETRY_MAX_DELAY environment variables,
+// and validates the options. The flags may be nil if the options are not
+// configured through flags.
+func (o *Options) LoadEnv(flags *pflag.FlagSet) error {
+	if flags == nil || !flags.Changed(flagRetries) {
+		if v := os.Getenv(EnvRetries); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %q is not a valid number", EnvRetries, v)
+			}
+			o.Retries = n
+		}
+	}
+	if flags == nil || !flags.Changed(flagMaxDelay) {
+		if v := os.Getenv(EnvMaxDelay); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %q is not a valid duration", EnvMaxDelay, v)
+			}
+			o.MaxDelay = d
+		}
+	}
+	if o.Retries < 0 {
+		return fmt.Errorf("invalid number of retries: %d: must be a positive number", o.Retries)
+	}
+	if o.MaxDelay < 0 {
+		return fmt.Errorf("invalid maximum retry delay: %s: must be a positive duration", o.MaxDelay)
+	}
+	return nil
+}
+
+// Delay returns the delay before the given retry, starting at 1 for the
+// first retry. The delay doubles for each retry, up to the maximum delay.
+func (o Options) Delay(retry int) time.Duration {
+	initial, maxDelay := o.InitialDelay, o.MaxDelay
+	if initial <= 0 {
+		initial = DefaultInitialDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxDelay
+	}
+	delay := initial
+	for i := 1; i < retry; i++ {
+		if delay > maxDelay/2 {
+			return maxDelay
+		}
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+func (o Options) retryable(err error) bool {
+	if o.Retryable != nil {
+		return o.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// Do calls fn until it succeeds, fails with an error that's not retryable,
+// or the number of retries is exhausted. It waits between attempts as
+// returned by [Options.Delay]. It stops retrying when ctx is done, and
+// returns the error of the last attempt.
+func Do(ctx context.Context, opts Options, fn func() error) error {
+	for retry := 1; ; retry++ {
+		err := fn()
+		if err == nil || retry > opts.Retries || ctx.Err() != nil || !opts.retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(opts.Delay(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// IsRetryable returns whether err is likely transient, so that the operation
+// that failed may succeed when retried. These are errors indicating that a
+// service is (temporarily) unavailable, timeouts, and connections that were
+// refused or interrupted.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errdefs.IsUnavailable(err) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsNotDelivered returns whether err indicates that a request was not handled
+// by the daemon: connections that were refused, and services that are
+// (temporarily) unavailable. Unlike [IsRetryable], interrupted connections
+// and timeouts are not included, as the request may have been handled, so it
+// can be used to retry operations that must not be applied twice, such as
+// creating or updating objects.
+func IsNotDelivered(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errdefs.IsUnavailable(err) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,272 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/spf13/pflag"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		doc      string
+		opts     Options
+		retry    int
+		expected time.Duration
+	}{
+		{
+			doc:      "defaults, first retry",
+			retry:    1,
+			expected: DefaultInitialDelay,
+		},
+		{
+			doc:      "defaults, third retry",
+			retry:    3,
+			expected: 4 * DefaultInitialDelay,
+		},
+		{
+			doc:      "defaults, capped",
+			retry:    10,
+			expected: DefaultMaxDelay,
+		},
+		{
+			doc:      "custom initial delay",
+			opts:     Options{InitialDelay: time.Second, MaxDelay: time.Minute},
+			retry:    4,
+			expected: 8 * time.Second,
+		},
+		{
+			doc:      "max delay below initial delay",
+			opts:     Options{InitialDelay: time.Second, MaxDelay: 100 * time.Millisecond},
+			retry:    1,
+			expected: 100 * time.Millisecond,
+		},
+		{
+			doc:      "many retries do not overflow",
+			opts:     Options{MaxDelay: time.Duration(1<<63 - 1)},
+			retry:    1000,
+			expected: time.Duration(1<<63 - 1),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			assert.Check(t, is.Equal(tc.opts.Delay(tc.retry), tc.expected))
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		doc      string
+		err      error
+		expected bool
+	}{
+		{doc: "nil"},
+		{doc: "generic error", err: errors.New("something went wrong")},
+		{doc: "canceled", err: context.Canceled},
+		{doc: "not found", err: errdefs.ErrNotFound},
+		{doc: "invalid argument", err: errdefs.ErrInvalidArgument.WithMessage("invalid spec")},
+		{doc: "unavailable", err: errdefs.ErrUnavailable, expected: true},
+		{doc: "wrapped unavailable", err: fmt.Errorf("failed: %w", errdefs.ErrUnavailable), expected: true},
+		{doc: "connection refused", err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, expected: true},
+		{doc: "connection reset", err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, expected: true},
+		{doc: "unexpected EOF", err: io.ErrUnexpectedEOF, expected: true},
+		{doc: "network timeout", err: &net.OpError{Op: "read", Err: timeoutError{}}, expected: true},
+		{doc: "deadline exceeded", err: context.DeadlineExceeded, expected: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			assert.Check(t, is.Equal(IsRetryable(tc.err), tc.expected))
+		})
+	}
+}
+
+func TestIsNotDelivered(t *testing.T) {
+	tests := []struct {
+		doc      string
+		err      error
+		expected bool
+	}{
+		{doc: "nil"},
+		{doc: "generic error", err: errors.New("something went wrong")},
+		{doc: "canceled", err: context.Canceled},
+		{doc: "unavailable", err: errdefs.ErrUnavailable, expected: true},
+		{doc: "wrapped unavailable", err: fmt.Errorf("failed: %w", errdefs.ErrUnavailable), expected: true},
+		{doc: "connection refused", err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, expected: true},
+		{doc: "connection reset", err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}},
+		{doc: "unexpected EOF", err: io.ErrUnexpectedEOF},
+		{doc: "network timeout", err: &net.OpError{Op: "read", Err: timeoutError{}}},
+		{doc: "deadline exceeded", err: context.DeadlineExceeded},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			assert.Check(t, is.Equal(IsNotDelivered(tc.err), tc.expected))
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	errTransient := errdefs.ErrUnavailable.WithMessage("transient")
+	errPermanent := errors.New("permanent")
+
+	tests := []struct {
+		doc              string
+		opts             Options
+		errs             []error
+		expectedErr      error
+		expectedAttempts int
+	}{
+		{
+			doc:              "success",
+			opts:             Options{Retries: 3},
+			expectedAttempts: 1,
+		},
+		{
+			doc:              "retries disabled",
+			errs:             []error{errTransient},
+			expectedErr:      errTransient,
+			expectedAttempts: 1,
+		},
+		{
+			doc:              "success after retries",
+			opts:             Options{Retries: 3},
+			errs:             []error{errTransient, errTransient},
+			expectedAttempts: 3,
+		},
+		{
+			doc:              "retries exhausted",
+			opts:             Options{Retries: 2},
+			errs:             []error{errTransient, errTransient, errTransient, errTransient},
+			expectedErr:      errTransient,
+			expectedAttempts: 3,
+		},
+		{
+			doc:              "not retryable",
+			opts:             Options{Retries: 3},
+			errs:             []error{errPermanent},
+			expectedErr:      errPermanent,
+			expectedAttempts: 1,
+		},
+		{
+			doc: "custom predicate",
+			opts: Options{Retries: 3, Retryable: func(err error) bool {
+				return errors.Is(err, errPermanent)
+			}},
+			errs:             []error{errPermanent, errTransient},
+			expectedErr:      errTransient,
+			expectedAttempts: 2,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			tc.opts.InitialDelay = time.Millisecond
+			var attempts int
+			err := Do(context.Background(), tc.opts, func() error {
+				attempts++
+				if attempts <= len(tc.errs) {
+					return tc.errs[attempts-1]
+				}
+				return nil
+			})
+			assert.Check(t, is.Equal(attempts, tc.expectedAttempts))
+			if tc.expectedErr == nil {
+				assert.Check(t, err)
+			} else {
+				assert.Check(t, is.ErrorIs(err, tc.expectedErr))
+			}
+		})
+	}
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errTransient := errdefs.ErrUnavailable.WithMessage("transient")
+
+	var attempts int
+	err := Do(ctx, Options{Retries: 5, InitialDelay: time.Hour}, func() error {
+		attempts++
+		cancel()
+		return errTransient
+	})
+	assert.Check(t, is.ErrorIs(err, errTransient))
+	assert.Check(t, is.Equal(attempts, 1))
+}
+
+func TestLoadEnv(t *testing.T) {
+	tests := []struct {
+		doc         string
+		args        []string
+		retries     string
+		maxDelay    string
+		expected    Options
+		expectedErr string
+	}{
+		{
+			doc:      "defaults",
+			expected: Options{MaxDelay: DefaultMaxDelay},
+		},
+		{
+			doc:      "from env",
+			retries:  "3",
+			maxDelay: "5s",
+			expected: Options{Retries: 3, MaxDelay: 5 * time.Second},
+		},
+		{
+			doc:      "flags take precedence",
+			args:     []string{"--retries=2", "--retry-max-delay=1s"},
+			retries:  "3",
+			maxDelay: "5s",
+			expected: Options{Retries: 2, MaxDelay: time.Second},
+		},
+		{
+			doc:         "invalid retries",
+			retries:     "many",
+			expectedErr: `invalid value for DOCKER_CLI_RETRIES: "many" is not a valid number`,
+		},
+		{
+			doc:         "invalid max delay",
+			maxDelay:    "forever",
+			expectedErr: `invalid value for DOCKER_CLI_RETRY_MAX_DELAY: "forever" is not a valid duration`,
+		},
+		{
+			doc:         "negative retries",
+			args:        []string{"--retries=-1"},
+			expectedErr: "invalid number of retries: -1: must be a positive number",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			t.Setenv(EnvRetries, tc.retries)
+			t.Setenv(EnvMaxDelay, tc.maxDelay)
+
+			var opts Options
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			AddFlags(flags, &opts)
+			assert.NilError(t, flags.Parse(tc.args))
+
+			err := opts.LoadEnv(flags)
+			if tc.expectedErr != "" {
+				assert.Check(t, is.Error(err, tc.expectedErr))
+				return
+			}
+			assert.NilError(t, err)
+			assert.Check(t, is.DeepEqual(opts, tc.expected))
+		})
+	}
+}
@@ -156,3 +156,17 @@ func TaskImage(image string) func(*swarm.TaskSpec) {
 		taskSpec.ContainerSpec.Image = image
 	}
 }
+
+// TaskLabels sets the labels of the task's container
+func TaskLabels(labels map[string]string) func(*swarm.TaskSpec) {
+	return func(taskSpec *swarm.TaskSpec) {
+		taskSpec.ContainerSpec.Labels = labels
+	}
+}
+
+// TaskResources sets the task's resource requirements
+func TaskResources(resources *swarm.ResourceRequirements) func(*swarm.TaskSpec) {
+	return func(taskSpec *swarm.TaskSpec) {
+		taskSpec.Resources = resources
+	}
+}
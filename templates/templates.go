@@ -80,12 +80,17 @@ func padWithSpace(source string, prefix, suffix int) string {
 	return strings.Repeat(" ", prefix) + source + strings.Repeat(" ", suffix)
 }
 
-// truncateWithLength truncates the source string up to the length provided by the input
+// truncateWithLength truncates the input to the given number of characters,
+// without splitting multibyte characters.
 func truncateWithLength(source string, length int) string {
-	if len(source) < length {
+	if len(source) <= length {
 		return source
 	}
-	return source[:length]
+	runes := []rune(source)
+	if len(runes) <= length {
+		return source
+	}
+	return string(runes[:length])
 }
 
 func formatJSON(v any) string {
@@ -89,6 +89,47 @@ func TestParseTruncateFunction(t *testing.T) {
 	}
 }
 
+func TestParseTruncateFunctionMultibyte(t *testing.T) {
+	const source = "héllo-wörld-日本語"
+
+	testCases := []struct {
+		template string
+		expected string
+	}{
+		{
+			template: `{{truncate . 2}}`,
+			expected: "hé",
+		},
+		{
+			template: `{{truncate . 14}}`,
+			expected: "héllo-wörld-日本",
+		},
+		{
+			template: `{{truncate . 15}}`,
+			expected: "héllo-wörld-日本語",
+		},
+		{
+			template: `{{truncate . 16}}`,
+			expected: "héllo-wörld-日本語",
+		},
+		{
+			template: `{{truncate . 0}}`,
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			tm, err := Parse(tc.template)
+			assert.NilError(t, err)
+
+			var b bytes.Buffer
+			assert.NilError(t, tm.Execute(&b, source))
+			assert.Check(t, is.Equal(tc.expected, b.String()))
+		})
+	}
+}
+
 func TestHeaderFunctions(t *testing.T) {
 	const source = "hello world"
 